@@ -5,6 +5,7 @@ go 1.24
 require (
 	github.com/charmbracelet/bubbletea v1.3.4
 	github.com/charmbracelet/lipgloss v1.0.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -5,14 +5,24 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"path/filepath"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/gentleman-programming/gentle-ai/internal/backup"
 	"github.com/gentleman-programming/gentle-ai/internal/cli"
+	"github.com/gentleman-programming/gentle-ai/internal/config"
+	"github.com/gentleman-programming/gentle-ai/internal/events"
+	"github.com/gentleman-programming/gentle-ai/internal/journal"
+	"github.com/gentleman-programming/gentle-ai/internal/lock"
 	"github.com/gentleman-programming/gentle-ai/internal/model"
 	"github.com/gentleman-programming/gentle-ai/internal/pipeline"
 	"github.com/gentleman-programming/gentle-ai/internal/planner"
+	"github.com/gentleman-programming/gentle-ai/internal/preset"
+	"github.com/gentleman-programming/gentle-ai/internal/state"
 	"github.com/gentleman-programming/gentle-ai/internal/system"
 	"github.com/gentleman-programming/gentle-ai/internal/tui"
 	"github.com/gentleman-programming/gentle-ai/internal/update"
@@ -40,16 +50,34 @@ func RunArgs(args []string, stdout io.Writer) error {
 		return system.EnsureSupportedPlatform(result.System.Profile)
 	}
 
+	if len(args) == 0 || resolvesPresets(args[0]) {
+		if err := loadUserPresets(); err != nil {
+			return err
+		}
+	}
+
+	interrupted := interruptedInstall()
+
 	if len(args) == 0 {
 		m := tui.NewModel(result, Version)
-		m.ExecuteFn = tuiExecute
+		m.InterruptedRun = interrupted
+		cfg, cfgErr := loadConfig()
+		m.Hooks = cfg.Hooks
+		m.ConfigErr = cfgErr
+		m.ExecuteFn = tuiExecutor(cfg, cfgErr)
 		m.RestoreFn = tuiRestore
 		m.Backups = ListBackups()
+		m.LastRun = lastStateRecord()
 		p := tea.NewProgram(m, tea.WithAltScreen())
 		_, err := p.Run()
 		return err
 	}
 
+	// install reports the interrupted run itself, with the same advice.
+	if interrupted != nil && !slices.Contains([]string{"install", "version", "--version", "-v"}, args[0]) {
+		fmt.Fprintf(os.Stderr, "WARNING: %s\n", interruptedInstallNotice(*interrupted))
+	}
+
 	switch args[0] {
 	case "version", "--version", "-v":
 		_, _ = fmt.Fprintf(stdout, "gentle-ai %s\n", Version)
@@ -60,94 +88,307 @@ func RunArgs(args []string, stdout io.Writer) error {
 		_, _ = fmt.Fprint(stdout, update.RenderCLI(results))
 		return nil
 	case "install":
-		installResult, err := cli.RunInstall(args[1:], result)
+		ctx, stop := interruptContext()
+		defer stop()
+		installResult, err := cli.RunInstallContext(ctx, args[1:], result, Version)
 		if err != nil {
+			if installResult.Execution.Err != nil {
+				_, _ = fmt.Fprint(os.Stderr, cli.RenderAgentOutcomes(installResult.Agents))
+			}
 			return err
 		}
 
-		if installResult.DryRun {
+		if installResult.RolledBack {
+			if installResult.RestoredBackupID == "" {
+				_, _ = fmt.Fprintln(stdout, "Discarded the interrupted install; it stopped before changing any files.")
+			} else {
+				_, _ = fmt.Fprintf(stdout, "Rolled back the interrupted install by restoring backup %s.\n", installResult.RestoredBackupID)
+			}
+		} else if installResult.DryRun {
 			_, _ = fmt.Fprintln(stdout, cli.RenderDryRun(installResult))
 		} else {
-			_, _ = fmt.Fprint(stdout, verify.RenderReport(installResult.Verify))
+			// With --events=jsonl stdout carries only the event stream.
+			report := stdout
+			if installResult.Events != "" {
+				report = os.Stderr
+			}
+			_, _ = fmt.Fprint(report, cli.RenderChangeSummary(installResult.Execution))
+			_, _ = fmt.Fprint(report, verify.RenderReport(installResult.Verify))
+			if pruned := len(installResult.PrunedBackups); pruned > 0 {
+				_, _ = fmt.Fprintf(report, "Pruned %d old backups (retention policy: ~/.gentle-ai/config.json).\n", pruned)
+			}
+		}
+
+		return nil
+	case "diff":
+		installResult, err := cli.RunInstall(append([]string{"--diff"}, args[1:]...), result)
+		if err != nil {
+			return err
+		}
+
+		_, _ = fmt.Fprintln(stdout, cli.RenderDiff(installResult.Changes))
+		return nil
+	case "profile":
+		output, err := cli.RunProfile(args[1:], result)
+		if err != nil {
+			return err
 		}
 
+		_, _ = fmt.Fprintln(stdout, strings.TrimRight(output, "\n"))
+		return nil
+	case "backup":
+		output, err := cli.RunBackup(args[1:], Version)
+		if output != "" {
+			_, _ = fmt.Fprintln(stdout, output)
+		}
+		return err
+	case "uninstall":
+		ctx, stop := interruptContext()
+		defer stop()
+		uninstallResult, err := cli.RunUninstallContext(ctx, args[1:], result, Version)
+		if err != nil {
+			return err
+		}
+
+		_, _ = fmt.Fprintln(stdout, cli.RenderUninstallReport(uninstallResult))
+		return nil
+	case "repair":
+		ctx, stop := interruptContext()
+		defer stop()
+		repairResult, err := cli.RunRepairContext(ctx, args[1:], result, Version)
+		if err != nil {
+			return err
+		}
+
+		_, _ = fmt.Fprintln(stdout, cli.RenderRepairReport(repairResult))
+		return nil
+	case "status":
+		report, err := cli.RunStatus(args[1:], result)
+		if err != nil {
+			return err
+		}
+
+		output, err := cli.RenderStatus(report)
+		if err != nil {
+			return err
+		}
+
+		_, _ = fmt.Fprintln(stdout, output)
 		return nil
 	default:
 		return fmt.Errorf("unknown command %q", args[0])
 	}
 }
 
+// resolvesPresets reports whether a command reads preset definitions. Other
+// commands keep working even when a user preset file is broken.
+func resolvesPresets(command string) bool {
+	switch command {
+	case "install", "diff", "profile", "repair", "status":
+		return true
+	default:
+		return false
+	}
+}
+
+// loadUserPresets makes presets from ~/.gentle-ai/presets available to every
+// command that resolves a preset.
+func loadUserPresets() error {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("resolve user home directory: %w", err)
+	}
+
+	registry, err := preset.Load(homeDir)
+	if err != nil {
+		return fmt.Errorf("load presets: %w", err)
+	}
+	preset.SetDefault(registry)
+
+	return nil
+}
+
+// interruptContext returns a context cancelled by Ctrl-C or SIGTERM, so an
+// interrupted run rolls back instead of stopping halfway. Once the context is
+// cancelled, a second signal terminates the process as usual.
+func interruptContext() (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	return ctx, stop
+}
+
+// tuiMaxParallel is how many independent install steps the TUI runs at once.
+// Command output is captured there rather than streamed, so parallel steps do
+// not interleave on screen the way they would with `install --jobs`.
+const tuiMaxParallel = 4
+
+// tuiExecutor returns the TUI's ExecuteFunc, which installs with cfg or, when
+// the config could not be loaded, fails with cfgErr.
+func tuiExecutor(cfg config.Config, cfgErr error) tui.ExecuteFunc {
+	return func(ctx context.Context, selection model.Selection, resolved planner.ResolvedPlan, detection system.DetectionResult, onProgress pipeline.ProgressFunc) (pipeline.ExecutionResult, []state.AgentOutcome) {
+		if cfgErr != nil {
+			return pipeline.ExecutionResult{Err: cfgErr}, nil
+		}
+		return tuiExecute(ctx, cfg, selection, resolved, detection, onProgress)
+	}
+}
+
 // tuiExecute creates a real install runtime and runs the pipeline with progress reporting.
 func tuiExecute(
+	ctx context.Context,
+	cfg config.Config,
 	selection model.Selection,
 	resolved planner.ResolvedPlan,
 	detection system.DetectionResult,
 	onProgress pipeline.ProgressFunc,
-) pipeline.ExecutionResult {
+) (pipeline.ExecutionResult, []state.AgentOutcome) {
 	restoreCommandOutput := cli.SetCommandOutputStreaming(false)
 	defer restoreCommandOutput()
 
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
-		return pipeline.ExecutionResult{Err: fmt.Errorf("resolve user home directory: %w", err)}
+		return pipeline.ExecutionResult{Err: fmt.Errorf("resolve user home directory: %w", err)}, nil
+	}
+
+	runLock, err := lock.Acquire(homeDir, "install")
+	if err != nil {
+		return pipeline.ExecutionResult{Err: err}, nil
 	}
+	defer func() { _ = runLock.Release() }()
 
 	profile := cli.ResolveInstallProfile(detection)
 	resolved.PlatformDecision = planner.PlatformDecisionFromProfile(profile)
 
-	stagePlan, err := cli.BuildRealStagePlan(homeDir, selection, resolved, profile)
+	run, err := cli.NewInstallRun(homeDir, selection, resolved, profile, cfg, Version)
 	if err != nil {
-		return pipeline.ExecutionResult{Err: fmt.Errorf("build stage plan: %w", err)}
+		return pipeline.ExecutionResult{Err: fmt.Errorf("build stage plan: %w", err)}, nil
 	}
+	if err := run.StartJournal(); err != nil {
+		return pipeline.ExecutionResult{Err: err}, nil
+	}
+
+	// The run log is best-effort for the same reason as the ledger below.
+	stream := events.NewWriter(state.CommandInstall)
+	_, _ = stream.AddLog(homeDir, time.Now())
+	stream.Start()
+	var runErr error
+	defer func() {
+		stream.Finish(runErr)
+		_ = stream.Close()
+	}()
 
+	// The TUI already keeps going after a failed step, so it also rolls
+	// back only what failed.
 	orchestrator := pipeline.NewOrchestrator(
-		pipeline.DefaultRollbackPolicy(),
+		run.RollbackPolicy(true),
 		pipeline.WithFailurePolicy(pipeline.ContinueOnError),
-		pipeline.WithProgressFunc(onProgress),
+		pipeline.WithProgressFunc(run.JournalProgress(stream.Progress(run.ChangedFiles, onProgress))),
+		pipeline.WithMaxParallel(tuiMaxParallel),
 	)
 
-	return orchestrator.Execute(stagePlan)
+	execution := orchestrator.Execute(ctx, run.Plan)
+	runErr = execution.Err
+	_ = run.EndJournal(execution)
+	// The ledger is best-effort here: writing to stderr would corrupt the
+	// alternate screen, and the install itself already finished.
+	_ = run.Record("tui", execution)
+	if execution.Err == nil {
+		_, _ = run.PruneBackups()
+	}
+
+	return execution, run.AgentOutcomes(execution)
 }
 
-// tuiRestore restores a backup from its manifest.
-func tuiRestore(manifest backup.Manifest) error {
-	return backup.RestoreService{}.Restore(manifest)
+// interruptedInstall returns the journal of an install that was killed before
+// it finished, or nil. A journal that belongs to a run still going on is not
+// reported.
+func interruptedInstall() *journal.Run {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+
+	interrupted, ok, err := journal.Load(homeDir)
+	if err != nil || !ok || interrupted.Command != state.CommandInstall {
+		return nil
+	}
+	if _, held, err := lock.Holder(homeDir); err != nil || held {
+		return nil
+	}
+	return &interrupted
 }
 
-// ListBackups returns all backup manifests from the backup directory.
-func ListBackups() []backup.Manifest {
+// interruptedInstallNotice tells the user how to deal with an interrupted
+// install.
+func interruptedInstallNotice(interrupted journal.Run) string {
+	return fmt.Sprintf("the install started %s was interrupted; run `gentle-ai install --resume` to finish it or `gentle-ai install --rollback` to undo it",
+		interrupted.StartedAt.Local().Format("2006-01-02 15:04"))
+}
+
+// loadConfig reads ~/.gentle-ai/config.json for the TUI, which shows the
+// error on the welcome screen and refuses to install until it is fixed.
+func loadConfig() (config.Config, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return config.Config{}, fmt.Errorf("resolve user home directory: %w", err)
+	}
+	return config.Load(homeDir)
+}
+
+// lastStateRecord returns the newest state ledger record, or nil when there is
+// none (first run, or an unreadable ledger).
+func lastStateRecord() *state.Record {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		return nil
 	}
 
-	backupRoot := filepath.Join(homeDir, ".gentle-ai", "backups")
-	entries, err := os.ReadDir(backupRoot)
+	ledger, err := state.Load(homeDir)
 	if err != nil {
 		return nil
 	}
 
-	manifests := make([]backup.Manifest, 0, len(entries))
-	for _, entry := range entries {
-		if !entry.IsDir() {
-			continue
-		}
+	record, ok := ledger.Latest()
+	if !ok {
+		return nil
+	}
+	return &record
+}
 
-		manifestPath := filepath.Join(backupRoot, entry.Name(), backup.ManifestFilename)
-		manifest, err := backup.ReadManifest(manifestPath)
-		if err != nil {
-			continue
-		}
-		manifests = append(manifests, manifest)
+// tuiRestore restores a backup from its manifest.
+func tuiRestore(manifest backup.Manifest) error {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("resolve user home directory: %w", err)
 	}
 
-	// Sort by creation time (newest first) — the IDs are timestamps.
-	for i := 0; i < len(manifests); i++ {
-		for j := i + 1; j < len(manifests); j++ {
-			if manifests[j].CreatedAt.After(manifests[i].CreatedAt) {
-				manifests[i], manifests[j] = manifests[j], manifests[i]
-			}
-		}
+	runLock, err := lock.Acquire(homeDir, "backup restore")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = runLock.Release() }()
+
+	managed, err := cli.RecordedFiles(homeDir)
+	if err != nil {
+		return err
+	}
+
+	return backup.RestoreService{SafetyRoot: backup.DefaultRoot(homeDir), Managed: managed, Version: Version}.Restore(manifest)
+}
+
+// ListBackups returns all backup manifests from the backup directory, newest first.
+func ListBackups() []backup.Manifest {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+
+	manifests, err := backup.NewStore(homeDir).List()
+	if err != nil {
+		return nil
 	}
 
 	return manifests
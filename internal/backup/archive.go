@@ -0,0 +1,315 @@
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// maxArchiveManifestSize bounds how much of an archive's manifest.json is
+// read on import.
+const maxArchiveManifestSize = 64 << 20
+
+// Export writes the backup with the given ID to w as a gzip-compressed tar
+// archive holding its manifest and one blobs/<sha256> file per distinct
+// content. Paths in the archived manifest are relative to homeDir ("~/..."),
+// so Import can place them under another home directory. Every snapshot is
+// verified first, and a path outside homeDir fails the export.
+func (s Store) Export(id string, homeDir string, w io.Writer) (Manifest, error) {
+	manifest, err := s.Get(id)
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	contents := make([][]byte, len(manifest.Entries))
+	if problems := verifyEntries(manifest.Entries, contents); len(problems) > 0 {
+		return Manifest{}, &DamagedError{ID: manifest.ID, Problems: problems}
+	}
+
+	exported := manifest
+	exported.RootDir = ""
+	exported.Entries = make([]ManifestEntry, len(manifest.Entries))
+	blobs := map[string][]byte{}
+	var hashes []string
+	for i, entry := range manifest.Entries {
+		relative, err := homeRelative(homeDir, entry.OriginalPath)
+		if err != nil {
+			return Manifest{}, err
+		}
+		entry.OriginalPath = relative
+		entry.SnapshotPath = ""
+		if entry.Kind == KindSymlink {
+			if err := checkLinkTarget(homeDir, manifest.Entries[i].OriginalPath, entry.LinkTarget); err != nil {
+				return Manifest{}, err
+			}
+			if filepath.IsAbs(entry.LinkTarget) {
+				// Links into the home directory should point into the new one.
+				if entry.LinkTarget, err = homeRelative(homeDir, entry.LinkTarget); err != nil {
+					return Manifest{}, err
+				}
+			}
+		}
+
+		if entry.hasContent() {
+			// Entries from before the blob store have no hash yet.
+			sum := sha256.Sum256(contents[i])
+			entry.Hash = hex.EncodeToString(sum[:])
+			entry.Size = int64(len(contents[i]))
+			entry.SnapshotPath = path.Join(BlobDirname, entry.Hash)
+			if _, ok := blobs[entry.Hash]; !ok {
+				blobs[entry.Hash] = contents[i]
+				hashes = append(hashes, entry.Hash)
+			}
+		}
+		exported.Entries[i] = entry
+	}
+
+	content, err := json.MarshalIndent(exported, "", "  ")
+	if err != nil {
+		return Manifest{}, fmt.Errorf("marshal manifest: %w", err)
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	files := append([]string{ManifestFilename}, hashes...)
+	for _, name := range files {
+		data := content
+		if name != ManifestFilename {
+			data = blobs[name]
+			name = path.Join(BlobDirname, name)
+		}
+		header := &tar.Header{
+			Name:     name,
+			Typeflag: tar.TypeReg,
+			Mode:     0o600,
+			Size:     int64(len(data)),
+			ModTime:  manifest.CreatedAt,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return Manifest{}, fmt.Errorf("write archive entry %q: %w", name, err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			return Manifest{}, fmt.Errorf("write archive entry %q: %w", name, err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return Manifest{}, fmt.Errorf("finish archive: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return Manifest{}, fmt.Errorf("finish archive: %w", err)
+	}
+
+	return manifest, nil
+}
+
+// Import reads an archive written by Export into the store, placing its
+// home-relative paths under homeDir. The backup keeps its ID, so importing
+// one that already exists fails. Blobs are staged next to the store and
+// only moved into it once the whole backup has been checked, so a rejected
+// archive leaves nothing behind.
+func (s Store) Import(r io.Reader, homeDir string) (Manifest, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return Manifest{}, fmt.Errorf("read archive: %w", err)
+	}
+	defer gz.Close()
+
+	if err := os.MkdirAll(s.Root, 0o755); err != nil {
+		return Manifest{}, fmt.Errorf("create backup root %q: %w", s.Root, err)
+	}
+	staging, err := os.MkdirTemp(s.Root, ".import-*")
+	if err != nil {
+		return Manifest{}, fmt.Errorf("create import staging directory: %w", err)
+	}
+	defer os.RemoveAll(staging)
+
+	var manifest Manifest
+	var haveManifest bool
+	received := map[string]string{}
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return Manifest{}, fmt.Errorf("read archive: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			return Manifest{}, fmt.Errorf("unexpected archive entry %q", header.Name)
+		}
+
+		switch dir, name := path.Split(header.Name); {
+		case header.Name == ManifestFilename:
+			content, err := io.ReadAll(io.LimitReader(tr, maxArchiveManifestSize))
+			if err != nil {
+				return Manifest{}, fmt.Errorf("read archived manifest: %w", err)
+			}
+			if err := json.Unmarshal(content, &manifest); err != nil {
+				return Manifest{}, fmt.Errorf("parse archived manifest: %w", err)
+			}
+			haveManifest = true
+		case dir == BlobDirname+"/" && isHash(name):
+			hash, staged, _, err := storeBlob(staging, tr, header.Name)
+			if err != nil {
+				return Manifest{}, err
+			}
+			if hash != name {
+				return Manifest{}, fmt.Errorf("archive entry %q is corrupted: sha256 %s", header.Name, hash)
+			}
+			received[hash] = staged
+		default:
+			return Manifest{}, fmt.Errorf("unexpected archive entry %q", header.Name)
+		}
+	}
+
+	if !haveManifest {
+		return Manifest{}, fmt.Errorf("archive has no %s", ManifestFilename)
+	}
+
+	dir, err := s.dir(manifest.ID)
+	if err != nil {
+		return Manifest{}, err
+	}
+	if _, err := os.Stat(dir); err == nil {
+		return Manifest{}, fmt.Errorf("backup %q already exists", manifest.ID)
+	}
+
+	manifest.RootDir = dir
+	for i, entry := range manifest.Entries {
+		original, err := fromHomeRelative(homeDir, entry.OriginalPath)
+		if err != nil {
+			return Manifest{}, err
+		}
+		entry.OriginalPath = original
+		entry.SnapshotPath = ""
+		if entry.Kind == KindSymlink {
+			if entry.LinkTarget == "~" || strings.HasPrefix(entry.LinkTarget, "~/") {
+				if entry.LinkTarget, err = fromHomeRelative(homeDir, entry.LinkTarget); err != nil {
+					return Manifest{}, err
+				}
+			}
+			if err := checkLinkTarget(homeDir, original, entry.LinkTarget); err != nil {
+				return Manifest{}, err
+			}
+		}
+
+		if entry.hasContent() {
+			if !isHash(entry.Hash) {
+				return Manifest{}, fmt.Errorf("archived entry %q has no valid checksum", original)
+			}
+			// Verify the staged copy; it moves into the store below.
+			entry.SnapshotPath = received[entry.Hash]
+			if entry.SnapshotPath == "" {
+				entry.SnapshotPath = blobPath(s.Root, entry.Hash)
+				if _, err := os.Stat(entry.SnapshotPath); err != nil {
+					return Manifest{}, fmt.Errorf("archive is missing the content of %q", original)
+				}
+			}
+		}
+		manifest.Entries[i] = entry
+	}
+
+	if problems := Verify(manifest); len(problems) > 0 {
+		return Manifest{}, &DamagedError{ID: manifest.ID, Problems: problems}
+	}
+
+	for hash, staged := range received {
+		if err := moveBlob(staged, blobPath(s.Root, hash)); err != nil {
+			return Manifest{}, err
+		}
+	}
+	for i, entry := range manifest.Entries {
+		if entry.hasContent() {
+			manifest.Entries[i].SnapshotPath = blobPath(s.Root, entry.Hash)
+		}
+	}
+	if err := WriteManifest(filepath.Join(dir, ManifestFilename), manifest); err != nil {
+		return Manifest{}, err
+	}
+
+	return manifest, nil
+}
+
+// moveBlob moves a staged blob into the store. A blob the store already
+// holds is kept and only has its mtime refreshed, as storeBlob does.
+func moveBlob(staged string, destination string) error {
+	if _, err := os.Stat(destination); err == nil {
+		now := time.Now()
+		if err := os.Chtimes(destination, now, now); err != nil {
+			return fmt.Errorf("refresh blob %q: %w", destination, err)
+		}
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(destination), 0o755); err != nil {
+		return fmt.Errorf("create blob directory for %q: %w", destination, err)
+	}
+	if err := os.Rename(staged, destination); err != nil {
+		return fmt.Errorf("store blob %q: %w", destination, err)
+	}
+	return nil
+}
+
+// checkLinkTarget rejects a symlink at linkPath whose target, absolute or
+// relative to the link's directory, lies outside homeDir.
+func checkLinkTarget(homeDir string, linkPath string, target string) error {
+	resolved := target
+	if !filepath.IsAbs(resolved) {
+		resolved = filepath.Join(filepath.Dir(linkPath), filepath.FromSlash(resolved))
+	}
+	if !within(resolved, homeDir) {
+		return fmt.Errorf("symlink %q points outside the home directory %q (%s)", linkPath, homeDir, target)
+	}
+	return nil
+}
+
+// homeRelative rewrites an absolute path under homeDir as "~/...", using
+// forward slashes on every platform.
+func homeRelative(homeDir string, original string) (string, error) {
+	rel, err := filepath.Rel(homeDir, original)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("path %q is outside the home directory %q and cannot be exported", original, homeDir)
+	}
+	if rel == "." {
+		return "~", nil
+	}
+	return "~/" + filepath.ToSlash(rel), nil
+}
+
+// fromHomeRelative is the inverse of homeRelative. It rejects paths that
+// would land outside homeDir.
+func fromHomeRelative(homeDir string, relative string) (string, error) {
+	if relative == "~" {
+		return homeDir, nil
+	}
+	rest, ok := strings.CutPrefix(relative, "~/")
+	if !ok {
+		return "", fmt.Errorf("archived path %q is not relative to the home directory", relative)
+	}
+
+	rel := filepath.Clean(filepath.FromSlash(rest))
+	if filepath.IsAbs(rel) || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("archived path %q escapes the home directory", relative)
+	}
+	return filepath.Join(homeDir, rel), nil
+}
+
+func isHash(name string) bool {
+	if len(name) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(name)
+	return err == nil
+}
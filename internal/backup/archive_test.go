@@ -0,0 +1,185 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExportImportMovesBackupToAnotherHome(t *testing.T) {
+	source := t.TempDir()
+	settings := filepath.Join(source, ".claude", "settings.json")
+	skill := filepath.Join(source, ".claude", "skills", "sdd-init")
+	writeFile(t, settings, "{}\n", 0o644)
+	writeFile(t, filepath.Join(skill, "SKILL.md"), "skill\n", 0o644)
+	if err := os.Symlink(settings, filepath.Join(skill, "settings.json")); err != nil {
+		t.Fatalf("Symlink() error = %v", err)
+	}
+
+	store := Store{Root: DefaultRoot(source)}
+	if _, err := NewSnapshotter(store.Root).Create(filepath.Join(store.Root, "snap"), []string{settings, skill}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	var archive bytes.Buffer
+	if _, err := store.Export("snap", source, &archive); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	if bytes.Contains(gunzip(t, archive.Bytes()), []byte(source)) {
+		t.Fatalf("archive still mentions the source home %q", source)
+	}
+
+	target := t.TempDir()
+	imported, err := Store{Root: DefaultRoot(target)}.Import(bytes.NewReader(archive.Bytes()), target)
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if imported.ID != "snap" || imported.RootDir != filepath.Join(DefaultRoot(target), "snap") {
+		t.Fatalf("imported = %s in %s", imported.ID, imported.RootDir)
+	}
+
+	if err := (RestoreService{}).Restore(imported); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	restored := filepath.Join(target, ".claude", "settings.json")
+	if content, err := os.ReadFile(restored); err != nil || string(content) != "{}\n" {
+		t.Fatalf("restored settings = %q, %v", content, err)
+	}
+	if link, err := os.Readlink(filepath.Join(target, ".claude", "skills", "sdd-init", "settings.json")); err != nil || link != restored {
+		t.Fatalf("restored link = %q, %v; want it to point into the new home", link, err)
+	}
+
+	if _, err := (Store{Root: DefaultRoot(target)}).Import(bytes.NewReader(archive.Bytes()), target); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("second Import() error = %v", err)
+	}
+}
+
+func TestExportRejectsPathsOutsideHome(t *testing.T) {
+	home := t.TempDir()
+	outside := filepath.Join(t.TempDir(), "config.json")
+	writeFile(t, outside, "{}\n", 0o644)
+
+	store := Store{Root: DefaultRoot(home)}
+	if _, err := NewSnapshotter(store.Root).Create(filepath.Join(store.Root, "snap"), []string{outside}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	if _, err := store.Export("snap", home, &bytes.Buffer{}); err == nil || !strings.Contains(err.Error(), "outside the home directory") {
+		t.Fatalf("Export() error = %v", err)
+	}
+}
+
+func TestImportRejectsPathsEscapingHome(t *testing.T) {
+	tests := map[string]string{
+		"traversal": `{"id": "evil", "entries": [{"original_path": "~/../../etc/passwd", "existed": false}]}`,
+		"absolute":  `{"id": "evil", "entries": [{"original_path": "/etc/passwd", "existed": false}]}`,
+		"bad id":    `{"id": "../evil", "entries": []}`,
+	}
+
+	for name, manifest := range tests {
+		t.Run(name, func(t *testing.T) {
+			home := t.TempDir()
+			archive := tarball(t, map[string]string{ManifestFilename: manifest})
+			if _, err := (Store{Root: DefaultRoot(home)}).Import(bytes.NewReader(archive), home); err == nil {
+				t.Fatalf("Import() accepted %s", manifest)
+			}
+		})
+	}
+}
+
+func TestImportRejectsCorruptedBlob(t *testing.T) {
+	home := t.TempDir()
+	hash := strings.Repeat("ab", 32)
+	archive := tarball(t, map[string]string{
+		ManifestFilename:         `{"id": "snap", "entries": [{"original_path": "~/a.json", "sha256": "` + hash + `", "size": 3, "existed": true}]}`,
+		BlobDirname + "/" + hash: "{}\n",
+	})
+
+	if _, err := (Store{Root: DefaultRoot(home)}).Import(bytes.NewReader(archive), home); err == nil || !strings.Contains(err.Error(), "corrupted") {
+		t.Fatalf("Import() error = %v", err)
+	}
+}
+
+func TestRejectedImportLeavesNoBlobs(t *testing.T) {
+	home := t.TempDir()
+	sum := sha256.Sum256([]byte("{}\n"))
+	hash := hex.EncodeToString(sum[:])
+	archive := tarball(t, map[string]string{
+		ManifestFilename:         `{"id": "snap", "entries": [{"original_path": "~/../a.json", "sha256": "` + hash + `", "size": 3, "existed": true}]}`,
+		BlobDirname + "/" + hash: "{}\n",
+	})
+
+	store := Store{Root: DefaultRoot(home)}
+	if _, err := store.Import(bytes.NewReader(archive), home); err == nil {
+		t.Fatalf("Import() accepted a path escaping home")
+	}
+	entries, err := os.ReadDir(store.Root)
+	if err != nil {
+		t.Fatalf("ReadDir() error = %v", err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("backup root after a rejected import = %v, want it empty", entries)
+	}
+}
+
+func TestImportRejectsSymlinksLeavingHome(t *testing.T) {
+	tests := map[string]string{
+		"absolute": "/etc/passwd",
+		"relative": "../../../etc/passwd",
+	}
+
+	for name, target := range tests {
+		t.Run(name, func(t *testing.T) {
+			home := t.TempDir()
+			archive := tarball(t, map[string]string{
+				ManifestFilename: `{"id": "snap", "entries": [{"original_path": "~/.claude/link", "kind": "symlink", "link_target": "` + target + `", "existed": true}]}`,
+			})
+			if _, err := (Store{Root: DefaultRoot(home)}).Import(bytes.NewReader(archive), home); err == nil || !strings.Contains(err.Error(), "points outside the home directory") {
+				t.Fatalf("Import() error = %v", err)
+			}
+		})
+	}
+}
+
+func tarball(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0o600, Size: int64(len(content))}); err != nil {
+			t.Fatalf("WriteHeader() error = %v", err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	return buf.Bytes()
+}
+
+func gunzip(t *testing.T, archive []byte) []byte {
+	t.Helper()
+
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		t.Fatalf("gzip.NewReader() error = %v", err)
+	}
+	var out bytes.Buffer
+	if _, err := out.ReadFrom(gz); err != nil {
+		t.Fatalf("ReadFrom() error = %v", err)
+	}
+	return out.Bytes()
+}
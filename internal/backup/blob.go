@@ -0,0 +1,111 @@
+package backup
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// BlobDirname is the directory under a backup root that holds snapshot file
+// contents, addressed by their SHA-256. Manifests in the same root share it,
+// so an unchanged file is stored once no matter how many snapshots hold it.
+const BlobDirname = "blobs"
+
+// blobGracePeriod protects blobs written or reused recently from garbage
+// collection, so a snapshot that has not written its manifest yet keeps its
+// blobs.
+const blobGracePeriod = 10 * time.Minute
+
+func blobPath(root string, hash string) string {
+	if len(hash) < 2 {
+		return filepath.Join(root, BlobDirname, hash)
+	}
+	return filepath.Join(root, BlobDirname, hash[:2], hash)
+}
+
+// writeBlob stores the content of source under root and returns its hash,
+// blob path and size. A blob that already exists is reused and only has its
+// mtime refreshed.
+func writeBlob(root string, source string) (string, string, int64, error) {
+	input, err := os.Open(source)
+	if err != nil {
+		return "", "", 0, fmt.Errorf("open source file %q: %w", source, err)
+	}
+	defer input.Close()
+
+	return storeBlob(root, input, source)
+}
+
+// storeBlob is writeBlob for any reader; name identifies the content in
+// errors.
+func storeBlob(root string, input io.Reader, name string) (string, string, int64, error) {
+	blobDir := filepath.Join(root, BlobDirname)
+	if err := os.MkdirAll(blobDir, 0o755); err != nil {
+		return "", "", 0, fmt.Errorf("create blob directory %q: %w", blobDir, err)
+	}
+
+	tmp, err := os.CreateTemp(blobDir, ".blob-*.tmp")
+	if err != nil {
+		return "", "", 0, fmt.Errorf("create temp blob for %q: %w", name, err)
+	}
+	tmpPath := tmp.Name()
+	defer func() { _ = os.Remove(tmpPath) }()
+
+	hasher := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hasher), input)
+	if err != nil {
+		_ = tmp.Close()
+		return "", "", 0, fmt.Errorf("copy %q to blob store: %w", name, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", "", 0, fmt.Errorf("close temp blob for %q: %w", name, err)
+	}
+
+	hash := hex.EncodeToString(hasher.Sum(nil))
+	destination := blobPath(root, hash)
+	if _, err := os.Stat(destination); err == nil {
+		now := time.Now()
+		if err := os.Chtimes(destination, now, now); err != nil {
+			return "", "", 0, fmt.Errorf("refresh blob %q: %w", destination, err)
+		}
+		return hash, destination, size, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(destination), 0o755); err != nil {
+		return "", "", 0, fmt.Errorf("create blob directory for %q: %w", destination, err)
+	}
+	if err := os.Chmod(tmpPath, 0o600); err != nil {
+		return "", "", 0, fmt.Errorf("set permissions on blob for %q: %w", name, err)
+	}
+	if err := os.Rename(tmpPath, destination); err != nil {
+		return "", "", 0, fmt.Errorf("store blob %q: %w", destination, err)
+	}
+
+	return hash, destination, size, nil
+}
+
+// readSnapshot returns the snapshot content of entry. Content-addressed
+// entries are checked against their hash, so a damaged blob is never
+// written back.
+func readSnapshot(entry ManifestEntry) ([]byte, error) {
+	content, err := os.ReadFile(entry.SnapshotPath)
+	if err != nil {
+		return nil, fmt.Errorf("read snapshot file %q: %w", entry.SnapshotPath, err)
+	}
+
+	if entry.Hash != "" {
+		if int64(len(content)) != entry.Size {
+			return nil, fmt.Errorf("snapshot of %q is truncated: %d bytes, want %d", entry.OriginalPath, len(content), entry.Size)
+		}
+		sum := sha256.Sum256(content)
+		if got := hex.EncodeToString(sum[:]); got != entry.Hash {
+			return nil, fmt.Errorf("snapshot of %q is corrupted: sha256 %s, want %s", entry.OriginalPath, got, entry.Hash)
+		}
+	}
+
+	return content, nil
+}
@@ -0,0 +1,188 @@
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSnapshotterDeduplicatesUnchangedFiles(t *testing.T) {
+	home := t.TempDir()
+	root := filepath.Join(home, "backups")
+	settings := filepath.Join(home, "settings.json")
+	if err := os.WriteFile(settings, []byte("{}\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	first, err := NewSnapshotter(root).Create(filepath.Join(root, "one"), []string{settings})
+	if err != nil {
+		t.Fatalf("Create(one) error = %v", err)
+	}
+	second, err := NewSnapshotter(root).Create(filepath.Join(root, "two"), []string{settings})
+	if err != nil {
+		t.Fatalf("Create(two) error = %v", err)
+	}
+
+	if first.Entries[0].Hash == "" || first.Entries[0].Hash != second.Entries[0].Hash {
+		t.Fatalf("hashes = %q and %q, want the same non-empty hash", first.Entries[0].Hash, second.Entries[0].Hash)
+	}
+	if first.Entries[0].SnapshotPath != second.Entries[0].SnapshotPath {
+		t.Fatalf("snapshot paths differ: %q and %q", first.Entries[0].SnapshotPath, second.Entries[0].SnapshotPath)
+	}
+	if got := countBlobs(t, root); got != 1 {
+		t.Fatalf("blob count = %d, want 1", got)
+	}
+
+	// Each snapshot directory holds only its manifest.
+	entries, err := os.ReadDir(filepath.Join(root, "two"))
+	if err != nil {
+		t.Fatalf("ReadDir() error = %v", err)
+	}
+	if len(entries) != 1 || entries[0].Name() != ManifestFilename {
+		t.Fatalf("snapshot directory entries = %v", entries)
+	}
+}
+
+func TestSnapshotterWritesBlobsUnderItsOwnRoot(t *testing.T) {
+	home := t.TempDir()
+	root := filepath.Join(home, "store")
+	settings := filepath.Join(home, "settings.json")
+	if err := os.WriteFile(settings, []byte("{}\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	manifest, err := NewSnapshotter(root).Create(filepath.Join(home, "elsewhere", "nested", "snap"), []string{settings})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	if !strings.HasPrefix(manifest.Entries[0].SnapshotPath, filepath.Join(root, BlobDirname)) {
+		t.Fatalf("snapshot path = %q, want a blob under %q", manifest.Entries[0].SnapshotPath, root)
+	}
+	if got := countBlobs(t, root); got != 1 {
+		t.Fatalf("blob count = %d, want 1", got)
+	}
+}
+
+func TestRestoreRejectsCorruptedBlob(t *testing.T) {
+	home := t.TempDir()
+	settings := filepath.Join(home, "settings.json")
+	if err := os.WriteFile(settings, []byte("original\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	manifest, err := NewSnapshotter(filepath.Join(home, "backups")).Create(filepath.Join(home, "backups", "one"), []string{settings})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if err := os.WriteFile(manifest.Entries[0].SnapshotPath, []byte("tampered\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile(blob) error = %v", err)
+	}
+	if err := os.WriteFile(settings, []byte("changed\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	err = RestoreService{}.Restore(manifest)
+	if err == nil || !strings.Contains(err.Error(), "corrupted") {
+		t.Fatalf("Restore() error = %v, want corruption error", err)
+	}
+	if content, _ := os.ReadFile(settings); string(content) != "changed\n" {
+		t.Fatalf("corrupted blob was written back: %q", content)
+	}
+}
+
+func TestDeleteCollectsUnreferencedBlobs(t *testing.T) {
+	home := t.TempDir()
+	store := Store{Root: filepath.Join(home, "backups")}
+	shared := filepath.Join(home, "shared.json")
+	only := filepath.Join(home, "only.json")
+	for path, content := range map[string]string{shared: "shared\n", only: "only\n"} {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+
+	first, err := NewSnapshotter(store.Root).Create(filepath.Join(store.Root, "one"), []string{shared, only})
+	if err != nil {
+		t.Fatalf("Create(one) error = %v", err)
+	}
+	if _, err := NewSnapshotter(store.Root).Create(filepath.Join(store.Root, "two"), []string{shared}); err != nil {
+		t.Fatalf("Create(two) error = %v", err)
+	}
+	ageBlobs(t, store.Root)
+
+	if err := store.Delete("one"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	if got := countBlobs(t, store.Root); got != 1 {
+		t.Fatalf("blob count = %d, want only the shared blob", got)
+	}
+	if _, err := os.Stat(first.Entries[0].SnapshotPath); err != nil {
+		t.Fatalf("shared blob removed: %v", err)
+	}
+	if _, err := os.Stat(first.Entries[1].SnapshotPath); !os.IsNotExist(err) {
+		t.Fatalf("unreferenced blob kept, err = %v", err)
+	}
+}
+
+func TestCollectGarbageKeepsRecentBlobs(t *testing.T) {
+	home := t.TempDir()
+	store := Store{Root: filepath.Join(home, "backups")}
+	path := filepath.Join(home, "file.json")
+	if err := os.WriteFile(path, []byte("x\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	// A blob without a manifest looks like a snapshot still in progress.
+	if _, _, _, err := writeBlob(store.Root, path); err != nil {
+		t.Fatalf("writeBlob() error = %v", err)
+	}
+
+	removed, err := store.CollectGarbage()
+	if err != nil || removed != 0 {
+		t.Fatalf("CollectGarbage() = %d, %v; want recent blob kept", removed, err)
+	}
+
+	ageBlobs(t, store.Root)
+	removed, err = store.CollectGarbage()
+	if err != nil || removed != 1 {
+		t.Fatalf("CollectGarbage() = %d, %v; want old unreferenced blob removed", removed, err)
+	}
+}
+
+func countBlobs(t *testing.T, root string) int {
+	t.Helper()
+
+	count := 0
+	err := filepath.WalkDir(filepath.Join(root, BlobDirname), func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() {
+			count++
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WalkDir() error = %v", err)
+	}
+	return count
+}
+
+func ageBlobs(t *testing.T, root string) {
+	t.Helper()
+
+	old := time.Now().Add(-2 * blobGracePeriod)
+	err := filepath.WalkDir(filepath.Join(root, BlobDirname), func(path string, entry os.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		return os.Chtimes(path, old, old)
+	})
+	if err != nil {
+		t.Fatalf("age blobs: %v", err)
+	}
+}
@@ -5,21 +5,180 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/gentleman-programming/gentle-ai/internal/model"
 )
 
+// Manifest describes one snapshot. Pinned snapshots are never removed by
+// pruning or the retention policy.
 type Manifest struct {
 	ID        string          `json:"id"`
 	CreatedAt time.Time       `json:"created_at"`
 	RootDir   string          `json:"root_dir"`
+	Label     Label           `json:"label,omitzero"`
+	Pinned    bool            `json:"pinned,omitempty"`
 	Entries   []ManifestEntry `json:"entries"`
 }
 
+// Label records what took a snapshot: the command (install, uninstall,
+// repair or restore), a summary of what it was working on, the gentle-ai
+// version, and an optional note the user gave with --backup-label.
+type Label struct {
+	Command string `json:"command,omitempty"`
+	Summary string `json:"summary,omitempty"`
+	Version string `json:"version,omitempty"`
+	Note    string `json:"note,omitempty"`
+}
+
+// String renders the label on one line, e.g.
+// `install claude-code: sdd, skills "before the beta" (gentle-ai 1.4.0)`.
+func (l Label) String() string {
+	parts := []string{}
+	for _, part := range []string{l.Command, l.Summary} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	if l.Note != "" {
+		parts = append(parts, strconv.Quote(l.Note))
+	}
+	if l.Version != "" {
+		parts = append(parts, "(gentle-ai "+l.Version+")")
+	}
+	return strings.Join(parts, " ")
+}
+
+// EntryKind is what a snapshotted path was. Entries written before
+// directory snapshots existed have no kind and are files.
+type EntryKind string
+
+const (
+	KindFile    EntryKind = "file"
+	KindDir     EntryKind = "dir"
+	KindSymlink EntryKind = "symlink"
+)
+
+// ManifestEntry is one snapshotted path. Hash and Size describe the content
+// in the blob store and let restore and `backup verify` detect damage;
+// entries written before blobs existed have neither and point at a plain
+// copy. Agents and Components name what manages the path; a file shared by
+// several components, such as an agent's settings.json, lists all of them.
+//
+// A snapshotted directory is one dir entry followed by an entry for
+// everything it held, so restore can also delete files added since.
 type ManifestEntry struct {
-	OriginalPath string `json:"original_path"`
-	SnapshotPath string `json:"snapshot_path"`
-	Existed      bool   `json:"existed"`
-	Mode         uint32 `json:"mode,omitempty"`
+	OriginalPath string              `json:"original_path"`
+	SnapshotPath string              `json:"snapshot_path"`
+	Kind         EntryKind           `json:"kind,omitempty"`
+	Hash         string              `json:"sha256,omitempty"`
+	Size         int64               `json:"size,omitempty"`
+	Existed      bool                `json:"existed"`
+	Mode         uint32              `json:"mode,omitempty"`
+	ModTime      time.Time           `json:"mtime,omitzero"`
+	LinkTarget   string              `json:"link_target,omitempty"`
+	Agents       []model.AgentID     `json:"agents,omitempty"`
+	Components   []model.ComponentID `json:"components,omitempty"`
+}
+
+// IsDir reports whether the entry is a snapshotted directory.
+func (e ManifestEntry) IsDir() bool {
+	return e.Kind == KindDir
+}
+
+// hasContent reports whether restoring the entry needs snapshot content.
+func (e ManifestEntry) hasContent() bool {
+	return e.Existed && (e.Kind == "" || e.Kind == KindFile)
+}
+
+// Filter selects manifest entries. An entry matches when it matches every
+// non-empty field: its path is one of Paths or inside one of them, it is
+// tagged with one of Agents, and it is tagged with one of Components.
+type Filter struct {
+	Paths      []string
+	Agents     []model.AgentID
+	Components []model.ComponentID
+}
+
+// IsZero reports whether the filter selects every entry.
+func (f Filter) IsZero() bool {
+	return len(f.Paths) == 0 && len(f.Agents) == 0 && len(f.Components) == 0
+}
+
+// Matches reports whether entry is selected by the filter.
+func (f Filter) Matches(entry ManifestEntry) bool {
+	if len(f.Paths) > 0 && !slices.ContainsFunc(f.Paths, func(path string) bool { return within(entry.OriginalPath, path) }) {
+		return false
+	}
+	if len(f.Agents) > 0 && !slices.ContainsFunc(entry.Agents, func(agent model.AgentID) bool { return slices.Contains(f.Agents, agent) }) {
+		return false
+	}
+	if len(f.Components) > 0 && !slices.ContainsFunc(entry.Components, func(component model.ComponentID) bool { return slices.Contains(f.Components, component) }) {
+		return false
+	}
+	return true
+}
+
+// SharedEntry is a selected file that agents or components outside the
+// filter also manage.
+type SharedEntry struct {
+	Path       string
+	Agents     []model.AgentID
+	Components []model.ComponentID
+}
+
+// Shared lists the files among entries that the filter selects but that are
+// also tagged with agents or components it does not name. Restoring one puts
+// the whole file back, reverting those other owners' changes as well. A
+// filter that only names paths never reports shared files: the user picked
+// them explicitly.
+func (f Filter) Shared(entries []ManifestEntry) []SharedEntry {
+	shared := []SharedEntry{}
+	for _, entry := range entries {
+		if entry.IsDir() || !f.Matches(entry) {
+			continue
+		}
+
+		other := SharedEntry{Path: entry.OriginalPath}
+		if len(f.Agents) > 0 {
+			for _, agent := range entry.Agents {
+				if !slices.Contains(f.Agents, agent) {
+					other.Agents = append(other.Agents, agent)
+				}
+			}
+		}
+		if len(f.Components) > 0 {
+			for _, component := range entry.Components {
+				if !slices.Contains(f.Components, component) {
+					other.Components = append(other.Components, component)
+				}
+			}
+		}
+		if len(other.Agents) > 0 || len(other.Components) > 0 {
+			shared = append(shared, other)
+		}
+	}
+	return shared
+}
+
+// Select returns a copy of the manifest holding only the entries filter
+// matches.
+func (m Manifest) Select(filter Filter) Manifest {
+	if filter.IsZero() {
+		return m
+	}
+
+	selected := m
+	selected.Entries = nil
+	for _, entry := range m.Entries {
+		if filter.Matches(entry) {
+			selected.Entries = append(selected.Entries, entry)
+		}
+	}
+	return selected
 }
 
 func WriteManifest(path string, manifest Manifest) error {
@@ -53,3 +212,12 @@ func ReadManifest(path string) (Manifest, error) {
 
 	return manifest, nil
 }
+
+// within reports whether path is dir or inside it.
+func within(path string, dir string) bool {
+	path, dir = filepath.Clean(path), filepath.Clean(dir)
+	if path == dir {
+		return true
+	}
+	return strings.HasPrefix(path, strings.TrimSuffix(dir, string(filepath.Separator))+string(filepath.Separator))
+}
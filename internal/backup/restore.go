@@ -1,45 +1,311 @@
 package backup
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"time"
 
 	"github.com/gentleman-programming/gentle-ai/internal/components/filemerge"
 )
 
-type RestoreService struct{}
+var writeFileAtomic = filemerge.WriteFileAtomic
 
+// RestoreService puts snapshots back. A restore is all-or-nothing: the
+// current state of every path it touches is snapshotted first, and if any
+// entry fails the paths are rolled back to that safety snapshot.
+//
+// With SafetyRoot set, the safety snapshot is written there as a regular
+// backup the user can return to; otherwise it lives in a temporary directory
+// that is removed once the restore ends.
+//
+// Managed lists the files gentle-ai wrote. A snapshotted directory only
+// loses the managed paths it did not hold at snapshot time; anything else
+// found in it, such as a command or skill the user added later, is kept.
+//
+// Version is the gentle-ai version recorded on the safety snapshot's label.
+type RestoreService struct {
+	SafetyRoot string
+	Managed    []string
+	Version    string
+}
+
+// Restore puts every entry of manifest back.
 func (s RestoreService) Restore(manifest Manifest) error {
-	for _, entry := range manifest.Entries {
-		if entry.Existed {
-			if err := restoreEntry(entry); err != nil {
-				return err
-			}
-			continue
+	_, err := s.RestoreWithSnapshot(manifest)
+	return err
+}
+
+// RestoreWithSnapshot restores manifest and returns the safety snapshot of
+// the state it replaced. The returned manifest is zero when SafetyRoot is
+// empty. Every snapshot is read and checked first, so a damaged backup fails
+// before any file is touched.
+func (s RestoreService) RestoreWithSnapshot(manifest Manifest) (Manifest, error) {
+	contents := make([][]byte, len(manifest.Entries))
+	if problems := verifyEntries(manifest.Entries, contents); len(problems) > 0 {
+		return Manifest{}, &DamagedError{ID: manifest.ID, Problems: problems}
+	}
+
+	root := s.SafetyRoot
+	if root == "" {
+		tmp, err := os.MkdirTemp("", "gentle-ai-restore-*")
+		if err != nil {
+			return Manifest{}, fmt.Errorf("create safety snapshot directory: %w", err)
 		}
+		defer os.RemoveAll(tmp)
+		root = tmp
+	}
+
+	snapshotDir := filepath.Join(root, time.Now().UTC().Format("20060102150405.000000000"))
+	label := Label{Command: "restore", Summary: fmt.Sprintf("state before restoring %s", manifest.ID), Version: s.Version}
+	safety, err := NewSnapshotter(root).WithLabel(label).Create(snapshotDir, topLevelPaths(manifest.Entries))
+	if err != nil {
+		return Manifest{}, fmt.Errorf("snapshot current state before restore: %w", err)
+	}
 
-		if err := os.Remove(entry.OriginalPath); err != nil && !os.IsNotExist(err) {
-			return fmt.Errorf("remove path %q: %w", entry.OriginalPath, err)
+	if err := apply(manifest.Entries, contents, s.managed(), true); err != nil {
+		// The safety snapshot did not hold what this restore wrote, so
+		// rolling back may remove those paths too.
+		written := s
+		for _, entry := range manifest.Entries {
+			written.Managed = append(written.Managed, entry.OriginalPath)
+		}
+		if rollbackErr := written.rollback(safety); rollbackErr != nil {
+			return Manifest{}, fmt.Errorf("%w; rolling back to safety snapshot %q also failed: %v", err, safety.ID, rollbackErr)
 		}
+		return Manifest{}, fmt.Errorf("%w (rolled back to the previous state)", err)
 	}
 
-	return nil
+	if s.SafetyRoot == "" {
+		return Manifest{}, nil
+	}
+	return safety, nil
 }
 
-func restoreEntry(entry ManifestEntry) error {
-	content, err := os.ReadFile(entry.SnapshotPath)
-	if err != nil {
-		return fmt.Errorf("read snapshot file %q: %w", entry.SnapshotPath, err)
+// rollback puts the safety snapshot back, attempting every entry even when
+// some fail so as little as possible is left half restored.
+func (s RestoreService) rollback(safety Manifest) error {
+	contents := make([][]byte, len(safety.Entries))
+	if problems := verifyEntries(safety.Entries, contents); len(problems) > 0 {
+		return &DamagedError{ID: safety.ID, Problems: problems}
+	}
+
+	return apply(safety.Entries, contents, s.managed(), false)
+}
+
+func (s RestoreService) managed() managedPaths {
+	return managedPaths(s.Managed)
+}
+
+// managedPaths are the files gentle-ai wrote.
+type managedPaths []string
+
+// owns reports whether path is a managed file or lies inside one.
+func (m managedPaths) owns(path string) bool {
+	return slices.ContainsFunc(m, func(managed string) bool { return within(path, managed) })
+}
+
+// holds reports whether the directory path contains a managed file.
+func (m managedPaths) holds(path string) bool {
+	return slices.ContainsFunc(m, func(managed string) bool { return within(managed, path) })
+}
+
+// topLevelPaths returns the entry paths that are not inside a snapshotted
+// directory of the same manifest; snapshotting them covers every entry.
+func topLevelPaths(entries []ManifestEntry) []string {
+	var dirs []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			dirs = append(dirs, entry.OriginalPath)
+		}
+	}
+
+	paths := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		inside := slices.ContainsFunc(dirs, func(dir string) bool {
+			return dir != entry.OriginalPath && within(entry.OriginalPath, dir)
+		})
+		if !inside {
+			paths = append(paths, entry.OriginalPath)
+		}
+	}
+	return paths
+}
+
+// apply puts entries back in manifest order, so a directory is recreated
+// before its contents. Snapshotted directories then lose the managed paths
+// they did not hold, and mtimes are reset last because writing into a
+// directory changes its own. With failFast unset every entry is attempted
+// and the errors are joined.
+func apply(entries []ManifestEntry, contents [][]byte, managed managedPaths, failFast bool) error {
+	var errs []error
+	fail := func(err error) bool {
+		errs = append(errs, err)
+		return failFast
+	}
+
+	for i, entry := range entries {
+		var err error
+		switch {
+		case !entry.Existed:
+			err = removeCreated(entry.OriginalPath, managed)
+		case entry.IsDir():
+			err = restoreDir(entry)
+		case entry.Kind == KindSymlink:
+			err = restoreSymlink(entry)
+		default:
+			err = restoreEntry(entry, contents[i])
+		}
+		if err != nil && fail(err) {
+			return errors.Join(errs...)
+		}
 	}
 
+	if err := pruneDirs(entries, managed); err != nil && fail(err) {
+		return errors.Join(errs...)
+	}
+
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		if !entry.Existed || entry.ModTime.IsZero() || entry.Kind == KindSymlink {
+			continue
+		}
+		if err := os.Chtimes(entry.OriginalPath, entry.ModTime, entry.ModTime); err != nil && fail(fmt.Errorf("restore mtime of %q: %w", entry.OriginalPath, err)) {
+			return errors.Join(errs...)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func restoreEntry(entry ManifestEntry, content []byte) error {
 	if err := os.MkdirAll(filepath.Dir(entry.OriginalPath), 0o755); err != nil {
 		return fmt.Errorf("create restore directory for %q: %w", entry.OriginalPath, err)
 	}
 
-	if _, err := filemerge.WriteFileAtomic(entry.OriginalPath, content, os.FileMode(entry.Mode)); err != nil {
+	if _, err := writeFileAtomic(entry.OriginalPath, content, os.FileMode(entry.Mode)); err != nil {
 		return fmt.Errorf("restore path %q: %w", entry.OriginalPath, err)
 	}
 
 	return nil
 }
+
+func restoreDir(entry ManifestEntry) error {
+	if info, err := os.Lstat(entry.OriginalPath); err == nil && !info.IsDir() {
+		if err := os.Remove(entry.OriginalPath); err != nil {
+			return fmt.Errorf("replace %q with a directory: %w", entry.OriginalPath, err)
+		}
+	}
+
+	perm := os.FileMode(entry.Mode).Perm()
+	if err := os.MkdirAll(entry.OriginalPath, perm); err != nil {
+		return fmt.Errorf("restore directory %q: %w", entry.OriginalPath, err)
+	}
+	if err := os.Chmod(entry.OriginalPath, perm); err != nil {
+		return fmt.Errorf("restore permissions of %q: %w", entry.OriginalPath, err)
+	}
+
+	return nil
+}
+
+func restoreSymlink(entry ManifestEntry) error {
+	if err := os.MkdirAll(filepath.Dir(entry.OriginalPath), 0o755); err != nil {
+		return fmt.Errorf("create restore directory for %q: %w", entry.OriginalPath, err)
+	}
+	if err := os.RemoveAll(entry.OriginalPath); err != nil {
+		return fmt.Errorf("replace %q with a symlink: %w", entry.OriginalPath, err)
+	}
+	if err := os.Symlink(entry.LinkTarget, entry.OriginalPath); err != nil {
+		return fmt.Errorf("restore symlink %q: %w", entry.OriginalPath, err)
+	}
+
+	return nil
+}
+
+// removeCreated removes a path that did not exist when the snapshot was
+// taken. A file is removed outright; a directory only loses its managed
+// contents, and is removed itself once nothing else is left in it.
+func removeCreated(path string, managed managedPaths) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("stat path %q: %w", path, err)
+	}
+
+	if !info.IsDir() || managed.owns(path) {
+		if err := os.RemoveAll(path); err != nil {
+			return fmt.Errorf("remove path %q: %w", path, err)
+		}
+		return nil
+	}
+
+	if err := removeManaged(path, nil, managed); err != nil {
+		return err
+	}
+	removeIfEmpty(path)
+	return nil
+}
+
+// pruneDirs removes what an install added inside the snapshotted
+// directories: managed paths the snapshot does not list.
+func pruneDirs(entries []ManifestEntry, managed managedPaths) error {
+	known := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		known[filepath.Clean(entry.OriginalPath)] = true
+	}
+
+	var errs []error
+	for _, entry := range entries {
+		if !entry.Existed || !entry.IsDir() {
+			continue
+		}
+		if err := removeManaged(entry.OriginalPath, known, managed); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// removeManaged removes the managed paths below dir that known does not
+// list, descending into unknown directories that hold managed paths and
+// removing them when they end up empty.
+func removeManaged(dir string, known map[string]bool, managed managedPaths) error {
+	children, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("read directory %q: %w", dir, err)
+	}
+
+	var errs []error
+	for _, child := range children {
+		path := filepath.Join(dir, child.Name())
+		switch {
+		case known[path]:
+			continue
+		case managed.owns(path):
+			if err := os.RemoveAll(path); err != nil {
+				errs = append(errs, fmt.Errorf("remove added path %q: %w", path, err))
+			}
+		case child.IsDir() && managed.holds(path):
+			if err := removeManaged(path, known, managed); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			removeIfEmpty(path)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// removeIfEmpty removes dir when nothing is left in it. A directory that
+// still holds files is expected, so failures are ignored.
+func removeIfEmpty(dir string) {
+	if remaining, err := os.ReadDir(dir); err == nil && len(remaining) == 0 {
+		_ = os.Remove(dir)
+	}
+}
@@ -1,9 +1,15 @@
 package backup
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
+
+	"github.com/gentleman-programming/gentle-ai/internal/components/filemerge"
+	"github.com/gentleman-programming/gentle-ai/internal/model"
 )
 
 func TestRestoreRestoresExistingAndRemovesCreated(t *testing.T) {
@@ -68,3 +74,145 @@ func TestRestoreFailsWhenSnapshotMissing(t *testing.T) {
 		t.Fatalf("Restore() expected error for missing snapshot")
 	}
 }
+
+func TestRestoreRollsBackWhenAnEntryFails(t *testing.T) {
+	home := t.TempDir()
+	first := filepath.Join(home, "a.json")
+	second := filepath.Join(home, "b.json")
+	created := filepath.Join(home, "c.json")
+	for path, content := range map[string]string{first: "a-old\n", second: "b-old\n"} {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+
+	manifest, err := NewSnapshotter(filepath.Join(home, "backups")).Create(filepath.Join(home, "backups", "one"), []string{first, second, created})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	for path, content := range map[string]string{first: "a-new\n", second: "b-new\n", created: "c-new\n"} {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+
+	restoreWrite := writeFileAtomic
+	t.Cleanup(func() { writeFileAtomic = restoreWrite })
+	writeFileAtomic = func(path string, content []byte, perm os.FileMode) (filemerge.WriteResult, error) {
+		if path == second && string(content) == "b-old\n" {
+			return filemerge.WriteResult{}, errors.New("disk full")
+		}
+		return restoreWrite(path, content, perm)
+	}
+
+	err = RestoreService{}.Restore(manifest)
+	if err == nil || !strings.Contains(err.Error(), "rolled back") {
+		t.Fatalf("Restore() error = %v, want a rolled back failure", err)
+	}
+
+	for path, want := range map[string]string{first: "a-new\n", second: "b-new\n", created: "c-new\n"} {
+		content, err := os.ReadFile(path)
+		if err != nil || string(content) != want {
+			t.Fatalf("%s = %q, %v; want %q", filepath.Base(path), content, err, want)
+		}
+	}
+}
+
+func TestRestoreKeepsSafetySnapshotInStore(t *testing.T) {
+	home := t.TempDir()
+	store := Store{Root: filepath.Join(home, "backups")}
+	settings := filepath.Join(home, "settings.json")
+	if err := os.WriteFile(settings, []byte("old\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	manifest, err := NewSnapshotter(store.Root).Create(filepath.Join(store.Root, "one"), []string{settings})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if err := os.WriteFile(settings, []byte("current\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	safety, err := RestoreService{SafetyRoot: store.Root}.RestoreWithSnapshot(manifest)
+	if err != nil {
+		t.Fatalf("RestoreWithSnapshot() error = %v", err)
+	}
+	if safety.ID == "" {
+		t.Fatalf("expected a safety snapshot")
+	}
+
+	listed, err := store.Get(safety.ID)
+	if err != nil {
+		t.Fatalf("safety snapshot not in store: %v", err)
+	}
+
+	// Returning to the safety snapshot undoes the restore.
+	if err := (RestoreService{}).Restore(listed); err != nil {
+		t.Fatalf("Restore(safety) error = %v", err)
+	}
+	if content, _ := os.ReadFile(settings); string(content) != "current\n" {
+		t.Fatalf("settings after undo = %q", content)
+	}
+}
+
+func TestRestoreSelectedEntriesOnly(t *testing.T) {
+	home := t.TempDir()
+
+	settings := filepath.Join(home, ".claude", "settings.json")
+	prompt := filepath.Join(home, ".config", "opencode", "AGENTS.md")
+	for _, path := range []string{settings, prompt} {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("MkdirAll() error = %v", err)
+		}
+		if err := os.WriteFile(path, []byte("before\n"), 0o644); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+
+	manifest, err := NewSnapshotter(filepath.Join(home, "backups")).CreateTargets(filepath.Join(home, "backups", "snap"), []Target{
+		{Path: settings, Agents: []model.AgentID{model.AgentClaudeCode}, Components: []model.ComponentID{model.ComponentPermission, model.ComponentPersona}},
+		{Path: prompt, Agents: []model.AgentID{model.AgentOpenCode}, Components: []model.ComponentID{model.ComponentPersona}},
+	})
+	if err != nil {
+		t.Fatalf("CreateTargets() error = %v", err)
+	}
+	for _, path := range []string{settings, prompt} {
+		if err := os.WriteFile(path, []byte("after\n"), 0o644); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+
+	if got := manifest.Select(Filter{Components: []model.ComponentID{model.ComponentPersona}}); len(got.Entries) != 2 {
+		t.Fatalf("persona selection = %d entries, want 2", len(got.Entries))
+	}
+	if got := manifest.Select(Filter{Agents: []model.AgentID{model.AgentOpenCode}, Components: []model.ComponentID{model.ComponentPermission}}); len(got.Entries) != 0 {
+		t.Fatalf("filters should combine, got %#v", got.Entries)
+	}
+	if got := manifest.Select(Filter{Paths: []string{prompt + "/"}}); len(got.Entries) != 1 || got.Entries[0].OriginalPath != prompt {
+		t.Fatalf("path selection = %#v", got.Entries)
+	}
+
+	shared := Filter{Components: []model.ComponentID{model.ComponentPersona}}.Shared(manifest.Entries)
+	if len(shared) != 1 || shared[0].Path != settings || !slices.Equal(shared[0].Components, []model.ComponentID{model.ComponentPermission}) {
+		t.Fatalf("shared = %#v, want settings shared with permissions", shared)
+	}
+	if shared := (Filter{Paths: []string{settings}}).Shared(manifest.Entries); len(shared) != 0 {
+		t.Fatalf("explicit path selection reported shared files: %#v", shared)
+	}
+
+	selected := manifest.Select(Filter{Agents: []model.AgentID{model.AgentClaudeCode}})
+	if err := (RestoreService{}).Restore(selected); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+
+	if content, _ := os.ReadFile(settings); string(content) != "before\n" {
+		t.Fatalf("settings = %q, want it restored", content)
+	}
+	if content, _ := os.ReadFile(prompt); string(content) != "after\n" {
+		t.Fatalf("prompt = %q, want it untouched", content)
+	}
+	if len(manifest.Entries) != 2 {
+		t.Fatalf("Select() modified the original manifest: %#v", manifest.Entries)
+	}
+}
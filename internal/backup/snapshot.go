@@ -2,24 +2,61 @@ package backup
 
 import (
 	"fmt"
-	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
-	"strings"
+	"slices"
 	"time"
+
+	"github.com/gentleman-programming/gentle-ai/internal/model"
 )
 
 const ManifestFilename = "manifest.json"
 
+// Snapshotter records the current state of a set of paths. File contents go
+// to the blob store shared by every snapshot in the same backup root, so each
+// snapshot directory holds only its manifest.
 type Snapshotter struct {
-	now func() time.Time
+	root  string
+	now   func() time.Time
+	label Label
+}
+
+// NewSnapshotter returns a snapshotter that stores file contents in the blob
+// store under root, normally the backup root that also holds the snapshot
+// directories.
+func NewSnapshotter(root string) Snapshotter {
+	return Snapshotter{root: root, now: time.Now}
 }
 
-func NewSnapshotter() Snapshotter {
-	return Snapshotter{now: time.Now}
+// WithLabel returns a snapshotter that records label on its manifests.
+func (s Snapshotter) WithLabel(label Label) Snapshotter {
+	s.label = label
+	return s
 }
 
+// Target is a path to snapshot together with the agents and components that
+// manage it, so a restore can be narrowed to one of them later.
+type Target struct {
+	Path       string
+	Agents     []model.AgentID
+	Components []model.ComponentID
+}
+
+// Create snapshots paths without agent or component tags.
 func (s Snapshotter) Create(snapshotDir string, paths []string) (Manifest, error) {
+	targets := make([]Target, 0, len(paths))
+	for _, path := range paths {
+		targets = append(targets, Target{Path: path})
+	}
+	return s.CreateTargets(snapshotDir, targets)
+}
+
+// CreateTargets snapshots targets and records their tags on each entry. A
+// directory target is snapshotted recursively and everything in it inherits
+// its tags; a path reached through more than one target is recorded once
+// with the tags of all of them.
+func (s Snapshotter) CreateTargets(snapshotDir string, targets []Target) (Manifest, error) {
 	if err := os.MkdirAll(snapshotDir, 0o755); err != nil {
 		return Manifest{}, fmt.Errorf("create snapshot directory %q: %w", snapshotDir, err)
 	}
@@ -28,15 +65,27 @@ func (s Snapshotter) Create(snapshotDir string, paths []string) (Manifest, error
 		ID:        filepath.Base(snapshotDir),
 		CreatedAt: s.now().UTC(),
 		RootDir:   snapshotDir,
-		Entries:   make([]ManifestEntry, 0, len(paths)),
+		Label:     s.label,
+		Entries:   make([]ManifestEntry, 0, len(targets)),
 	}
 
-	for _, path := range paths {
-		entry, err := s.snapshotPath(snapshotDir, path)
+	index := map[string]int{}
+	for _, target := range targets {
+		entries, err := s.snapshotPath(target.Path)
 		if err != nil {
 			return Manifest{}, err
 		}
-		manifest.Entries = append(manifest.Entries, entry)
+		for _, entry := range entries {
+			if i, ok := index[entry.OriginalPath]; ok {
+				manifest.Entries[i].Agents = mergeTags(manifest.Entries[i].Agents, target.Agents)
+				manifest.Entries[i].Components = mergeTags(manifest.Entries[i].Components, target.Components)
+				continue
+			}
+			entry.Agents = slices.Clone(target.Agents)
+			entry.Components = slices.Clone(target.Components)
+			index[entry.OriginalPath] = len(manifest.Entries)
+			manifest.Entries = append(manifest.Entries, entry)
+		}
 	}
 
 	if err := WriteManifest(filepath.Join(snapshotDir, ManifestFilename), manifest); err != nil {
@@ -46,65 +95,108 @@ func (s Snapshotter) Create(snapshotDir string, paths []string) (Manifest, error
 	return manifest, nil
 }
 
-func (s Snapshotter) snapshotPath(snapshotDir string, sourcePath string) (ManifestEntry, error) {
+// snapshotPath records sourcePath, following it if it is a symlink. A
+// directory yields its own entry followed by entries for its contents.
+func (s Snapshotter) snapshotPath(sourcePath string) ([]ManifestEntry, error) {
 	cleanSource := filepath.Clean(sourcePath)
-	entry := ManifestEntry{OriginalPath: cleanSource}
 
 	info, err := os.Stat(cleanSource)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return entry, nil
+			return []ManifestEntry{{OriginalPath: cleanSource}}, nil
 		}
-		return ManifestEntry{}, fmt.Errorf("stat source path %q: %w", cleanSource, err)
+		return nil, fmt.Errorf("stat source path %q: %w", cleanSource, err)
 	}
 
 	if info.IsDir() {
-		return ManifestEntry{}, fmt.Errorf("backup source path %q is a directory", cleanSource)
+		return snapshotDirectory(s.root, cleanSource, info)
 	}
 
-	// Strip volume name (e.g. "C:") on Windows so the remaining path
-	// can be used safely as a relative directory inside the snapshot.
-	relative := strings.TrimPrefix(cleanSource, filepath.VolumeName(cleanSource))
-	relative = strings.TrimPrefix(relative, string(filepath.Separator))
-	if relative == "" {
-		relative = "root"
-	}
-
-	destination := filepath.Join(snapshotDir, "files", relative)
-	if err := copyFile(cleanSource, destination, info.Mode()); err != nil {
-		return ManifestEntry{}, err
+	entry, err := snapshotFile(s.root, cleanSource, info)
+	if err != nil {
+		return nil, err
 	}
-
-	entry.SnapshotPath = destination
-	entry.Existed = true
-	entry.Mode = uint32(info.Mode())
-	return entry, nil
+	return []ManifestEntry{entry}, nil
 }
 
-func copyFile(source string, destination string, mode os.FileMode) error {
-	input, err := os.Open(source)
+// snapshotDirectory records dir and everything below it. Symlinks inside are
+// recorded as links, not followed; anything that is not a file, directory or
+// symlink is skipped.
+func snapshotDirectory(root string, dir string, info fs.FileInfo) ([]ManifestEntry, error) {
+	entries := []ManifestEntry{{
+		OriginalPath: dir,
+		Kind:         KindDir,
+		Existed:      true,
+		Mode:         uint32(info.Mode()),
+		ModTime:      info.ModTime().UTC(),
+	}}
+
+	children, err := os.ReadDir(dir)
 	if err != nil {
-		return fmt.Errorf("open source file %q: %w", source, err)
+		return nil, fmt.Errorf("read directory %q: %w", dir, err)
 	}
-	defer input.Close()
 
-	if err := os.MkdirAll(filepath.Dir(destination), 0o755); err != nil {
-		return fmt.Errorf("create backup directory for %q: %w", destination, err)
+	for _, child := range children {
+		path := filepath.Join(dir, child.Name())
+		childInfo, err := os.Lstat(path)
+		if err != nil {
+			return nil, fmt.Errorf("stat source path %q: %w", path, err)
+		}
+
+		switch mode := childInfo.Mode(); {
+		case mode.IsDir():
+			nested, err := snapshotDirectory(root, path, childInfo)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, nested...)
+		case mode&fs.ModeSymlink != 0:
+			target, err := os.Readlink(path)
+			if err != nil {
+				return nil, fmt.Errorf("read symlink %q: %w", path, err)
+			}
+			entries = append(entries, ManifestEntry{
+				OriginalPath: path,
+				Kind:         KindSymlink,
+				Existed:      true,
+				Mode:         uint32(mode),
+				LinkTarget:   target,
+			})
+		case mode.IsRegular():
+			entry, err := snapshotFile(root, path, childInfo)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, entry)
+		}
 	}
 
-	output, err := os.OpenFile(destination, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode.Perm())
+	return entries, nil
+}
+
+func snapshotFile(root string, path string, info fs.FileInfo) (ManifestEntry, error) {
+	hash, blob, size, err := writeBlob(root, path)
 	if err != nil {
-		return fmt.Errorf("create snapshot file %q: %w", destination, err)
+		return ManifestEntry{}, err
 	}
 
-	if _, err := io.Copy(output, input); err != nil {
-		_ = output.Close()
-		return fmt.Errorf("copy %q to %q: %w", source, destination, err)
-	}
+	return ManifestEntry{
+		OriginalPath: path,
+		SnapshotPath: blob,
+		Kind:         KindFile,
+		Hash:         hash,
+		Size:         size,
+		Existed:      true,
+		Mode:         uint32(info.Mode()),
+		ModTime:      info.ModTime().UTC(),
+	}, nil
+}
 
-	if err := output.Close(); err != nil {
-		return fmt.Errorf("close snapshot file %q: %w", destination, err)
+func mergeTags[T comparable](tags []T, more []T) []T {
+	for _, tag := range more {
+		if !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
 	}
-
-	return nil
+	return tags
 }
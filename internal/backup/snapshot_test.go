@@ -0,0 +1,128 @@
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDirectorySnapshotRestoresTreeAndRemovesManagedAddedFiles(t *testing.T) {
+	home := t.TempDir()
+	dir := filepath.Join(home, "skills", "sdd-init")
+	nested := filepath.Join(dir, "references")
+	if err := os.MkdirAll(nested, 0o755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+
+	skill := filepath.Join(dir, "SKILL.md")
+	script := filepath.Join(nested, "run.sh")
+	link := filepath.Join(dir, "latest")
+	writeFile(t, skill, "before\n", 0o644)
+	writeFile(t, script, "#!/bin/sh\n", 0o755)
+	if err := os.Symlink("references/run.sh", link); err != nil {
+		t.Fatalf("Symlink() error = %v", err)
+	}
+	mtime := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	if err := os.Chtimes(skill, mtime, mtime); err != nil {
+		t.Fatalf("Chtimes() error = %v", err)
+	}
+
+	manifest, err := NewSnapshotter(filepath.Join(home, "backups")).Create(filepath.Join(home, "backups", "snap"), []string{dir})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if len(manifest.Entries) != 5 || !manifest.Entries[0].IsDir() {
+		t.Fatalf("entries = %#v, want the directory followed by its 4 children", manifest.Entries)
+	}
+
+	// What an install might do: rewrite a file, replace the link, add files.
+	writeFile(t, skill, "after\n", 0o600)
+	if err := os.Remove(link); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	writeFile(t, link, "not a link\n", 0o644)
+	writeFile(t, filepath.Join(dir, "added.md"), "new\n", 0o644)
+	writeFile(t, filepath.Join(dir, "assets", "added.json"), "{}\n", 0o644)
+	// And what the user added since, which the restore must keep.
+	mine := filepath.Join(dir, "mine.md")
+	writeFile(t, mine, "mine\n", 0o644)
+
+	managed := []string{filepath.Join(dir, "added.md"), filepath.Join(dir, "assets", "added.json")}
+	if err := (RestoreService{Managed: managed}).Restore(manifest); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+
+	info, err := os.Stat(skill)
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+	if content, _ := os.ReadFile(skill); string(content) != "before\n" {
+		t.Fatalf("skill = %q", content)
+	}
+	if info.Mode().Perm() != 0o644 || !info.ModTime().Equal(mtime) {
+		t.Fatalf("skill mode = %v, mtime = %v; want 0644 and %v", info.Mode().Perm(), info.ModTime(), mtime)
+	}
+	if info, err := os.Stat(script); err != nil || info.Mode().Perm() != 0o755 {
+		t.Fatalf("script mode = %v, err = %v", info, err)
+	}
+	if target, err := os.Readlink(link); err != nil || target != "references/run.sh" {
+		t.Fatalf("link target = %q, err = %v", target, err)
+	}
+	for _, added := range []string{filepath.Join(dir, "added.md"), filepath.Join(dir, "assets")} {
+		if _, err := os.Lstat(added); !os.IsNotExist(err) {
+			t.Fatalf("added path %q should be removed, err = %v", added, err)
+		}
+	}
+	if content, err := os.ReadFile(mine); err != nil || string(content) != "mine\n" {
+		t.Fatalf("user file = %q, err = %v; want it kept", content, err)
+	}
+}
+
+func TestDirectorySnapshotOfMissingDirectoryRemovesItOnRestore(t *testing.T) {
+	home := t.TempDir()
+	dir := filepath.Join(home, "commands")
+
+	manifest, err := NewSnapshotter(filepath.Join(home, "backups")).Create(filepath.Join(home, "backups", "snap"), []string{dir})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	installed := filepath.Join(dir, "sdd-init.md")
+	writeFile(t, installed, "new\n", 0o644)
+	service := RestoreService{Managed: []string{installed}}
+
+	if err := service.Restore(manifest); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Fatalf("directory created by the install should be removed, err = %v", err)
+	}
+
+	// A command the user added to the directory keeps it in place.
+	writeFile(t, installed, "new\n", 0o644)
+	mine := filepath.Join(dir, "mine.md")
+	writeFile(t, mine, "mine\n", 0o644)
+	if err := service.Restore(manifest); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if _, err := os.Stat(installed); !os.IsNotExist(err) {
+		t.Fatalf("installed command should be removed, err = %v", err)
+	}
+	if _, err := os.Stat(mine); err != nil {
+		t.Fatalf("user command was removed: %v", err)
+	}
+}
+
+func writeFile(t *testing.T, path string, content string, mode os.FileMode) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), mode); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		t.Fatalf("Chmod() error = %v", err)
+	}
+}
@@ -0,0 +1,394 @@
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned for backup IDs with no snapshot directory.
+var ErrNotFound = errors.New("backup not found")
+
+// Store manages the snapshot directories under a backup root, one directory
+// per backup named after its ID.
+type Store struct {
+	Root string
+}
+
+// PrunePolicy selects backups to delete. A backup is kept if it is among
+// the newest Keep, or younger than OlderThan; the rest are deleted. When
+// MaxTotalSize is set, the oldest remaining backups are then deleted until the
+// store fits. Pinned backups and the IDs in Protect are never deleted. A zero
+// field does not constrain the selection.
+type PrunePolicy struct {
+	Keep         int
+	OlderThan    time.Duration
+	MaxTotalSize int64
+	Protect      []string
+}
+
+// IsZero reports whether the policy would never delete anything.
+func (p PrunePolicy) IsZero() bool {
+	return p.Keep == 0 && p.OlderThan <= 0 && p.MaxTotalSize <= 0
+}
+
+// DefaultRoot returns the directory installs write their snapshots to.
+func DefaultRoot(homeDir string) string {
+	return filepath.Join(homeDir, ".gentle-ai", "backups")
+}
+
+// NewStore returns the store for the user's default backup root.
+func NewStore(homeDir string) Store {
+	return Store{Root: DefaultRoot(homeDir)}
+}
+
+// List returns every readable backup, newest first. Directories without a
+// valid manifest are skipped. A missing root means there are no backups.
+func (s Store) List() ([]Manifest, error) {
+	entries, err := os.ReadDir(s.Root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read backup directory %q: %w", s.Root, err)
+	}
+
+	manifests := make([]Manifest, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		manifest, err := ReadManifest(filepath.Join(s.Root, entry.Name(), ManifestFilename))
+		if err != nil {
+			continue
+		}
+		manifests = append(manifests, manifest)
+	}
+
+	sort.SliceStable(manifests, func(i, j int) bool {
+		return manifests[i].CreatedAt.After(manifests[j].CreatedAt)
+	})
+
+	return manifests, nil
+}
+
+// Get reads the manifest of the backup with the given ID.
+func (s Store) Get(id string) (Manifest, error) {
+	dir, err := s.dir(id)
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	manifest, err := ReadManifest(filepath.Join(dir, ManifestFilename))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return Manifest{}, fmt.Errorf("%w: %q", ErrNotFound, id)
+		}
+		return Manifest{}, err
+	}
+
+	return manifest, nil
+}
+
+// Delete removes the backup with the given ID, then any blobs no other
+// backup references.
+func (s Store) Delete(id string) error {
+	if err := s.remove(id); err != nil {
+		return err
+	}
+
+	_, err := s.CollectGarbage()
+	return err
+}
+
+func (s Store) remove(id string) error {
+	if _, err := s.Get(id); err != nil {
+		return err
+	}
+
+	dir, err := s.dir(id)
+	if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("delete backup %q: %w", id, err)
+	}
+
+	return nil
+}
+
+// CollectGarbage removes blobs no manifest references and returns how many
+// it removed. Blobs touched within the last few minutes are kept so a
+// snapshot still being written does not lose its content. It refuses to run
+// while any manifest is unreadable, since its blobs cannot be accounted for.
+func (s Store) CollectGarbage() (int, error) {
+	entries, err := os.ReadDir(s.Root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("read backup directory %q: %w", s.Root, err)
+	}
+
+	referenced := map[string]bool{}
+	for _, entry := range entries {
+		if !entry.IsDir() || entry.Name() == BlobDirname {
+			continue
+		}
+
+		manifest, err := ReadManifest(filepath.Join(s.Root, entry.Name(), ManifestFilename))
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return 0, fmt.Errorf("collect unused blobs: %w", err)
+		}
+		for _, item := range manifest.Entries {
+			if item.Hash != "" {
+				referenced[item.Hash] = true
+			}
+		}
+	}
+
+	cutoff := time.Now().Add(-blobGracePeriod)
+	removed := 0
+	err = filepath.WalkDir(filepath.Join(s.Root, BlobDirname), func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if entry.IsDir() || referenced[entry.Name()] {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		if info.ModTime().After(cutoff) {
+			return nil
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		removed++
+		return nil
+	})
+	if err != nil {
+		return removed, fmt.Errorf("collect unused blobs: %w", err)
+	}
+
+	return removed, nil
+}
+
+// PruneCandidates returns the backups policy would delete, newest first.
+func (s Store) PruneCandidates(policy PrunePolicy, now time.Time) ([]Manifest, error) {
+	if policy.Keep < 0 {
+		return nil, fmt.Errorf("keep must not be negative, got %d", policy.Keep)
+	}
+	if policy.IsZero() {
+		return nil, fmt.Errorf("prune needs a keep count, a maximum age or a maximum total size")
+	}
+
+	manifests, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+
+	protected := map[string]bool{}
+	for _, id := range policy.Protect {
+		protected[id] = true
+	}
+
+	cutoff := now.Add(-policy.OlderThan)
+	byCount := policy.Keep > 0 || policy.OlderThan > 0
+	candidates := []Manifest{}
+	remaining := []Manifest{}
+	for i, manifest := range manifests {
+		keep := manifest.Pinned || protected[manifest.ID] || !byCount ||
+			i < policy.Keep ||
+			(policy.OlderThan > 0 && !manifest.CreatedAt.Before(cutoff))
+		if keep {
+			remaining = append(remaining, manifest)
+			continue
+		}
+		candidates = append(candidates, manifest)
+	}
+
+	if policy.MaxTotalSize > 0 {
+		usage, err := s.usage(remaining)
+		if err != nil {
+			return nil, err
+		}
+
+		// Oldest first, so the newest backups survive the size cap. Deleting
+		// a backup frees its directory plus the blobs only it referenced.
+		overflow := []Manifest{}
+		total := usage.total
+		for i := len(remaining) - 1; i >= 0 && total > policy.MaxTotalSize; i-- {
+			manifest := remaining[i]
+			if manifest.Pinned || protected[manifest.ID] {
+				continue
+			}
+			overflow = append(overflow, manifest)
+			total -= usage.dirs[i]
+			for _, hash := range usage.hashes[i] {
+				usage.refs[hash]--
+				if usage.refs[hash] == 0 {
+					total -= usage.blobs[hash]
+				}
+			}
+		}
+		candidates = append(candidates, overflow...)
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return candidates[i].CreatedAt.After(candidates[j].CreatedAt)
+		})
+	}
+
+	return candidates, nil
+}
+
+// Prune deletes the backups selected by policy and returns them.
+func (s Store) Prune(policy PrunePolicy, now time.Time) ([]Manifest, error) {
+	candidates, err := s.PruneCandidates(policy, now)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, manifest := range candidates {
+		if err := s.remove(manifest.ID); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := s.CollectGarbage(); err != nil {
+		return nil, err
+	}
+
+	return candidates, nil
+}
+
+// SetPinned pins or unpins a backup.
+func (s Store) SetPinned(id string, pinned bool) (Manifest, error) {
+	manifest, err := s.Get(id)
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	dir, err := s.dir(id)
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	manifest.Pinned = pinned
+	if err := WriteManifest(filepath.Join(dir, ManifestFilename), manifest); err != nil {
+		return Manifest{}, err
+	}
+
+	return manifest, nil
+}
+
+// Size returns the bytes a backup occupies on disk: its directory plus every
+// blob it references, counting blobs shared with other backups in full.
+func (s Store) Size(id string) (int64, error) {
+	manifest, err := s.Get(id)
+	if err != nil {
+		return 0, err
+	}
+
+	usage, err := s.usage([]Manifest{manifest})
+	if err != nil {
+		return 0, err
+	}
+	return usage.total, nil
+}
+
+// storeUsage is the disk usage of a set of backups: per-backup directory size
+// and blob hashes, plus each blob's size and how many backups reference it.
+type storeUsage struct {
+	total  int64
+	dirs   []int64
+	hashes [][]string
+	blobs  map[string]int64
+	refs   map[string]int
+}
+
+func (s Store) usage(manifests []Manifest) (storeUsage, error) {
+	usage := storeUsage{
+		dirs:   make([]int64, len(manifests)),
+		hashes: make([][]string, len(manifests)),
+		blobs:  map[string]int64{},
+		refs:   map[string]int{},
+	}
+
+	for i, manifest := range manifests {
+		dir, err := s.dir(manifest.ID)
+		if err != nil {
+			return storeUsage{}, err
+		}
+		size, err := dirSize(dir)
+		if err != nil {
+			return storeUsage{}, fmt.Errorf("measure backup %q: %w", manifest.ID, err)
+		}
+		usage.dirs[i] = size
+		usage.total += size
+
+		seen := map[string]bool{}
+		for _, entry := range manifest.Entries {
+			if entry.Hash == "" || seen[entry.Hash] {
+				continue
+			}
+			seen[entry.Hash] = true
+			usage.hashes[i] = append(usage.hashes[i], entry.Hash)
+			usage.refs[entry.Hash]++
+
+			if _, ok := usage.blobs[entry.Hash]; ok {
+				continue
+			}
+			info, err := os.Stat(blobPath(s.Root, entry.Hash))
+			if err != nil {
+				if os.IsNotExist(err) {
+					usage.blobs[entry.Hash] = 0
+					continue
+				}
+				return storeUsage{}, fmt.Errorf("measure blob %s: %w", entry.Hash, err)
+			}
+			usage.blobs[entry.Hash] = info.Size()
+			usage.total += info.Size()
+		}
+	}
+
+	return usage, nil
+}
+
+func dirSize(dir string) (int64, error) {
+	var total int64
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		total += info.Size()
+		return nil
+	})
+	return total, err
+}
+
+func (s Store) dir(id string) (string, error) {
+	if id == "" || id == "." || id == ".." || strings.ContainsAny(id, `/\`) {
+		return "", fmt.Errorf("invalid backup id %q", id)
+	}
+	return filepath.Join(s.Root, id), nil
+}
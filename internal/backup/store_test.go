@@ -0,0 +1,163 @@
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStoreListSortsNewestFirstAndSkipsInvalid(t *testing.T) {
+	store := Store{Root: t.TempDir()}
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	writeTestManifest(t, store, "old", base)
+	writeTestManifest(t, store, "new", base.Add(time.Hour))
+	if err := os.MkdirAll(filepath.Join(store.Root, "broken"), 0o755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+
+	manifests, err := store.List()
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(manifests) != 2 || manifests[0].ID != "new" || manifests[1].ID != "old" {
+		t.Fatalf("List() = %#v", manifests)
+	}
+}
+
+func TestStoreListWithoutRootIsEmpty(t *testing.T) {
+	manifests, err := Store{Root: filepath.Join(t.TempDir(), "missing")}.List()
+	if err != nil || len(manifests) != 0 {
+		t.Fatalf("List() = %v, %v", manifests, err)
+	}
+}
+
+func TestStoreGetAndDelete(t *testing.T) {
+	store := Store{Root: t.TempDir()}
+	writeTestManifest(t, store, "one", time.Now())
+
+	if _, err := store.Get("one"); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if _, err := store.Get("../one"); err == nil || !strings.Contains(err.Error(), "invalid backup id") {
+		t.Fatalf("Get(traversal) error = %v", err)
+	}
+	if err := store.Delete("missing"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("Delete(missing) error = %v", err)
+	}
+
+	if err := store.Delete("one"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(store.Root, "one")); !os.IsNotExist(err) {
+		t.Fatalf("backup directory still exists, err = %v", err)
+	}
+}
+
+func TestStorePruneCombinesKeepAndAge(t *testing.T) {
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	tests := map[string]struct {
+		policy PrunePolicy
+		want   []string
+	}{
+		"keep only":       {PrunePolicy{Keep: 2}, []string{"c", "d"}},
+		"age only":        {PrunePolicy{OlderThan: 30 * 24 * time.Hour}, []string{"c", "d"}},
+		"keep and age":    {PrunePolicy{Keep: 3, OlderThan: 30 * 24 * time.Hour}, []string{"d"}},
+		"keep covers all": {PrunePolicy{Keep: 10}, nil},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			store := Store{Root: t.TempDir()}
+			writeTestManifest(t, store, "a", now.Add(-time.Hour))
+			writeTestManifest(t, store, "b", now.Add(-10*24*time.Hour))
+			writeTestManifest(t, store, "c", now.Add(-40*24*time.Hour))
+			writeTestManifest(t, store, "d", now.Add(-90*24*time.Hour))
+
+			pruned, err := store.Prune(tc.policy, now)
+			if err != nil {
+				t.Fatalf("Prune() error = %v", err)
+			}
+
+			got := []string{}
+			for _, manifest := range pruned {
+				got = append(got, manifest.ID)
+			}
+			if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+				t.Fatalf("pruned = %v, want %v", got, tc.want)
+			}
+
+			remaining, err := store.List()
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			if len(remaining) != 4-len(tc.want) {
+				t.Fatalf("remaining = %d backups, want %d", len(remaining), 4-len(tc.want))
+			}
+		})
+	}
+}
+
+func TestStorePruneNeverDeletesPinnedOrProtected(t *testing.T) {
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	store := Store{Root: t.TempDir()}
+	writeTestManifest(t, store, "a", now.Add(-1*time.Hour))
+	writeTestManifest(t, store, "b", now.Add(-2*time.Hour))
+	writeTestManifest(t, store, "c", now.Add(-3*time.Hour))
+	if _, err := store.SetPinned("c", true); err != nil {
+		t.Fatalf("SetPinned() error = %v", err)
+	}
+
+	pruned, err := store.Prune(PrunePolicy{Keep: 0, OlderThan: time.Minute, Protect: []string{"a"}}, now)
+	if err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+	if len(pruned) != 1 || pruned[0].ID != "b" {
+		t.Fatalf("pruned = %#v, want only b", pruned)
+	}
+}
+
+func TestStorePruneEnforcesMaxTotalSize(t *testing.T) {
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	store := Store{Root: t.TempDir()}
+	for i, id := range []string{"new", "mid", "old"} {
+		writeTestManifest(t, store, id, now.Add(-time.Duration(i+1)*time.Hour))
+		payload := filepath.Join(store.Root, id, "files", "payload")
+		if err := os.MkdirAll(filepath.Dir(payload), 0o755); err != nil {
+			t.Fatalf("MkdirAll() error = %v", err)
+		}
+		if err := os.WriteFile(payload, make([]byte, 1000), 0o644); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+
+	size, err := store.Size("new")
+	if err != nil || size < 1000 {
+		t.Fatalf("Size() = %d, %v", size, err)
+	}
+
+	pruned, err := store.Prune(PrunePolicy{MaxTotalSize: 2 * size}, now)
+	if err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+	if len(pruned) != 1 || pruned[0].ID != "old" {
+		t.Fatalf("pruned = %#v, want only the oldest backup", pruned)
+	}
+}
+
+func TestStorePruneRequiresPolicy(t *testing.T) {
+	if _, err := (Store{Root: t.TempDir()}).Prune(PrunePolicy{}, time.Now()); err == nil {
+		t.Fatalf("Prune() expected error without keep or age")
+	}
+}
+
+func writeTestManifest(t *testing.T, store Store, id string, createdAt time.Time) {
+	t.Helper()
+
+	dir := filepath.Join(store.Root, id)
+	manifest := Manifest{ID: id, CreatedAt: createdAt, RootDir: dir}
+	if err := WriteManifest(filepath.Join(dir, ManifestFilename), manifest); err != nil {
+		t.Fatalf("WriteManifest() error = %v", err)
+	}
+}
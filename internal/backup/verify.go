@@ -0,0 +1,120 @@
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Problem is a snapshot entry that cannot be restored.
+type Problem struct {
+	OriginalPath string
+	Reason       string
+}
+
+// DamagedError reports every problem found in a backup.
+type DamagedError struct {
+	ID       string
+	Problems []Problem
+}
+
+func (e *DamagedError) Error() string {
+	reasons := make([]string, 0, len(e.Problems))
+	for _, problem := range e.Problems {
+		reasons = append(reasons, problem.Reason)
+	}
+	return fmt.Sprintf("backup %q is damaged (%d entries): %s", e.ID, len(e.Problems), strings.Join(reasons, "; "))
+}
+
+// Verify reads every snapshot in manifest and checks it against the recorded
+// size and checksum. It returns nil when the backup can be fully restored.
+func Verify(manifest Manifest) []Problem {
+	return verifyEntries(manifest.Entries, nil)
+}
+
+// verifyEntries checks each entry; when contents is not nil it also keeps the
+// verified content of each entry at the same index.
+func verifyEntries(entries []ManifestEntry, contents [][]byte) []Problem {
+	var problems []Problem
+	for i, entry := range entries {
+		if !entry.hasContent() {
+			continue
+		}
+
+		content, err := readSnapshot(entry)
+		if err != nil {
+			problems = append(problems, Problem{OriginalPath: entry.OriginalPath, Reason: err.Error()})
+			continue
+		}
+		if contents != nil {
+			contents[i] = content
+		}
+	}
+
+	return problems
+}
+
+// VerifyResult is the outcome of checking one backup. Err is set when the
+// manifest itself cannot be read.
+type VerifyResult struct {
+	ID       string
+	Manifest Manifest
+	Err      error
+	Problems []Problem
+}
+
+// OK reports whether the backup can be fully restored.
+func (r VerifyResult) OK() bool {
+	return r.Err == nil && len(r.Problems) == 0
+}
+
+// Verify checks one backup.
+func (s Store) Verify(id string) (VerifyResult, error) {
+	if _, err := s.dir(id); err != nil {
+		return VerifyResult{}, err
+	}
+
+	manifest, err := s.Get(id)
+	if errors.Is(err, ErrNotFound) {
+		return VerifyResult{}, err
+	}
+	if err != nil {
+		return VerifyResult{ID: id, Err: err}, nil
+	}
+
+	return VerifyResult{ID: id, Manifest: manifest, Problems: Verify(manifest)}, nil
+}
+
+// VerifyAll checks every backup directory, including those whose manifest is
+// unreadable and which List skips.
+func (s Store) VerifyAll() ([]VerifyResult, error) {
+	entries, err := os.ReadDir(s.Root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read backup directory %q: %w", s.Root, err)
+	}
+
+	results := []VerifyResult{}
+	for _, entry := range entries {
+		if !entry.IsDir() || entry.Name() == BlobDirname {
+			continue
+		}
+
+		manifest, err := ReadManifest(filepath.Join(s.Root, entry.Name(), ManifestFilename))
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				err = fmt.Errorf("missing %s", ManifestFilename)
+			}
+			results = append(results, VerifyResult{ID: entry.Name(), Err: err})
+			continue
+		}
+		results = append(results, VerifyResult{ID: entry.Name(), Manifest: manifest, Problems: Verify(manifest)})
+	}
+
+	return results, nil
+}
@@ -0,0 +1,95 @@
+package backup
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRestoreChecksEveryEntryBeforeWriting(t *testing.T) {
+	home := t.TempDir()
+	first := filepath.Join(home, "a.json")
+	second := filepath.Join(home, "b.json")
+	for _, path := range []string{first, second} {
+		if err := os.WriteFile(path, []byte("before\n"), 0o644); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+
+	manifest, err := NewSnapshotter(filepath.Join(home, "backups")).Create(filepath.Join(home, "backups", "one"), []string{first, second})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	for _, path := range []string{first, second} {
+		if err := os.WriteFile(path, []byte("after\n"), 0o644); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+
+	// Both files share a blob; point the second entry at a missing one.
+	manifest.Entries[1].SnapshotPath = filepath.Join(home, "backups", BlobDirname, "missing")
+
+	err = RestoreService{}.Restore(manifest)
+	var damaged *DamagedError
+	if !errors.As(err, &damaged) || len(damaged.Problems) != 1 {
+		t.Fatalf("Restore() error = %v, want DamagedError with one problem", err)
+	}
+	if content, _ := os.ReadFile(first); string(content) != "after\n" {
+		t.Fatalf("first entry was restored before the damage was found: %q", content)
+	}
+}
+
+func TestVerifyDetectsTruncatedSnapshot(t *testing.T) {
+	home := t.TempDir()
+	path := filepath.Join(home, "settings.json")
+	if err := os.WriteFile(path, []byte("{\"theme\": \"dark\"}\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	manifest, err := NewSnapshotter(filepath.Join(home, "backups")).Create(filepath.Join(home, "backups", "one"), []string{path})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if manifest.Entries[0].Size != 18 {
+		t.Fatalf("recorded size = %d, want 18", manifest.Entries[0].Size)
+	}
+	if problems := Verify(manifest); len(problems) != 0 {
+		t.Fatalf("Verify() on intact backup = %v", problems)
+	}
+
+	if err := os.WriteFile(manifest.Entries[0].SnapshotPath, []byte("{\"th"), 0o600); err != nil {
+		t.Fatalf("WriteFile(blob) error = %v", err)
+	}
+	problems := Verify(manifest)
+	if len(problems) != 1 || !strings.Contains(problems[0].Reason, "truncated") {
+		t.Fatalf("Verify() = %v, want a truncation problem", problems)
+	}
+}
+
+func TestStoreVerifyAllReportsUnreadableManifests(t *testing.T) {
+	store := Store{Root: t.TempDir()}
+	writeTestManifest(t, store, "good", time.Now())
+	if err := os.MkdirAll(filepath.Join(store.Root, "empty"), 0o755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+
+	results, err := store.VerifyAll()
+	if err != nil {
+		t.Fatalf("VerifyAll() error = %v", err)
+	}
+
+	status := map[string]bool{}
+	for _, result := range results {
+		status[result.ID] = result.OK()
+	}
+	if len(status) != 2 || !status["good"] || status["empty"] {
+		t.Fatalf("VerifyAll() status = %v", status)
+	}
+
+	if _, err := store.Verify("missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Verify(missing) error = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,517 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gentleman-programming/gentle-ai/internal/backup"
+	"github.com/gentleman-programming/gentle-ai/internal/catalog"
+	"github.com/gentleman-programming/gentle-ai/internal/config"
+	"github.com/gentleman-programming/gentle-ai/internal/lock"
+	"github.com/gentleman-programming/gentle-ai/internal/model"
+)
+
+const backupUsage = "backup list | show ID | verify [ID] | restore ID [--file PATH] [--agent A] [--component C] | delete ID | export ID [-o FILE] | import FILE | pin ID | unpin ID | prune [--keep N] [--older-than AGE] [--max-size SIZE] [--dry-run]"
+
+// RunBackup handles `gentle-ai backup <subcommand>` and returns the text to
+// print. It manages the snapshots installs, uninstalls and repairs leave in
+// ~/.gentle-ai/backups without going through the TUI. verify returns its
+// report together with an error when a backup is damaged, so callers should
+// print the text even on error.
+//
+//	backup list                                   list backups, newest first
+//	backup show ID                                list the files in a backup
+//	backup verify [ID]                            check snapshots against their checksums
+//	backup restore ID                             put every file back as it was
+//	backup restore ID --agent claude-code         only restore what one agent owns
+//	backup delete ID                              remove a backup
+//	backup export ID -o backup.tar.gz             archive a backup for another machine
+//	backup import backup.tar.gz                   add an exported backup to this one
+//	backup pin ID / unpin ID                      exempt a backup from pruning
+//	backup prune --keep N --older-than 30d        remove old backups
+//
+// prune without limits applies the retention policy from
+// ~/.gentle-ai/config.json, the same one installs apply automatically.
+// version is recorded on the safety snapshot a restore takes.
+func RunBackup(args []string, version string) (string, error) {
+	if len(args) == 0 {
+		return "", fmt.Errorf("missing backup subcommand (usage: gentle-ai %s)", backupUsage)
+	}
+
+	homeDir, err := osUserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("resolve user home directory: %w", err)
+	}
+	store := backup.NewStore(homeDir)
+
+	// Subcommands that change files or the store take the run lock, so they
+	// cannot interleave with an install or with each other.
+	switch args[0] {
+	case "restore", "delete", "import", "pin", "unpin", "prune":
+		runLock, err := lock.Acquire(homeDir, "backup "+args[0])
+		if err != nil {
+			return "", err
+		}
+		defer releaseRunLock(runLock)
+	}
+
+	switch args[0] {
+	case "list":
+		if len(args) != 1 {
+			return "", fmt.Errorf("usage: gentle-ai backup list")
+		}
+		manifests, err := store.List()
+		if err != nil {
+			return "", err
+		}
+		return RenderBackupList(manifests), nil
+	case "show":
+		id, err := backupIDArg("show", args[1:])
+		if err != nil {
+			return "", err
+		}
+		manifest, err := store.Get(id)
+		if err != nil {
+			return "", err
+		}
+		return RenderBackupManifest(manifest), nil
+	case "verify":
+		if len(args) > 2 {
+			return "", fmt.Errorf("usage: gentle-ai backup verify [ID]")
+		}
+		var results []backup.VerifyResult
+		if len(args) == 2 {
+			result, err := store.Verify(args[1])
+			if err != nil {
+				return "", err
+			}
+			results = []backup.VerifyResult{result}
+		} else {
+			results, err = store.VerifyAll()
+			if err != nil {
+				return "", err
+			}
+		}
+		return renderVerifyResults(results)
+	case "restore":
+		return runBackupRestore(homeDir, store, args[1:], version)
+	case "delete":
+		id, err := backupIDArg("delete", args[1:])
+		if err != nil {
+			return "", err
+		}
+		if err := store.Delete(id); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Deleted backup %s", id), nil
+	case "export":
+		return runBackupExport(homeDir, store, args[1:])
+	case "import":
+		if len(args) != 2 || strings.TrimSpace(args[1]) == "" {
+			return "", fmt.Errorf("usage: gentle-ai backup import FILE")
+		}
+		file, err := os.Open(args[1])
+		if err != nil {
+			return "", fmt.Errorf("open backup archive: %w", err)
+		}
+		defer file.Close()
+		manifest, err := store.Import(file, homeDir)
+		if err != nil {
+			return "", fmt.Errorf("import %s: %w", args[1], err)
+		}
+		return fmt.Sprintf("Imported backup %s (%d files); restore it with `gentle-ai backup restore %s`", manifest.ID, len(manifest.Entries), manifest.ID), nil
+	case "pin", "unpin":
+		id, err := backupIDArg(args[0], args[1:])
+		if err != nil {
+			return "", err
+		}
+		if _, err := store.SetPinned(id, args[0] == "pin"); err != nil {
+			return "", err
+		}
+		if args[0] == "pin" {
+			return fmt.Sprintf("Pinned backup %s; pruning will keep it", id), nil
+		}
+		return fmt.Sprintf("Unpinned backup %s", id), nil
+	case "prune":
+		return runBackupPrune(homeDir, store, args[1:])
+	default:
+		return "", fmt.Errorf("unknown backup subcommand %q (usage: gentle-ai %s)", args[0], backupUsage)
+	}
+}
+
+// runBackupRestore restores a backup, or with --file, --agent or --component
+// only the entries that match. Entries are tagged with the agents and
+// components that manage them; backups taken before tagging existed can still
+// be restored selectively with --file.
+func runBackupRestore(homeDir string, store backup.Store, args []string, version string) (string, error) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") || strings.TrimSpace(args[0]) == "" {
+		return "", fmt.Errorf("usage: gentle-ai backup restore ID [--file PATH] [--agent A] [--component C]")
+	}
+	id := strings.TrimSpace(args[0])
+
+	var files, agents, components []string
+	fs := flag.NewFlagSet("backup restore", flag.ContinueOnError)
+	fs.SetOutput(ioDiscard{})
+	registerListFlag(fs, "file", &files)
+	registerListFlag(fs, "files", &files)
+	registerListFlag(fs, "agent", &agents)
+	registerListFlag(fs, "agents", &agents)
+	registerListFlag(fs, "component", &components)
+	registerListFlag(fs, "components", &components)
+
+	if err := fs.Parse(args[1:]); err != nil {
+		return "", err
+	}
+	if fs.NArg() > 0 {
+		return "", fmt.Errorf("unexpected backup restore argument %q", fs.Arg(0))
+	}
+
+	filter, err := restoreFilter(homeDir, files, agents, components)
+	if err != nil {
+		return "", err
+	}
+
+	manifest, err := store.Get(id)
+	if err != nil {
+		return "", err
+	}
+	selected := manifest.Select(filter)
+	if len(selected.Entries) == 0 {
+		return "", fmt.Errorf("no files in backup %q match the selection (backups taken before entries were tagged only support --file)", id)
+	}
+
+	managed, err := RecordedFiles(homeDir)
+	if err != nil {
+		return "", err
+	}
+	safety, err := backup.RestoreService{SafetyRoot: store.Root, Managed: managed, Version: version}.RestoreWithSnapshot(selected)
+	if err != nil {
+		return "", fmt.Errorf("restore backup %q: %w", id, err)
+	}
+	restored, removed := countEntries(selected)
+	return fmt.Sprintf("%sRestored backup %s: %d files restored, %d created files removed\nThe previous state was saved as backup %s; restore it to undo.", renderSharedWarning(filter.Shared(selected.Entries)), id, restored, removed, safety.ID), nil
+}
+
+// renderSharedWarning explains that restoring a file other agents or
+// components also manage reverts their changes too, since files are
+// restored whole.
+func renderSharedWarning(shared []backup.SharedEntry) string {
+	if len(shared) == 0 {
+		return ""
+	}
+
+	b := &strings.Builder{}
+	_, _ = fmt.Fprintln(b, "Warning: these files are shared with agents or components outside the selection and were restored whole, reverting their changes too:")
+	for _, entry := range shared {
+		owners := make([]string, 0, len(entry.Agents)+len(entry.Components))
+		for _, agent := range entry.Agents {
+			owners = append(owners, string(agent))
+		}
+		for _, component := range entry.Components {
+			owners = append(owners, string(component))
+		}
+		_, _ = fmt.Fprintf(b, "  %s (also %s)\n", entry.Path, strings.Join(owners, ", "))
+	}
+	return b.String()
+}
+
+func restoreFilter(homeDir string, files, agents, components []string) (backup.Filter, error) {
+	filter := backup.Filter{}
+
+	for _, file := range files {
+		if file == "~" || strings.HasPrefix(file, "~/") {
+			file = filepath.Join(homeDir, strings.TrimPrefix(file, "~"))
+		}
+		path, err := filepath.Abs(file)
+		if err != nil {
+			return backup.Filter{}, fmt.Errorf("resolve path %q: %w", file, err)
+		}
+		filter.Paths = append(filter.Paths, path)
+	}
+
+	for _, agent := range asAgentIDs(agents) {
+		if !catalog.IsSupportedAgent(agent) {
+			return backup.Filter{}, fmt.Errorf("unsupported agent %q", agent)
+		}
+		filter.Agents = append(filter.Agents, agent)
+	}
+
+	if len(components) > 0 {
+		componentIDs, err := normalizeComponents(components, model.PresetCustom)
+		if err != nil {
+			return backup.Filter{}, err
+		}
+		filter.Components = componentIDs
+	}
+
+	return filter, nil
+}
+
+// runBackupExport writes a backup archive. The archive is written next to its
+// destination and renamed into place, so a failed export leaves no partial
+// file behind.
+func runBackupExport(homeDir string, store backup.Store, args []string) (string, error) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") || strings.TrimSpace(args[0]) == "" {
+		return "", fmt.Errorf("usage: gentle-ai backup export ID [-o FILE]")
+	}
+	id := strings.TrimSpace(args[0])
+
+	var output string
+	fs := flag.NewFlagSet("backup export", flag.ContinueOnError)
+	fs.SetOutput(ioDiscard{})
+	fs.StringVar(&output, "o", "", "archive to write")
+	fs.StringVar(&output, "output", "", "archive to write")
+	if err := fs.Parse(args[1:]); err != nil {
+		return "", err
+	}
+	if fs.NArg() > 0 {
+		return "", fmt.Errorf("unexpected backup export argument %q", fs.Arg(0))
+	}
+	if output == "" {
+		output = fmt.Sprintf("gentle-ai-backup-%s.tar.gz", id)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(output), ".gentle-ai-export-*.tmp")
+	if err != nil {
+		return "", fmt.Errorf("create backup archive: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	manifest, err := store.Export(id, homeDir, tmp)
+	if closeErr := tmp.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("write backup archive: %w", closeErr)
+	}
+	if err != nil {
+		return "", fmt.Errorf("export backup %q: %w", id, err)
+	}
+	if err := os.Rename(tmp.Name(), output); err != nil {
+		return "", fmt.Errorf("write backup archive %q: %w", output, err)
+	}
+
+	return fmt.Sprintf("Exported backup %s (%d files) to %s", id, len(manifest.Entries), output), nil
+}
+
+func runBackupPrune(homeDir string, store backup.Store, args []string) (string, error) {
+	var keep int
+	var olderThan, maxSize string
+	var dryRun bool
+
+	fs := flag.NewFlagSet("backup prune", flag.ContinueOnError)
+	fs.SetOutput(ioDiscard{})
+	fs.IntVar(&keep, "keep", 0, "number of newest backups to keep")
+	fs.StringVar(&olderThan, "older-than", "", "only prune backups older than this age (e.g. 30d, 12h)")
+	fs.StringVar(&maxSize, "max-size", "", "delete the oldest backups until the rest fit in this size (e.g. 500MB)")
+	fs.BoolVar(&dryRun, "dry-run", false, "list the backups that would be deleted")
+
+	if err := fs.Parse(args); err != nil {
+		return "", err
+	}
+	if fs.NArg() > 0 {
+		return "", fmt.Errorf("unexpected backup prune argument %q", fs.Arg(0))
+	}
+
+	policy := backup.PrunePolicy{Keep: keep}
+	if olderThan != "" {
+		age, err := config.ParseAge(olderThan)
+		if err != nil {
+			return "", err
+		}
+		policy.OlderThan = age
+	}
+	if maxSize != "" {
+		size, err := config.ParseSize(maxSize)
+		if err != nil {
+			return "", err
+		}
+		policy.MaxTotalSize = size
+	}
+	if policy.IsZero() {
+		configured, err := retentionPolicy(homeDir)
+		if err != nil {
+			return "", err
+		}
+		if configured.IsZero() {
+			return "", fmt.Errorf("no retention configured; pass --keep, --older-than or --max-size")
+		}
+		policy = configured
+	}
+
+	var pruned []backup.Manifest
+	var err error
+	if dryRun {
+		pruned, err = store.PruneCandidates(policy, timeNow())
+	} else {
+		pruned, err = store.Prune(policy, timeNow())
+	}
+	if err != nil {
+		return "", err
+	}
+
+	b := &strings.Builder{}
+	verb := "Deleted"
+	if dryRun {
+		verb = "Would delete"
+	}
+	_, _ = fmt.Fprintf(b, "%s %d backups\n", verb, len(pruned))
+	for _, manifest := range pruned {
+		_, _ = fmt.Fprintf(b, "- %s (%s)\n", manifest.ID, formatBackupTime(manifest.CreatedAt))
+	}
+
+	return strings.TrimRight(b.String(), "\n"), nil
+}
+
+// retentionPolicy reads the configured backup retention policy.
+func retentionPolicy(homeDir string) (backup.PrunePolicy, error) {
+	cfg, err := config.Load(homeDir)
+	if err != nil {
+		return backup.PrunePolicy{}, err
+	}
+	return cfg.Backups.Policy()
+}
+
+func renderVerifyResults(results []backup.VerifyResult) (string, error) {
+	if len(results) == 0 {
+		return "No backups found.", nil
+	}
+
+	b := &strings.Builder{}
+	damaged := 0
+	for _, result := range results {
+		if result.OK() {
+			_, _ = fmt.Fprintf(b, "ok       %s (%d files)\n", result.ID, len(result.Manifest.Entries))
+			continue
+		}
+
+		damaged++
+		_, _ = fmt.Fprintf(b, "DAMAGED  %s\n", result.ID)
+		if result.Err != nil {
+			_, _ = fmt.Fprintf(b, "  - manifest: %v\n", result.Err)
+		}
+		for _, problem := range result.Problems {
+			_, _ = fmt.Fprintf(b, "  - %s\n", problem.Reason)
+		}
+	}
+
+	output := strings.TrimRight(b.String(), "\n")
+	if damaged > 0 {
+		return output, fmt.Errorf("%d of %d backups are damaged", damaged, len(results))
+	}
+	return output, nil
+}
+
+func backupIDArg(subcommand string, args []string) (string, error) {
+	if len(args) != 1 || strings.TrimSpace(args[0]) == "" {
+		return "", fmt.Errorf("usage: gentle-ai backup %s ID", subcommand)
+	}
+	return strings.TrimSpace(args[0]), nil
+}
+
+func countEntries(manifest backup.Manifest) (existed int, created int) {
+	for _, entry := range manifest.Entries {
+		if entry.Existed {
+			existed++
+		} else {
+			created++
+		}
+	}
+	return existed, created
+}
+
+func formatBackupTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02 15:04:05 UTC")
+}
+
+// RenderBackupList formats backups as a table, newest first, with the label
+// each one was taken with.
+func RenderBackupList(manifests []backup.Manifest) string {
+	if len(manifests) == 0 {
+		return "No backups found."
+	}
+
+	width := len("ID")
+	for _, manifest := range manifests {
+		width = max(width, len(manifest.ID))
+	}
+
+	b := &strings.Builder{}
+	_, _ = fmt.Fprintf(b, "%-*s  %-23s  %-5s  %s\n", width, "ID", "CREATED", "FILES", "LABEL")
+	for _, manifest := range manifests {
+		label := manifest.Label.String()
+		if manifest.Pinned {
+			label = strings.TrimSpace("(pinned) " + label)
+		}
+		row := fmt.Sprintf("%-*s  %-23s  %-5d  %s", width, manifest.ID, formatBackupTime(manifest.CreatedAt), len(manifest.Entries), label)
+		_, _ = fmt.Fprintln(b, strings.TrimRight(row, " "))
+	}
+
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// RenderBackupManifest lists every file in a backup with the agents and
+// components that manage it. "existed" files are restored from the snapshot;
+// "created" files did not exist before the run and are removed on restore.
+func RenderBackupManifest(manifest backup.Manifest) string {
+	existed, created := countEntries(manifest)
+
+	b := &strings.Builder{}
+	_, _ = fmt.Fprintf(b, "Backup: %s\n", manifest.ID)
+	_, _ = fmt.Fprintf(b, "Created: %s\n", formatBackupTime(manifest.CreatedAt))
+	_, _ = fmt.Fprintf(b, "Location: %s\n", manifest.RootDir)
+	for _, field := range []struct{ name, value string }{
+		{"Command", manifest.Label.Command},
+		{"Selection", manifest.Label.Summary},
+		{"Version", manifest.Label.Version},
+		{"Note", manifest.Label.Note},
+	} {
+		if field.value != "" {
+			_, _ = fmt.Fprintf(b, "%s: %s\n", field.name, field.value)
+		}
+	}
+	if manifest.Pinned {
+		_, _ = fmt.Fprintln(b, "Pinned: yes (exempt from pruning)")
+	}
+	_, _ = fmt.Fprintf(b, "Files: %d (%d existed, %d created)\n", len(manifest.Entries), existed, created)
+	for _, entry := range manifest.Entries {
+		status := "created"
+		if entry.Existed {
+			status = "existed"
+		}
+		path := entry.OriginalPath
+		switch {
+		case entry.IsDir():
+			path += string(filepath.Separator)
+		case entry.Kind == backup.KindSymlink:
+			path += " -> " + entry.LinkTarget
+		}
+		line := fmt.Sprintf("- %-7s  %s", status, path)
+		if tags := entryTags(entry); tags != "" {
+			line += "  [" + tags + "]"
+		}
+		_, _ = fmt.Fprintln(b, line)
+	}
+
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func entryTags(entry backup.ManifestEntry) string {
+	tags := []string{}
+	if len(entry.Agents) > 0 {
+		agents := make([]string, 0, len(entry.Agents))
+		for _, agent := range entry.Agents {
+			agents = append(agents, string(agent))
+		}
+		tags = append(tags, "agents: "+strings.Join(agents, ", "))
+	}
+	if len(entry.Components) > 0 {
+		components := make([]string, 0, len(entry.Components))
+		for _, component := range entry.Components {
+			components = append(components, string(component))
+		}
+		tags = append(tags, "components: "+strings.Join(components, ", "))
+	}
+	return strings.Join(tags, "; ")
+}
@@ -0,0 +1,403 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gentleman-programming/gentle-ai/internal/backup"
+	"github.com/gentleman-programming/gentle-ai/internal/components/sdd"
+	"github.com/gentleman-programming/gentle-ai/internal/model"
+	"github.com/gentleman-programming/gentle-ai/internal/system"
+)
+
+func TestRunBackupShowRestoreAndDelete(t *testing.T) {
+	home := t.TempDir()
+	stubInstallEnvironment(t, home)
+
+	settings := filepath.Join(home, ".claude", "settings.json")
+	added := filepath.Join(home, ".claude", "CLAUDE.md")
+	writeTestFile(t, settings, "before\n")
+
+	store := backup.NewStore(home)
+	manifest, err := backup.NewSnapshotter(store.Root).Create(filepath.Join(store.Root, "snap"), []string{settings, added})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	writeTestFile(t, settings, "after\n")
+	writeTestFile(t, added, "new\n")
+
+	list, err := RunBackup([]string{"list"}, "")
+	if err != nil {
+		t.Fatalf("RunBackup(list) error = %v", err)
+	}
+	if !strings.Contains(list, manifest.ID) {
+		t.Fatalf("list output missing backup:\n%s", list)
+	}
+
+	show, err := RunBackup([]string{"show", manifest.ID}, "")
+	if err != nil {
+		t.Fatalf("RunBackup(show) error = %v", err)
+	}
+	for _, want := range []string{"Files: 2 (1 existed, 1 created)", "existed  " + settings, "created  " + added} {
+		if !strings.Contains(show, want) {
+			t.Fatalf("show output missing %q:\n%s", want, show)
+		}
+	}
+
+	if _, err := RunBackup([]string{"restore", manifest.ID}, ""); err != nil {
+		t.Fatalf("RunBackup(restore) error = %v", err)
+	}
+	if content, _ := os.ReadFile(settings); string(content) != "before\n" {
+		t.Fatalf("settings after restore = %q", content)
+	}
+	if _, err := os.Stat(added); !os.IsNotExist(err) {
+		t.Fatalf("created file should be removed on restore, err = %v", err)
+	}
+
+	if _, err := RunBackup([]string{"delete", manifest.ID}, ""); err != nil {
+		t.Fatalf("RunBackup(delete) error = %v", err)
+	}
+	if _, err := RunBackup([]string{"show", manifest.ID}, ""); err == nil {
+		t.Fatalf("RunBackup(show) expected error after delete")
+	}
+}
+
+func TestRunBackupPruneDryRunKeepsFiles(t *testing.T) {
+	home := t.TempDir()
+	stubInstallEnvironment(t, home)
+
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	restoreNow := timeNow
+	timeNow = func() time.Time { return now }
+	t.Cleanup(func() { timeNow = restoreNow })
+
+	store := backup.NewStore(home)
+	for id, age := range map[string]time.Duration{"recent": time.Hour, "stale": 45 * 24 * time.Hour} {
+		dir := filepath.Join(store.Root, id)
+		if err := backup.WriteManifest(filepath.Join(dir, backup.ManifestFilename), backup.Manifest{ID: id, CreatedAt: now.Add(-age), RootDir: dir}); err != nil {
+			t.Fatalf("WriteManifest() error = %v", err)
+		}
+	}
+
+	output, err := RunBackup([]string{"prune", "--older-than", "30d", "--dry-run"}, "")
+	if err != nil {
+		t.Fatalf("RunBackup(prune --dry-run) error = %v", err)
+	}
+	if !strings.Contains(output, "Would delete 1 backups") || !strings.Contains(output, "stale") {
+		t.Fatalf("dry-run output = %q", output)
+	}
+	if manifests, _ := store.List(); len(manifests) != 2 {
+		t.Fatalf("dry-run deleted backups: %d left", len(manifests))
+	}
+
+	if _, err := RunBackup([]string{"prune", "--older-than", "30d"}, ""); err != nil {
+		t.Fatalf("RunBackup(prune) error = %v", err)
+	}
+	if manifests, _ := store.List(); len(manifests) != 1 || manifests[0].ID != "recent" {
+		t.Fatalf("remaining backups = %#v", manifests)
+	}
+}
+
+func TestRunInstallAppliesBackupRetention(t *testing.T) {
+	home := t.TempDir()
+	stubInstallEnvironment(t, home)
+
+	// Run the retention check a year from now so every backup, including the
+	// one the install takes, is past max_age.
+	restoreNow := timeNow
+	timeNow = func() time.Time { return time.Now().Add(365 * 24 * time.Hour) }
+	t.Cleanup(func() { timeNow = restoreNow })
+
+	writeTestFile(t, filepath.Join(home, ".gentle-ai", "config.json"), `{"backups": {"keep_last": 0, "max_age": "30d"}}`)
+
+	store := backup.NewStore(home)
+	for _, id := range []string{"old", "pinned"} {
+		dir := filepath.Join(store.Root, id)
+		manifest := backup.Manifest{ID: id, CreatedAt: time.Now().Add(-time.Hour), RootDir: dir, Pinned: id == "pinned"}
+		if err := backup.WriteManifest(filepath.Join(dir, backup.ManifestFilename), manifest); err != nil {
+			t.Fatalf("WriteManifest() error = %v", err)
+		}
+	}
+
+	result, err := RunInstall([]string{"--agent", "opencode", "--component", "permissions"}, system.DetectionResult{})
+	if err != nil {
+		t.Fatalf("RunInstall() error = %v", err)
+	}
+
+	if len(result.PrunedBackups) != 1 || result.PrunedBackups[0].ID != "old" {
+		t.Fatalf("pruned = %#v, want only the unpinned old backup", result.PrunedBackups)
+	}
+
+	remaining, err := store.List()
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	ids := map[string]bool{}
+	for _, manifest := range remaining {
+		ids[manifest.ID] = true
+	}
+	if len(remaining) != 2 || !ids["pinned"] || ids["old"] {
+		t.Fatalf("remaining backups = %v, want the pinned one and this install's snapshot", ids)
+	}
+}
+
+func TestRunBackupPinExemptsFromPrune(t *testing.T) {
+	home := t.TempDir()
+	stubInstallEnvironment(t, home)
+
+	store := backup.NewStore(home)
+	for i, id := range []string{"newer", "older"} {
+		dir := filepath.Join(store.Root, id)
+		manifest := backup.Manifest{ID: id, CreatedAt: time.Now().Add(-time.Duration(i+1) * time.Hour), RootDir: dir}
+		if err := backup.WriteManifest(filepath.Join(dir, backup.ManifestFilename), manifest); err != nil {
+			t.Fatalf("WriteManifest() error = %v", err)
+		}
+	}
+
+	if _, err := RunBackup([]string{"pin", "older"}, ""); err != nil {
+		t.Fatalf("RunBackup(pin) error = %v", err)
+	}
+	list, err := RunBackup([]string{"list"}, "")
+	if err != nil || !strings.Contains(list, "(pinned)") {
+		t.Fatalf("list = %q, err = %v", list, err)
+	}
+
+	if _, err := RunBackup([]string{"prune", "--keep", "1"}, ""); err != nil {
+		t.Fatalf("RunBackup(prune) error = %v", err)
+	}
+	if manifests, _ := store.List(); len(manifests) != 2 {
+		t.Fatalf("pinned backup was pruned: %#v", manifests)
+	}
+}
+
+func TestRunBackupVerifyReportsDamage(t *testing.T) {
+	home := t.TempDir()
+	stubInstallEnvironment(t, home)
+
+	settings := filepath.Join(home, ".claude", "settings.json")
+	writeTestFile(t, settings, "{}\n")
+
+	store := backup.NewStore(home)
+	manifest, err := backup.NewSnapshotter(store.Root).Create(filepath.Join(store.Root, "snap"), []string{settings})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	output, err := RunBackup([]string{"verify"}, "")
+	if err != nil || !strings.Contains(output, "ok       snap (1 files)") {
+		t.Fatalf("verify intact = %q, %v", output, err)
+	}
+
+	writeTestFile(t, manifest.Entries[0].SnapshotPath, "[]\n")
+	output, err = RunBackup([]string{"verify", "snap"}, "")
+	if err == nil || !strings.Contains(err.Error(), "1 of 1 backups are damaged") {
+		t.Fatalf("verify damaged error = %v", err)
+	}
+	if !strings.Contains(output, "DAMAGED  snap") || !strings.Contains(output, "corrupted") {
+		t.Fatalf("verify damaged output = %q", output)
+	}
+
+	if _, err := RunBackup([]string{"restore", "snap"}, ""); err == nil {
+		t.Fatalf("restore of a damaged backup should fail")
+	}
+}
+
+func TestRunBackupRestoreSelectsByAgentAndFile(t *testing.T) {
+	home := t.TempDir()
+	stubInstallEnvironment(t, home)
+
+	if _, err := RunInstall([]string{"--agent", "opencode,claude-code", "--component", "permissions"}, system.DetectionResult{}); err != nil {
+		t.Fatalf("RunInstall() error = %v", err)
+	}
+
+	store := backup.NewStore(home)
+	manifests, err := store.List()
+	if err != nil || len(manifests) != 1 {
+		t.Fatalf("List() = %d backups, err = %v", len(manifests), err)
+	}
+	manifest := manifests[0]
+
+	tagged := map[string]backup.ManifestEntry{}
+	for _, entry := range manifest.Entries {
+		for _, agent := range entry.Agents {
+			tagged[string(agent)] = entry
+		}
+	}
+	opencodeEntry, ok := tagged["opencode"]
+	if !ok || !slices.Contains(opencodeEntry.Components, model.ComponentPermission) {
+		t.Fatalf("no opencode permissions entry in %#v", manifest.Entries)
+	}
+	claudeEntry, ok := tagged["claude-code"]
+	if !ok {
+		t.Fatalf("no claude-code entry in %#v", manifest.Entries)
+	}
+
+	show, err := RunBackup([]string{"show", manifest.ID}, "")
+	if err != nil || !strings.Contains(show, "[agents: opencode; components: permissions]") {
+		t.Fatalf("show = %q, err = %v", show, err)
+	}
+
+	claudeAfter, err := os.ReadFile(claudeEntry.OriginalPath)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+
+	if _, err := RunBackup([]string{"restore", manifest.ID, "--agent", "opencode"}, ""); err != nil {
+		t.Fatalf("RunBackup(restore --agent) error = %v", err)
+	}
+	if _, err := os.Stat(opencodeEntry.OriginalPath); opencodeEntry.Existed == os.IsNotExist(err) {
+		t.Fatalf("opencode file not restored, existed = %v, err = %v", opencodeEntry.Existed, err)
+	}
+	if content, err := os.ReadFile(claudeEntry.OriginalPath); err != nil || string(content) != string(claudeAfter) {
+		t.Fatalf("claude-code file changed by an opencode restore: %q, %v", content, err)
+	}
+
+	if _, err := RunBackup([]string{"restore", manifest.ID, "--file", claudeEntry.OriginalPath}, ""); err != nil {
+		t.Fatalf("RunBackup(restore --file) error = %v", err)
+	}
+	if _, err := os.Stat(claudeEntry.OriginalPath); claudeEntry.Existed == os.IsNotExist(err) {
+		t.Fatalf("claude-code file not restored, existed = %v, err = %v", claudeEntry.Existed, err)
+	}
+
+	if _, err := RunBackup([]string{"restore", manifest.ID, "--component", "engram"}, ""); err == nil || !strings.Contains(err.Error(), "match the selection") {
+		t.Fatalf("restore with no matches error = %v", err)
+	}
+	if _, err := RunBackup([]string{"restore", manifest.ID, "--agent", "vim"}, ""); err == nil || !strings.Contains(err.Error(), "unsupported agent") {
+		t.Fatalf("restore with unknown agent error = %v", err)
+	}
+}
+
+func TestRunBackupRestoreWarnsAboutSharedFiles(t *testing.T) {
+	home := t.TempDir()
+	stubInstallEnvironment(t, home)
+
+	if _, err := RunInstall([]string{"--agent", "claude-code", "--component", "permissions,persona"}, system.DetectionResult{}); err != nil {
+		t.Fatalf("RunInstall() error = %v", err)
+	}
+
+	manifests, err := backup.NewStore(home).List()
+	if err != nil || len(manifests) != 1 {
+		t.Fatalf("List() = %d backups, err = %v", len(manifests), err)
+	}
+
+	output, err := RunBackup([]string{"restore", manifests[0].ID, "--component", "permissions"}, "")
+	if err != nil {
+		t.Fatalf("RunBackup(restore --component) error = %v", err)
+	}
+	settings := filepath.Join(home, ".claude", "settings.json")
+	if !strings.Contains(output, "Warning:") || !strings.Contains(output, settings+" (also persona)") {
+		t.Fatalf("restore output = %q, want a warning that settings.json is shared with persona", output)
+	}
+
+	output, err = RunBackup([]string{"restore", manifests[0].ID, "--file", settings}, "")
+	if err != nil || strings.Contains(output, "Warning:") {
+		t.Fatalf("restore --file output = %q, err = %v, want no warning", output, err)
+	}
+}
+
+func TestRunBackupRestoreKeepsFilesTheUserAddedToManagedDirectories(t *testing.T) {
+	home := t.TempDir()
+	stubInstallEnvironment(t, home)
+
+	if err := os.MkdirAll(sdd.OpenCodePluginDependencyPath(home), 0o755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	if _, err := RunInstall([]string{"--agent", "opencode", "--component", "sdd"}, system.DetectionResult{}); err != nil {
+		t.Fatalf("RunInstall() error = %v", err)
+	}
+	manifests, err := backup.NewStore(home).List()
+	if err != nil || len(manifests) != 1 {
+		t.Fatalf("List() = %d backups, err = %v", len(manifests), err)
+	}
+
+	commands := filepath.Join(home, ".config", "opencode", "commands")
+	mine := filepath.Join(commands, "mine.md")
+	writeTestFile(t, mine, "# my command\n")
+
+	if _, err := RunBackup([]string{"restore", manifests[0].ID}, ""); err != nil {
+		t.Fatalf("RunBackup(restore) error = %v", err)
+	}
+
+	entries, err := os.ReadDir(commands)
+	if err != nil {
+		t.Fatalf("ReadDir() error = %v", err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "mine.md" {
+		t.Fatalf("commands after restore = %v, want only the user's command", entries)
+	}
+}
+
+func TestRunBackupExportAndImport(t *testing.T) {
+	home := t.TempDir()
+	stubInstallEnvironment(t, home)
+
+	settings := filepath.Join(home, ".claude", "settings.json")
+	writeTestFile(t, settings, "{}\n")
+
+	store := backup.NewStore(home)
+	if _, err := backup.NewSnapshotter(store.Root).Create(filepath.Join(store.Root, "snap"), []string{settings}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	archive := filepath.Join(t.TempDir(), "snap.tar.gz")
+	output, err := RunBackup([]string{"export", "snap", "-o", archive}, "")
+	if err != nil || !strings.Contains(output, "Exported backup snap (1 files)") {
+		t.Fatalf("RunBackup(export) = %q, %v", output, err)
+	}
+
+	// Import on a "new machine": a different, empty home directory.
+	other := t.TempDir()
+	osUserHomeDir = func() (string, error) { return other, nil }
+
+	output, err = RunBackup([]string{"import", archive}, "")
+	if err != nil || !strings.Contains(output, "Imported backup snap") {
+		t.Fatalf("RunBackup(import) = %q, %v", output, err)
+	}
+	show, err := RunBackup([]string{"show", "snap"}, "")
+	if err != nil || !strings.Contains(show, filepath.Join(other, ".claude", "settings.json")) {
+		t.Fatalf("imported backup should point into the new home:\n%s\nerr = %v", show, err)
+	}
+}
+
+func TestRunInstallLabelsBackup(t *testing.T) {
+	home := t.TempDir()
+	stubInstallEnvironment(t, home)
+
+	args := []string{"--agent", "opencode", "--component", "permissions", "--backup-label", "before the beta"}
+	if _, err := RunInstallContext(context.Background(), args, system.DetectionResult{}, "1.2.3"); err != nil {
+		t.Fatalf("RunInstallContext() error = %v", err)
+	}
+
+	manifests, err := backup.NewStore(home).List()
+	if err != nil || len(manifests) != 1 {
+		t.Fatalf("List() = %d backups, err = %v", len(manifests), err)
+	}
+	want := backup.Label{Command: "install", Summary: "opencode: permissions", Version: "1.2.3", Note: "before the beta"}
+	if manifests[0].Label != want {
+		t.Fatalf("label = %#v, want %#v", manifests[0].Label, want)
+	}
+
+	list, err := RunBackup([]string{"list"}, "")
+	if err != nil || !strings.Contains(list, `install opencode: permissions "before the beta"`) {
+		t.Fatalf("list = %q, err = %v", list, err)
+	}
+	show, err := RunBackup([]string{"show", manifests[0].ID}, "")
+	if err != nil || !strings.Contains(show, "Command: install") || !strings.Contains(show, "Note: before the beta") {
+		t.Fatalf("show = %q, err = %v", show, err)
+	}
+
+	output, err := RunBackup([]string{"restore", manifests[0].ID}, "1.2.4")
+	if err != nil {
+		t.Fatalf("RunBackup(restore) error = %v", err)
+	}
+	safetyID := output[strings.LastIndex(output, "backup ")+len("backup ") : strings.Index(output, "; restore it")]
+	safety, err := backup.NewStore(home).Get(safetyID)
+	if err != nil || safety.Label.Command != "restore" || !strings.Contains(safety.Label.Summary, manifests[0].ID) || safety.Label.Version != "1.2.4" {
+		t.Fatalf("safety label = %#v, err = %v", safety.Label, err)
+	}
+}
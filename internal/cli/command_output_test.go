@@ -1,15 +1,18 @@
 package cli
 
 import (
+	"context"
+	"errors"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestExecuteCommandQuietModeIncludesCapturedOutputOnFailure(t *testing.T) {
 	restore := SetCommandOutputStreaming(false)
 	defer restore()
 
-	err := executeCommand("bash", "-c", "echo boom && exit 1")
+	err := executeCommand(context.Background(), "bash", "-c", "echo boom && exit 1")
 	if err == nil {
 		t.Fatal("executeCommand() error = nil, want non-nil")
 	}
@@ -32,3 +35,54 @@ func TestSetCommandOutputStreamingRestore(t *testing.T) {
 		t.Fatal("restore should reset streamCommandOutput to previous value")
 	}
 }
+
+func TestExecuteCommandStopsWhenContextIsCancelled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	started := time.Now()
+	err := executeCommand(ctx, "sleep", "30")
+	if err == nil {
+		t.Fatal("executeCommand() error = nil, want the command killed")
+	}
+	if elapsed := time.Since(started); elapsed > 10*time.Second {
+		t.Fatalf("executeCommand() took %s after cancellation", elapsed)
+	}
+}
+
+func TestPackageManagersLooksThroughSudoAndEnv(t *testing.T) {
+	commands := [][]string{
+		{"bash", "-c", "curl -fsSL https://deb.nodesource.com/setup_lts.x | sudo -E bash -"},
+		{"sudo", "apt-get", "install", "-y", "nodejs"},
+		{"sudo", "npm", "install", "-g", "opencode-ai"},
+		{"env", "CGO_ENABLED=0", "go", "install", "example.com/tool@latest"},
+	}
+
+	if got := packageManagers(commands); strings.Join(got, ",") != "apt,npm" {
+		t.Fatalf("packageManagers() = %v, want [apt npm]", got)
+	}
+	if got := packageManagers([][]string{{"sudo", "bash", "install.sh"}}); len(got) != 0 {
+		t.Fatalf("packageManagers() = %v, want none for a script", got)
+	}
+}
+
+func TestRunCommandSequenceStopsWaitingForLockWhenContextIsCancelled(t *testing.T) {
+	restoreCommand := runCommand
+	t.Cleanup(func() { runCommand = restoreCommand })
+	runCommand = func(context.Context, string, ...string) error { return nil }
+
+	unlock, err := lockPackageManagers(context.Background(), [][]string{{"brew", "install", "git"}})
+	if err != nil {
+		t.Fatalf("lockPackageManagers() error = %v", err)
+	}
+	defer unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := runCommandSequence(ctx, [][]string{{"brew", "install", "curl"}}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("runCommandSequence() error = %v, want it to give up waiting for brew", err)
+	}
+	if err := runCommandSequence(context.Background(), [][]string{{"sudo", "apt-get", "install", "-y", "git"}}); err != nil {
+		t.Fatalf("runCommandSequence() error = %v, want apt not to wait for brew", err)
+	}
+}
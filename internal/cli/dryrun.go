@@ -26,12 +26,23 @@ func RenderDryRun(result InstallResult) string {
 	_, _ = fmt.Fprintf(b, "Platform decision: %s\n", formatPlatformDecision(result.Review.PlatformDecision))
 	_, _ = fmt.Fprintf(b, "Prepare steps: %d\n", len(result.Plan.Prepare))
 	_, _ = fmt.Fprintf(b, "Apply steps: %d\n", len(result.Plan.Apply))
+	renderHooks(b, result.Hooks)
+	if result.ChangesErr != nil {
+		_, _ = fmt.Fprintf(b, "File changes: unavailable (%v)\n", result.ChangesErr)
+	} else {
+		renderChangeSummary(b, result.Changes)
+	}
 
 	if len(result.Dependencies.Dependencies) > 0 {
 		_, _ = fmt.Fprintln(b, "")
 		_, _ = fmt.Fprintln(b, system.RenderDependencyReport(result.Dependencies))
 	}
 
+	if result.Diff && len(result.Changes) > 0 {
+		_, _ = fmt.Fprintln(b, "")
+		_, _ = fmt.Fprintln(b, RenderDiff(result.Changes))
+	}
+
 	return strings.TrimRight(b.String(), "\n")
 }
 
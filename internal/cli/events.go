@@ -0,0 +1,37 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/gentleman-programming/gentle-ai/internal/events"
+)
+
+// eventsOutput receives the event stream of `install --events=jsonl`.
+var eventsOutput io.Writer = os.Stdout
+
+// openEventStream starts the event stream of a run: always into a log file
+// under ~/.gentle-ai/logs, and onto stdout as well when format is jsonl. A
+// log that cannot be opened is only reported.
+func openEventStream(homeDir string, command string, format string) *events.Writer {
+	var outputs []io.Writer
+	if format == events.FormatJSONLines {
+		outputs = append(outputs, eventsOutput)
+	}
+
+	stream := events.NewWriter(command, outputs...)
+	if _, err := stream.AddLog(homeDir, timeNow()); err != nil {
+		fmt.Fprintf(os.Stderr, "WARNING: could not open run log: %v\n", err)
+	}
+	stream.Start()
+	return stream
+}
+
+// closeEventStream writes the end of a run that finished with runErr.
+func closeEventStream(stream *events.Writer, runErr error) {
+	stream.Finish(runErr)
+	if err := stream.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "WARNING: could not write run events: %v\n", err)
+	}
+}
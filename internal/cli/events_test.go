@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/gentleman-programming/gentle-ai/internal/config"
+	"github.com/gentleman-programming/gentle-ai/internal/events"
+	"github.com/gentleman-programming/gentle-ai/internal/system"
+)
+
+func TestRunInstallEventsStreamsJSONLinesAndKeepsALog(t *testing.T) {
+	home := t.TempDir()
+	stubInstallEnvironment(t, home)
+
+	var out bytes.Buffer
+	restoreOutput := eventsOutput
+	t.Cleanup(func() { eventsOutput = restoreOutput })
+	eventsOutput = &out
+
+	result, err := RunInstall([]string{"--agent", "claude-code", "--component", "persona", "--events", "jsonl"}, system.DetectionResult{})
+	if err != nil {
+		t.Fatalf("RunInstall() error = %v", err)
+	}
+	if result.Events != events.FormatJSONLines {
+		t.Fatalf("result.Events = %q, want %q", result.Events, events.FormatJSONLines)
+	}
+
+	records := []events.Record{}
+	scanner := bufio.NewScanner(bytes.NewReader(out.Bytes()))
+	for scanner.Scan() {
+		var record events.Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("stdout line %q is not JSON: %v", scanner.Text(), err)
+		}
+		records = append(records, record)
+	}
+	if len(records) < 3 || records[0].Kind != events.KindRunStarted {
+		t.Fatalf("records = %#v, want run-started first", records)
+	}
+	if last := records[len(records)-1]; last.Kind != events.KindRunFinished || last.Status != "succeeded" {
+		t.Fatalf("last record = %#v, want a succeeded run-finished", last)
+	}
+
+	claudeMD := filepath.Join(home, ".claude", "CLAUDE.md")
+	found := false
+	for _, record := range records {
+		if record.Step != "component:persona" || record.Status != "succeeded" {
+			continue
+		}
+		found = true
+		if record.Stage != "apply" || !slices.Contains(record.Files, claudeMD) {
+			t.Fatalf("persona record = %#v, want the apply stage and %s changed", record, claudeMD)
+		}
+	}
+	if !found {
+		t.Fatalf("records = %#v, want a succeeded component:persona step", records)
+	}
+
+	logs, err := os.ReadDir(events.LogDir(home))
+	if err != nil || len(logs) != 1 {
+		t.Fatalf("logs = %v, err = %v; want one run log", logs, err)
+	}
+	logged, err := os.ReadFile(filepath.Join(events.LogDir(home), logs[0].Name()))
+	if err != nil {
+		t.Fatalf("ReadFile(log) error = %v", err)
+	}
+	if !bytes.Equal(logged, out.Bytes()) {
+		t.Fatalf("log differs from the stream on stdout")
+	}
+}
+
+func TestRunInstallEventsReportRollback(t *testing.T) {
+	home := t.TempDir()
+	stubInstallEnvironment(t, home)
+	writeTestFile(t, config.Path(home), testHooksConfig)
+	stubHookCommands(t, "register-mcp")
+
+	var out bytes.Buffer
+	restoreOutput := eventsOutput
+	t.Cleanup(func() { eventsOutput = restoreOutput })
+	eventsOutput = &out
+
+	if _, err := RunInstall([]string{"--agent", "claude-code", "--component", "persona", "--events", "jsonl"}, system.DetectionResult{}); err == nil {
+		t.Fatalf("RunInstall() error = nil, want the failed hook")
+	}
+
+	rolledBack := []string{}
+	var last events.Record
+	scanner := bufio.NewScanner(bytes.NewReader(out.Bytes()))
+	for scanner.Scan() {
+		var record events.Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("stdout line %q is not JSON: %v", scanner.Text(), err)
+		}
+		if record.Stage == "rollback" && record.Status == "rolled-back" {
+			rolledBack = append(rolledBack, record.Step)
+		}
+		last = record
+	}
+	if !slices.Contains(rolledBack, "apply:rollback-restore") || !slices.Contains(rolledBack, "hook:pre-prepare:company-ca") {
+		t.Fatalf("rolled back steps = %v, want the restore and the pre-prepare hook", rolledBack)
+	}
+	if last.Kind != events.KindRunFinished || last.Status != "failed" {
+		t.Fatalf("last record = %#v, want a failed run-finished", last)
+	}
+}
+
+func TestParseInstallFlagsRejectsUnknownEventsFormat(t *testing.T) {
+	if _, err := ParseInstallFlags([]string{"--events", "xml"}); err == nil {
+		t.Fatalf("ParseInstallFlags(--events xml) should fail")
+	}
+}
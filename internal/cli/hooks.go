@@ -0,0 +1,129 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gentleman-programming/gentle-ai/internal/config"
+	"github.com/gentleman-programming/gentle-ai/internal/pipeline"
+	"github.com/gentleman-programming/gentle-ai/internal/system"
+)
+
+// hookSteps returns the steps for the hooks configured at point, in order.
+// Each one waits for the steps named by after and for the hooks before it.
+func hookSteps(hooks config.Hooks, point config.HookPoint, profile system.PlatformProfile, after []string) []pipeline.Step {
+	steps := []pipeline.Step{}
+	dependsOn := slices.Clone(after)
+	for _, hook := range hooks.At(point) {
+		// config.Load has already rejected invalid timeouts.
+		timeout, _ := hook.TimeoutDuration()
+		step := hookStep{id: hook.StepID(point), hook: hook, timeout: timeout, dependsOn: dependsOn, profile: profile}
+		steps = append(steps, step)
+		dependsOn = append(slices.Clone(dependsOn), step.id)
+	}
+	return steps
+}
+
+// RollbackPolicy returns the rollback policy for the install. Pre-prepare
+// hooks are rolled back with the rest of the install when it fails, unless
+// keepSucceeded limits the rollback to the steps that failed.
+func (r *InstallRun) RollbackPolicy(keepSucceeded bool) pipeline.RollbackPolicy {
+	policy := pipeline.DefaultRollbackPolicy()
+	if keepSucceeded {
+		policy = pipeline.PartialRollbackPolicy()
+	}
+	policy.RollbackPrepare = len(r.runtime.config.Hooks.At(config.HookPrePrepare)) > 0
+	return policy
+}
+
+// stepIDs returns the IDs of steps.
+func stepIDs(steps []pipeline.Step) []string {
+	ids := make([]string, 0, len(steps))
+	for _, step := range steps {
+		ids = append(ids, step.ID())
+	}
+	return ids
+}
+
+// hookStep runs a user hook from ~/.gentle-ai/config.json.
+type hookStep struct {
+	id        string
+	hook      config.Hook
+	timeout   time.Duration
+	dependsOn []string
+	profile   system.PlatformProfile
+}
+
+func (s hookStep) ID() string {
+	return s.id
+}
+
+func (s hookStep) DependsOn() []string {
+	return s.dependsOn
+}
+
+func (s hookStep) Timeout() time.Duration {
+	return s.timeout
+}
+
+func (s hookStep) Run(ctx context.Context) error {
+	if err := runHookCommand(ctx, s.profile, s.hook.Run); err != nil {
+		return fmt.Errorf("hook %q: %w", s.hook.ID, err)
+	}
+	return nil
+}
+
+// Rollback runs the hook's rollback command, if it has one, under the same
+// timeout as the hook itself.
+func (s hookStep) Rollback() error {
+	if strings.TrimSpace(s.hook.Rollback) == "" {
+		return nil
+	}
+
+	ctx := context.Background()
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+	if err := runHookCommand(ctx, s.profile, s.hook.Rollback); err != nil {
+		return fmt.Errorf("roll back hook %q: %w", s.hook.ID, err)
+	}
+	return nil
+}
+
+// runHookCommand runs a hook command line through the platform shell.
+func runHookCommand(ctx context.Context, profile system.PlatformProfile, line string) error {
+	command := []string{"sh", "-c", line}
+	if profile.OS == "windows" {
+		command = []string{"cmd", "/C", line}
+	}
+	if err := runCommand(ctx, command[0], command[1:]...); err != nil {
+		return &commandError{command: command, err: err}
+	}
+	return nil
+}
+
+// renderHooks lists the hooks an install will run, for the dry-run report.
+func renderHooks(b *strings.Builder, hooks config.Hooks) {
+	for _, point := range []config.HookPoint{config.HookPrePrepare, config.HookPostApply} {
+		for _, hook := range hooks.At(point) {
+			details := []string{}
+			if hook.Rollback != "" {
+				details = append(details, "rollback: "+hook.Rollback)
+			}
+			if hook.Timeout != "" {
+				details = append(details, "timeout: "+hook.Timeout)
+			}
+
+			line := fmt.Sprintf("- %s: %s", hook.StepID(point), hook.Run)
+			if len(details) > 0 {
+				line += " (" + strings.Join(details, ", ") + ")"
+			}
+			_, _ = fmt.Fprintln(b, line)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gentleman-programming/gentle-ai/internal/config"
+	"github.com/gentleman-programming/gentle-ai/internal/system"
+)
+
+const testHooksConfig = `{"hooks": {
+	"pre_prepare": [{"id": "company-ca", "run": "./write-ca.sh", "rollback": "./remove-ca.sh", "timeout": "30s"}],
+	"post_apply": [{"id": "internal-mcp", "run": "register-mcp"}]
+}}`
+
+// stubHookCommands records the hook command lines run through the shell and
+// fails the ones listed in fail.
+func stubHookCommands(t *testing.T, fail ...string) *[]string {
+	t.Helper()
+
+	var mu sync.Mutex
+	lines := []string{}
+	runCommand = func(_ context.Context, name string, args ...string) error {
+		if name != "sh" {
+			return nil
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		line := args[len(args)-1]
+		lines = append(lines, line)
+		for _, failing := range fail {
+			if line == failing {
+				return errors.New("exit status 1")
+			}
+		}
+		return nil
+	}
+	return &lines
+}
+
+func TestRunInstallRunsHooksAroundTheStages(t *testing.T) {
+	home := t.TempDir()
+	stubInstallEnvironment(t, home)
+	writeTestFile(t, config.Path(home), testHooksConfig)
+	lines := stubHookCommands(t)
+
+	result, err := RunInstall([]string{"--agent", "claude-code", "--component", "persona"}, system.DetectionResult{})
+	if err != nil {
+		t.Fatalf("RunInstall() error = %v", err)
+	}
+
+	if want := []string{"./write-ca.sh", "register-mcp"}; !reflect.DeepEqual(*lines, want) {
+		t.Fatalf("hook commands = %v, want %v", *lines, want)
+	}
+	if first := result.Execution.Prepare.Steps[0]; first.StepID != "hook:pre-prepare:company-ca" {
+		t.Fatalf("first prepare step = %q, want the pre-prepare hook", first.StepID)
+	}
+	applied := result.Execution.Apply.Steps
+	if last := applied[len(applied)-1]; last.StepID != "hook:post-apply:internal-mcp" {
+		t.Fatalf("last apply step = %q, want the post-apply hook", last.StepID)
+	}
+}
+
+func TestRunInstallRollsBackHooksWhenAPostApplyHookFails(t *testing.T) {
+	home := t.TempDir()
+	stubInstallEnvironment(t, home)
+	writeTestFile(t, config.Path(home), testHooksConfig)
+	lines := stubHookCommands(t, "register-mcp")
+
+	_, err := RunInstall([]string{"--agent", "claude-code", "--component", "persona"}, system.DetectionResult{})
+	if err == nil || !strings.Contains(err.Error(), `hook "internal-mcp"`) {
+		t.Fatalf("RunInstall() error = %v, want the failed hook", err)
+	}
+
+	if want := []string{"./write-ca.sh", "register-mcp", "./remove-ca.sh"}; !reflect.DeepEqual(*lines, want) {
+		t.Fatalf("hook commands = %v, want %v", *lines, want)
+	}
+	if _, err := os.Stat(filepath.Join(home, ".claude", "CLAUDE.md")); !os.IsNotExist(err) {
+		t.Fatalf("CLAUDE.md should be rolled back, stat err = %v", err)
+	}
+}
+
+func TestRunInstallDryRunListsHooks(t *testing.T) {
+	home := t.TempDir()
+	stubInstallEnvironment(t, home)
+	writeTestFile(t, config.Path(home), testHooksConfig)
+	lines := stubHookCommands(t)
+
+	result, err := RunInstall([]string{"--agent", "claude-code", "--component", "persona", "--dry-run"}, system.DetectionResult{})
+	if err != nil {
+		t.Fatalf("RunInstall(--dry-run) error = %v", err)
+	}
+	if len(*lines) != 0 {
+		t.Fatalf("dry run ran hooks: %v", *lines)
+	}
+
+	if got := result.Plan.Prepare[0].ID(); got != "hook:pre-prepare:company-ca" {
+		t.Fatalf("first prepare step = %q, want the pre-prepare hook", got)
+	}
+	output := RenderDryRun(result)
+	for _, want := range []string{
+		"- hook:pre-prepare:company-ca: ./write-ca.sh (rollback: ./remove-ca.sh, timeout: 30s)",
+		"- hook:post-apply:internal-mcp: register-mcp",
+	} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("RenderDryRun() missing %q\noutput=%s", want, output)
+		}
+	}
+}
@@ -4,16 +4,36 @@ import (
 	"flag"
 	"fmt"
 	"strings"
+	"time"
+
+	"github.com/gentleman-programming/gentle-ai/internal/events"
 )
 
+// defaultJobs is how many independent install steps run at once without
+// --jobs, as many as the TUI runs.
+const defaultJobs = 4
+
 type InstallFlags struct {
-	Agents     []string
-	Components []string
-	Skills     []string
-	Persona    string
-	Preset     string
-	SDDMode    string
-	DryRun     bool
+	Agents      []string
+	Components  []string
+	Skills      []string
+	Persona     string
+	Preset      string
+	SDDMode     string
+	Profile     string
+	DryRun      bool
+	Diff        bool
+	BackupLabel string
+	Wait        bool
+	Jobs        int
+	Timeout     time.Duration
+	StepTimeout time.Duration
+	Resume      bool
+	Rollback    bool
+	Events      string
+	// KeepGoing runs every step it can after one fails and rolls back only
+	// the files of the agents and components that failed.
+	KeepGoing bool
 }
 
 func ParseInstallFlags(args []string) (InstallFlags, error) {
@@ -30,12 +50,53 @@ func ParseInstallFlags(args []string) (InstallFlags, error) {
 	fs.StringVar(&opts.Persona, "persona", "", "persona to apply")
 	fs.StringVar(&opts.Preset, "preset", "", "preset to apply")
 	fs.StringVar(&opts.SDDMode, "sdd-mode", "", "SDD orchestrator mode: single or multi (default: single)")
+	fs.StringVar(&opts.Profile, "profile", "", "install profile file to apply (flags given explicitly take precedence)")
 	fs.BoolVar(&opts.DryRun, "dry-run", false, "preview plan without executing")
+	fs.BoolVar(&opts.Diff, "diff", false, "print a unified diff of every file the install would change (implies --dry-run)")
+	fs.StringVar(&opts.BackupLabel, "backup-label", "", "note to record on the backup this run takes")
+	fs.BoolVar(&opts.Wait, "wait", false, "wait for another running gentle-ai to finish instead of failing")
+	fs.IntVar(&opts.Jobs, "jobs", defaultJobs, "number of independent install steps to run at once")
+	fs.DurationVar(&opts.Timeout, "timeout", 0, "give up and roll back if the install takes longer than this (e.g. 15m)")
+	fs.DurationVar(&opts.StepTimeout, "step-timeout", 0, "fail any single step that runs longer than this (e.g. 5m)")
+	fs.StringVar(&opts.Events, "events", "", "also stream every pipeline event on stdout; the only format is jsonl")
+	fs.BoolVar(&opts.KeepGoing, "keep-going", false, "run every step it can after a failure and roll back only what failed")
+	fs.BoolVar(&opts.Resume, "resume", false, "finish an interrupted install from its first unfinished step")
+	fs.BoolVar(&opts.Rollback, "rollback", false, "undo an interrupted install by restoring the backup it took")
 
 	if err := fs.Parse(args); err != nil {
 		return InstallFlags{}, err
 	}
 
+	if opts.Jobs < 1 {
+		return InstallFlags{}, fmt.Errorf("--jobs must be at least 1, got %d", opts.Jobs)
+	}
+	if opts.Timeout < 0 || opts.StepTimeout < 0 {
+		return InstallFlags{}, fmt.Errorf("--timeout and --step-timeout cannot be negative")
+	}
+	if opts.Events != "" && opts.Events != events.FormatJSONLines {
+		return InstallFlags{}, fmt.Errorf("unsupported --events format %q (supported: %s)", opts.Events, events.FormatJSONLines)
+	}
+
+	if opts.Resume || opts.Rollback {
+		if opts.Resume && opts.Rollback {
+			return InstallFlags{}, fmt.Errorf("--resume and --rollback cannot be combined")
+		}
+		// The interrupted run's journal decides what gets installed.
+		var conflict string
+		fs.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "resume", "rollback", "wait", "jobs", "timeout", "step-timeout", "events", "keep-going":
+			default:
+				if conflict == "" {
+					conflict = f.Name
+				}
+			}
+		})
+		if conflict != "" {
+			return InstallFlags{}, fmt.Errorf("--%s cannot be used with --resume or --rollback", conflict)
+		}
+	}
+
 	if fs.NArg() > 0 {
 		return InstallFlags{}, fmt.Errorf("unexpected install argument %q", fs.Arg(0))
 	}
@@ -1,10 +1,14 @@
 package cli
 
 import (
+	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/gentleman-programming/gentle-ai/internal/model"
+	"github.com/gentleman-programming/gentle-ai/internal/preset"
 	"github.com/gentleman-programming/gentle-ai/internal/system"
 )
 
@@ -36,6 +40,16 @@ func TestParseInstallFlagsSupportsCSVAndRepeated(t *testing.T) {
 	}
 }
 
+func TestParseInstallFlagsRunsStepsInParallelByDefault(t *testing.T) {
+	flags, err := ParseInstallFlags(nil)
+	if err != nil {
+		t.Fatalf("ParseInstallFlags() error = %v", err)
+	}
+	if flags.Jobs != 4 {
+		t.Fatalf("Jobs = %d, want 4", flags.Jobs)
+	}
+}
+
 func TestNormalizeInstallFlagsDefaults(t *testing.T) {
 	input, err := NormalizeInstallFlags(InstallFlags{}, system.DetectionResult{})
 	if err != nil {
@@ -69,6 +83,51 @@ func TestNormalizeInstallFlagsRejectsUnknownPersona(t *testing.T) {
 	}
 }
 
+func TestNormalizeInstallFlagsUsesUserPreset(t *testing.T) {
+	home := t.TempDir()
+	dir := preset.UserDir(home)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	content := `{"id": "backend", "components": ["engram", "sdd"], "skills": ["go-testing"], "persona": "neutral", "sdd_mode": "multi"}`
+	if err := os.WriteFile(filepath.Join(dir, "backend.json"), []byte(content), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	registry, err := preset.Load(home)
+	if err != nil {
+		t.Fatalf("preset.Load() error = %v", err)
+	}
+	defer preset.SetDefault(registry)()
+
+	input, err := NormalizeInstallFlags(InstallFlags{Agents: []string{"opencode"}, Preset: "backend"}, system.DetectionResult{})
+	if err != nil {
+		t.Fatalf("NormalizeInstallFlags() error = %v", err)
+	}
+
+	if !reflect.DeepEqual(input.Selection.Components, []model.ComponentID{model.ComponentEngram, model.ComponentSDD}) {
+		t.Fatalf("components = %v", input.Selection.Components)
+	}
+	if input.Selection.Persona != model.PersonaNeutral || input.Selection.SDDMode != model.SDDModeMulti {
+		t.Fatalf("persona = %q, sdd mode = %q", input.Selection.Persona, input.Selection.SDDMode)
+	}
+
+	input, err = NormalizeInstallFlags(InstallFlags{Preset: "backend", Persona: "gentleman", SDDMode: "single"}, system.DetectionResult{})
+	if err != nil {
+		t.Fatalf("NormalizeInstallFlags() error = %v", err)
+	}
+	if input.Selection.Persona != model.PersonaGentleman || input.Selection.SDDMode != model.SDDModeSingle {
+		t.Fatalf("flags did not override preset: persona = %q, sdd mode = %q", input.Selection.Persona, input.Selection.SDDMode)
+	}
+}
+
+func TestNormalizeInstallFlagsRejectsUnknownPreset(t *testing.T) {
+	_, err := NormalizeInstallFlags(InstallFlags{Preset: "backend"}, system.DetectionResult{})
+	if err == nil || !strings.Contains(err.Error(), "available: full-gentleman, ecosystem-only, minimal, custom") {
+		t.Fatalf("NormalizeInstallFlags() error = %v", err)
+	}
+}
+
 func TestNormalizeSDDMode(t *testing.T) {
 	tests := []struct {
 		name    string
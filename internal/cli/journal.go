@@ -0,0 +1,195 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gentleman-programming/gentle-ai/internal/backup"
+	"github.com/gentleman-programming/gentle-ai/internal/journal"
+	"github.com/gentleman-programming/gentle-ai/internal/pipeline"
+	"github.com/gentleman-programming/gentle-ai/internal/planner"
+	"github.com/gentleman-programming/gentle-ai/internal/state"
+)
+
+// backupStepID is the prepare step that snapshots the files an install is
+// about to change.
+const backupStepID = "prepare:backup-snapshot"
+
+// ErrNoInterruptedInstall is returned by --resume and --rollback when no
+// install was interrupted.
+var ErrNoInterruptedInstall = errors.New("no interrupted install to resume or roll back")
+
+// StartJournal opens the write-ahead journal for a new install. When an
+// earlier install was interrupted, the error says how to deal with it.
+func (r *InstallRun) StartJournal() error {
+	w, err := journal.Start(r.runtime.homeDir, state.CommandInstall, r.runtime.selection)
+	var pending *journal.PendingError
+	if errors.As(err, &pending) {
+		return fmt.Errorf("%w; run `gentle-ai install --resume` to finish it or `gentle-ai install --rollback` to undo it", err)
+	}
+	if err != nil {
+		return err
+	}
+
+	r.journal = w
+	return nil
+}
+
+// ResumeJournal continues the install interrupted run describes: the backup
+// it took becomes this run's backup, and the steps it finished are not run
+// again.
+func (r *InstallRun) ResumeJournal(interrupted journal.Run) error {
+	if interrupted.BackupID != "" {
+		manifest, err := backup.Store{Root: r.runtime.backupRoot}.Get(interrupted.BackupID)
+		if err != nil {
+			return fmt.Errorf("load backup %q of the interrupted install: %w", interrupted.BackupID, err)
+		}
+		r.runtime.state.manifest = manifest
+
+		r.Plan.Prepare = skipFinished(r.Plan.Prepare, func(id string) bool { return id == backupStepID })
+		r.Plan.Apply = skipFinished(r.Plan.Apply, interrupted.Succeeded)
+	}
+	// Without a backup the install never reached the apply stage, so it
+	// simply starts over.
+
+	w, err := journal.Resume(r.runtime.homeDir)
+	if err != nil {
+		return err
+	}
+	r.journal = w
+	return nil
+}
+
+// skipFinished replaces the steps finished reports with finishedStep. The
+// rollback step is always kept: it restores the backup if the resumed run
+// fails.
+func skipFinished(steps []pipeline.Step, finished func(id string) bool) []pipeline.Step {
+	result := make([]pipeline.Step, 0, len(steps))
+	for _, step := range steps {
+		if _, ok := step.(rollbackRestoreStep); !ok && finished(step.ID()) {
+			step = finishedStep{id: step.ID()}
+		}
+		result = append(result, step)
+	}
+	return result
+}
+
+// JournalProgress returns a progress func that writes every step transition
+// to the journal before passing it on to next, which may be nil.
+func (r *InstallRun) JournalProgress(next pipeline.ProgressFunc) pipeline.ProgressFunc {
+	return func(event pipeline.ProgressEvent) {
+		if r.journal != nil && r.journalErr == nil {
+			if event.StepID == backupStepID && event.Status == pipeline.StepStatusSucceeded {
+				r.journalErr = r.journal.Backup(r.runtime.state.manifest.ID)
+			}
+			if r.journalErr == nil {
+				r.journalErr = r.journal.Step(string(event.Stage), event.StepID, string(event.Status), event.Err)
+			}
+		}
+		if next != nil {
+			next(event)
+		}
+	}
+}
+
+// EndJournal closes the journal once execution has finished. It is removed
+// unless a failed rollback left files half restored, in which case it stays
+// so `install --rollback` can restore the backup again.
+func (r *InstallRun) EndJournal(execution pipeline.ExecutionResult) error {
+	if r.journal == nil {
+		return nil
+	}
+
+	var err error
+	if execution.Rollback.Err != nil {
+		err = r.journal.Close()
+	} else {
+		err = r.journal.Finish()
+	}
+	return errors.Join(r.journalErr, err)
+}
+
+// loadInterruptedInstall returns the journal of the install that was
+// interrupted, or ErrNoInterruptedInstall.
+func loadInterruptedInstall(homeDir string) (journal.Run, error) {
+	interrupted, ok, err := journal.Load(homeDir)
+	if err != nil {
+		return journal.Run{}, err
+	}
+	if !ok || interrupted.Command != state.CommandInstall {
+		return journal.Run{}, ErrNoInterruptedInstall
+	}
+	return interrupted, nil
+}
+
+// rollbackInterruptedInstall restores the backup the interrupted install
+// took and discards its journal. It returns the ID of the restored backup,
+// which is empty when the install stopped before changing any file.
+func rollbackInterruptedInstall(ctx context.Context, homeDir string, wait bool, version string) (string, error) {
+	runLock, err := acquireRunLock(ctx, homeDir, "install --rollback", wait)
+	if err != nil {
+		return "", err
+	}
+	defer releaseRunLock(runLock)
+
+	interrupted, err := loadInterruptedInstall(homeDir)
+	if err != nil {
+		return "", err
+	}
+
+	if interrupted.BackupID != "" {
+		manifest, err := backup.NewStore(homeDir).Get(interrupted.BackupID)
+		if err != nil {
+			return "", fmt.Errorf("load backup %q of the interrupted install: %w", interrupted.BackupID, err)
+		}
+		managed, err := interruptedFiles(homeDir, interrupted)
+		if err != nil {
+			return "", err
+		}
+		if err := (backup.RestoreService{SafetyRoot: backup.DefaultRoot(homeDir), Managed: managed, Version: version}).Restore(manifest); err != nil {
+			return "", fmt.Errorf("restore backup %q: %w", interrupted.BackupID, err)
+		}
+	}
+
+	if err := journal.Discard(homeDir); err != nil {
+		return "", err
+	}
+	return interrupted.BackupID, nil
+}
+
+// interruptedFiles returns the files a restore of the interrupted install's
+// backup may remove: those earlier runs recorded plus those the interrupted
+// selection writes, which never reached the ledger.
+func interruptedFiles(homeDir string, interrupted journal.Run) ([]string, error) {
+	files, err := RecordedFiles(homeDir)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved, err := planner.NewResolver(planner.MVPGraph()).Resolve(interrupted.Selection)
+	if err != nil {
+		return nil, fmt.Errorf("resolve the interrupted install's selection: %w", err)
+	}
+	files = append(files, managedFiles(homeDir, interrupted.Selection, resolveAdapters(resolved.Agents), resolved.OrderedComponents)...)
+	return sortedUnique(files), nil
+}
+
+// finishedStep stands in for a step an interrupted install already
+// completed, so resuming it does not run the step again.
+type finishedStep struct {
+	id string
+}
+
+func (s finishedStep) ID() string {
+	return s.id
+}
+
+// DependsOn keeps a finished step from holding up the steps after it.
+func (s finishedStep) DependsOn() []string {
+	return nil
+}
+
+func (s finishedStep) Run(context.Context) error {
+	return nil
+}
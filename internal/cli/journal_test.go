@@ -0,0 +1,149 @@
+package cli
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gentleman-programming/gentle-ai/internal/backup"
+	"github.com/gentleman-programming/gentle-ai/internal/journal"
+	"github.com/gentleman-programming/gentle-ai/internal/model"
+	"github.com/gentleman-programming/gentle-ai/internal/system"
+)
+
+// writeInterruptedInstall leaves home as an install of claude-code with the
+// persona component would if it were killed while applying the persona: the
+// backup is taken, the agent is installed and CLAUDE.md is half written.
+func writeInterruptedInstall(t *testing.T, home string) (claudeMD string, backupID string) {
+	t.Helper()
+
+	claudeMD = filepath.Join(home, ".claude", "CLAUDE.md")
+	manifest, err := backup.NewSnapshotter(backup.DefaultRoot(home)).Create(filepath.Join(backup.DefaultRoot(home), "20260101120000.000000000"), []string{claudeMD})
+	if err != nil {
+		t.Fatalf("Create() backup error = %v", err)
+	}
+
+	w, err := journal.Start(home, "install", model.Selection{
+		Agents:     []model.AgentID{model.AgentClaudeCode},
+		Components: []model.ComponentID{model.ComponentPersona},
+		Persona:    model.PersonaGentleman,
+	})
+	if err != nil {
+		t.Fatalf("journal.Start() error = %v", err)
+	}
+	for _, step := range []struct{ stage, id, status string }{
+		{"prepare", "prepare:check-dependencies", "succeeded"},
+		{"prepare", backupStepID, "running"},
+	} {
+		if err := w.Step(step.stage, step.id, step.status, nil); err != nil {
+			t.Fatalf("Step() error = %v", err)
+		}
+	}
+	if err := w.Backup(manifest.ID); err != nil {
+		t.Fatalf("Backup() error = %v", err)
+	}
+	for _, step := range []struct{ stage, id, status string }{
+		{"prepare", backupStepID, "succeeded"},
+		{"apply", "apply:rollback-restore", "succeeded"},
+		{"apply", "agent:claude-code", "succeeded"},
+		{"apply", "component:persona", "running"},
+	} {
+		if err := w.Step(step.stage, step.id, step.status, nil); err != nil {
+			t.Fatalf("Step() error = %v", err)
+		}
+	}
+	// The process dies here, without finishing the journal.
+	_ = w.Close()
+
+	if err := os.MkdirAll(filepath.Dir(claudeMD), 0o755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	if err := os.WriteFile(claudeMD, []byte("# half wri"), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	return claudeMD, manifest.ID
+}
+
+func TestRunInstallResumeSkipsStepsTheInterruptedRunFinished(t *testing.T) {
+	home := t.TempDir()
+	restoreHome := osUserHomeDir
+	restoreCommand := runCommand
+	restoreLookPath := cmdLookPath
+	t.Cleanup(func() {
+		osUserHomeDir = restoreHome
+		runCommand = restoreCommand
+		cmdLookPath = restoreLookPath
+	})
+
+	osUserHomeDir = func() (string, error) { return home, nil }
+	recorder := &commandRecorder{}
+	runCommand = recorder.record
+	cmdLookPath = missingBinaryLookPath
+
+	claudeMD, _ := writeInterruptedInstall(t, home)
+
+	if _, err := RunInstall([]string{"--agent", "opencode"}, system.DetectionResult{}); err == nil || !strings.Contains(err.Error(), "--resume") {
+		t.Fatalf("RunInstall() with an interrupted install pending error = %v, want a pointer to --resume", err)
+	}
+
+	result, err := RunInstall([]string{"--resume"}, system.DetectionResult{})
+	if err != nil {
+		t.Fatalf("RunInstall(--resume) error = %v", err)
+	}
+
+	if len(recorder.get()) != 0 {
+		t.Fatalf("commands = %v, want the agent the interrupted run installed left alone", recorder.get())
+	}
+	if _, ok := result.Plan.Apply[1].(finishedStep); !ok {
+		t.Fatalf("apply step %q = %T, want it marked finished", result.Plan.Apply[1].ID(), result.Plan.Apply[1])
+	}
+	content, err := os.ReadFile(claudeMD)
+	if err != nil {
+		t.Fatalf("ReadFile(CLAUDE.md) error = %v", err)
+	}
+	if string(content) == "# half wri" {
+		t.Fatalf("CLAUDE.md was not rewritten by the resumed persona step")
+	}
+	if _, ok, err := journal.Load(home); ok || err != nil {
+		t.Fatalf("journal left behind after the resumed install: ok = %v, err = %v", ok, err)
+	}
+}
+
+func TestRunInstallRollbackRestoresTheInterruptedRunsBackup(t *testing.T) {
+	home := t.TempDir()
+	restoreHome := osUserHomeDir
+	t.Cleanup(func() { osUserHomeDir = restoreHome })
+	osUserHomeDir = func() (string, error) { return home, nil }
+
+	claudeMD, backupID := writeInterruptedInstall(t, home)
+
+	result, err := RunInstall([]string{"--rollback"}, system.DetectionResult{})
+	if err != nil {
+		t.Fatalf("RunInstall(--rollback) error = %v", err)
+	}
+	if !result.RolledBack || result.RestoredBackupID != backupID {
+		t.Fatalf("result = %+v, want backup %s restored", result, backupID)
+	}
+	if _, err := os.Stat(claudeMD); !os.IsNotExist(err) {
+		t.Fatalf("CLAUDE.md should be gone after the rollback, stat err = %v", err)
+	}
+
+	if _, err := RunInstall([]string{"--rollback"}, system.DetectionResult{}); !errors.Is(err, ErrNoInterruptedInstall) {
+		t.Fatalf("second --rollback error = %v, want ErrNoInterruptedInstall", err)
+	}
+}
+
+func TestParseInstallFlagsResumeTakesTheSelectionFromTheJournal(t *testing.T) {
+	if _, err := ParseInstallFlags([]string{"--resume", "--jobs", "2", "--wait"}); err != nil {
+		t.Fatalf("ParseInstallFlags(--resume --jobs 2 --wait) error = %v", err)
+	}
+	if _, err := ParseInstallFlags([]string{"--resume", "--agent", "opencode"}); err == nil {
+		t.Fatalf("ParseInstallFlags(--resume --agent) should fail")
+	}
+	if _, err := ParseInstallFlags([]string{"--resume", "--rollback"}); err == nil {
+		t.Fatalf("ParseInstallFlags(--resume --rollback) should fail")
+	}
+}
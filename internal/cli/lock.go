@@ -0,0 +1,37 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/gentleman-programming/gentle-ai/internal/lock"
+)
+
+// acquireRunLock takes the lock that keeps two gentle-ai runs from changing
+// the same files at once. With wait set it blocks until the run holding the
+// lock finishes or ctx is cancelled; otherwise that run is reported as an
+// error.
+func acquireRunLock(ctx context.Context, homeDir string, command string, wait bool) (*lock.Lock, error) {
+	if wait {
+		return lock.Wait(ctx, homeDir, command, func(owner lock.Owner) {
+			fmt.Fprintf(os.Stderr, "Waiting for gentle-ai %s (pid %d on %s) to finish...\n", owner.Command, owner.PID, owner.Host)
+		})
+	}
+
+	runLock, err := lock.Acquire(homeDir, command)
+	var held *lock.HeldError
+	if errors.As(err, &held) {
+		return nil, fmt.Errorf("%w; wait for it to finish or pass --wait", err)
+	}
+	return runLock, err
+}
+
+// releaseRunLock releases a lock taken by acquireRunLock. A failure leaves a
+// lock file the next run detects as stale, so it is only reported.
+func releaseRunLock(runLock *lock.Lock) {
+	if err := runLock.Release(); err != nil {
+		fmt.Fprintf(os.Stderr, "WARNING: could not release run lock: %v\n", err)
+	}
+}
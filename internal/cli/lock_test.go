@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gentleman-programming/gentle-ai/internal/backup"
+	"github.com/gentleman-programming/gentle-ai/internal/lock"
+	"github.com/gentleman-programming/gentle-ai/internal/system"
+)
+
+func TestRunInstallFailsWhileAnotherRunHoldsTheLock(t *testing.T) {
+	home := t.TempDir()
+	stubInstallEnvironment(t, home)
+
+	held, err := lock.Acquire(home, "uninstall")
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+
+	_, err = RunInstall([]string{"--agent", "opencode", "--component", "permissions"}, system.DetectionResult{})
+	if err == nil || !strings.Contains(err.Error(), "uninstall (pid") || !strings.Contains(err.Error(), "--wait") {
+		t.Fatalf("RunInstall() error = %v, want the lock holder and a --wait hint", err)
+	}
+	if manifests, _ := backup.NewStore(home).List(); len(manifests) != 0 {
+		t.Fatalf("a locked-out install took %d backups", len(manifests))
+	}
+
+	if _, err := RunInstall([]string{"--agent", "opencode", "--component", "permissions", "--dry-run"}, system.DetectionResult{}); err != nil {
+		t.Fatalf("dry run should not need the lock: %v", err)
+	}
+	if _, err := RunBackup([]string{"prune", "--keep", "1"}, ""); err == nil {
+		t.Fatalf("RunBackup(prune) should not run while the lock is held")
+	}
+
+	if err := held.Release(); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	if _, err := RunInstall([]string{"--agent", "opencode", "--component", "permissions"}, system.DetectionResult{}); err != nil {
+		t.Fatalf("RunInstall() after release error = %v", err)
+	}
+	if _, err := os.Stat(lock.Path(home)); !os.IsNotExist(err) {
+		t.Fatalf("install left its lock behind, err = %v", err)
+	}
+}
@@ -0,0 +1,195 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/gentleman-programming/gentle-ai/internal/backup"
+	"github.com/gentleman-programming/gentle-ai/internal/model"
+	"github.com/gentleman-programming/gentle-ai/internal/pipeline"
+	"github.com/gentleman-programming/gentle-ai/internal/planner"
+	"github.com/gentleman-programming/gentle-ai/internal/state"
+)
+
+// agentError is a component step failing for one agent only; the other
+// agents the step configures are not affected.
+type agentError struct {
+	agent model.AgentID
+	err   error
+}
+
+func (e *agentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *agentError) Unwrap() error {
+	return e.err
+}
+
+// failedAgents returns the agents err blames when every error it joins is
+// an *agentError, and nil when any part of it concerns the whole step.
+func failedAgents(err error) []model.AgentID {
+	switch e := err.(type) {
+	case *agentError:
+		return []model.AgentID{e.agent}
+	case interface{ Unwrap() []error }:
+		agents := []model.AgentID{}
+		for _, inner := range e.Unwrap() {
+			blamed := failedAgents(inner)
+			if blamed == nil {
+				return nil
+			}
+			agents = append(agents, blamed...)
+		}
+		return agents
+	case interface{ Unwrap() error }:
+		return failedAgents(e.Unwrap())
+	default:
+		return nil
+	}
+}
+
+// failedOwners returns a manifest filter for each failed apply step: an
+// agent whose install failed owns every file tagged with it, a component
+// that failed for some agents owns its files for those agents, and one that
+// failed outright owns its files for every agent.
+func failedOwners(results []pipeline.StepResult) []backup.Filter {
+	owners := []backup.Filter{}
+	for _, result := range results {
+		if result.Status != pipeline.StepStatusFailed {
+			continue
+		}
+
+		if agent, ok := strings.CutPrefix(result.StepID, "agent:"); ok {
+			owners = append(owners, backup.Filter{Agents: []model.AgentID{model.AgentID(agent)}})
+			continue
+		}
+		if component, ok := strings.CutPrefix(result.StepID, "component:"); ok {
+			owners = append(owners, backup.Filter{
+				Agents:     failedAgents(result.Err),
+				Components: []model.ComponentID{model.ComponentID(component)},
+			})
+		}
+	}
+	return owners
+}
+
+// selectOwned returns the manifest narrowed to the entries any of owners
+// matches. A file several owners share goes back if one of them failed.
+func selectOwned(manifest backup.Manifest, owners []backup.Filter) backup.Manifest {
+	selected := manifest
+	selected.Entries = nil
+	for _, entry := range manifest.Entries {
+		if slices.ContainsFunc(owners, func(owner backup.Filter) bool { return !owner.IsZero() && owner.Matches(entry) }) {
+			selected.Entries = append(selected.Entries, entry)
+		}
+	}
+	return selected
+}
+
+// selectChanged returns the manifest narrowed to the entries for changed
+// files; a directory entry is kept when a changed file lies below it.
+func selectChanged(manifest backup.Manifest, changed []string) backup.Manifest {
+	selected := manifest
+	selected.Entries = nil
+	for _, entry := range manifest.Entries {
+		if slices.ContainsFunc(changed, func(path string) bool {
+			return path == entry.OriginalPath || (entry.IsDir() && strings.HasPrefix(path, entry.OriginalPath+string(filepath.Separator)))
+		}) {
+			selected.Entries = append(selected.Entries, entry)
+		}
+	}
+	return selected
+}
+
+// AgentOutcomes says what the run left configured for each agent once
+// execution has finished.
+func (r *InstallRun) AgentOutcomes(execution pipeline.ExecutionResult) []state.AgentOutcome {
+	return agentOutcomes(r.runtime.resolved, execution, r.runtime.state)
+}
+
+// agentOutcomes works out, for every agent, which components ended up in
+// place: their step succeeded or was already up to date, or failed only for
+// other agents, and no rollback restored their files for this agent.
+func agentOutcomes(resolved planner.ResolvedPlan, execution pipeline.ExecutionResult, runtime *runtimeState) []state.AgentOutcome {
+	steps := map[string]pipeline.StepResult{}
+	for _, step := range execution.Apply.Steps {
+		steps[step.StepID] = step
+	}
+
+	runtime.mu.Lock()
+	restoredAll, rolledBack, restored := runtime.restoredAll, runtime.rolledBack, runtime.restored
+	runtime.mu.Unlock()
+
+	// kept reports whether no rollback restored the files of component for
+	// agent; an empty component stands for the agent as a whole.
+	kept := func(agent model.AgentID, component model.ComponentID) bool {
+		if restoredAll {
+			return false
+		}
+		for _, owner := range rolledBack {
+			if (len(owner.Agents) == 0 || slices.Contains(owner.Agents, agent)) &&
+				(len(owner.Components) == 0 || component == "" || slices.Contains(owner.Components, component)) {
+				return false
+			}
+		}
+		if component == "" {
+			return true
+		}
+		return !slices.ContainsFunc(restored, func(entry backup.ManifestEntry) bool {
+			return slices.Contains(entry.Agents, agent) && slices.Contains(entry.Components, component)
+		})
+	}
+
+	outcomes := make([]state.AgentOutcome, 0, len(resolved.Agents))
+	for _, agent := range resolved.Agents {
+		outcome := state.AgentOutcome{Agent: agent}
+		installed := steps["agent:"+string(agent)].Done() && kept(agent, "")
+
+		for _, component := range resolved.OrderedComponents {
+			step, ok := steps["component:"+string(component)]
+			applied := ok && (step.Done() ||
+				(step.Status == pipeline.StepStatusFailed && failedAgents(step.Err) != nil && !slices.Contains(failedAgents(step.Err), agent)))
+			if installed && applied && kept(agent, component) {
+				outcome.Configured = append(outcome.Configured, component)
+			} else {
+				outcome.Missing = append(outcome.Missing, component)
+			}
+		}
+
+		switch {
+		case !installed || (len(outcome.Configured) == 0 && len(outcome.Missing) > 0):
+			outcome.Status = state.AgentRolledBack
+		case len(outcome.Missing) == 0:
+			outcome.Status = state.AgentConfigured
+		default:
+			outcome.Status = state.AgentPartial
+		}
+		outcomes = append(outcomes, outcome)
+	}
+
+	return outcomes
+}
+
+// RenderAgentOutcomes lists what an install left configured for each agent.
+func RenderAgentOutcomes(outcomes []state.AgentOutcome) string {
+	if len(outcomes) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	fmt.Fprintln(&b, "Agents:")
+	for _, outcome := range outcomes {
+		switch outcome.Status {
+		case state.AgentConfigured:
+			fmt.Fprintf(&b, "[ok] %s - fully configured\n", outcome.Agent)
+		case state.AgentPartial:
+			fmt.Fprintf(&b, "[~~] %s - partially configured, missing %s\n", outcome.Agent, joinComponentIDs(outcome.Missing))
+		default:
+			fmt.Fprintf(&b, "[xx] %s - rolled back\n", outcome.Agent)
+		}
+	}
+	return b.String()
+}
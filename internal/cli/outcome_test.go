@@ -0,0 +1,121 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gentleman-programming/gentle-ai/internal/backup"
+	"github.com/gentleman-programming/gentle-ai/internal/model"
+	"github.com/gentleman-programming/gentle-ai/internal/pipeline"
+	"github.com/gentleman-programming/gentle-ai/internal/state"
+	"github.com/gentleman-programming/gentle-ai/internal/system"
+)
+
+func TestRunInstallKeepGoingRollsBackOnlyTheFailedAgent(t *testing.T) {
+	home := t.TempDir()
+	stubInstallEnvironment(t, home)
+	// A directory where OpenCode's AGENTS.md goes makes its persona fail.
+	if err := os.MkdirAll(filepath.Join(home, ".config", "opencode", "AGENTS.md"), 0o755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+
+	result, err := RunInstall([]string{"--agent", "claude-code,opencode", "--component", "persona", "--keep-going"}, system.DetectionResult{})
+	if err == nil {
+		t.Fatalf("RunInstall() error = nil, want the OpenCode persona failure")
+	}
+
+	if _, err := os.Stat(filepath.Join(home, ".claude", "CLAUDE.md")); err != nil {
+		t.Fatalf("CLAUDE.md should be kept, stat err = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(home, ".config", "opencode", "opencode.json")); !os.IsNotExist(err) {
+		t.Fatalf("opencode.json should be rolled back, stat err = %v", err)
+	}
+
+	want := []state.AgentOutcome{
+		{Agent: model.AgentClaudeCode, Status: state.AgentConfigured, Configured: []model.ComponentID{model.ComponentPersona}},
+		{Agent: model.AgentOpenCode, Status: state.AgentRolledBack, Missing: []model.ComponentID{model.ComponentPersona}},
+	}
+	if !reflect.DeepEqual(result.Agents, want) {
+		t.Fatalf("result.Agents = %#v, want %#v", result.Agents, want)
+	}
+
+	ledger, err := state.Load(home)
+	if err != nil {
+		t.Fatalf("state.Load() error = %v", err)
+	}
+	record, ok := ledger.Latest(state.CommandInstall)
+	if !ok || !reflect.DeepEqual(record.AgentOutcomes, want) {
+		t.Fatalf("ledger record AgentOutcomes = %#v, want %#v", record.AgentOutcomes, want)
+	}
+	targets := failedTargets(record)
+	if len(targets) != 1 || !reflect.DeepEqual(targets[0].Agents, []model.AgentID{model.AgentOpenCode}) {
+		t.Fatalf("failedTargets() = %#v, want persona for OpenCode only", targets)
+	}
+}
+
+func TestFailedOwnersNarrowsComponentsToTheAgentsTheyFailedFor(t *testing.T) {
+	agentFailure := fmt.Errorf("inject: %w", errors.Join(
+		&agentError{agent: model.AgentOpenCode, err: errors.New("permission denied")},
+	))
+	results := []pipeline.StepResult{
+		{StepID: "agent:claude-code", Status: pipeline.StepStatusSucceeded},
+		{StepID: "agent:gemini-cli", Status: pipeline.StepStatusFailed, Err: errors.New("boom")},
+		{StepID: "component:persona", Status: pipeline.StepStatusFailed, Err: agentFailure},
+		{StepID: "component:skills", Status: pipeline.StepStatusFailed, Err: errors.New("boom")},
+	}
+
+	want := []backup.Filter{
+		{Agents: []model.AgentID{model.AgentGeminiCLI}},
+		{Agents: []model.AgentID{model.AgentOpenCode}, Components: []model.ComponentID{model.ComponentPersona}},
+		{Components: []model.ComponentID{model.ComponentSkills}},
+	}
+	if got := failedOwners(results); !reflect.DeepEqual(got, want) {
+		t.Fatalf("failedOwners() = %#v, want %#v", got, want)
+	}
+}
+
+func TestRollbackRestoreOwnsOnlyWhatTheFailedStepsChanged(t *testing.T) {
+	settings := "/home/u/.claude/settings.json"
+	runtime := &runtimeState{manifest: backup.Manifest{Entries: []backup.ManifestEntry{
+		{OriginalPath: settings, Agents: []model.AgentID{model.AgentClaudeCode}, Components: []model.ComponentID{model.ComponentEngram, model.ComponentPermission}},
+	}}}
+	step := rollbackRestoreStep{id: "apply:rollback-restore", state: runtime}
+	engramFailed := []pipeline.StepResult{
+		{StepID: "component:permissions", Status: pipeline.StepStatusSucceeded},
+		{StepID: "component:engram", Status: pipeline.StepStatusFailed, Err: errors.New("install engram: exit status 1")},
+	}
+
+	// The engram binary failed to install: its step wrote nothing.
+	runtime.recordChanges("component:permissions", []string{settings})
+	if step.OwnsFailed(engramFailed) {
+		t.Fatalf("OwnsFailed() = true, want false when the failed step changed nothing")
+	}
+
+	runtime.recordChanges("component:engram", []string{settings})
+	if !step.OwnsFailed(engramFailed) {
+		t.Fatalf("OwnsFailed() = false, want true for a file the failed step changed")
+	}
+}
+
+func TestRenderAgentOutcomesListsEachAgent(t *testing.T) {
+	output := RenderAgentOutcomes([]state.AgentOutcome{
+		{Agent: model.AgentClaudeCode, Status: state.AgentConfigured},
+		{Agent: model.AgentGeminiCLI, Status: state.AgentPartial, Missing: []model.ComponentID{model.ComponentSDD, model.ComponentSkills}},
+		{Agent: model.AgentOpenCode, Status: state.AgentRolledBack},
+	})
+
+	for _, want := range []string{
+		"[ok] claude-code - fully configured",
+		"[~~] gemini-cli - partially configured, missing sdd,skills",
+		"[xx] opencode - rolled back",
+	} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("RenderAgentOutcomes() missing %q\noutput=%s", want, output)
+		}
+	}
+}
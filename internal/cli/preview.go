@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/gentleman-programming/gentle-ai/internal/diff"
+	"github.com/gentleman-programming/gentle-ai/internal/model"
+	"github.com/gentleman-programming/gentle-ai/internal/planner"
+)
+
+// previewChanges runs every component injector of the plan, in install order,
+// against a scratch copy of the files they touch and returns the files whose
+// content would change. JSON merges, markdown sections and TOML upserts all go
+// through the real injectors, so the preview matches what install writes.
+func previewChanges(ctx context.Context, homeDir string, selection model.Selection, resolved planner.ResolvedPlan) ([]diff.FileChange, error) {
+	inventory := targetPaths(backupTargets(homeDir, selection, resolved))
+	rendered, err := renderInScratch(homeDir, inventory, noLock{}, func(scratch string) ([]string, error) {
+		written := []string{}
+		for _, component := range resolved.OrderedComponents {
+			files, err := injectComponentFiles(ctx, scratch, component, resolved.Agents, selection)
+			if err != nil {
+				return nil, err
+			}
+			written = append(written, files...)
+		}
+		return written, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("preview file changes: %w", err)
+	}
+
+	changes := []diff.FileChange{}
+	for _, path := range sortedKeys(rendered) {
+		change := diff.FileChange{Path: path, After: string(rendered[path])}
+		if before, err := os.ReadFile(path); err == nil {
+			change.Exists = true
+			change.Before = string(before)
+		}
+		if change.Changed() {
+			changes = append(changes, change)
+		}
+	}
+
+	return changes, nil
+}
+
+// RenderDiff prints a unified diff for every file an install would change.
+func RenderDiff(changes []diff.FileChange) string {
+	if len(changes) == 0 {
+		return "No file changes: every managed file is already up to date."
+	}
+
+	b := &strings.Builder{}
+	for _, change := range changes {
+		_, _ = fmt.Fprint(b, change.Unified())
+	}
+
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func renderChangeSummary(b *strings.Builder, changes []diff.FileChange) {
+	_, _ = fmt.Fprintf(b, "File changes: %d\n", len(changes))
+	for _, change := range changes {
+		verb := "modify"
+		if !change.Exists {
+			verb = "create"
+		}
+		_, _ = fmt.Fprintf(b, "- %s %s\n", verb, change.Path)
+	}
+}
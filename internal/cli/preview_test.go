@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gentleman-programming/gentle-ai/internal/components/sdd"
+	"github.com/gentleman-programming/gentle-ai/internal/system"
+)
+
+func TestRunInstallDiffShowsSettingsChangeWithoutWriting(t *testing.T) {
+	home := t.TempDir()
+	stubInstallEnvironment(t, home)
+
+	settings := filepath.Join(home, ".claude", "settings.json")
+	writeTestFile(t, settings, "{\n  \"model\": \"opus\"\n}\n")
+
+	result, err := RunInstall([]string{"--agent", "claude-code", "--component", "permissions", "--diff"}, system.DetectionResult{})
+	if err != nil {
+		t.Fatalf("RunInstall() error = %v", err)
+	}
+	if !result.DryRun {
+		t.Fatalf("--diff should imply --dry-run")
+	}
+
+	var found bool
+	for _, change := range result.Changes {
+		if change.Path == settings {
+			found = true
+			if !change.Exists || !strings.Contains(change.Before, "opus") {
+				t.Fatalf("settings change = %#v", change)
+			}
+		}
+	}
+	if !found {
+		t.Fatalf("changes missing %q: %#v", settings, result.Changes)
+	}
+
+	output := RenderDiff(result.Changes)
+	if !strings.Contains(output, "--- a"+settings) || !strings.Contains(output, "+++ b"+settings) {
+		t.Fatalf("diff missing settings headers:\n%s", output)
+	}
+	if !strings.Contains(output, "+  \"permissions\"") {
+		t.Fatalf("diff missing added permissions key:\n%s", output)
+	}
+
+	content, err := os.ReadFile(settings)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if string(content) != "{\n  \"model\": \"opus\"\n}\n" {
+		t.Fatalf("--diff modified settings.json: %q", string(content))
+	}
+}
+
+func TestRunInstallDiffIsEmptyAfterInstall(t *testing.T) {
+	home := t.TempDir()
+	stubInstallEnvironment(t, home)
+
+	args := []string{"--agent", "opencode", "--component", "persona,permissions"}
+	if _, err := RunInstall(args, system.DetectionResult{}); err != nil {
+		t.Fatalf("RunInstall() error = %v", err)
+	}
+
+	result, err := RunInstall(append(args, "--diff"), system.DetectionResult{})
+	if err != nil {
+		t.Fatalf("RunInstall(--diff) error = %v", err)
+	}
+	if len(result.Changes) != 0 {
+		t.Fatalf("changes after install = %#v, want none", result.Changes)
+	}
+	if !strings.Contains(RenderDiff(result.Changes), "No file changes") {
+		t.Fatalf("RenderDiff() = %q", RenderDiff(result.Changes))
+	}
+}
+
+func TestRunInstallDryRunWithExistingCommandsDirectory(t *testing.T) {
+	home := t.TempDir()
+	stubInstallEnvironment(t, home)
+
+	// Pretend the plugin dependency is installed so the real install never
+	// runs bun or npm.
+	if err := os.MkdirAll(sdd.OpenCodePluginDependencyPath(home), 0o755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	args := []string{"--agent", "opencode", "--component", "sdd"}
+	if _, err := RunInstall(args, system.DetectionResult{}); err != nil {
+		t.Fatalf("RunInstall() error = %v", err)
+	}
+	writeTestFile(t, filepath.Join(home, ".config", "opencode", "commands", "mine.md"), "# my command\n")
+
+	result, err := RunInstall(append(args, "--dry-run"), system.DetectionResult{})
+	if err != nil {
+		t.Fatalf("RunInstall(--dry-run) error = %v", err)
+	}
+	if result.ChangesErr != nil {
+		t.Fatalf("ChangesErr = %v, want the preview to handle directories", result.ChangesErr)
+	}
+	if len(result.Changes) != 0 {
+		t.Fatalf("changes after install = %#v, want none", result.Changes)
+	}
+	if output := RenderDryRun(result); strings.Contains(output, "unavailable") {
+		t.Fatalf("RenderDryRun() reports the preview as unavailable:\n%s", output)
+	}
+}
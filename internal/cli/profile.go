@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/gentleman-programming/gentle-ai/internal/profile"
+	"github.com/gentleman-programming/gentle-ai/internal/state"
+	"github.com/gentleman-programming/gentle-ai/internal/system"
+)
+
+// RunProfile handles `gentle-ai profile <export|validate>` and returns the
+// text to print.
+//
+//	profile export [--name NAME] [install flags]   print a profile to stdout
+//	profile validate FILE                          check a profile against the schema
+//
+// Without install flags, export captures the latest successful install
+// recorded in the state ledger, so `gentle-ai profile export > team.yaml`
+// shares exactly what this machine has.
+func RunProfile(args []string, detection system.DetectionResult) (string, error) {
+	if len(args) == 0 {
+		return "", fmt.Errorf("missing profile subcommand (export or validate)")
+	}
+
+	switch args[0] {
+	case "export":
+		return runProfileExport(args[1:], detection)
+	case "validate":
+		if len(args) != 2 {
+			return "", fmt.Errorf("usage: gentle-ai profile validate FILE")
+		}
+		loaded, err := profile.Load(args[1])
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s: valid profile (version %d, %d agents, %d components)", args[1], loaded.Version, len(loaded.Agents), len(loaded.Components)), nil
+	default:
+		return "", fmt.Errorf("unknown profile subcommand %q (expected export or validate)", args[0])
+	}
+}
+
+func runProfileExport(args []string, detection system.DetectionResult) (string, error) {
+	var name string
+	var flags InstallFlags
+
+	fs := flag.NewFlagSet("profile export", flag.ContinueOnError)
+	fs.SetOutput(ioDiscard{})
+	fs.StringVar(&name, "name", "", "name recorded in the profile")
+	registerListFlag(fs, "agent", &flags.Agents)
+	registerListFlag(fs, "agents", &flags.Agents)
+	registerListFlag(fs, "component", &flags.Components)
+	registerListFlag(fs, "components", &flags.Components)
+	registerListFlag(fs, "skill", &flags.Skills)
+	registerListFlag(fs, "skills", &flags.Skills)
+	fs.StringVar(&flags.Persona, "persona", "", "persona to export")
+	fs.StringVar(&flags.Preset, "preset", "", "preset to export")
+	fs.StringVar(&flags.SDDMode, "sdd-mode", "", "SDD orchestrator mode to export")
+	fs.StringVar(&flags.Profile, "profile", "", "profile file to start from")
+
+	if err := fs.Parse(args); err != nil {
+		return "", err
+	}
+	if fs.NArg() > 0 {
+		return "", fmt.Errorf("unexpected profile export argument %q", fs.Arg(0))
+	}
+
+	if fs.NFlag() == 0 || (fs.NFlag() == 1 && name != "") {
+		homeDir, err := osUserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("resolve user home directory: %w", err)
+		}
+		ledger, err := state.Load(homeDir)
+		if err != nil {
+			return "", err
+		}
+		if record, ok := latestSuccessfulInstall(ledger); ok {
+			return string(profile.Encode(profile.FromSelection(name, record.Selection()))), nil
+		}
+	}
+
+	input, err := NormalizeInstallFlags(flags, detection)
+	if err != nil {
+		return "", err
+	}
+
+	return string(profile.Encode(profile.FromSelection(name, input.Selection))), nil
+}
+
+func latestSuccessfulInstall(ledger state.Ledger) (state.Record, bool) {
+	for i := len(ledger.Records) - 1; i >= 0; i-- {
+		record := ledger.Records[i]
+		if record.Command == state.CommandInstall && record.Success {
+			return record, true
+		}
+	}
+	return state.Record{}, false
+}
@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gentleman-programming/gentle-ai/internal/model"
+	"github.com/gentleman-programming/gentle-ai/internal/profile"
+	"github.com/gentleman-programming/gentle-ai/internal/system"
+)
+
+func TestRunInstallAppliesProfile(t *testing.T) {
+	home := t.TempDir()
+	stubInstallEnvironment(t, home)
+
+	path := filepath.Join(t.TempDir(), "team.yaml")
+	writeTestFile(t, path, `version: 1
+agents: [opencode]
+components: [persona, permissions]
+persona: neutral
+sdd_mode: multi
+model_assignments:
+  sdd-apply:
+    provider_id: anthropic
+    model_id: claude-sonnet-4
+`)
+
+	result, err := RunInstall([]string{"--profile", path, "--dry-run"}, system.DetectionResult{})
+	if err != nil {
+		t.Fatalf("RunInstall() error = %v", err)
+	}
+
+	selection := result.Selection
+	if !reflect.DeepEqual(selection.Agents, []model.AgentID{model.AgentOpenCode}) {
+		t.Fatalf("agents = %v", selection.Agents)
+	}
+	if !reflect.DeepEqual(selection.Components, []model.ComponentID{model.ComponentPersona, model.ComponentPermission}) {
+		t.Fatalf("components = %v", selection.Components)
+	}
+	if selection.Persona != model.PersonaNeutral || selection.SDDMode != model.SDDModeMulti {
+		t.Fatalf("persona/sdd mode = %q/%q", selection.Persona, selection.SDDMode)
+	}
+	if got := selection.ModelAssignments["sdd-apply"]; got.ModelID != "claude-sonnet-4" {
+		t.Fatalf("model assignments = %#v", selection.ModelAssignments)
+	}
+
+	override, err := RunInstall([]string{"--profile", path, "--agent", "claude-code", "--dry-run"}, system.DetectionResult{})
+	if err != nil {
+		t.Fatalf("RunInstall(override) error = %v", err)
+	}
+	if !reflect.DeepEqual(override.Selection.Agents, []model.AgentID{model.AgentClaudeCode}) {
+		t.Fatalf("explicit --agent should win over the profile, got %v", override.Selection.Agents)
+	}
+}
+
+func TestRunInstallRejectsInvalidProfile(t *testing.T) {
+	home := t.TempDir()
+	stubInstallEnvironment(t, home)
+
+	path := filepath.Join(t.TempDir(), "team.yaml")
+	writeTestFile(t, path, "version: 1\nagents: [vim]\n")
+
+	_, err := RunInstall([]string{"--profile", path, "--dry-run"}, system.DetectionResult{})
+	if err == nil || !strings.Contains(err.Error(), "unsupported agent \"vim\"") {
+		t.Fatalf("RunInstall() error = %v, want unsupported agent", err)
+	}
+}
+
+func TestRunProfileExportCapturesLatestInstall(t *testing.T) {
+	home := t.TempDir()
+	stubInstallEnvironment(t, home)
+
+	if _, err := RunInstall([]string{"--agent", "claude-code", "--component", "persona", "--persona", "neutral"}, system.DetectionResult{}); err != nil {
+		t.Fatalf("RunInstall() error = %v", err)
+	}
+
+	output, err := RunProfile([]string{"export", "--name", "team"}, system.DetectionResult{})
+	if err != nil {
+		t.Fatalf("RunProfile(export) error = %v", err)
+	}
+
+	exported, err := profile.Decode([]byte(output))
+	if err != nil {
+		t.Fatalf("Decode(export) error = %v\n%s", err, output)
+	}
+	if exported.Name != "team" || exported.Persona != model.PersonaNeutral {
+		t.Fatalf("exported = %#v", exported)
+	}
+	if !reflect.DeepEqual(exported.Agents, []model.AgentID{model.AgentClaudeCode}) {
+		t.Fatalf("exported agents = %v", exported.Agents)
+	}
+	if !reflect.DeepEqual(exported.Components, []model.ComponentID{model.ComponentPersona}) {
+		t.Fatalf("exported components = %v", exported.Components)
+	}
+
+	path := filepath.Join(t.TempDir(), "team.yaml")
+	writeTestFile(t, path, output)
+	if _, err := RunProfile([]string{"validate", path}, system.DetectionResult{}); err != nil {
+		t.Fatalf("RunProfile(validate) error = %v", err)
+	}
+}
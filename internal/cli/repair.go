@@ -0,0 +1,529 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gentleman-programming/gentle-ai/internal/backup"
+	"github.com/gentleman-programming/gentle-ai/internal/catalog"
+	"github.com/gentleman-programming/gentle-ai/internal/model"
+	"github.com/gentleman-programming/gentle-ai/internal/pipeline"
+	"github.com/gentleman-programming/gentle-ai/internal/planner"
+	"github.com/gentleman-programming/gentle-ai/internal/state"
+	"github.com/gentleman-programming/gentle-ai/internal/system"
+)
+
+// Reasons a step is scheduled by `gentle-ai repair`.
+const (
+	RepairReasonFailed  = "failed"
+	RepairReasonDrifted = "drifted"
+)
+
+type RepairFlags struct {
+	Agents      []string
+	DryRun      bool
+	BackupLabel string
+	Wait        bool
+}
+
+func ParseRepairFlags(args []string) (RepairFlags, error) {
+	var opts RepairFlags
+
+	fs := flag.NewFlagSet("repair", flag.ContinueOnError)
+	fs.SetOutput(ioDiscard{})
+	registerListFlag(fs, "agent", &opts.Agents)
+	registerListFlag(fs, "agents", &opts.Agents)
+	fs.BoolVar(&opts.DryRun, "dry-run", false, "list the steps that would be re-run without applying them")
+	fs.StringVar(&opts.BackupLabel, "backup-label", "", "note to record on the backup this run takes")
+	fs.BoolVar(&opts.Wait, "wait", false, "wait for another running gentle-ai to finish instead of failing")
+
+	if err := fs.Parse(args); err != nil {
+		return RepairFlags{}, err
+	}
+
+	if fs.NArg() > 0 {
+		return RepairFlags{}, fmt.Errorf("unexpected repair argument %q", fs.Arg(0))
+	}
+
+	return opts, nil
+}
+
+// RepairTarget is one install step that repair will run again.
+type RepairTarget struct {
+	StepID    string
+	Agent     model.AgentID
+	Component model.ComponentID
+	Agents    []model.AgentID
+	Reason    string
+	Files     []FileStatus
+}
+
+type RepairResult struct {
+	Targets   []RepairTarget
+	Plan      pipeline.StagePlan
+	Execution pipeline.ExecutionResult
+	Backup    backup.Manifest
+	DryRun    bool
+}
+
+// RunRepair re-runs only the install steps that need it: agent and component
+// steps that failed (or were rolled back) in the most recent install or repair,
+// and components whose managed files no longer match the installer output.
+// The steps run behind a fresh backup snapshot, like a regular install.
+func RunRepair(args []string, detection system.DetectionResult) (RepairResult, error) {
+	return RunRepairContext(context.Background(), args, detection, "")
+}
+
+// RunRepairContext is RunRepair with a context; cancelling it stops the
+// repair and rolls back the steps that already ran. version is recorded on
+// the backup label.
+func RunRepairContext(ctx context.Context, args []string, detection system.DetectionResult, version string) (RepairResult, error) {
+	flags, err := ParseRepairFlags(args)
+	if err != nil {
+		return RepairResult{}, err
+	}
+
+	agentIDs, err := normalizeUninstallAgents(flags.Agents)
+	if err != nil {
+		return RepairResult{}, err
+	}
+
+	homeDir, err := osUserHomeDir()
+	if err != nil {
+		return RepairResult{}, fmt.Errorf("resolve user home directory: %w", err)
+	}
+
+	// Repair reads the ledger to decide what to run, so a real run locks
+	// before reading it.
+	if !flags.DryRun {
+		runLock, err := acquireRunLock(ctx, homeDir, state.CommandRepair, flags.Wait)
+		if err != nil {
+			return RepairResult{}, err
+		}
+		defer releaseRunLock(runLock)
+	}
+
+	ledger, err := state.Load(homeDir)
+	if err != nil {
+		return RepairResult{}, err
+	}
+	if !ledger.HasInstalls() {
+		return RepairResult{}, fmt.Errorf("no install recorded in %s; run `gentle-ai install` first", state.Path(homeDir))
+	}
+
+	targets, err := repairTargets(ctx, homeDir, ledger, agentIDs)
+	if err != nil {
+		return RepairResult{}, err
+	}
+
+	result := RepairResult{Targets: targets, DryRun: flags.DryRun}
+	if len(targets) == 0 {
+		return result, nil
+	}
+
+	runtime, err := newRepairRuntime(homeDir, ledger, targets, ResolveInstallProfile(detection))
+	if err != nil {
+		return result, err
+	}
+	runtime.state.label.Note = flags.BackupLabel
+	runtime.state.label.Version = version
+	result.Plan = runtime.stagePlan()
+
+	if flags.DryRun {
+		return result, nil
+	}
+
+	startedAt := time.Now().UTC()
+	stream := openEventStream(homeDir, state.CommandRepair, "")
+	defer func() { closeEventStream(stream, result.Execution.Err) }()
+	orchestrator := pipeline.NewOrchestrator(
+		pipeline.DefaultRollbackPolicy(),
+		pipeline.WithProgressFunc(stream.Progress(runtime.state.changedFiles, nil)),
+	)
+	result.Execution = orchestrator.Execute(ctx, result.Plan)
+	result.Backup = runtime.state.manifest
+
+	if err := state.Append(homeDir, runtime.record(startedAt, result.Execution)); err != nil {
+		fmt.Fprintf(os.Stderr, "WARNING: could not update install state: %v\n", err)
+	}
+	if result.Execution.Err != nil {
+		return result, fmt.Errorf("execute repair pipeline: %w", result.Execution.Err)
+	}
+
+	return result, nil
+}
+
+// repairTargets collects the steps to re-run for agentIDs. Failed steps come
+// from the latest install or repair record; drift is detected the same way
+// `gentle-ai status` does it.
+func repairTargets(ctx context.Context, homeDir string, ledger state.Ledger, agentIDs []model.AgentID) ([]RepairTarget, error) {
+	targets := []RepairTarget{}
+	scheduled := map[model.AgentID]map[model.ComponentID]bool{}
+	wanted := map[model.AgentID]bool{}
+	for _, agent := range agentIDs {
+		wanted[agent] = true
+		scheduled[agent] = map[model.ComponentID]bool{}
+	}
+
+	if record, ok := ledger.Latest(state.CommandInstall, state.CommandRepair); ok && !record.Success {
+		for _, target := range failedTargets(record) {
+			target.Agents = filterAgents(target.Agents, wanted)
+			if target.Agent != "" && !wanted[target.Agent] {
+				continue
+			}
+			if target.Component != "" && len(target.Agents) == 0 {
+				continue
+			}
+			for _, agent := range target.Agents {
+				scheduled[agent][target.Component] = true
+			}
+			targets = append(targets, target)
+		}
+	}
+
+	sharedAgents := []model.AgentID{}
+	for _, agent := range agentIDs {
+		for _, component := range catalog.MVPComponents() {
+			if !ledger.Installed(agent, component.ID) || scheduled[agent][component.ID] {
+				continue
+			}
+			if component.ID == model.ComponentGGA {
+				sharedAgents = append(sharedAgents, agent)
+				continue
+			}
+
+			files, err := componentDrift(ctx, homeDir, component.ID, []model.AgentID{agent}, statusSelection(ledger, agent), noLock{})
+			if err != nil {
+				return nil, err
+			}
+			if driftError(files) != nil {
+				targets = append(targets, RepairTarget{
+					StepID:    "component:" + string(component.ID),
+					Component: component.ID,
+					Agents:    []model.AgentID{agent},
+					Reason:    RepairReasonDrifted,
+					Files:     files,
+				})
+			}
+		}
+	}
+
+	if len(sharedAgents) > 0 {
+		files, err := componentDrift(ctx, homeDir, model.ComponentGGA, sharedAgents, statusSelection(ledger, ""), noLock{})
+		if err != nil {
+			return nil, err
+		}
+		if driftError(files) != nil {
+			targets = append(targets, RepairTarget{
+				StepID:    "component:" + string(model.ComponentGGA),
+				Component: model.ComponentGGA,
+				Agents:    sharedAgents,
+				Reason:    RepairReasonDrifted,
+				Files:     files,
+			})
+		}
+	}
+
+	return targets, nil
+}
+
+// failedTargets lists the agent and component steps of a failed run that did
+// not take effect. When the run was rolled back every component step was
+// undone, so all of them are repeated; otherwise only the ones that failed or
+// never ran. A step skipped because it was already up to date took effect.
+func failedTargets(record state.Record) []RepairTarget {
+	done := map[string]bool{}
+	restored := false
+	for _, step := range record.Steps {
+		switch step.Stage {
+		case string(pipeline.StageApply):
+			done[step.ID] = step.Status == string(pipeline.StepStatusSucceeded) ||
+				(step.Status == string(pipeline.StepStatusSkipped) && step.Check == string(pipeline.CheckUpToDate))
+		case string(pipeline.StageRollback):
+			restored = restored || step.Status == string(pipeline.StepStatusRolledBack)
+		}
+	}
+
+	targets := []RepairTarget{}
+	for _, agent := range record.Agents {
+		id := "agent:" + string(agent)
+		if !done[id] {
+			targets = append(targets, RepairTarget{StepID: id, Agent: agent, Reason: RepairReasonFailed})
+		}
+	}
+
+	for _, component := range record.Components {
+		id := "component:" + string(component)
+		if len(record.AgentOutcomes) > 0 {
+			// A partial rollback leaves some agents configured; repair only
+			// the ones the component is missing for.
+			agents := []model.AgentID{}
+			for _, outcome := range record.AgentOutcomes {
+				if slices.Contains(outcome.Missing, component) {
+					agents = append(agents, outcome.Agent)
+				}
+			}
+			if len(agents) > 0 {
+				targets = append(targets, RepairTarget{StepID: id, Component: component, Agents: agents, Reason: RepairReasonFailed})
+			}
+			continue
+		}
+		if !restored && done[id] {
+			continue
+		}
+
+		agents := record.Agents
+		if record.Scope != nil {
+			agents = []model.AgentID{}
+			for _, agent := range record.Agents {
+				for _, scoped := range record.Scope[agent] {
+					if scoped == component {
+						agents = append(agents, agent)
+					}
+				}
+			}
+		}
+
+		targets = append(targets, RepairTarget{
+			StepID:    id,
+			Component: component,
+			Agents:    agents,
+			Reason:    RepairReasonFailed,
+		})
+	}
+
+	return targets
+}
+
+func filterAgents(agentIDs []model.AgentID, wanted map[model.AgentID]bool) []model.AgentID {
+	filtered := []model.AgentID{}
+	for _, agent := range agentIDs {
+		if wanted[agent] {
+			filtered = append(filtered, agent)
+		}
+	}
+	return filtered
+}
+
+type repairRuntime struct {
+	homeDir    string
+	profile    system.PlatformProfile
+	selection  model.Selection
+	agents     []model.AgentID
+	components []model.ComponentID
+	scope      map[model.AgentID][]model.ComponentID
+	backupRoot string
+	state      *runtimeState
+}
+
+// newRepairRuntime merges targets into one agent step per agent and one
+// component step per component, ordered like an install.
+func newRepairRuntime(homeDir string, ledger state.Ledger, targets []RepairTarget, profile system.PlatformProfile) (*repairRuntime, error) {
+	runtime := &repairRuntime{
+		homeDir:    homeDir,
+		profile:    profile,
+		scope:      map[model.AgentID][]model.ComponentID{},
+		backupRoot: filepath.Join(homeDir, ".gentle-ai", "backups"),
+		state:      &runtimeState{},
+	}
+
+	dependencies := map[model.ComponentID][]model.ComponentID{}
+	graph := planner.MVPGraph()
+	for _, target := range targets {
+		if target.Agent != "" {
+			runtime.agents = append(runtime.agents, target.Agent)
+			continue
+		}
+		for _, agent := range target.Agents {
+			runtime.scope[agent] = unique(append(runtime.scope[agent], target.Component))
+		}
+		dependencies[target.Component] = nil
+	}
+	for component := range dependencies {
+		for _, dependency := range graph.DependenciesOf(component) {
+			if _, ok := dependencies[dependency]; ok {
+				dependencies[component] = append(dependencies[component], dependency)
+			}
+		}
+	}
+
+	ordered, err := planner.TopologicalSort(dependencies)
+	if err != nil {
+		return nil, err
+	}
+	runtime.components = ordered
+	runtime.agents = unique(runtime.agents)
+	runtime.selection = repairSelection(ledger, runtime.scopeAgents())
+	runtime.state.label = backup.Label{
+		Command: state.CommandRepair,
+		Summary: selectionSummary(unique(append(append([]model.AgentID{}, runtime.agents...), runtime.scopeAgents()...)), runtime.components),
+	}
+
+	return runtime, nil
+}
+
+// repairSelection replays the failed run's selection when there is one, and
+// otherwise the newest install that covered any of agentIDs.
+func repairSelection(ledger state.Ledger, agentIDs []model.AgentID) model.Selection {
+	if record, ok := ledger.Latest(state.CommandInstall, state.CommandRepair); ok && !record.Success {
+		return record.Selection()
+	}
+
+	newest := state.Record{}
+	for _, agent := range agentIDs {
+		if record, ok := ledger.LatestInstallFor(agent); ok && record.StartedAt.After(newest.StartedAt) {
+			newest = record
+		}
+	}
+	if newest.ID == "" {
+		return statusSelection(ledger, "")
+	}
+
+	return newest.Selection()
+}
+
+func (r *repairRuntime) scopeAgents() []model.AgentID {
+	agentIDs := make([]model.AgentID, 0, len(r.scope))
+	for agent := range r.scope {
+		agentIDs = append(agentIDs, agent)
+	}
+	sort.Slice(agentIDs, func(i, j int) bool { return agentIDs[i] < agentIDs[j] })
+	return agentIDs
+}
+
+func (r *repairRuntime) componentAgents(component model.ComponentID) []model.AgentID {
+	agentIDs := []model.AgentID{}
+	for _, agent := range r.scopeAgents() {
+		if hasComponent(r.scope[agent], component) {
+			agentIDs = append(agentIDs, agent)
+		}
+	}
+	return agentIDs
+}
+
+// repairedAgents returns the agents of component that the repair also
+// reinstalls, whose steps the component step has to wait for.
+func (r *repairRuntime) repairedAgents(component model.ComponentID) []model.AgentID {
+	agentIDs := []model.AgentID{}
+	for _, agent := range r.componentAgents(component) {
+		if slices.Contains(r.agents, agent) {
+			agentIDs = append(agentIDs, agent)
+		}
+	}
+	return agentIDs
+}
+
+func (r *repairRuntime) targets() []backup.Target {
+	set := targetSet{}
+	for _, component := range r.components {
+		set.addComponent(r.homeDir, r.selection, resolveAdapters(r.componentAgents(component)), component)
+	}
+	return set.targets()
+}
+
+func (r *repairRuntime) stagePlan() pipeline.StagePlan {
+	prepare := []pipeline.Step{
+		checkDependenciesStep{id: "prepare:check-dependencies", profile: r.profile},
+		prepareBackupStep{
+			id:          "prepare:backup-snapshot",
+			snapshotter: backup.NewSnapshotter(r.backupRoot),
+			snapshotDir: filepath.Join(r.backupRoot, time.Now().UTC().Format("20060102150405.000000000")),
+			targets:     r.targets(),
+			state:       r.state,
+		},
+	}
+
+	apply := make([]pipeline.Step, 0, len(r.agents)+len(r.components)+1)
+	managed := []string{}
+	for _, component := range r.components {
+		managed = append(managed, managedFiles(r.homeDir, r.selection, resolveAdapters(r.componentAgents(component)), []model.ComponentID{component})...)
+	}
+	apply = append(apply, rollbackRestoreStep{id: "apply:rollback-restore", managed: managed, state: r.state})
+
+	for _, agent := range r.agents {
+		apply = append(apply, agentInstallStep{id: "agent:" + string(agent), agent: agent, homeDir: r.homeDir, profile: r.profile, retry: commandRetryPolicy})
+	}
+
+	for _, component := range r.components {
+		apply = append(apply, componentApplyStep{
+			id:        "component:" + string(component),
+			dependsOn: componentStepDependencies(component, r.components, r.repairedAgents(component)),
+			component: component,
+			homeDir:   r.homeDir,
+			agents:    r.componentAgents(component),
+			selection: r.selection,
+			profile:   r.profile,
+			state:     r.state,
+			retry:     commandRetryPolicy,
+		})
+	}
+
+	return pipeline.StagePlan{Prepare: prepare, Apply: apply}
+}
+
+// record builds the ledger entry for a repair. Its Scope lists exactly which
+// components were re-applied for which agent.
+func (r *repairRuntime) record(startedAt time.Time, execution pipeline.ExecutionResult) state.Record {
+	record := newStateRecord(state.CommandRepair, "cli", startedAt, r.state, execution)
+	record.Agents = unique(append(append([]model.AgentID{}, r.agents...), r.scopeAgents()...))
+	record.Components = r.components
+	record.Scope = r.scope
+	if hasComponent(r.components, model.ComponentSkills) {
+		record.Skills = selectedSkillIDs(r.selection)
+	}
+	record.Persona = r.selection.Persona
+	record.Preset = r.selection.Preset
+	record.SDDMode = r.selection.SDDMode
+	record.ModelAssignments = r.selection.ModelAssignments
+
+	return record
+}
+
+// RenderRepairReport summarizes a repair run (or dry-run) for the CLI.
+func RenderRepairReport(result RepairResult) string {
+	b := &strings.Builder{}
+
+	title := "gentle-ai repair"
+	if result.DryRun {
+		title += " (dry-run)"
+	}
+	_, _ = fmt.Fprintln(b, title)
+	_, _ = fmt.Fprintln(b, strings.Repeat("=", len(title)))
+
+	if len(result.Targets) == 0 {
+		_, _ = fmt.Fprintln(b, "Nothing to repair: the last install succeeded and no managed files have drifted.")
+		return strings.TrimRight(b.String(), "\n")
+	}
+
+	_, _ = fmt.Fprintf(b, "Steps to re-run: %d\n", len(result.Targets))
+	for _, target := range result.Targets {
+		if target.Agent != "" {
+			_, _ = fmt.Fprintf(b, "- %s (%s)\n", target.StepID, target.Reason)
+			continue
+		}
+		_, _ = fmt.Fprintf(b, "- %s for %s (%s)\n", target.StepID, joinAgentIDs(target.Agents), target.Reason)
+		for _, file := range target.Files {
+			if file.Status != FileOK {
+				_, _ = fmt.Fprintf(b, "    %s (%s)\n", file.Path, file.Status)
+			}
+		}
+	}
+
+	if result.DryRun {
+		return strings.TrimRight(b.String(), "\n")
+	}
+
+	if result.Backup.RootDir != "" {
+		_, _ = fmt.Fprintf(b, "Backup: %s\n", result.Backup.RootDir)
+	}
+	_, _ = fmt.Fprintln(b, "Repair complete.")
+
+	return strings.TrimRight(b.String(), "\n")
+}
@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gentleman-programming/gentle-ai/internal/model"
+	"github.com/gentleman-programming/gentle-ai/internal/state"
+	"github.com/gentleman-programming/gentle-ai/internal/system"
+)
+
+func TestRunRepairReappliesOnlyDriftedComponent(t *testing.T) {
+	home := t.TempDir()
+	stubInstallEnvironment(t, home)
+
+	if _, err := RunInstall([]string{"--agent", "claude-code", "--component", "persona,permissions"}, system.DetectionResult{}); err != nil {
+		t.Fatalf("RunInstall() error = %v", err)
+	}
+
+	claudeMD := filepath.Join(home, ".claude", "CLAUDE.md")
+	writeTestFile(t, claudeMD, "# My rules only\n")
+
+	result, err := RunRepair(nil, system.DetectionResult{})
+	if err != nil {
+		t.Fatalf("RunRepair() error = %v", err)
+	}
+
+	if len(result.Targets) != 1 || result.Targets[0].StepID != "component:persona" || result.Targets[0].Reason != RepairReasonDrifted {
+		t.Fatalf("targets = %#v, want only drifted component:persona", result.Targets)
+	}
+	if result.Backup.RootDir == "" {
+		t.Fatalf("expected repair to take a backup snapshot")
+	}
+
+	content, err := os.ReadFile(claudeMD)
+	if err != nil {
+		t.Fatalf("ReadFile(CLAUDE.md) error = %v", err)
+	}
+	if !strings.Contains(string(content), "# My rules only") || !strings.Contains(string(content), "gentle-ai:persona") {
+		t.Fatalf("CLAUDE.md after repair = %q", string(content))
+	}
+
+	ledger, err := state.Load(home)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	record, ok := ledger.Latest()
+	if !ok || record.Command != state.CommandRepair || !record.Success {
+		t.Fatalf("latest record = %#v, want successful repair", record)
+	}
+	if got := record.Scope[model.AgentClaudeCode]; len(got) != 1 || got[0] != model.ComponentPersona {
+		t.Fatalf("repair scope = %#v", record.Scope)
+	}
+
+	again, err := RunRepair(nil, system.DetectionResult{})
+	if err != nil {
+		t.Fatalf("second RunRepair() error = %v", err)
+	}
+	if len(again.Targets) != 0 {
+		t.Fatalf("second repair targets = %#v, want none", again.Targets)
+	}
+}
+
+func TestRunRepairSchedulesFailedStepsFromLedger(t *testing.T) {
+	home := t.TempDir()
+	stubInstallEnvironment(t, home)
+
+	if err := state.Append(home, state.Record{
+		ID:         "1",
+		Command:    state.CommandInstall,
+		Agents:     []model.AgentID{model.AgentClaudeCode},
+		Components: []model.ComponentID{model.ComponentPersona, model.ComponentPermission},
+		Persona:    model.PersonaGentleman,
+		Steps: []state.StepRecord{
+			{ID: "agent:claude-code", Stage: "apply", Status: "succeeded"},
+			{ID: "component:persona", Stage: "apply", Status: "succeeded"},
+			{ID: "component:permissions", Stage: "apply", Status: "failed", Error: "network"},
+		},
+	}); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+
+	result, err := RunRepair([]string{"--dry-run"}, system.DetectionResult{})
+	if err != nil {
+		t.Fatalf("RunRepair() error = %v", err)
+	}
+
+	if len(result.Targets) != 1 || result.Targets[0].StepID != "component:permissions" || result.Targets[0].Reason != RepairReasonFailed {
+		t.Fatalf("targets = %#v, want only failed component:permissions", result.Targets)
+	}
+
+	ids := []string{}
+	for _, step := range result.Plan.Apply {
+		ids = append(ids, step.ID())
+	}
+	if strings.Join(ids, ",") != "apply:rollback-restore,component:permissions" {
+		t.Fatalf("apply steps = %v", ids)
+	}
+	if _, err := os.Stat(filepath.Join(home, ".claude", "settings.json")); !os.IsNotExist(err) {
+		t.Fatalf("dry-run must not write settings.json, stat error = %v", err)
+	}
+}
+
+func TestRunRepairRequiresRecordedInstall(t *testing.T) {
+	home := t.TempDir()
+	stubInstallEnvironment(t, home)
+
+	if _, err := RunRepair(nil, system.DetectionResult{}); err == nil {
+		t.Fatalf("RunRepair() expected error without an install record")
+	}
+}
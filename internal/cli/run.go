@@ -1,12 +1,17 @@
 package cli
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gentleman-programming/gentle-ai/internal/agents"
@@ -19,9 +24,14 @@ import (
 	"github.com/gentleman-programming/gentle-ai/internal/components/sdd"
 	"github.com/gentleman-programming/gentle-ai/internal/components/skills"
 	"github.com/gentleman-programming/gentle-ai/internal/components/theme"
+	"github.com/gentleman-programming/gentle-ai/internal/config"
+	"github.com/gentleman-programming/gentle-ai/internal/diff"
+	"github.com/gentleman-programming/gentle-ai/internal/events"
+	"github.com/gentleman-programming/gentle-ai/internal/journal"
 	"github.com/gentleman-programming/gentle-ai/internal/model"
 	"github.com/gentleman-programming/gentle-ai/internal/pipeline"
 	"github.com/gentleman-programming/gentle-ai/internal/planner"
+	"github.com/gentleman-programming/gentle-ai/internal/state"
 	"github.com/gentleman-programming/gentle-ai/internal/system"
 	"github.com/gentleman-programming/gentle-ai/internal/verify"
 )
@@ -35,6 +45,26 @@ type InstallResult struct {
 	Verify       verify.Report
 	Dependencies system.DependencyReport
 	DryRun       bool
+	Diff         bool
+	// Changes lists the files a dry-run would create or modify. ChangesErr
+	// is set instead when the preview could not be computed.
+	Changes    []diff.FileChange
+	ChangesErr error
+	// PrunedBackups lists the old backups the retention policy removed
+	// after a successful install.
+	PrunedBackups []backup.Manifest
+	// RolledBack is set by --rollback, which undoes an interrupted install
+	// instead of installing. RestoredBackupID is the backup it restored,
+	// empty when the install stopped before changing any file.
+	RolledBack       bool
+	RestoredBackupID string
+	// Events is the --events format the run streamed on stdout, if any.
+	Events string
+	// Hooks are the user hooks from ~/.gentle-ai/config.json the install
+	// runs around its stages.
+	Hooks config.Hooks
+	// Agents says what the install left configured for each agent.
+	Agents []state.AgentOutcome
 }
 
 var (
@@ -43,6 +73,7 @@ var (
 	osStat              = os.Stat
 	runCommand          = executeCommand
 	cmdLookPath         = exec.LookPath
+	timeNow             = time.Now
 	streamCommandOutput = true
 )
 
@@ -56,15 +87,50 @@ func SetCommandOutputStreaming(enabled bool) func() {
 	}
 }
 
+// RunInstall is RunInstallContext with a context that is never cancelled and
+// no version on the backup label.
 func RunInstall(args []string, detection system.DetectionResult) (InstallResult, error) {
+	return RunInstallContext(context.Background(), args, detection, "")
+}
+
+// RunInstallContext parses install flags and runs the install. Cancelling ctx
+// interrupts the running steps and skips the rest; whatever already ran is
+// rolled back from the backup snapshot. Every step is journaled so an install
+// killed outright can be finished with --resume or undone with --rollback.
+// version is the gentle-ai version recorded on the backups the run takes.
+func RunInstallContext(ctx context.Context, args []string, detection system.DetectionResult, version string) (InstallResult, error) {
 	flags, err := ParseInstallFlags(args)
 	if err != nil {
 		return InstallResult{}, err
 	}
 
-	input, err := NormalizeInstallFlags(flags, detection)
-	if err != nil {
-		return InstallResult{}, err
+	if flags.Rollback {
+		homeDir, err := osUserHomeDir()
+		if err != nil {
+			return InstallResult{}, fmt.Errorf("resolve user home directory: %w", err)
+		}
+		restored, err := rollbackInterruptedInstall(ctx, homeDir, flags.Wait, version)
+		return InstallResult{RolledBack: true, RestoredBackupID: restored}, err
+	}
+
+	var input InstallInput
+	var interrupted *journal.Run
+	if flags.Resume {
+		homeDir, err := osUserHomeDir()
+		if err != nil {
+			return InstallResult{}, fmt.Errorf("resolve user home directory: %w", err)
+		}
+		pending, err := loadInterruptedInstall(homeDir)
+		if err != nil {
+			return InstallResult{}, err
+		}
+		interrupted = &pending
+		input.Selection = pending.Selection
+	} else {
+		input, err = NormalizeInstallFlags(flags, detection)
+		if err != nil {
+			return InstallResult{}, err
+		}
 	}
 
 	resolved, err := planner.NewResolver(planner.MVPGraph()).Resolve(input.Selection)
@@ -74,8 +140,17 @@ func RunInstall(args []string, detection system.DetectionResult) (InstallResult,
 	profile := ResolveInstallProfile(detection)
 	resolved.PlatformDecision = planner.PlatformDecisionFromProfile(profile)
 
+	homeDir, err := osUserHomeDir()
+	if err != nil {
+		return InstallResult{}, fmt.Errorf("resolve user home directory: %w", err)
+	}
+	cfg, err := config.Load(homeDir)
+	if err != nil {
+		return InstallResult{}, err
+	}
+
 	review := planner.BuildReviewPayload(input.Selection, resolved)
-	stagePlan := buildStagePlan(input.Selection, resolved)
+	stagePlan := buildStagePlan(input.Selection, resolved, cfg.Hooks)
 
 	result := InstallResult{
 		Selection:    input.Selection,
@@ -84,21 +159,42 @@ func RunInstall(args []string, detection system.DetectionResult) (InstallResult,
 		Plan:         stagePlan,
 		Dependencies: detection.Dependencies,
 		DryRun:       input.DryRun,
+		Diff:         input.Diff,
+		Hooks:        cfg.Hooks,
 	}
 
 	if input.DryRun {
+		result.Changes, result.ChangesErr = previewChanges(ctx, homeDir, input.Selection, resolved)
+		if input.Diff && result.ChangesErr != nil {
+			return result, result.ChangesErr
+		}
 		return result, nil
 	}
 
-	homeDir, err := osUserHomeDir()
+	runLock, err := acquireRunLock(ctx, homeDir, state.CommandInstall, flags.Wait)
 	if err != nil {
-		return result, fmt.Errorf("resolve user home directory: %w", err)
+		return result, err
 	}
+	defer releaseRunLock(runLock)
 
-	runtime, err := newInstallRuntime(homeDir, input.Selection, resolved, profile)
+	run, err := NewInstallRun(homeDir, input.Selection, resolved, profile, cfg, version)
 	if err != nil {
 		return result, err
 	}
+	run.runtime.state.label.Note = flags.BackupLabel
+	if interrupted != nil {
+		// Another run may have finished the journal while this one waited
+		// for the lock.
+		current, err := loadInterruptedInstall(homeDir)
+		if err != nil {
+			return result, err
+		}
+		if err := run.ResumeJournal(current); err != nil {
+			return result, err
+		}
+	} else if err := run.StartJournal(); err != nil {
+		return result, err
+	}
 
 	// Print dependency warnings before the pipeline starts (CLI only).
 	// The TUI surfaces these on the complete screen instead.
@@ -108,24 +204,80 @@ func RunInstall(args []string, detection system.DetectionResult) (InstallResult,
 			system.FormatMissingDepsMessage(detection.Dependencies))
 	}
 
-	stagePlan = runtime.stagePlan()
-	result.Plan = stagePlan
+	result.Plan = run.Plan
 
-	orchestrator := pipeline.NewOrchestrator(pipeline.DefaultRollbackPolicy())
-	result.Execution = orchestrator.Execute(stagePlan)
+	if flags.Events == events.FormatJSONLines {
+		// Command output would break the JSON lines on stdout.
+		defer SetCommandOutputStreaming(false)()
+	}
+	result.Events = flags.Events
+	stream := openEventStream(homeDir, state.CommandInstall, flags.Events)
+	// runErr is what the stream reports as the outcome of the run.
+	var runErr error
+	defer func() { closeEventStream(stream, runErr) }()
+
+	failurePolicy := pipeline.StopOnError
+	if flags.KeepGoing {
+		failurePolicy = pipeline.ContinueOnError
+	}
+	orchestrator := pipeline.NewOrchestrator(
+		run.RollbackPolicy(flags.KeepGoing),
+		pipeline.WithFailurePolicy(failurePolicy),
+		pipeline.WithMaxParallel(flags.Jobs),
+		pipeline.WithTimeout(flags.Timeout),
+		pipeline.WithStepTimeout(flags.StepTimeout),
+		pipeline.WithProgressFunc(run.JournalProgress(stream.Progress(run.runtime.state.changedFiles, nil))),
+	)
+	result.Execution = orchestrator.Execute(ctx, run.Plan)
+	result.Agents = run.AgentOutcomes(result.Execution)
+	if err := run.EndJournal(result.Execution); err != nil {
+		fmt.Fprintf(os.Stderr, "WARNING: could not update install journal: %v\n", err)
+	}
+	if err := run.Record("cli", result.Execution); err != nil {
+		fmt.Fprintf(os.Stderr, "WARNING: could not update install state: %v\n", err)
+	}
 	if result.Execution.Err != nil {
-		return result, fmt.Errorf("execute install pipeline: %w", result.Execution.Err)
+		runErr = fmt.Errorf("execute install pipeline: %w", result.Execution.Err)
+		return result, runErr
 	}
 
 	result.Verify = runPostApplyVerification(homeDir, input.Selection, resolved)
 	result.Verify = withPostInstallNotes(result.Verify, resolved)
 	if !result.Verify.Ready {
-		return result, fmt.Errorf("post-apply verification failed:\n%s", verify.RenderReport(result.Verify))
+		runErr = fmt.Errorf("post-apply verification failed:\n%s", verify.RenderReport(result.Verify))
+		return result, runErr
 	}
 
+	pruned, err := run.PruneBackups()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "WARNING: could not prune old backups: %v\n", err)
+	}
+	result.PrunedBackups = pruned
+
 	return result, nil
 }
 
+// RenderChangeSummary lists the agent and component steps of an install
+// that changed something apart from those that were already configured.
+func RenderChangeSummary(execution pipeline.ExecutionResult) string {
+	changed, upToDate := execution.Apply.Changes()
+	if len(changed)+len(upToDate) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Install steps: %d changed, %d already configured\n", len(changed), len(upToDate))
+	for _, step := range execution.Apply.Steps {
+		switch {
+		case step.UpToDate():
+			fmt.Fprintf(&b, "[==] %s - %s\n", step.StepID, step.Check.Reason)
+		case slices.Contains(changed, step.StepID):
+			fmt.Fprintf(&b, "[ok] %s - %s\n", step.StepID, step.Check.Reason)
+		}
+	}
+	return b.String()
+}
+
 func withPostInstallNotes(report verify.Report, resolved planner.ResolvedPlan) verify.Report {
 	if hasComponent(resolved.OrderedComponents, model.ComponentGGA) && report.Ready {
 		report.FinalNote = report.FinalNote + "\n\nGGA is now installed globally. To enable project hooks, run in each repo:\n- gga init\n- gga install"
@@ -182,7 +334,7 @@ func isInPATH(dir string) bool {
 	return false
 }
 
-func buildStagePlan(selection model.Selection, resolved planner.ResolvedPlan) pipeline.StagePlan {
+func buildStagePlan(selection model.Selection, resolved planner.ResolvedPlan, hooks config.Hooks) pipeline.StagePlan {
 	prepare := []pipeline.Step{
 		noopStep{id: "prepare:system-check"},
 		noopStep{id: "prepare:check-dependencies"},
@@ -201,6 +353,15 @@ func buildStagePlan(selection model.Selection, resolved planner.ResolvedPlan) pi
 		prepare = nil
 	}
 
+	pre := []pipeline.Step{}
+	for _, hook := range hooks.PrePrepare {
+		pre = append(pre, noopStep{id: hook.StepID(config.HookPrePrepare)})
+	}
+	prepare = append(pre, prepare...)
+	for _, hook := range hooks.PostApply {
+		apply = append(apply, noopStep{id: hook.StepID(config.HookPostApply)})
+	}
+
 	return pipeline.StagePlan{Prepare: prepare, Apply: apply}
 }
 
@@ -210,14 +371,60 @@ type installRuntime struct {
 	resolved   planner.ResolvedPlan
 	profile    system.PlatformProfile
 	backupRoot string
+	config     config.Config
 	state      *runtimeState
 }
 
 type runtimeState struct {
 	manifest backup.Manifest
+	files    []string
+	// label is recorded on the backup snapshot the run takes.
+	label backup.Label
+
+	// changes lists, by step ID, the files each step changed.
+	changes map[string][]string
+
+	// restoredAll is set once a rollback restored the whole backup. A
+	// partial rollback instead records the owners it was for and the
+	// entries it put back, which may include files they share with others.
+	restoredAll bool
+	rolledBack  []backup.Filter
+	restored    []backup.ManifestEntry
+
+	mu sync.Mutex
+	// configMu is held while a component writes agent configuration.
+	// Components without a dependency between them can run in parallel, but
+	// several of them merge into the same settings files.
+	configMu sync.Mutex
+}
+
+// addFiles records paths reported by injectors so the state ledger can list
+// every file a run wrote.
+func (s *runtimeState) addFiles(paths ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.files = append(s.files, paths...)
+}
+
+// recordChanges records the files step changed, for the event stream and a
+// partial rollback. Every attempt of a retried step adds to the list.
+func (s *runtimeState) recordChanges(step string, paths []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.changes == nil {
+		s.changes = map[string][]string{}
+	}
+	s.changes[step] = sortedUnique(append(s.changes[step], paths...))
+}
+
+// changedFiles returns the files step changed.
+func (s *runtimeState) changedFiles(step string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.changes[step]
 }
 
-func newInstallRuntime(homeDir string, selection model.Selection, resolved planner.ResolvedPlan, profile system.PlatformProfile) (*installRuntime, error) {
+func newInstallRuntime(homeDir string, selection model.Selection, resolved planner.ResolvedPlan, profile system.PlatformProfile, cfg config.Config) (*installRuntime, error) {
 	backupRoot := filepath.Join(homeDir, ".gentle-ai", "backups")
 	if err := os.MkdirAll(backupRoot, 0o755); err != nil {
 		return nil, fmt.Errorf("create backup root directory %q: %w", backupRoot, err)
@@ -229,41 +436,78 @@ func newInstallRuntime(homeDir string, selection model.Selection, resolved plann
 		resolved:   resolved,
 		profile:    profile,
 		backupRoot: backupRoot,
-		state:      &runtimeState{},
+		config:     cfg,
+		state: &runtimeState{label: backup.Label{
+			Command: state.CommandInstall,
+			Summary: selectionSummary(resolved.Agents, resolved.OrderedComponents),
+		}},
 	}, nil
 }
 
+// selectionSummary describes the agents and components a run works on for
+// backup labels, e.g. "claude-code, opencode: engram, sdd".
+func selectionSummary(agentIDs []model.AgentID, components []model.ComponentID) string {
+	agentNames := make([]string, 0, len(agentIDs))
+	for _, agent := range agentIDs {
+		agentNames = append(agentNames, string(agent))
+	}
+	componentNames := make([]string, 0, len(components))
+	for _, component := range components {
+		componentNames = append(componentNames, string(component))
+	}
+
+	switch {
+	case len(componentNames) == 0:
+		return strings.Join(agentNames, ", ")
+	case len(agentNames) == 0:
+		return strings.Join(componentNames, ", ")
+	default:
+		return strings.Join(agentNames, ", ") + ": " + strings.Join(componentNames, ", ")
+	}
+}
+
 func (r *installRuntime) stagePlan() pipeline.StagePlan {
 	targets := backupTargets(r.homeDir, r.selection, r.resolved)
-	prepare := []pipeline.Step{
+	prepare := hookSteps(r.config.Hooks, config.HookPrePrepare, r.profile, nil)
+	prepare = append(prepare,
 		checkDependenciesStep{id: "prepare:check-dependencies", profile: r.profile},
 		prepareBackupStep{
 			id:          "prepare:backup-snapshot",
-			snapshotter: backup.NewSnapshotter(),
+			snapshotter: backup.NewSnapshotter(r.backupRoot),
 			snapshotDir: filepath.Join(r.backupRoot, time.Now().UTC().Format("20060102150405.000000000")),
 			targets:     targets,
 			state:       r.state,
 		},
-	}
+	)
 
 	apply := make([]pipeline.Step, 0, len(r.resolved.Agents)+len(r.resolved.OrderedComponents)+1)
-	apply = append(apply, rollbackRestoreStep{id: "apply:rollback-restore", state: r.state})
+	apply = append(apply, rollbackRestoreStep{
+		id:      "apply:rollback-restore",
+		managed: managedFiles(r.homeDir, r.selection, resolveAdapters(r.resolved.Agents), r.resolved.OrderedComponents),
+		state:   r.state,
+	})
 
 	for _, agent := range r.resolved.Agents {
-		apply = append(apply, agentInstallStep{id: "agent:" + string(agent), agent: agent, homeDir: r.homeDir, profile: r.profile})
+		apply = append(apply, agentInstallStep{id: "agent:" + string(agent), agent: agent, homeDir: r.homeDir, profile: r.profile, retry: commandRetryPolicy})
 	}
 
 	for _, component := range r.resolved.OrderedComponents {
 		apply = append(apply, componentApplyStep{
 			id:        "component:" + string(component),
+			dependsOn: componentStepDependencies(component, r.resolved.OrderedComponents, r.resolved.Agents),
 			component: component,
 			homeDir:   r.homeDir,
 			agents:    r.resolved.Agents,
 			selection: r.selection,
 			profile:   r.profile,
+			state:     r.state,
+			retry:     commandRetryPolicy,
 		})
 	}
 
+	// Post-apply hooks run only once every apply step has succeeded.
+	apply = append(apply, hookSteps(r.config.Hooks, config.HookPostApply, r.profile, stepIDs(apply))...)
+
 	return pipeline.StagePlan{Prepare: prepare, Apply: apply}
 }
 
@@ -271,7 +515,7 @@ type prepareBackupStep struct {
 	id          string
 	snapshotter backup.Snapshotter
 	snapshotDir string
-	targets     []string
+	targets     []backup.Target
 	state       *runtimeState
 }
 
@@ -279,8 +523,8 @@ func (s prepareBackupStep) ID() string {
 	return s.id
 }
 
-func (s prepareBackupStep) Run() error {
-	manifest, err := s.snapshotter.Create(s.snapshotDir, s.targets)
+func (s prepareBackupStep) Run(context.Context) error {
+	manifest, err := s.snapshotter.WithLabel(s.state.label).CreateTargets(s.snapshotDir, s.targets)
 	if err != nil {
 		return fmt.Errorf("create backup snapshot: %w", err)
 	}
@@ -289,25 +533,72 @@ func (s prepareBackupStep) Run() error {
 	return nil
 }
 
+// rollbackRestoreStep puts the run's backup back when the run fails.
+// managed lists the files the run's components write; with the files the
+// run actually wrote, they are what the restore may remove from the
+// directories it puts back.
 type rollbackRestoreStep struct {
-	id    string
-	state *runtimeState
+	id      string
+	managed []string
+	state   *runtimeState
 }
 
 func (s rollbackRestoreStep) ID() string {
 	return s.id
 }
 
-func (s rollbackRestoreStep) Run() error {
+func (s rollbackRestoreStep) Run(context.Context) error {
 	return nil
 }
 
 func (s rollbackRestoreStep) Rollback() error {
+	s.state.mu.Lock()
+	s.state.restoredAll = true
+	service := backup.RestoreService{Managed: append(slices.Clone(s.managed), s.state.files...)}
+	s.state.mu.Unlock()
+
 	if len(s.state.manifest.Entries) == 0 {
 		return nil
 	}
 
-	return backup.RestoreService{}.Restore(s.state.manifest)
+	return service.Restore(s.state.manifest)
+}
+
+// OwnsFailed reports whether the failed steps changed files the backup holds
+// for their agents and components.
+func (s rollbackRestoreStep) OwnsFailed(results []pipeline.StepResult) bool {
+	_, selected := s.failedEntries(results)
+	return len(selected.Entries) > 0
+}
+
+// RollbackFailed restores only the backup entries owned by the agents and
+// components whose steps failed, and only the files those steps changed, so
+// what succeeded for the others is kept.
+func (s rollbackRestoreStep) RollbackFailed(results []pipeline.StepResult) error {
+	owners, selected := s.failedEntries(results)
+
+	s.state.mu.Lock()
+	s.state.rolledBack = owners
+	s.state.restored = selected.Entries
+	service := backup.RestoreService{Managed: append(slices.Clone(s.managed), s.state.files...)}
+	s.state.mu.Unlock()
+
+	return service.Restore(selected)
+}
+
+// failedEntries returns the owners of the failed steps among results and the
+// backup narrowed to their entries for the files those steps changed. A step
+// that failed before changing anything has nothing to restore.
+func (s rollbackRestoreStep) failedEntries(results []pipeline.StepResult) ([]backup.Filter, backup.Manifest) {
+	changed := []string{}
+	for _, result := range results {
+		if result.Status == pipeline.StepStatusFailed {
+			changed = append(changed, s.state.changedFiles(result.StepID)...)
+		}
+	}
+
+	owners := failedOwners(results)
+	return owners, selectChanged(selectOwned(s.state.manifest, owners), changed)
 }
 
 type agentInstallStep struct {
@@ -315,13 +606,46 @@ type agentInstallStep struct {
 	agent   model.AgentID
 	homeDir string
 	profile system.PlatformProfile
+	retry   pipeline.RetryPolicy
 }
 
 func (s agentInstallStep) ID() string {
 	return s.id
 }
 
-func (s agentInstallStep) Run() error {
+// DependsOn lets agents install alongside each other; only the steps before
+// the first agent have to finish first. Component steps wait for the agents
+// they configure.
+func (s agentInstallStep) DependsOn() []string {
+	return nil
+}
+
+func (s agentInstallStep) RetryPolicy() pipeline.RetryPolicy {
+	return s.retry
+}
+
+// Check reports an agent that is already installed, or that gentle-ai does
+// not install itself, as up to date.
+func (s agentInstallStep) Check(ctx context.Context) (pipeline.CheckResult, error) {
+	adapter, err := agents.NewAdapter(s.agent)
+	if err != nil {
+		return pipeline.CheckResult{}, fmt.Errorf("create adapter for %q: %w", s.agent, err)
+	}
+	if !adapter.SupportsAutoInstall() {
+		return pipeline.CheckResult{Outcome: pipeline.CheckUpToDate, Reason: "installed separately"}, nil
+	}
+
+	installed, _, _, _, err := adapter.Detect(ctx, s.homeDir)
+	if err != nil {
+		return pipeline.CheckResult{}, fmt.Errorf("detect agent %q: %w", s.agent, err)
+	}
+	if installed {
+		return pipeline.CheckResult{Outcome: pipeline.CheckUpToDate, Reason: "already installed"}, nil
+	}
+	return pipeline.CheckResult{Outcome: pipeline.CheckWillCreate, Reason: "not installed"}, nil
+}
+
+func (s agentInstallStep) Run(ctx context.Context) error {
 	adapter, err := agents.NewAdapter(s.agent)
 	if err != nil {
 		return fmt.Errorf("create adapter for %q: %w", s.agent, err)
@@ -331,7 +655,7 @@ func (s agentInstallStep) Run() error {
 		return nil
 	}
 
-	installed, _, _, _, err := adapter.Detect(context.Background(), s.homeDir)
+	installed, _, _, _, err := adapter.Detect(ctx, s.homeDir)
 	if err != nil {
 		return fmt.Errorf("detect agent %q: %w", s.agent, err)
 	}
@@ -344,22 +668,50 @@ func (s agentInstallStep) Run() error {
 		return fmt.Errorf("resolve install command for %q: %w", s.agent, err)
 	}
 
-	return runCommandSequence(commands)
+	return runCommandSequence(ctx, commands)
 }
 
 type componentApplyStep struct {
 	id        string
+	dependsOn []string
 	component model.ComponentID
 	homeDir   string
 	agents    []model.AgentID
 	selection model.Selection
 	profile   system.PlatformProfile
+	state     *runtimeState
+	retry     pipeline.RetryPolicy
 }
 
 func (s componentApplyStep) ID() string {
 	return s.id
 }
 
+func (s componentApplyStep) DependsOn() []string {
+	return s.dependsOn
+}
+
+func (s componentApplyStep) RetryPolicy() pipeline.RetryPolicy {
+	return s.retry
+}
+
+// componentStepDependencies returns the step IDs a component step waits for:
+// the install steps of agentIDs, whose configuration it writes, and the
+// steps of the components in plan that component depends on in the component
+// graph.
+func componentStepDependencies(component model.ComponentID, plan []model.ComponentID, agentIDs []model.AgentID) []string {
+	deps := []string{}
+	for _, agent := range agentIDs {
+		deps = append(deps, "agent:"+string(agent))
+	}
+	for _, dep := range planner.MVPGraph().DependenciesOf(component) {
+		if hasComponent(plan, dep) {
+			deps = append(deps, "component:"+string(dep))
+		}
+	}
+	return deps
+}
+
 // resolveAdapters creates adapters for each agent ID, skipping unsupported ones.
 func resolveAdapters(agentIDs []model.AgentID) []agents.Adapter {
 	adapters := make([]agents.Adapter, 0, len(agentIDs))
@@ -373,9 +725,60 @@ func resolveAdapters(agentIDs []model.AgentID) []agents.Adapter {
 	return adapters
 }
 
-func (s componentApplyStep) Run() error {
-	adapters := resolveAdapters(s.agents)
+// Check replays the component's injectors against a scratch copy of its
+// files, as status does, and looks for the binary the component installs.
+// The component is up to date when the binary is there and no file would
+// change.
+func (s componentApplyStep) Check(ctx context.Context) (pipeline.CheckResult, error) {
+	switch s.component {
+	case model.ComponentEngram:
+		if _, err := cmdLookPath("engram"); err != nil {
+			return pipeline.CheckResult{Outcome: pipeline.CheckWillCreate, Reason: "engram is not installed"}, nil
+		}
+	case model.ComponentGGA:
+		if !ggaAvailable(s.profile) {
+			return pipeline.CheckResult{Outcome: pipeline.CheckWillCreate, Reason: "gga is not installed"}, nil
+		}
+		if _, err := osStat(gga.RuntimePRModePath(s.homeDir)); err != nil {
+			return pipeline.CheckResult{Outcome: pipeline.CheckWillCreate, Reason: "gga runtime files are missing"}, nil
+		}
+	case model.ComponentSDD:
+		// Rendering in scratch assumes the OpenCode plugin dependency is
+		// there; the real install fetches it when it is not.
+		if slices.Contains(s.agents, model.AgentOpenCode) {
+			if _, err := osStat(sdd.OpenCodePluginDependencyPath(s.homeDir)); err != nil {
+				return pipeline.CheckResult{Outcome: pipeline.CheckWillCreate, Reason: "OpenCode plugin dependency is missing"}, nil
+			}
+		}
+	}
+
+	// Other components merge into the same files; reading them while one of
+	// them writes could see half its changes.
+	files, err := componentDrift(ctx, s.homeDir, s.component, s.agents, s.selection, &s.state.configMu)
+	if err != nil {
+		return pipeline.CheckResult{}, err
+	}
+
+	missing, drifted := 0, 0
+	for _, file := range files {
+		switch file.Status {
+		case FileMissing:
+			missing++
+		case FileDrifted:
+			drifted++
+		}
+	}
+	switch {
+	case missing > 0:
+		return pipeline.CheckResult{Outcome: pipeline.CheckWillCreate, Reason: fmt.Sprintf("%d file(s) to create", missing)}, nil
+	case drifted > 0:
+		return pipeline.CheckResult{Outcome: pipeline.CheckWillChange, Reason: fmt.Sprintf("%d file(s) to update", drifted)}, nil
+	default:
+		return pipeline.CheckResult{Outcome: pipeline.CheckUpToDate, Reason: "already configured"}, nil
+	}
+}
 
+func (s componentApplyStep) Run(ctx context.Context) error {
 	switch s.component {
 	case model.ComponentEngram:
 		if _, err := cmdLookPath("engram"); err != nil {
@@ -387,7 +790,7 @@ func (s componentApplyStep) Run() error {
 					if goCommands == nil {
 						return fmt.Errorf("go is required to install engram but cannot be auto-installed on this platform")
 					}
-					if err := runCommandSequence(goCommands); err != nil {
+					if err := runCommandSequence(ctx, goCommands); err != nil {
 						return fmt.Errorf("install go (required for engram): %w", err)
 					}
 					if s.profile.OS == "windows" {
@@ -401,93 +804,203 @@ func (s componentApplyStep) Run() error {
 			if err != nil {
 				return fmt.Errorf("resolve install command for component %q: %w", s.component, err)
 			}
-			if err := runCommandSequence(commands); err != nil {
+			if err := runCommandSequence(ctx, commands); err != nil {
 				return err
 			}
 		}
-		setupMode := engram.ParseSetupMode(os.Getenv(engram.SetupModeEnvVar))
-		setupStrict := engram.ParseSetupStrict(os.Getenv(engram.SetupStrictEnvVar))
-		for _, adapter := range adapters {
-			if engram.ShouldAttemptSetup(setupMode, adapter.Agent()) {
-				slug, _ := engram.SetupAgentSlug(adapter.Agent())
-				if err := runCommand("engram", "setup", slug); err != nil {
-					if setupStrict {
-						return fmt.Errorf("engram setup for %q: %w", adapter.Agent(), err)
-					}
+		if err := s.setupEngram(ctx); err != nil {
+			return err
+		}
+	case model.ComponentGGA:
+		if !ggaAvailable(s.profile) {
+			// GGA not found on any known PATH — install it.
+			commands, err := gga.InstallCommand(s.profile)
+			if err != nil {
+				return fmt.Errorf("resolve install command for component %q: %w", s.component, err)
+			}
+			if err := runCommandSequence(ctx, commands); err != nil {
+				return err
+			}
+		}
+		if err := gga.EnsureRuntimeAssets(s.homeDir); err != nil {
+			return fmt.Errorf("ensure gga runtime assets: %w", err)
+		}
+	case model.ComponentContext7, model.ComponentPersona, model.ComponentPermission,
+		model.ComponentSDD, model.ComponentSkills, model.ComponentTheme:
+		// Configuration-only components: nothing to install first.
+	default:
+		return fmt.Errorf("component %q is not supported in install runtime", s.component)
+	}
+
+	agentIDs := s.agents
+	var dependencyErr error
+	if s.component == model.ComponentSDD && slices.Contains(agentIDs, model.AgentOpenCode) {
+		// The plugin dependency is fetched before taking the config lock, so
+		// other components keep writing while bun or npm runs.
+		if err := sdd.InstallOpenCodePluginDependency(ctx, s.homeDir); err != nil {
+			dependencyErr = &agentError{agent: model.AgentOpenCode, err: fmt.Errorf("inject sdd for %q: %w", model.AgentOpenCode, err)}
+			agentIDs = slices.DeleteFunc(slices.Clone(agentIDs), func(agent model.AgentID) bool { return agent == model.AgentOpenCode })
+		}
+	}
+
+	s.state.configMu.Lock()
+	defer s.state.configMu.Unlock()
+	paths := componentPaths(s.homeDir, s.selection, resolveAdapters(agentIDs), s.component)
+	before := readContents(paths)
+	files, err := injectComponentFiles(ctx, s.homeDir, s.component, agentIDs, s.selection)
+	s.state.addFiles(files...)
+	written := files
+	if err != nil {
+		// A failed injector reports nothing of what it wrote before failing.
+		written = append(slices.Clone(files), paths...)
+	}
+	s.state.recordChanges(s.id, changedPaths(before, written))
+	return errors.Join(dependencyErr, err)
+}
+
+// readContents reads every path that exists.
+func readContents(paths []string) map[string][]byte {
+	contents := make(map[string][]byte, len(paths))
+	for _, path := range paths {
+		if content, err := os.ReadFile(path); err == nil {
+			contents[path] = content
+		}
+	}
+	return contents
+}
+
+// changedPaths returns the written files whose content differs from before.
+// A file missing from before was created; directories and paths that still
+// do not exist are left out.
+func changedPaths(before map[string][]byte, written []string) []string {
+	changed := []string{}
+	for _, path := range sortedUnique(written) {
+		info, err := os.Lstat(path)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		previous, existed := before[path]
+		if content, err := os.ReadFile(path); err == nil && existed && bytes.Equal(previous, content) {
+			continue
+		}
+		changed = append(changed, path)
+	}
+	return changed
+}
+
+// setupEngram runs `engram setup` for the agents the setup mode selects.
+// Setup writes agent configuration, so it holds the same lock as injection.
+func (s componentApplyStep) setupEngram(ctx context.Context) error {
+	s.state.configMu.Lock()
+	defer s.state.configMu.Unlock()
+
+	setupMode := engram.ParseSetupMode(os.Getenv(engram.SetupModeEnvVar))
+	setupStrict := engram.ParseSetupStrict(os.Getenv(engram.SetupStrictEnvVar))
+	for _, adapter := range resolveAdapters(s.agents) {
+		if engram.ShouldAttemptSetup(setupMode, adapter.Agent()) {
+			slug, _ := engram.SetupAgentSlug(adapter.Agent())
+			if err := runCommand(ctx, "engram", "setup", slug); err != nil {
+				if setupStrict {
+					return fmt.Errorf("engram setup for %q: %w", adapter.Agent(), err)
 				}
 			}
-			if _, err := engram.Inject(s.homeDir, adapter); err != nil {
-				return fmt.Errorf("inject engram for %q: %w", adapter.Agent(), err)
+		}
+	}
+
+	return nil
+}
+
+// injectComponentFiles writes the configuration files of component into
+// homeDir for every agent and returns the paths the injectors reported. It
+// never installs binaries or runs setup commands, so it can be replayed
+// against a scratch copy of the files to detect drift. An agent whose
+// injector fails does not stop the others: its *agentError is joined into
+// the error, with the files of the agents that succeeded. Cancelling ctx
+// stops the package install the SDD injector may run.
+func injectComponentFiles(ctx context.Context, homeDir string, component model.ComponentID, agentIDs []model.AgentID, selection model.Selection) ([]string, error) {
+	adapters := resolveAdapters(agentIDs)
+	files := []string{}
+	var errs []error
+
+	switch component {
+	case model.ComponentEngram:
+		for _, adapter := range adapters {
+			result, err := engram.Inject(homeDir, adapter)
+			if err != nil {
+				errs = append(errs, &agentError{agent: adapter.Agent(), err: fmt.Errorf("inject engram for %q: %w", adapter.Agent(), err)})
+				continue
 			}
+			files = append(files, result.Files...)
 		}
-		return nil
 	case model.ComponentContext7:
 		for _, adapter := range adapters {
-			if _, err := mcp.Inject(s.homeDir, adapter); err != nil {
-				return fmt.Errorf("inject context7 for %q: %w", adapter.Agent(), err)
+			result, err := mcp.Inject(homeDir, adapter)
+			if err != nil {
+				errs = append(errs, &agentError{agent: adapter.Agent(), err: fmt.Errorf("inject context7 for %q: %w", adapter.Agent(), err)})
+				continue
 			}
+			files = append(files, result.Files...)
 		}
-		return nil
 	case model.ComponentPersona:
 		for _, adapter := range adapters {
-			if _, err := persona.Inject(s.homeDir, adapter, s.selection.Persona); err != nil {
-				return fmt.Errorf("inject persona for %q: %w", adapter.Agent(), err)
+			result, err := persona.Inject(homeDir, adapter, selection.Persona)
+			if err != nil {
+				errs = append(errs, &agentError{agent: adapter.Agent(), err: fmt.Errorf("inject persona for %q: %w", adapter.Agent(), err)})
+				continue
 			}
+			files = append(files, result.Files...)
 		}
-		return nil
 	case model.ComponentPermission:
 		for _, adapter := range adapters {
-			if _, err := permissions.Inject(s.homeDir, adapter); err != nil {
-				return fmt.Errorf("inject permissions for %q: %w", adapter.Agent(), err)
+			result, err := permissions.Inject(homeDir, adapter)
+			if err != nil {
+				errs = append(errs, &agentError{agent: adapter.Agent(), err: fmt.Errorf("inject permissions for %q: %w", adapter.Agent(), err)})
+				continue
 			}
+			files = append(files, result.Files...)
 		}
-		return nil
 	case model.ComponentSDD:
 		for _, adapter := range adapters {
-			if _, err := sdd.Inject(s.homeDir, adapter, s.selection.SDDMode, s.selection.ModelAssignments); err != nil {
-				return fmt.Errorf("inject sdd for %q: %w", adapter.Agent(), err)
+			result, err := sdd.Inject(ctx, homeDir, adapter, selection.SDDMode, selection.ModelAssignments)
+			if err != nil {
+				errs = append(errs, &agentError{agent: adapter.Agent(), err: fmt.Errorf("inject sdd for %q: %w", adapter.Agent(), err)})
+				continue
 			}
+			files = append(files, result.Files...)
 		}
-		return nil
 	case model.ComponentSkills:
-		skillIDs := selectedSkillIDs(s.selection)
+		skillIDs := selectedSkillIDs(selection)
 		if len(skillIDs) == 0 {
-			return nil
+			return nil, nil
 		}
 		for _, adapter := range adapters {
-			if _, err := skills.Inject(s.homeDir, adapter, skillIDs); err != nil {
-				return fmt.Errorf("inject skills for %q: %w", adapter.Agent(), err)
-			}
-		}
-		return nil
-	case model.ComponentGGA:
-		if !ggaAvailable(s.profile) {
-			// GGA not found on any known PATH — install it.
-			commands, err := gga.InstallCommand(s.profile)
+			result, err := skills.Inject(homeDir, adapter, skillIDs)
 			if err != nil {
-				return fmt.Errorf("resolve install command for component %q: %w", s.component, err)
+				errs = append(errs, &agentError{agent: adapter.Agent(), err: fmt.Errorf("inject skills for %q: %w", adapter.Agent(), err)})
+				continue
 			}
-			if err := runCommandSequence(commands); err != nil {
-				return err
-			}
-		}
-		if err := gga.EnsureRuntimeAssets(s.homeDir); err != nil {
-			return fmt.Errorf("ensure gga runtime assets: %w", err)
+			files = append(files, result.Files...)
 		}
-		if _, err := gga.Inject(s.homeDir, s.agents); err != nil {
-			return fmt.Errorf("inject gga config: %w", err)
+	case model.ComponentGGA:
+		result, err := gga.Inject(homeDir, agentIDs)
+		if err != nil {
+			return nil, fmt.Errorf("inject gga config: %w", err)
 		}
-		return nil
+		files = append(files, result.FilesWritten()...)
 	case model.ComponentTheme:
 		for _, adapter := range adapters {
-			if _, err := theme.Inject(s.homeDir, adapter); err != nil {
-				return fmt.Errorf("inject theme for %q: %w", adapter.Agent(), err)
+			result, err := theme.Inject(homeDir, adapter)
+			if err != nil {
+				errs = append(errs, &agentError{agent: adapter.Agent(), err: fmt.Errorf("inject theme for %q: %w", adapter.Agent(), err)})
+				continue
 			}
+			files = append(files, result.Files...)
 		}
-		return nil
 	default:
-		return fmt.Errorf("component %q is not supported in install runtime", s.component)
+		return nil, fmt.Errorf("component %q is not supported in install runtime", component)
 	}
+
+	return files, errors.Join(errs...)
 }
 
 func ensureGoAvailableAfterInstall(profile system.PlatformProfile) error {
@@ -529,18 +1042,18 @@ func windowsGoCandidates() []string {
 
 // BuildRealStagePlan creates a StagePlan with real backup, agent install, and component apply steps.
 // It is used by both the CLI and TUI paths.
-func BuildRealStagePlan(homeDir string, selection model.Selection, resolved planner.ResolvedPlan, profile system.PlatformProfile) (pipeline.StagePlan, error) {
+func BuildRealStagePlan(homeDir string, selection model.Selection, resolved planner.ResolvedPlan, profile system.PlatformProfile, cfg config.Config) (pipeline.StagePlan, error) {
 	backupRoot := filepath.Join(homeDir, ".gentle-ai", "backups")
 	if err := os.MkdirAll(backupRoot, 0o755); err != nil {
 		return pipeline.StagePlan{}, fmt.Errorf("create backup root directory %q: %w", backupRoot, err)
 	}
 
-	runtime, err := newInstallRuntime(homeDir, selection, resolved, profile)
+	run, err := NewInstallRun(homeDir, selection, resolved, profile, cfg, "")
 	if err != nil {
 		return pipeline.StagePlan{}, err
 	}
 
-	return runtime.stagePlan(), nil
+	return run.Plan, nil
 }
 
 // ResolveInstallProfile returns the platform profile from detection, defaulting to darwin/brew.
@@ -573,27 +1086,145 @@ func ggaAvailable(_ system.PlatformProfile) bool {
 	return err == nil
 }
 
+// commandError is an external command that could not run or exited with an
+// error.
+type commandError struct {
+	command []string
+	err     error
+}
+
+func (e *commandError) Error() string {
+	return fmt.Sprintf("run command %q: %v", strings.Join(e.command, " "), e.err)
+}
+
+func (e *commandError) Unwrap() error {
+	return e.err
+}
+
+// commandRetryPolicy retries install steps whose external commands fail:
+// npm, brew, go install and download scripts fail intermittently on flaky
+// networks. Any other error fails the step at once.
+var commandRetryPolicy = pipeline.RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: 2 * time.Second,
+	MaxBackoff:     30 * time.Second,
+	Retryable:      isRetryableCommandFailure,
+}
+
+// isRetryableCommandFailure reports whether err is a failed command that may
+// succeed when run again. A missing program or a permission error will not.
+func isRetryableCommandFailure(err error) bool {
+	var failed *commandError
+	if !errors.As(err, &failed) {
+		return false
+	}
+	return !errors.Is(err, exec.ErrNotFound) && !errors.Is(err, os.ErrPermission)
+}
+
+// commandLocks holds one semaphore, a channel with room for one token, per
+// package manager. Package managers such as brew and apt refuse to run twice
+// at once, so steps running in parallel take turns with any package manager
+// they share.
+var commandLocks sync.Map
+
+// lockedPackageManagers maps the programs that must not run concurrently to
+// the package manager whose lock they take.
+var lockedPackageManagers = map[string]string{
+	"brew":    "brew",
+	"apt":     "apt",
+	"apt-get": "apt",
+	"dpkg":    "apt",
+	"pacman":  "pacman",
+	"dnf":     "dnf",
+	"winget":  "winget",
+	"npm":     "npm",
+}
+
+// packageManagers returns the package managers commands run, sorted so that
+// every sequence takes their locks in the same order. Wrappers such as sudo
+// and env are looked through: `sudo apt-get install` runs apt.
+func packageManagers(commands [][]string) []string {
+	managers := []string{}
+	for _, command := range commands {
+		program := commandProgram(command)
+		if manager, ok := lockedPackageManagers[program]; ok && !slices.Contains(managers, manager) {
+			managers = append(managers, manager)
+		}
+	}
+	slices.Sort(managers)
+	return managers
+}
+
+// commandProgram returns the program command runs once sudo and env, with
+// their flags and variable assignments, are skipped.
+func commandProgram(command []string) string {
+	for i := 0; i < len(command); i++ {
+		name := filepath.Base(command[i])
+		switch {
+		case name == "sudo" || name == "env":
+			continue
+		case i > 0 && (strings.HasPrefix(command[i], "-") || strings.Contains(command[i], "=")):
+			continue
+		}
+		return strings.TrimSuffix(name, ".exe")
+	}
+	return ""
+}
+
+// lockPackageManagers takes the lock of every package manager commands run.
+// Waiting stops when ctx is cancelled. The returned func releases the locks.
+func lockPackageManagers(ctx context.Context, commands [][]string) (func(), error) {
+	held := []chan struct{}{}
+	release := func() {
+		for i := len(held) - 1; i >= 0; i-- {
+			<-held[i]
+		}
+	}
+
+	for _, manager := range packageManagers(commands) {
+		sem, _ := commandLocks.LoadOrStore(manager, make(chan struct{}, 1))
+		select {
+		case sem.(chan struct{}) <- struct{}{}:
+			held = append(held, sem.(chan struct{}))
+		case <-ctx.Done():
+			release()
+			return nil, ctx.Err()
+		}
+	}
+	return release, nil
+}
+
 // runCommandSequence runs each command in the sequence one at a time, stopping on first error.
-func runCommandSequence(commands [][]string) error {
+func runCommandSequence(ctx context.Context, commands [][]string) error {
 	if len(commands) == 0 {
 		return fmt.Errorf("empty command sequence")
 	}
+	unlock, err := lockPackageManagers(ctx, commands)
+	if err != nil {
+		return err
+	}
+	defer unlock()
 
 	for _, command := range commands {
 		if len(command) == 0 {
 			return fmt.Errorf("empty command in sequence")
 		}
 
-		if err := runCommand(command[0], command[1:]...); err != nil {
-			return fmt.Errorf("run command %q: %w", strings.Join(command, " "), err)
+		if err := runCommand(ctx, command[0], command[1:]...); err != nil {
+			return &commandError{command: command, err: err}
 		}
 	}
 
 	return nil
 }
 
-func executeCommand(name string, args ...string) error {
-	cmd := exec.Command(name, args...)
+// commandWaitDelay is how long a cancelled command's output may stay open
+// after it is killed, for child processes that inherited it.
+const commandWaitDelay = 5 * time.Second
+
+func executeCommand(ctx context.Context, name string, args ...string) error {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.WaitDelay = commandWaitDelay
 
 	if streamCommandOutput {
 		cmd.Stdout = os.Stdout
@@ -622,24 +1253,113 @@ func selectedSkillIDs(selection model.Selection) []model.SkillID {
 	return skills.SkillsForPreset(selection.Preset)
 }
 
-func backupTargets(homeDir string, selection model.Selection, resolved planner.ResolvedPlan) []string {
-	paths := map[string]struct{}{}
+// backupTargets returns every file the selected components may write, tagged
+// with the agents and components that manage it.
+func backupTargets(homeDir string, selection model.Selection, resolved planner.ResolvedPlan) []backup.Target {
+	set := targetSet{}
 	adapters := resolveAdapters(resolved.Agents)
-
 	for _, component := range resolved.OrderedComponents {
-		for _, path := range componentPaths(homeDir, selection, adapters, component) {
-			paths[path] = struct{}{}
+		set.addComponent(homeDir, selection, adapters, component)
+	}
+
+	return set.targets()
+}
+
+// targetSet collects backup targets by path, merging the tags of paths that
+// several agents or components share.
+type targetSet map[string]*backup.Target
+
+// addComponent adds the directories a component owns for each adapter and
+// the files it writes outside them. Directories are snapshotted whole, so a
+// restore also removes files the install added to them.
+func (t targetSet) addComponent(homeDir string, selection model.Selection, adapters []agents.Adapter, component model.ComponentID) {
+	for _, adapter := range adapters {
+		dirs := componentDirs(homeDir, selection, adapter, component)
+		for _, dir := range dirs {
+			t.add(dir, adapter.Agent(), component)
+		}
+		for _, path := range componentPaths(homeDir, selection, []agents.Adapter{adapter}, component) {
+			if slices.ContainsFunc(dirs, func(dir string) bool { return strings.HasPrefix(path, dir+string(filepath.Separator)) }) {
+				continue
+			}
+			t.add(path, adapter.Agent(), component)
 		}
 	}
+}
 
-	targets := make([]string, 0, len(paths))
-	for path := range paths {
-		targets = append(targets, path)
+func (t targetSet) add(path string, agent model.AgentID, component model.ComponentID) {
+	target, ok := t[path]
+	if !ok {
+		target = &backup.Target{Path: path}
+		t[path] = target
+	}
+	if !slices.Contains(target.Agents, agent) {
+		target.Agents = append(target.Agents, agent)
 	}
+	if !slices.Contains(target.Components, component) {
+		target.Components = append(target.Components, component)
+	}
+}
 
+// targets returns the collected targets sorted by path.
+func (t targetSet) targets() []backup.Target {
+	targets := make([]backup.Target, 0, len(t))
+	for _, target := range t {
+		targets = append(targets, *target)
+	}
+	sort.Slice(targets, func(i, j int) bool { return targets[i].Path < targets[j].Path })
 	return targets
 }
 
+// managedFiles lists the files components write for adapters. Unlike
+// backup targets these never name a whole directory, so a restore limited to
+// them keeps whatever else the user keeps in commands or skill directories.
+func managedFiles(homeDir string, selection model.Selection, adapters []agents.Adapter, components []model.ComponentID) []string {
+	files := []string{}
+	for _, component := range components {
+		files = append(files, componentPaths(homeDir, selection, adapters, component)...)
+	}
+	return sortedUnique(files)
+}
+
+func targetPaths(targets []backup.Target) []string {
+	paths := make([]string, 0, len(targets))
+	for _, target := range targets {
+		paths = append(paths, target.Path)
+	}
+	return paths
+}
+
+// componentDirs returns the directories component fills for adapter: one per
+// installed skill, the OpenCode commands directory and its plugins directory.
+func componentDirs(homeDir string, selection model.Selection, adapter agents.Adapter, component model.ComponentID) []string {
+	dirs := []string{}
+	switch component {
+	case model.ComponentSDD:
+		if adapter.SupportsSlashCommands() {
+			dirs = append(dirs, adapter.CommandsDir(homeDir))
+		}
+		if adapter.Agent() == model.AgentOpenCode {
+			dirs = append(dirs, filepath.Join(homeDir, ".config", "opencode", "plugins"))
+		}
+		if adapter.SupportsSkills() {
+			if skillDir := adapter.SkillsDir(homeDir); skillDir != "" {
+				dirs = append(dirs, filepath.Join(skillDir, "_shared"))
+				for _, name := range sdd.Skills {
+					dirs = append(dirs, filepath.Join(skillDir, name))
+				}
+			}
+		}
+	case model.ComponentSkills:
+		for _, skillID := range selectedSkillIDs(selection) {
+			if path := skills.SkillPathForAgent(homeDir, adapter, skillID); path != "" {
+				dirs = append(dirs, filepath.Dir(path))
+			}
+		}
+	}
+	return dirs
+}
+
 func componentPaths(homeDir string, selection model.Selection, adapters []agents.Adapter, component model.ComponentID) []string {
 	paths := []string{}
 	for _, adapter := range adapters {
@@ -659,9 +1379,10 @@ func componentPaths(homeDir string, selection model.Selection, adapters []agents
 			case model.StrategyTOMLFile:
 				if p := adapter.MCPConfigPath(homeDir, "engram"); p != "" {
 					paths = append(paths, p)
+					paths = append(paths, engram.CodexInstructionPaths(homeDir)...)
 				}
 			}
-			if adapter.SystemPromptStrategy() == model.StrategyMarkdownSections {
+			if adapter.SupportsSystemPrompt() {
 				paths = append(paths, adapter.SystemPromptFile(homeDir))
 			}
 		case model.ComponentSDD:
@@ -682,21 +1403,12 @@ func componentPaths(homeDir string, selection model.Selection, adapters []agents
 			if adapter.SupportsSkills() {
 				skillDir := adapter.SkillsDir(homeDir)
 				if skillDir != "" {
-					paths = append(paths,
-						filepath.Join(skillDir, "_shared", "persistence-contract.md"),
-						filepath.Join(skillDir, "_shared", "engram-convention.md"),
-						filepath.Join(skillDir, "_shared", "openspec-convention.md"),
-						filepath.Join(skillDir, "_shared", "sdd-phase-common.md"),
-						filepath.Join(skillDir, "sdd-init", "SKILL.md"),
-						filepath.Join(skillDir, "sdd-explore", "SKILL.md"),
-						filepath.Join(skillDir, "sdd-propose", "SKILL.md"),
-						filepath.Join(skillDir, "sdd-spec", "SKILL.md"),
-						filepath.Join(skillDir, "sdd-design", "SKILL.md"),
-						filepath.Join(skillDir, "sdd-tasks", "SKILL.md"),
-						filepath.Join(skillDir, "sdd-apply", "SKILL.md"),
-						filepath.Join(skillDir, "sdd-verify", "SKILL.md"),
-						filepath.Join(skillDir, "sdd-archive", "SKILL.md"),
-					)
+					for _, name := range sdd.SharedFiles {
+						paths = append(paths, filepath.Join(skillDir, "_shared", name))
+					}
+					for _, name := range sdd.Skills {
+						paths = append(paths, filepath.Join(skillDir, name, "SKILL.md"))
+					}
 				}
 			}
 		case model.ComponentSkills:
@@ -844,13 +1556,13 @@ func (s checkDependenciesStep) ID() string {
 	return s.id
 }
 
-func (s checkDependenciesStep) Run() error {
+func (s checkDependenciesStep) Run(ctx context.Context) error {
 	// Run detection but do NOT write to stdout/stderr — this step runs
 	// inside the Bubble Tea alternate screen in TUI mode, so any raw
 	// output corrupts the display (see issue #2). Missing deps are
 	// surfaced on the TUI complete screen and by the actual install steps
 	// failing with real error messages.
-	_ = system.DetectDependencies(context.Background(), s.profile)
+	_ = system.DetectDependencies(ctx, s.profile)
 	return nil
 }
 
@@ -862,6 +1574,6 @@ func (s noopStep) ID() string {
 	return s.id
 }
 
-func (s noopStep) Run() error {
+func (s noopStep) Run(context.Context) error {
 	return nil
 }
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/gentleman-programming/gentle-ai/internal/model"
+	"github.com/gentleman-programming/gentle-ai/internal/planner"
 )
 
 func TestComponentPathsSDDIncludesSystemPromptForAllSupportedAgents(t *testing.T) {
@@ -115,3 +116,27 @@ func containsPath(paths []string, want string) bool {
 	}
 	return false
 }
+
+func TestBackupTargetsSnapshotOpenCodeDirectoriesWhole(t *testing.T) {
+	home := t.TempDir()
+
+	targets := targetPaths(backupTargets(home, model.Selection{}, planner.ResolvedPlan{
+		Agents:            []model.AgentID{model.AgentOpenCode},
+		OrderedComponents: []model.ComponentID{model.ComponentSDD},
+	}))
+
+	opencode := filepath.Join(home, ".config", "opencode")
+	for _, dir := range []string{filepath.Join(opencode, "commands"), filepath.Join(opencode, "plugins")} {
+		if !containsPath(targets, dir) {
+			t.Fatalf("backupTargets(sdd) missing directory %q\ntargets=%v", dir, targets)
+		}
+	}
+	for _, file := range []string{filepath.Join(opencode, "commands", "sdd-init.md"), filepath.Join(opencode, "plugins", "background-agents.ts")} {
+		if containsPath(targets, file) {
+			t.Fatalf("backupTargets(sdd) lists %q, which its directory already covers", file)
+		}
+	}
+	if !containsPath(targets, filepath.Join(opencode, "opencode.json")) {
+		t.Fatalf("backupTargets(sdd) missing OpenCode settings\ntargets=%v", targets)
+	}
+}
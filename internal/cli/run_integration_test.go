@@ -1,11 +1,13 @@
 package cli
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 	"testing"
@@ -13,6 +15,7 @@ import (
 
 	"github.com/gentleman-programming/gentle-ai/internal/agents/opencode"
 	"github.com/gentleman-programming/gentle-ai/internal/installcmd"
+	"github.com/gentleman-programming/gentle-ai/internal/pipeline"
 	"github.com/gentleman-programming/gentle-ai/internal/system"
 )
 
@@ -37,7 +40,7 @@ func TestRunInstallAppliesFilesystemChanges(t *testing.T) {
 	})
 
 	osUserHomeDir = func() (string, error) { return home, nil }
-	runCommand = func(string, ...string) error { return nil }
+	runCommand = func(context.Context, string, ...string) error { return nil }
 	cmdLookPath = missingBinaryLookPath
 
 	result, err := RunInstall([]string{"--agent", "opencode", "--component", "permissions"}, system.DetectionResult{})
@@ -78,7 +81,7 @@ func TestRunInstallRollsBackOnComponentFailure(t *testing.T) {
 	cmdLookPath = missingBinaryLookPath
 
 	osUserHomeDir = func() (string, error) { return home, nil }
-	runCommand = func(name string, args ...string) error {
+	runCommand = func(_ context.Context, name string, args ...string) error {
 		if name == "brew" && len(args) == 2 && args[0] == "install" && args[1] == "engram" {
 			return os.ErrPermission
 		}
@@ -133,7 +136,7 @@ type commandRecorder struct {
 	commands []string
 }
 
-func (r *commandRecorder) record(name string, args ...string) error {
+func (r *commandRecorder) record(_ context.Context, name string, args ...string) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	r.commands = append(r.commands, fmt.Sprintf("%s %s", name, strings.Join(args, " ")))
@@ -357,7 +360,7 @@ func TestRunInstallLinuxRollsBackOnComponentFailure(t *testing.T) {
 	setupValidGoEnvInInstallcmd(t)
 
 	osUserHomeDir = func() (string, error) { return home, nil }
-	runCommand = func(name string, args ...string) error {
+	runCommand = func(_ context.Context, name string, args ...string) error {
 		// Fail the engram install command to trigger rollback.
 		// Command is now: env CGO_ENABLED=0 go install .../engram@latest
 		if name == "env" && strings.Contains(strings.Join(args, " "), "engram") {
@@ -450,7 +453,7 @@ func TestRunInstallLinuxVerificationReportsReadyOnSuccess(t *testing.T) {
 	})
 
 	osUserHomeDir = func() (string, error) { return home, nil }
-	runCommand = func(string, ...string) error { return nil }
+	runCommand = func(context.Context, string, ...string) error { return nil }
 	cmdLookPath = missingBinaryLookPath
 
 	detection := linuxDetectionResult(system.LinuxDistroUbuntu, "apt")
@@ -482,7 +485,7 @@ func TestRunInstallLinuxArchVerificationReportsReadyOnSuccess(t *testing.T) {
 	})
 
 	osUserHomeDir = func() (string, error) { return home, nil }
-	runCommand = func(string, ...string) error { return nil }
+	runCommand = func(context.Context, string, ...string) error { return nil }
 	cmdLookPath = missingBinaryLookPath
 
 	detection := linuxDetectionResult(system.LinuxDistroArch, "pacman")
@@ -620,7 +623,7 @@ func TestRunInstallMacOSVerificationMatchesPreLinuxBehavior(t *testing.T) {
 	})
 
 	osUserHomeDir = func() (string, error) { return home, nil }
-	runCommand = func(string, ...string) error { return nil }
+	runCommand = func(context.Context, string, ...string) error { return nil }
 	cmdLookPath = missingBinaryLookPath
 
 	detection := macOSDetectionResult()
@@ -663,7 +666,7 @@ func TestRunInstallMacOSRollbackStillWorks(t *testing.T) {
 	cmdLookPath = missingBinaryLookPath
 
 	osUserHomeDir = func() (string, error) { return home, nil }
-	runCommand = func(name string, args ...string) error {
+	runCommand = func(_ context.Context, name string, args ...string) error {
 		if name == "brew" && len(args) == 2 && args[0] == "install" && args[1] == "engram" {
 			return os.ErrPermission
 		}
@@ -792,7 +795,7 @@ func TestRunInstallEngramFallsBackToInjectWhenSetupFails(t *testing.T) {
 	cmdLookPath = func(name string) (string, error) {
 		return "/usr/local/bin/" + name, nil
 	}
-	runCommand = func(name string, args ...string) error {
+	runCommand = func(_ context.Context, name string, args ...string) error {
 		if name == "engram" && len(args) == 2 && args[0] == "setup" && args[1] == "opencode" {
 			return errors.New("setup failed")
 		}
@@ -833,7 +836,7 @@ func TestRunInstallEngramSetupStrictFailsWhenSetupFails(t *testing.T) {
 	cmdLookPath = func(name string) (string, error) {
 		return "/usr/local/bin/" + name, nil
 	}
-	runCommand = func(name string, args ...string) error {
+	runCommand = func(_ context.Context, name string, args ...string) error {
 		if name == "engram" && len(args) == 2 && args[0] == "setup" && args[1] == "opencode" {
 			return errors.New("setup failed")
 		}
@@ -1207,7 +1210,7 @@ func TestRunInstallDryRunMatchesActualInstall(t *testing.T) {
 	})
 
 	osUserHomeDir = func() (string, error) { return home, nil }
-	runCommand = func(string, ...string) error { return nil }
+	runCommand = func(context.Context, string, ...string) error { return nil }
 	cmdLookPath = missingBinaryLookPath
 
 	realResult, err := RunInstall(installArgs, system.DetectionResult{})
@@ -1266,7 +1269,7 @@ func TestRunInstallDryRunMatchesActualInstallOpenCodeSDDMulti(t *testing.T) {
 	})
 
 	osUserHomeDir = func() (string, error) { return home, nil }
-	runCommand = func(string, ...string) error { return nil }
+	runCommand = func(context.Context, string, ...string) error { return nil }
 	cmdLookPath = missingBinaryLookPath
 
 	realResult, err := RunInstall(installArgs, system.DetectionResult{})
@@ -1357,7 +1360,7 @@ func TestRunInstallUpgradeIdempotency(t *testing.T) {
 	})
 
 	osUserHomeDir = func() (string, error) { return home, nil }
-	runCommand = func(string, ...string) error { return nil }
+	runCommand = func(context.Context, string, ...string) error { return nil }
 	// Simulate all binaries already on PATH so install steps are skipped and
 	// the test only exercises injection idempotency.
 	cmdLookPath = func(name string) (string, error) {
@@ -1452,3 +1455,207 @@ func TestRunInstallUpgradeIdempotency(t *testing.T) {
 			commandCount, engramJSON)
 	}
 }
+
+func TestRunInstallWithJobsRunsComponentsAfterTheirDependencies(t *testing.T) {
+	home := t.TempDir()
+	restoreHome := osUserHomeDir
+	restoreCommand := runCommand
+	restoreLookPath := cmdLookPath
+	t.Cleanup(func() {
+		osUserHomeDir = restoreHome
+		runCommand = restoreCommand
+		cmdLookPath = restoreLookPath
+	})
+
+	osUserHomeDir = func() (string, error) { return home, nil }
+	runCommand = func(context.Context, string, ...string) error { return nil }
+	cmdLookPath = missingBinaryLookPath
+
+	result, err := RunInstall([]string{
+		"--agent", "claude-code,gemini-cli",
+		"--component", "engram,sdd,persona,permissions",
+		"--jobs", "4",
+	}, system.DetectionResult{})
+	if err != nil {
+		t.Fatalf("RunInstall() error = %v", err)
+	}
+
+	steps := map[string]pipelineStepTimes{}
+	for _, step := range result.Execution.Apply.Steps {
+		if step.Err != nil {
+			t.Fatalf("step %s error = %v", step.StepID, step.Err)
+		}
+		steps[step.StepID] = pipelineStepTimes{started: step.StartedAt, finished: step.FinishedAt}
+	}
+	if len(steps) != len(result.Plan.Apply) {
+		t.Fatalf("ran %d steps, want %d", len(steps), len(result.Plan.Apply))
+	}
+	if steps["component:sdd"].started.Before(steps["component:engram"].finished) {
+		t.Fatalf("sdd started before engram finished: %+v", steps)
+	}
+	for _, agent := range []string{"agent:claude-code", "agent:gemini-cli"} {
+		if steps["component:persona"].started.Before(steps[agent].finished) {
+			t.Fatalf("persona started before %s finished: %+v", agent, steps)
+		}
+	}
+}
+
+type pipelineStepTimes struct {
+	started, finished time.Time
+}
+
+func TestRunInstallContextCancelledRollsBackAndSkipsRemainingSteps(t *testing.T) {
+	home := t.TempDir()
+	restoreHome := osUserHomeDir
+	restoreCommand := runCommand
+	restoreLookPath := cmdLookPath
+	t.Cleanup(func() {
+		osUserHomeDir = restoreHome
+		runCommand = restoreCommand
+		cmdLookPath = restoreLookPath
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The first command is interrupted, as Ctrl-C would interrupt a hung
+	// npm install.
+	osUserHomeDir = func() (string, error) { return home, nil }
+	runCommand = func(ctx context.Context, _ string, _ ...string) error {
+		cancel()
+		return ctx.Err()
+	}
+	cmdLookPath = missingBinaryLookPath
+
+	result, err := RunInstallContext(ctx, []string{"--agent", "claude-code", "--component", "engram,sdd"}, system.DetectionResult{}, "")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("RunInstallContext() error = %v, want context.Canceled", err)
+	}
+
+	failed, skipped := 0, 0
+	for _, step := range result.Execution.Apply.Steps {
+		switch {
+		case step.Status == "failed":
+			failed++
+		case step.Status == "skipped":
+			skipped++
+		case failed > 0:
+			t.Fatalf("step %s is %s after the interrupted step", step.StepID, step.Status)
+		}
+	}
+	if failed != 1 || skipped == 0 {
+		t.Fatalf("apply steps = %+v, want the steps that never ran reported skipped", result.Execution.Apply.Steps)
+	}
+	if len(result.Execution.Rollback.Steps) == 0 {
+		t.Fatalf("rollback steps = 0, want the applied steps rolled back")
+	}
+	if _, err := os.Stat(filepath.Join(home, ".claude", "CLAUDE.md")); !os.IsNotExist(err) {
+		t.Fatalf("CLAUDE.md should not exist after the rollback, stat err = %v", err)
+	}
+}
+
+func TestRunInstallRetriesFailedInstallCommand(t *testing.T) {
+	home := t.TempDir()
+	restoreHome := osUserHomeDir
+	restoreCommand := runCommand
+	restoreLookPath := cmdLookPath
+	restorePolicy := commandRetryPolicy
+	t.Cleanup(func() {
+		osUserHomeDir = restoreHome
+		runCommand = restoreCommand
+		cmdLookPath = restoreLookPath
+		commandRetryPolicy = restorePolicy
+	})
+
+	osUserHomeDir = func() (string, error) { return home, nil }
+	cmdLookPath = missingBinaryLookPath
+	commandRetryPolicy.InitialBackoff = time.Millisecond
+
+	// Installing engram with brew fails once, as it would on a dropped
+	// connection.
+	recorder := &commandRecorder{}
+	brewFailures := 1
+	runCommand = func(ctx context.Context, name string, args ...string) error {
+		if name == "brew" && slices.Contains(args, "engram") && brewFailures > 0 {
+			brewFailures--
+			return errors.New("curl: (56) Recv failure: Connection reset by peer")
+		}
+		return recorder.record(ctx, name, args...)
+	}
+
+	result, err := RunInstall([]string{"--agent", "opencode", "--component", "engram"}, macOSDetectionResult())
+	if err != nil {
+		t.Fatalf("RunInstall() error = %v", err)
+	}
+
+	for _, step := range result.Execution.Apply.Steps {
+		if step.StepID != "component:engram" {
+			continue
+		}
+		if step.Status != "succeeded" || len(step.Attempts) != 2 {
+			t.Fatalf("engram step = %s after %d attempts, want succeeded after 2", step.Status, len(step.Attempts))
+		}
+		if step.Attempts[0].Err == nil || !strings.Contains(step.Attempts[0].Err.Error(), "Connection reset") {
+			t.Fatalf("first attempt error = %v, want the brew failure", step.Attempts[0].Err)
+		}
+		return
+	}
+	t.Fatalf("no component:engram step in %+v", result.Execution.Apply.Steps)
+}
+
+func TestRunInstallAgainSkipsComponentsThatAreAlreadyConfigured(t *testing.T) {
+	home := t.TempDir()
+	restoreHome := osUserHomeDir
+	restoreCommand := runCommand
+	restoreLookPath := cmdLookPath
+	t.Cleanup(func() {
+		osUserHomeDir = restoreHome
+		runCommand = restoreCommand
+		cmdLookPath = restoreLookPath
+	})
+
+	osUserHomeDir = func() (string, error) { return home, nil }
+	runCommand = (&commandRecorder{}).record
+	cmdLookPath = missingBinaryLookPath
+
+	args := []string{"--agent", "claude-code", "--component", "persona,permissions"}
+	first, err := RunInstall(args, system.DetectionResult{})
+	if err != nil {
+		t.Fatalf("first RunInstall() error = %v", err)
+	}
+	if changed, _ := first.Execution.Apply.Changes(); !slices.Contains(changed, "component:persona") {
+		t.Fatalf("first install changed = %v, want the persona written", changed)
+	}
+
+	claudeMD := filepath.Join(home, ".claude", "CLAUDE.md")
+	before, err := os.Stat(claudeMD)
+	if err != nil {
+		t.Fatalf("Stat(CLAUDE.md) error = %v", err)
+	}
+
+	second, err := RunInstall(args, system.DetectionResult{})
+	if err != nil {
+		t.Fatalf("second RunInstall() error = %v", err)
+	}
+	_, upToDate := second.Execution.Apply.Changes()
+	if !slices.Contains(upToDate, "component:persona") || !slices.Contains(upToDate, "component:permissions") {
+		t.Fatalf("second install up to date = %v, want both components skipped", upToDate)
+	}
+	for _, step := range second.Execution.Apply.Steps {
+		if step.StepID == "component:persona" && (step.Status != pipeline.StepStatusSkipped || step.Check.Reason == "") {
+			t.Fatalf("persona step = %+v, want skipped with a reason", step)
+		}
+	}
+	after, err := os.Stat(claudeMD)
+	if err != nil {
+		t.Fatalf("Stat(CLAUDE.md) error = %v", err)
+	}
+	if !after.ModTime().Equal(before.ModTime()) {
+		t.Fatalf("CLAUDE.md was rewritten by an install that had nothing to do")
+	}
+
+	summary := RenderChangeSummary(second.Execution)
+	if !strings.Contains(summary, "[==] component:persona - already configured") {
+		t.Fatalf("RenderChangeSummary() = %q, want persona listed as already configured", summary)
+	}
+}
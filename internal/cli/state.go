@@ -0,0 +1,155 @@
+package cli
+
+import (
+	"sort"
+	"time"
+
+	"github.com/gentleman-programming/gentle-ai/internal/backup"
+	"github.com/gentleman-programming/gentle-ai/internal/config"
+	"github.com/gentleman-programming/gentle-ai/internal/journal"
+	"github.com/gentleman-programming/gentle-ai/internal/model"
+	"github.com/gentleman-programming/gentle-ai/internal/pipeline"
+	"github.com/gentleman-programming/gentle-ai/internal/planner"
+	"github.com/gentleman-programming/gentle-ai/internal/state"
+	"github.com/gentleman-programming/gentle-ai/internal/system"
+)
+
+// InstallRun is a ready-to-execute install: the real stage plan plus the
+// runtime bookkeeping needed to record its outcome in the state ledger.
+type InstallRun struct {
+	Plan      pipeline.StagePlan
+	runtime   *installRuntime
+	startedAt time.Time
+	// journal records step transitions so an interrupted install can be
+	// resumed; journalErr is the first write that failed.
+	journal    *journal.Writer
+	journalErr error
+}
+
+// NewInstallRun prepares a real install for the TUI and CLI paths. cfg is the
+// loaded ~/.gentle-ai/config.json, for its hooks and backup retention, and
+// version is the gentle-ai version recorded on the backups the run takes.
+func NewInstallRun(homeDir string, selection model.Selection, resolved planner.ResolvedPlan, profile system.PlatformProfile, cfg config.Config, version string) (*InstallRun, error) {
+	runtime, err := newInstallRuntime(homeDir, selection, resolved, profile, cfg)
+	if err != nil {
+		return nil, err
+	}
+	runtime.state.label.Version = version
+
+	return &InstallRun{
+		Plan:      runtime.stagePlan(),
+		runtime:   runtime,
+		startedAt: time.Now().UTC(),
+	}, nil
+}
+
+// ChangedFiles returns the files step changed during the run.
+func (r *InstallRun) ChangedFiles(step string) []string {
+	return r.runtime.state.changedFiles(step)
+}
+
+// Record appends the outcome of execution to ~/.gentle-ai/state.json. source
+// identifies the front end that ran the install ("cli" or "tui").
+func (r *InstallRun) Record(source string, execution pipeline.ExecutionResult) error {
+	record := newStateRecord(state.CommandInstall, source, r.startedAt, r.runtime.state, execution)
+	record.Agents = r.runtime.resolved.Agents
+	record.Components = r.runtime.resolved.OrderedComponents
+	if hasComponent(r.runtime.resolved.OrderedComponents, model.ComponentSkills) {
+		record.Skills = selectedSkillIDs(r.runtime.selection)
+	}
+	record.Persona = r.runtime.selection.Persona
+	record.Preset = r.runtime.selection.Preset
+	record.SDDMode = r.runtime.selection.SDDMode
+	record.ModelAssignments = r.runtime.selection.ModelAssignments
+	record.AgentOutcomes = r.AgentOutcomes(execution)
+
+	return state.Append(r.runtime.homeDir, record)
+}
+
+// PruneBackups applies the configured retention policy to the backup store
+// and returns the backups it deleted. The snapshot this run took is always
+// kept. Call it only after a successful install.
+func (r *InstallRun) PruneBackups() ([]backup.Manifest, error) {
+	policy, err := r.runtime.config.Backups.Policy()
+	if err != nil {
+		return nil, err
+	}
+	if policy.IsZero() {
+		return nil, nil
+	}
+	if r.runtime.state.manifest.ID != "" {
+		policy.Protect = append(policy.Protect, r.runtime.state.manifest.ID)
+	}
+
+	return backup.Store{Root: r.runtime.backupRoot}.Prune(policy, timeNow())
+}
+
+// RecordedFiles returns the files the installs and repairs in the state
+// ledger wrote. Restoring a backup may remove these from the directories it
+// puts back; anything else found there belongs to the user.
+func RecordedFiles(homeDir string) ([]string, error) {
+	ledger, err := state.Load(homeDir)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []string{}
+	for _, record := range ledger.Records {
+		if record.Command == state.CommandInstall || record.Command == state.CommandRepair {
+			files = append(files, record.Files...)
+		}
+	}
+	return sortedUnique(files), nil
+}
+
+// newStateRecord fills the fields every ledger record shares: timing, written
+// files, backup ID and per-step outcome.
+func newStateRecord(command, source string, startedAt time.Time, runtime *runtimeState, execution pipeline.ExecutionResult) state.Record {
+	record := state.Record{
+		ID:         startedAt.Format("20060102150405.000000000"),
+		Command:    command,
+		Source:     source,
+		StartedAt:  startedAt,
+		FinishedAt: time.Now().UTC(),
+		Files:      sortedUnique(runtime.files),
+		BackupID:   runtime.manifest.ID,
+		Steps:      stepRecords(execution),
+		Success:    execution.Err == nil,
+	}
+	if execution.Err != nil {
+		record.Error = execution.Err.Error()
+	}
+
+	return record
+}
+
+func stepRecords(execution pipeline.ExecutionResult) []state.StepRecord {
+	records := []state.StepRecord{}
+	for _, stage := range []pipeline.StageResult{execution.Prepare, execution.Apply, execution.Rollback} {
+		for _, step := range stage.Steps {
+			record := state.StepRecord{
+				ID:     step.StepID,
+				Stage:  string(stage.Stage),
+				Status: string(step.Status),
+				Check:  string(step.Check.Outcome),
+				Reason: step.Check.Reason,
+			}
+			if step.Err != nil {
+				record.Error = step.Err.Error()
+			}
+			records = append(records, record)
+		}
+	}
+
+	return records
+}
+
+func sortedUnique(values []string) []string {
+	if len(values) == 0 {
+		return nil
+	}
+
+	result := unique(values)
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/gentleman-programming/gentle-ai/internal/model"
+	"github.com/gentleman-programming/gentle-ai/internal/state"
+	"github.com/gentleman-programming/gentle-ai/internal/system"
+)
+
+func TestRunInstallRecordsStateLedger(t *testing.T) {
+	home := t.TempDir()
+	stubInstallEnvironment(t, home)
+
+	result, err := RunInstall([]string{"--agent", "opencode", "--component", "permissions"}, system.DetectionResult{})
+	if err != nil {
+		t.Fatalf("RunInstall() error = %v", err)
+	}
+
+	ledger, err := state.Load(home)
+	if err != nil {
+		t.Fatalf("state.Load() error = %v", err)
+	}
+	record, ok := ledger.Latest(state.CommandInstall)
+	if !ok {
+		t.Fatalf("expected an install record in the ledger")
+	}
+
+	if !record.Success || record.Source != "cli" {
+		t.Fatalf("record success=%v source=%q", record.Success, record.Source)
+	}
+	if len(record.Agents) != 1 || record.Agents[0] != model.AgentOpenCode {
+		t.Fatalf("record agents = %v", record.Agents)
+	}
+	if record.Persona != result.Selection.Persona {
+		t.Fatalf("record persona = %q, want %q", record.Persona, result.Selection.Persona)
+	}
+	if record.BackupID == "" {
+		t.Fatalf("record missing backup ID")
+	}
+
+	settings := filepath.Join(home, ".config", "opencode", "opencode.json")
+	if !containsPath(record.Files, settings) {
+		t.Fatalf("record files missing %q: %v", settings, record.Files)
+	}
+
+	stepIDs := map[string]string{}
+	for _, step := range record.Steps {
+		stepIDs[step.ID] = step.Status
+	}
+	if stepIDs["component:permissions"] != "succeeded" {
+		t.Fatalf("record steps = %#v", record.Steps)
+	}
+}
+
+func TestRunUninstallRecordsStateLedger(t *testing.T) {
+	home := t.TempDir()
+	stubInstallEnvironment(t, home)
+
+	if _, err := RunUninstall([]string{"--agent", "opencode", "--component", "permissions"}, system.DetectionResult{}); err != nil {
+		t.Fatalf("RunUninstall() error = %v", err)
+	}
+
+	ledger, err := state.Load(home)
+	if err != nil {
+		t.Fatalf("state.Load() error = %v", err)
+	}
+	record, ok := ledger.Latest()
+	if !ok || record.Command != state.CommandUninstall {
+		t.Fatalf("latest record = %#v, want an uninstall record", record)
+	}
+}
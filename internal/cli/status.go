@@ -0,0 +1,547 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gentleman-programming/gentle-ai/internal/agents"
+	"github.com/gentleman-programming/gentle-ai/internal/catalog"
+	"github.com/gentleman-programming/gentle-ai/internal/components/sdd"
+	"github.com/gentleman-programming/gentle-ai/internal/model"
+	"github.com/gentleman-programming/gentle-ai/internal/state"
+	"github.com/gentleman-programming/gentle-ai/internal/system"
+	"github.com/gentleman-programming/gentle-ai/internal/verify"
+)
+
+// Component status values reported by `gentle-ai status`.
+const (
+	ComponentConfigured    = "configured"
+	ComponentDrifted       = "drifted"
+	ComponentNotConfigured = "not-configured"
+)
+
+// File status values reported for each managed file.
+const (
+	FileOK      = "ok"
+	FileMissing = "missing"
+	FileDrifted = "drifted"
+)
+
+type StatusFlags struct {
+	Agents []string
+	JSON   bool
+}
+
+func ParseStatusFlags(args []string) (StatusFlags, error) {
+	var opts StatusFlags
+
+	fs := flag.NewFlagSet("status", flag.ContinueOnError)
+	fs.SetOutput(ioDiscard{})
+	registerListFlag(fs, "agent", &opts.Agents)
+	registerListFlag(fs, "agents", &opts.Agents)
+	fs.BoolVar(&opts.JSON, "json", false, "print the report as JSON")
+
+	if err := fs.Parse(args); err != nil {
+		return StatusFlags{}, err
+	}
+
+	if fs.NArg() > 0 {
+		return StatusFlags{}, fmt.Errorf("unexpected status argument %q", fs.Arg(0))
+	}
+
+	return opts, nil
+}
+
+type StatusReport struct {
+	Agents []AgentStatus        `json:"agents"`
+	Shared []ComponentStatus    `json:"shared,omitempty"`
+	JSON   bool                 `json:"-"`
+	Checks []verify.CheckResult `json:"-"`
+}
+
+type AgentStatus struct {
+	Agent      model.AgentID     `json:"agent"`
+	Detected   bool              `json:"detected"`
+	BinaryPath string            `json:"binary_path,omitempty"`
+	ConfigPath string            `json:"config_path,omitempty"`
+	Components []ComponentStatus `json:"components"`
+	Skills     []string          `json:"skills"`
+}
+
+type ComponentStatus struct {
+	Component model.ComponentID `json:"component"`
+	Status    string            `json:"status"`
+	Files     []FileStatus      `json:"files,omitempty"`
+}
+
+type FileStatus struct {
+	Path   string `json:"path"`
+	Status string `json:"status"`
+}
+
+// Drifted reports whether any managed component was changed by hand.
+func (r StatusReport) Drifted() bool {
+	for _, agent := range r.Agents {
+		for _, component := range agent.Components {
+			if component.Status == ComponentDrifted {
+				return true
+			}
+		}
+	}
+	for _, component := range r.Shared {
+		if component.Status == ComponentDrifted {
+			return true
+		}
+	}
+	return false
+}
+
+// RunStatus inspects every agent and reports, per component, whether the
+// files on disk still match what the installer would write. A component is
+// "drifted" when it is installed but a managed section or merged key has been
+// removed or edited by hand.
+func RunStatus(args []string, detection system.DetectionResult) (StatusReport, error) {
+	flags, err := ParseStatusFlags(args)
+	if err != nil {
+		return StatusReport{}, err
+	}
+
+	agentIDs, err := normalizeUninstallAgents(flags.Agents)
+	if err != nil {
+		return StatusReport{}, err
+	}
+
+	homeDir, err := osUserHomeDir()
+	if err != nil {
+		return StatusReport{}, fmt.Errorf("resolve user home directory: %w", err)
+	}
+
+	ledger, err := state.Load(homeDir)
+	if err != nil {
+		return StatusReport{}, err
+	}
+
+	report := buildStatusReport(homeDir, agentIDs, detection, ledger)
+	report.JSON = flags.JSON
+	return report, nil
+}
+
+func buildStatusReport(homeDir string, agentIDs []model.AgentID, detection system.DetectionResult, ledger state.Ledger) StatusReport {
+	report := StatusReport{Agents: make([]AgentStatus, 0, len(agentIDs))}
+	checks := []verify.Check{}
+	slots := map[string]*ComponentStatus{}
+
+	addCheck := func(id string, slot *ComponentStatus, agentIDs []model.AgentID, selection model.Selection) {
+		slots[id] = slot
+		checks = append(checks, verify.Check{
+			ID:          id,
+			Description: "managed files match the installer output",
+			Run: func(ctx context.Context) error {
+				files, err := componentDrift(ctx, homeDir, slot.Component, agentIDs, selection, noLock{})
+				if err != nil {
+					return err
+				}
+				slot.Files = files
+				return driftError(files)
+			},
+		})
+	}
+
+	for _, agentID := range agentIDs {
+		adapter, err := agents.NewAdapter(agentID)
+		if err != nil {
+			continue
+		}
+
+		status := AgentStatus{Agent: agentID, Skills: presentSkills(homeDir, adapter)}
+		installed, binaryPath, configPath, configFound, _ := adapter.Detect(context.Background(), homeDir)
+		status.Detected = installed || configFound || detectedConfig(detection, agentID)
+		status.BinaryPath = binaryPath
+		if configFound {
+			status.ConfigPath = configPath
+		}
+
+		selection := statusSelection(ledger, agentID)
+		for _, component := range catalog.MVPComponents() {
+			if component.ID == model.ComponentGGA {
+				continue
+			}
+			status.Components = append(status.Components, ComponentStatus{Component: component.ID})
+		}
+		report.Agents = append(report.Agents, status)
+
+		agentStatus := &report.Agents[len(report.Agents)-1]
+		for i := range agentStatus.Components {
+			slot := &agentStatus.Components[i]
+			addCheck("status:"+string(agentID)+":"+string(slot.Component), slot, []model.AgentID{agentID}, selection)
+		}
+	}
+
+	report.Shared = []ComponentStatus{{Component: model.ComponentGGA}}
+	addCheck("status:shared:"+string(model.ComponentGGA), &report.Shared[0], agentIDs, statusSelection(ledger, ""))
+
+	report.Checks = verify.RunChecks(context.Background(), checks)
+	for _, result := range report.Checks {
+		slot := slots[result.ID]
+		slot.Status = componentState(slot, result, ledger, agentsForCheck(result.ID, agentIDs))
+	}
+
+	for i := range report.Agents {
+		report.Agents[i].Components = applicableComponents(report.Agents[i].Components)
+	}
+
+	return report
+}
+
+// componentState turns a drift check into a status. Without ledger history
+// (installs made before the ledger existed) a component counts as installed
+// when at least one of its files is still intact.
+func componentState(slot *ComponentStatus, result verify.CheckResult, ledger state.Ledger, agentIDs []model.AgentID) string {
+	if result.Status == verify.CheckStatusPassed {
+		return ComponentConfigured
+	}
+
+	installed := false
+	if ledger.HasInstalls() {
+		for _, agentID := range agentIDs {
+			installed = installed || ledger.Installed(agentID, slot.Component)
+		}
+	} else {
+		for _, file := range slot.Files {
+			installed = installed || file.Status == FileOK
+		}
+	}
+
+	if installed {
+		return ComponentDrifted
+	}
+	return ComponentNotConfigured
+}
+
+func agentsForCheck(checkID string, all []model.AgentID) []model.AgentID {
+	parts := strings.Split(checkID, ":")
+	if len(parts) == 3 && parts[1] != "shared" {
+		return []model.AgentID{model.AgentID(parts[1])}
+	}
+	return all
+}
+
+// applicableComponents drops components whose injector writes nothing for the
+// agent (for example Context7 on Codex), so they are not reported at all.
+func applicableComponents(components []ComponentStatus) []ComponentStatus {
+	result := make([]ComponentStatus, 0, len(components))
+	for _, component := range components {
+		if len(component.Files) == 0 && component.Status == ComponentConfigured {
+			continue
+		}
+		result = append(result, component)
+	}
+	return result
+}
+
+// statusSelection returns the selection the injectors are replayed with: the
+// one recorded by the latest install for the agent, or the installer defaults.
+func statusSelection(ledger state.Ledger, agentID model.AgentID) model.Selection {
+	var record state.Record
+	var ok bool
+	if agentID == "" {
+		record, ok = ledger.Latest(state.CommandInstall)
+	} else {
+		record, ok = ledger.LatestInstallFor(agentID)
+	}
+	if ok {
+		return record.Selection()
+	}
+
+	return model.Selection{
+		Persona: model.PersonaGentleman,
+		Preset:  model.PresetFullGentleman,
+	}
+}
+
+func detectedConfig(detection system.DetectionResult, agentID model.AgentID) bool {
+	for _, config := range detection.Configs {
+		if config.Agent == string(agentID) && config.Exists {
+			return true
+		}
+	}
+	return false
+}
+
+// presentSkills lists the skill directories in the agent's SkillsDir that
+// contain a SKILL.md, whoever installed them.
+func presentSkills(homeDir string, adapter agents.Adapter) []string {
+	skillsPresent := []string{}
+	if !adapter.SupportsSkills() {
+		return skillsPresent
+	}
+
+	skillDir := adapter.SkillsDir(homeDir)
+	entries, err := os.ReadDir(skillDir)
+	if err != nil {
+		return skillsPresent
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), "_") {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(skillDir, entry.Name(), "SKILL.md")); err == nil {
+			skillsPresent = append(skillsPresent, entry.Name())
+		}
+	}
+
+	sort.Strings(skillsPresent)
+	return skillsPresent
+}
+
+// componentDrift replays the component's injectors against a scratch copy of
+// its files and compares the result with what is on disk. Files the injector
+// would leave untouched are "ok"; anything it would create or rewrite is
+// "missing" or "drifted". reads is held while the real files are read, so a
+// running install's writes are never seen half done.
+func componentDrift(ctx context.Context, homeDir string, component model.ComponentID, agentIDs []model.AgentID, selection model.Selection, reads sync.Locker) ([]FileStatus, error) {
+	inventory := componentPaths(homeDir, selection, resolveAdapters(agentIDs), component)
+	rendered, err := renderInScratch(homeDir, inventory, reads, func(scratch string) ([]string, error) {
+		return injectComponentFiles(ctx, scratch, component, agentIDs, selection)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	reads.Lock()
+	defer reads.Unlock()
+	files := make([]FileStatus, 0, len(rendered))
+	for _, path := range sortedKeys(rendered) {
+		actual, err := os.ReadFile(path)
+		switch {
+		case err != nil:
+			files = append(files, FileStatus{Path: path, Status: FileMissing})
+		case sameContent(rendered[path], actual):
+			files = append(files, FileStatus{Path: path, Status: FileOK})
+		default:
+			files = append(files, FileStatus{Path: path, Status: FileDrifted})
+		}
+	}
+
+	return files, nil
+}
+
+// renderInScratch copies the inventory files into a scratch home, runs inject
+// against it and returns, keyed by real path, the content every written file
+// would have. Nothing under homeDir is modified. reads is held only while
+// the inventory is copied.
+func renderInScratch(homeDir string, inventory []string, reads sync.Locker, inject func(scratch string) ([]string, error)) (map[string][]byte, error) {
+	scratch, err := os.MkdirTemp("", "gentle-ai-scratch-*")
+	if err != nil {
+		return nil, fmt.Errorf("create scratch directory: %w", err)
+	}
+	defer os.RemoveAll(scratch)
+
+	reads.Lock()
+	for _, path := range inventory {
+		if err := copyIntoScratch(homeDir, scratch, path); err != nil {
+			reads.Unlock()
+			return nil, err
+		}
+	}
+	reads.Unlock()
+
+	// The SDD injector installs the OpenCode plugin dependency when it is
+	// missing; pretend it is present so rendering never runs bun or npm.
+	if err := os.MkdirAll(sdd.OpenCodePluginDependencyPath(scratch), 0o755); err != nil {
+		return nil, fmt.Errorf("prepare scratch directory: %w", err)
+	}
+
+	written, err := inject(scratch)
+	if err != nil {
+		return nil, err
+	}
+
+	rendered := map[string][]byte{}
+	for _, path := range written {
+		rel, err := filepath.Rel(scratch, path)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		rendered[filepath.Join(homeDir, rel)] = bytes.ReplaceAll(content, []byte(scratch), []byte(homeDir))
+	}
+
+	return rendered, nil
+}
+
+// noLock is the sync.Locker of callers that read files no install is
+// writing at the same time.
+type noLock struct{}
+
+func (noLock) Lock()   {}
+func (noLock) Unlock() {}
+
+func sortedKeys(rendered map[string][]byte) []string {
+	paths := make([]string, 0, len(rendered))
+	for path := range rendered {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// copyIntoScratch copies path, or every file below it when it is a
+// directory, to the same home-relative location in scratch.
+func copyIntoScratch(homeDir, scratch, path string) error {
+	rel, err := filepath.Rel(homeDir, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("stat %q: %w", path, err)
+	}
+	if !info.IsDir() {
+		return copyFileIntoScratch(filepath.Join(scratch, rel), path)
+	}
+
+	return filepath.WalkDir(path, func(current string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return fmt.Errorf("walk %q: %w", current, err)
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		if entry.Type()&fs.ModeSymlink != 0 {
+			// WalkDir does not follow links; one to a directory is skipped.
+			if target, err := os.Stat(current); err != nil || target.IsDir() {
+				return nil
+			}
+		} else if !entry.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(homeDir, current)
+		if err != nil {
+			return nil
+		}
+		return copyFileIntoScratch(filepath.Join(scratch, rel), current)
+	})
+}
+
+func copyFileIntoScratch(target, path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("read %q: %w", path, err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return fmt.Errorf("create scratch directory for %q: %w", path, err)
+	}
+	if err := os.WriteFile(target, content, 0o644); err != nil {
+		return fmt.Errorf("copy %q into scratch directory: %w", path, err)
+	}
+	return nil
+}
+
+// sameContent compares two files, treating JSON documents as equal when they
+// decode to the same value so key order and indentation are not drift.
+func sameContent(expected, actual []byte) bool {
+	if bytes.Equal(expected, actual) {
+		return true
+	}
+
+	var expectedJSON, actualJSON any
+	if json.Unmarshal(expected, &expectedJSON) != nil || json.Unmarshal(actual, &actualJSON) != nil {
+		return false
+	}
+	return reflect.DeepEqual(expectedJSON, actualJSON)
+}
+
+func driftError(files []FileStatus) error {
+	problems := []string{}
+	for _, file := range files {
+		if file.Status != FileOK {
+			problems = append(problems, fmt.Sprintf("%s %s", file.Path, file.Status))
+		}
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%s", strings.Join(problems, "; "))
+}
+
+// RenderStatus renders the report as text, or as JSON when --json was given.
+func RenderStatus(report StatusReport) (string, error) {
+	if report.JSON {
+		content, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("marshal status report: %w", err)
+		}
+		return string(content), nil
+	}
+
+	b := &strings.Builder{}
+	_, _ = fmt.Fprintln(b, "gentle-ai status")
+	_, _ = fmt.Fprintln(b, "================")
+
+	for _, agent := range report.Agents {
+		detected := "not detected"
+		if agent.Detected {
+			detected = "detected"
+		}
+		_, _ = fmt.Fprintf(b, "\n%s (%s)\n", agent.Agent, detected)
+		for _, component := range agent.Components {
+			renderComponentStatus(b, component)
+		}
+		if len(agent.Skills) > 0 {
+			_, _ = fmt.Fprintf(b, "  skills: %s\n", strings.Join(agent.Skills, ", "))
+		}
+	}
+
+	if len(report.Shared) > 0 {
+		_, _ = fmt.Fprintln(b, "\nshared")
+		for _, component := range report.Shared {
+			renderComponentStatus(b, component)
+		}
+	}
+
+	return strings.TrimRight(b.String(), "\n"), nil
+}
+
+func renderComponentStatus(b *strings.Builder, component ComponentStatus) {
+	marker := "[--]"
+	switch component.Status {
+	case ComponentConfigured:
+		marker = "[ok]"
+	case ComponentDrifted:
+		marker = "[!!]"
+	}
+
+	_, _ = fmt.Fprintf(b, "  %s %-12s %s\n", marker, component.Component, component.Status)
+	if component.Status != ComponentDrifted {
+		return
+	}
+	for _, file := range component.Files {
+		if file.Status != FileOK {
+			_, _ = fmt.Fprintf(b, "       %s (%s)\n", file.Path, file.Status)
+		}
+	}
+}
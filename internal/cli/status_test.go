@@ -0,0 +1,135 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gentleman-programming/gentle-ai/internal/model"
+	"github.com/gentleman-programming/gentle-ai/internal/system"
+)
+
+func findComponentStatus(t *testing.T, report StatusReport, agent model.AgentID, component model.ComponentID) ComponentStatus {
+	t.Helper()
+	for _, current := range report.Agents {
+		if current.Agent != agent {
+			continue
+		}
+		for _, status := range current.Components {
+			if status.Component == component {
+				return status
+			}
+		}
+	}
+	t.Fatalf("status for %s/%s not found in report", agent, component)
+	return ComponentStatus{}
+}
+
+func TestRunStatusReportsConfiguredAfterInstall(t *testing.T) {
+	home := t.TempDir()
+	stubInstallEnvironment(t, home)
+
+	if _, err := RunInstall([]string{"--agent", "claude-code", "--component", "engram,sdd,skills,persona"}, system.DetectionResult{}); err != nil {
+		t.Fatalf("RunInstall() error = %v", err)
+	}
+
+	report, err := RunStatus([]string{"--agent", "claude-code"}, system.DetectionResult{})
+	if err != nil {
+		t.Fatalf("RunStatus() error = %v", err)
+	}
+
+	for _, component := range []model.ComponentID{model.ComponentEngram, model.ComponentSDD, model.ComponentSkills, model.ComponentPersona} {
+		if got := findComponentStatus(t, report, model.AgentClaudeCode, component); got.Status != ComponentConfigured {
+			t.Fatalf("%s status = %q, want configured; files = %#v", component, got.Status, got.Files)
+		}
+	}
+	if got := findComponentStatus(t, report, model.AgentClaudeCode, model.ComponentContext7); got.Status != ComponentNotConfigured {
+		t.Fatalf("context7 status = %q, want not-configured", got.Status)
+	}
+	if report.Drifted() {
+		t.Fatalf("fresh install should not report drift")
+	}
+
+	skills := report.Agents[0].Skills
+	if len(skills) == 0 || !strings.Contains(strings.Join(skills, ","), "sdd-apply") {
+		t.Fatalf("skills = %v, want sdd-apply present", skills)
+	}
+}
+
+func TestRunStatusFlagsHandEditedSectionAsDrift(t *testing.T) {
+	home := t.TempDir()
+	stubInstallEnvironment(t, home)
+
+	if _, err := RunInstall([]string{"--agent", "claude-code", "--component", "persona,permissions"}, system.DetectionResult{}); err != nil {
+		t.Fatalf("RunInstall() error = %v", err)
+	}
+
+	claudeMD := filepath.Join(home, ".claude", "CLAUDE.md")
+	writeTestFile(t, claudeMD, "# My rules only\n")
+
+	report, err := RunStatus([]string{"--agent", "claude-code"}, system.DetectionResult{})
+	if err != nil {
+		t.Fatalf("RunStatus() error = %v", err)
+	}
+
+	persona := findComponentStatus(t, report, model.AgentClaudeCode, model.ComponentPersona)
+	if persona.Status != ComponentDrifted {
+		t.Fatalf("persona status = %q, want drifted", persona.Status)
+	}
+	if !containsFileStatus(persona.Files, claudeMD, FileDrifted) {
+		t.Fatalf("persona files = %#v, want %q drifted", persona.Files, claudeMD)
+	}
+	if got := findComponentStatus(t, report, model.AgentClaudeCode, model.ComponentPermission); got.Status != ComponentConfigured {
+		t.Fatalf("permissions status = %q, want configured", got.Status)
+	}
+
+	output, err := RenderStatus(report)
+	if err != nil {
+		t.Fatalf("RenderStatus() error = %v", err)
+	}
+	if !strings.Contains(output, claudeMD) {
+		t.Fatalf("status output missing drifted file:\n%s", output)
+	}
+}
+
+func TestRunStatusJSONOutput(t *testing.T) {
+	home := t.TempDir()
+	stubInstallEnvironment(t, home)
+
+	report, err := RunStatus([]string{"--agent", "opencode", "--json"}, system.DetectionResult{})
+	if err != nil {
+		t.Fatalf("RunStatus() error = %v", err)
+	}
+
+	output, err := RenderStatus(report)
+	if err != nil {
+		t.Fatalf("RenderStatus() error = %v", err)
+	}
+
+	var decoded StatusReport
+	if err := json.Unmarshal([]byte(output), &decoded); err != nil {
+		t.Fatalf("Unmarshal() error = %v\n%s", err, output)
+	}
+	if len(decoded.Agents) != 1 || decoded.Agents[0].Agent != model.AgentOpenCode {
+		t.Fatalf("decoded agents = %#v", decoded.Agents)
+	}
+	for _, component := range decoded.Agents[0].Components {
+		if component.Status != ComponentNotConfigured {
+			t.Fatalf("%s status = %q on empty home, want not-configured", component.Component, component.Status)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(home, ".config", "opencode")); !os.IsNotExist(err) {
+		t.Fatalf("status must not write into the home directory, stat error = %v", err)
+	}
+}
+
+func containsFileStatus(files []FileStatus, path, status string) bool {
+	for _, file := range files {
+		if file.Path == path && file.Status == status {
+			return true
+		}
+	}
+	return false
+}
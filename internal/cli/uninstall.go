@@ -0,0 +1,361 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gentleman-programming/gentle-ai/internal/agents"
+	"github.com/gentleman-programming/gentle-ai/internal/backup"
+	"github.com/gentleman-programming/gentle-ai/internal/catalog"
+	"github.com/gentleman-programming/gentle-ai/internal/components/engram"
+	"github.com/gentleman-programming/gentle-ai/internal/components/gga"
+	"github.com/gentleman-programming/gentle-ai/internal/components/mcp"
+	"github.com/gentleman-programming/gentle-ai/internal/components/permissions"
+	"github.com/gentleman-programming/gentle-ai/internal/components/persona"
+	"github.com/gentleman-programming/gentle-ai/internal/components/sdd"
+	"github.com/gentleman-programming/gentle-ai/internal/components/skills"
+	"github.com/gentleman-programming/gentle-ai/internal/components/theme"
+	"github.com/gentleman-programming/gentle-ai/internal/model"
+	"github.com/gentleman-programming/gentle-ai/internal/pipeline"
+	"github.com/gentleman-programming/gentle-ai/internal/planner"
+	"github.com/gentleman-programming/gentle-ai/internal/state"
+	"github.com/gentleman-programming/gentle-ai/internal/system"
+)
+
+type UninstallFlags struct {
+	Agents      []string
+	Components  []string
+	DryRun      bool
+	BackupLabel string
+	Wait        bool
+}
+
+func ParseUninstallFlags(args []string) (UninstallFlags, error) {
+	var opts UninstallFlags
+
+	fs := flag.NewFlagSet("uninstall", flag.ContinueOnError)
+	fs.SetOutput(ioDiscard{})
+	registerListFlag(fs, "agent", &opts.Agents)
+	registerListFlag(fs, "agents", &opts.Agents)
+	registerListFlag(fs, "component", &opts.Components)
+	registerListFlag(fs, "components", &opts.Components)
+	fs.BoolVar(&opts.DryRun, "dry-run", false, "list affected files without removing anything")
+	fs.StringVar(&opts.BackupLabel, "backup-label", "", "note to record on the backup this run takes")
+	fs.BoolVar(&opts.Wait, "wait", false, "wait for another running gentle-ai to finish instead of failing")
+
+	if err := fs.Parse(args); err != nil {
+		return UninstallFlags{}, err
+	}
+
+	if fs.NArg() > 0 {
+		return UninstallFlags{}, fmt.Errorf("unexpected uninstall argument %q", fs.Arg(0))
+	}
+
+	return opts, nil
+}
+
+type UninstallResult struct {
+	Agents     []model.AgentID
+	Components []model.ComponentID
+	Files      []string
+	Plan       pipeline.StagePlan
+	Execution  pipeline.ExecutionResult
+	Backup     backup.Manifest
+	DryRun     bool
+}
+
+// RunUninstall removes everything gentle-ai wrote for the selected agents and
+// components. Without flags it targets every known agent and component; files
+// that were never written are simply skipped.
+//
+// A backup snapshot of every affected file is taken first, and the removal is
+// rolled back from it if any step fails.
+func RunUninstall(args []string, detection system.DetectionResult) (UninstallResult, error) {
+	return RunUninstallContext(context.Background(), args, detection, "")
+}
+
+// RunUninstallContext is RunUninstall with a context; cancelling it stops the
+// removal and rolls back what was already removed. version is recorded on
+// the backup label.
+func RunUninstallContext(ctx context.Context, args []string, _ system.DetectionResult, version string) (UninstallResult, error) {
+	flags, err := ParseUninstallFlags(args)
+	if err != nil {
+		return UninstallResult{}, err
+	}
+
+	agentIDs, err := normalizeUninstallAgents(flags.Agents)
+	if err != nil {
+		return UninstallResult{}, err
+	}
+
+	components, err := normalizeUninstallComponents(flags.Components)
+	if err != nil {
+		return UninstallResult{}, err
+	}
+
+	homeDir, err := osUserHomeDir()
+	if err != nil {
+		return UninstallResult{}, fmt.Errorf("resolve user home directory: %w", err)
+	}
+
+	runtime := newUninstallRuntime(homeDir, agentIDs, components)
+	runtime.state.label.Note = flags.BackupLabel
+	runtime.state.label.Version = version
+
+	result := UninstallResult{
+		Agents:     agentIDs,
+		Components: components,
+		Files:      targetPaths(runtime.targets()),
+		Plan:       runtime.stagePlan(),
+		DryRun:     flags.DryRun,
+	}
+
+	if flags.DryRun {
+		return result, nil
+	}
+
+	runLock, err := acquireRunLock(ctx, homeDir, state.CommandUninstall, flags.Wait)
+	if err != nil {
+		return result, err
+	}
+	defer releaseRunLock(runLock)
+
+	startedAt := time.Now().UTC()
+	stream := openEventStream(homeDir, state.CommandUninstall, "")
+	defer func() { closeEventStream(stream, result.Execution.Err) }()
+	orchestrator := pipeline.NewOrchestrator(
+		pipeline.DefaultRollbackPolicy(),
+		pipeline.WithProgressFunc(stream.Progress(runtime.state.changedFiles, nil)),
+	)
+	result.Execution = orchestrator.Execute(ctx, result.Plan)
+	result.Backup = runtime.state.manifest
+
+	record := newStateRecord(state.CommandUninstall, "cli", startedAt, runtime.state, result.Execution)
+	record.Agents = agentIDs
+	record.Components = components
+	if err := state.Append(homeDir, record); err != nil {
+		fmt.Fprintf(os.Stderr, "WARNING: could not update install state: %v\n", err)
+	}
+	if result.Execution.Err != nil {
+		return result, fmt.Errorf("execute uninstall pipeline: %w", result.Execution.Err)
+	}
+
+	return result, nil
+}
+
+// uninstallSelection is the widest selection an install could have produced.
+// componentPaths uses it so the inventory covers every persona and skill file.
+func uninstallSelection() model.Selection {
+	skillIDs := make([]model.SkillID, 0, len(catalog.MVPSkills()))
+	for _, skill := range catalog.MVPSkills() {
+		skillIDs = append(skillIDs, skill.ID)
+	}
+
+	return model.Selection{
+		Persona: model.PersonaGentleman,
+		Skills:  skillIDs,
+	}
+}
+
+func normalizeUninstallAgents(values []string) ([]model.AgentID, error) {
+	if len(values) == 0 {
+		catalogAgents := catalog.AllAgents()
+		agentIDs := make([]model.AgentID, 0, len(catalogAgents))
+		for _, agent := range catalogAgents {
+			agentIDs = append(agentIDs, agent.ID)
+		}
+		return agentIDs, nil
+	}
+
+	agentIDs := asAgentIDs(values)
+	for _, agent := range agentIDs {
+		if !catalog.IsSupportedAgent(agent) {
+			return nil, fmt.Errorf("unsupported agent %q", agent)
+		}
+	}
+
+	return unique(agentIDs), nil
+}
+
+func normalizeUninstallComponents(values []string) ([]model.ComponentID, error) {
+	if len(values) == 0 {
+		catalogComponents := catalog.MVPComponents()
+		components := make([]model.ComponentID, 0, len(catalogComponents))
+		for _, component := range catalogComponents {
+			components = append(components, component.ID)
+		}
+		return components, nil
+	}
+
+	return normalizeComponents(values, model.PresetCustom)
+}
+
+type uninstallRuntime struct {
+	homeDir    string
+	selection  model.Selection
+	agents     []model.AgentID
+	components []model.ComponentID
+	backupRoot string
+	state      *runtimeState
+}
+
+func newUninstallRuntime(homeDir string, agentIDs []model.AgentID, components []model.ComponentID) *uninstallRuntime {
+	return &uninstallRuntime{
+		homeDir:    homeDir,
+		selection:  uninstallSelection(),
+		agents:     agentIDs,
+		components: components,
+		backupRoot: filepath.Join(homeDir, ".gentle-ai", "backups"),
+		state: &runtimeState{label: backup.Label{
+			Command: state.CommandUninstall,
+			Summary: selectionSummary(agentIDs, components),
+		}},
+	}
+}
+
+// targets returns every file the selected components may have written,
+// using the same inventory as install backups.
+func (r *uninstallRuntime) targets() []backup.Target {
+	return backupTargets(r.homeDir, r.selection, planner.ResolvedPlan{
+		Agents:            r.agents,
+		OrderedComponents: r.components,
+	})
+}
+
+func (r *uninstallRuntime) stagePlan() pipeline.StagePlan {
+	prepare := []pipeline.Step{
+		prepareBackupStep{
+			id:          "prepare:backup-snapshot",
+			snapshotter: backup.NewSnapshotter(r.backupRoot),
+			snapshotDir: filepath.Join(r.backupRoot, time.Now().UTC().Format("20060102150405.000000000")),
+			targets:     r.targets(),
+			state:       r.state,
+		},
+	}
+
+	apply := make([]pipeline.Step, 0, len(r.components)+1)
+	apply = append(apply, rollbackRestoreStep{id: "apply:rollback-restore", state: r.state})
+
+	for _, component := range r.components {
+		apply = append(apply, componentRemoveStep{
+			id:        "remove:" + string(component),
+			component: component,
+			homeDir:   r.homeDir,
+			agents:    r.agents,
+			selection: r.selection,
+			state:     r.state,
+		})
+	}
+
+	return pipeline.StagePlan{Prepare: prepare, Apply: apply}
+}
+
+type componentRemoveStep struct {
+	id        string
+	component model.ComponentID
+	homeDir   string
+	agents    []model.AgentID
+	selection model.Selection
+	state     *runtimeState
+}
+
+func (s componentRemoveStep) ID() string {
+	return s.id
+}
+
+func (s componentRemoveStep) Run(context.Context) error {
+	adapters := resolveAdapters(s.agents)
+
+	switch s.component {
+	case model.ComponentEngram:
+		return removeForAdapters(adapters, s.component, s.state, func(adapter agents.Adapter) ([]string, error) {
+			result, err := engram.Remove(s.homeDir, adapter)
+			return result.Files, err
+		})
+	case model.ComponentContext7:
+		return removeForAdapters(adapters, s.component, s.state, func(adapter agents.Adapter) ([]string, error) {
+			result, err := mcp.Remove(s.homeDir, adapter)
+			return result.Files, err
+		})
+	case model.ComponentPersona:
+		return removeForAdapters(adapters, s.component, s.state, func(adapter agents.Adapter) ([]string, error) {
+			result, err := persona.Remove(s.homeDir, adapter)
+			return result.Files, err
+		})
+	case model.ComponentPermission:
+		return removeForAdapters(adapters, s.component, s.state, func(adapter agents.Adapter) ([]string, error) {
+			result, err := permissions.Remove(s.homeDir, adapter)
+			return result.Files, err
+		})
+	case model.ComponentSDD:
+		return removeForAdapters(adapters, s.component, s.state, func(adapter agents.Adapter) ([]string, error) {
+			result, err := sdd.Remove(s.homeDir, adapter)
+			return result.Files, err
+		})
+	case model.ComponentSkills:
+		skillIDs := selectedSkillIDs(s.selection)
+		return removeForAdapters(adapters, s.component, s.state, func(adapter agents.Adapter) ([]string, error) {
+			result, err := skills.Remove(s.homeDir, adapter, skillIDs)
+			return result.Files, err
+		})
+	case model.ComponentGGA:
+		result, err := gga.Remove(s.homeDir)
+		if err != nil {
+			return fmt.Errorf("remove gga config: %w", err)
+		}
+		s.state.addFiles(result.FilesWritten()...)
+		return nil
+	case model.ComponentTheme:
+		return removeForAdapters(adapters, s.component, s.state, func(adapter agents.Adapter) ([]string, error) {
+			result, err := theme.Remove(s.homeDir, adapter)
+			return result.Files, err
+		})
+	default:
+		return fmt.Errorf("component %q is not supported in uninstall runtime", s.component)
+	}
+}
+
+func removeForAdapters(adapters []agents.Adapter, component model.ComponentID, state *runtimeState, remove func(agents.Adapter) ([]string, error)) error {
+	for _, adapter := range adapters {
+		files, err := remove(adapter)
+		if err != nil {
+			return fmt.Errorf("remove %s for %q: %w", component, adapter.Agent(), err)
+		}
+		state.addFiles(files...)
+	}
+	return nil
+}
+
+// RenderUninstallReport summarizes an uninstall run (or dry-run) for the CLI.
+func RenderUninstallReport(result UninstallResult) string {
+	b := &strings.Builder{}
+
+	title := "gentle-ai uninstall"
+	if result.DryRun {
+		title += " (dry-run)"
+	}
+	_, _ = fmt.Fprintln(b, title)
+	_, _ = fmt.Fprintln(b, strings.Repeat("=", len(title)))
+	_, _ = fmt.Fprintf(b, "Agents: %s\n", joinAgentIDs(result.Agents))
+	_, _ = fmt.Fprintf(b, "Components: %s\n", joinComponentIDs(result.Components))
+
+	if result.DryRun {
+		_, _ = fmt.Fprintf(b, "Files that may be changed or removed: %d\n", len(result.Files))
+		for _, path := range result.Files {
+			_, _ = fmt.Fprintf(b, "- %s\n", path)
+		}
+		return strings.TrimRight(b.String(), "\n")
+	}
+
+	if result.Backup.RootDir != "" {
+		_, _ = fmt.Fprintf(b, "Backup: %s\n", result.Backup.RootDir)
+	}
+	_, _ = fmt.Fprintln(b, "Managed configuration removed. Installed binaries (engram, gga) were left in place.")
+
+	return strings.TrimRight(b.String(), "\n")
+}
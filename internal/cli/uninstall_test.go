@@ -0,0 +1,178 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gentleman-programming/gentle-ai/internal/system"
+)
+
+// stubInstallEnvironment points the CLI at a temp home with every binary on PATH
+// and no external commands executed.
+func stubInstallEnvironment(t *testing.T, home string) {
+	t.Helper()
+
+	restoreHome := osUserHomeDir
+	restoreCommand := runCommand
+	restoreLookPath := cmdLookPath
+	t.Cleanup(func() {
+		osUserHomeDir = restoreHome
+		runCommand = restoreCommand
+		cmdLookPath = restoreLookPath
+	})
+
+	osUserHomeDir = func() (string, error) { return home, nil }
+	runCommand = func(context.Context, string, ...string) error { return nil }
+	cmdLookPath = func(name string) (string, error) {
+		return "/usr/local/bin/" + name, nil
+	}
+}
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("MkdirAll(%q) error = %v", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("WriteFile(%q) error = %v", path, err)
+	}
+}
+
+func readJSONMap(t *testing.T, path string) map[string]any {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile(%q) error = %v", path, err)
+	}
+	parsed := map[string]any{}
+	if err := json.Unmarshal(content, &parsed); err != nil {
+		t.Fatalf("Unmarshal(%q) error = %v", path, err)
+	}
+	return parsed
+}
+
+func TestRunUninstallRestoresClaudeUserContent(t *testing.T) {
+	home := t.TempDir()
+	stubInstallEnvironment(t, home)
+
+	claudeMD := filepath.Join(home, ".claude", "CLAUDE.md")
+	settings := filepath.Join(home, ".claude", "settings.json")
+	writeTestFile(t, claudeMD, "# My rules\n\nAlways write tests.\n")
+	writeTestFile(t, settings, "{\n  \"model\": \"opus\"\n}\n")
+
+	_, err := RunInstall([]string{
+		"--agent", "claude-code",
+		"--component", "engram,sdd,skills,context7,persona,permissions",
+	}, system.DetectionResult{})
+	if err != nil {
+		t.Fatalf("RunInstall() error = %v", err)
+	}
+
+	result, err := RunUninstall([]string{"--agent", "claude-code"}, system.DetectionResult{})
+	if err != nil {
+		t.Fatalf("RunUninstall() error = %v", err)
+	}
+	if result.Backup.RootDir == "" {
+		t.Fatalf("expected uninstall to record a backup snapshot")
+	}
+
+	content, err := os.ReadFile(claudeMD)
+	if err != nil {
+		t.Fatalf("ReadFile(CLAUDE.md) error = %v", err)
+	}
+	if string(content) != "# My rules\n\nAlways write tests.\n" {
+		t.Fatalf("CLAUDE.md after uninstall = %q", string(content))
+	}
+
+	if got := readJSONMap(t, settings); !reflect.DeepEqual(got, map[string]any{"model": "opus"}) {
+		t.Fatalf("settings.json after uninstall = %#v", got)
+	}
+
+	for _, path := range []string{
+		filepath.Join(home, ".claude", "mcp", "engram.json"),
+		filepath.Join(home, ".claude", "mcp", "context7.json"),
+		filepath.Join(home, ".claude", "output-styles", "gentleman.md"),
+		filepath.Join(home, ".claude", "skills", "sdd-apply"),
+		filepath.Join(home, ".claude", "skills", "_shared"),
+	} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Fatalf("expected %q to be removed, stat error = %v", path, err)
+		}
+	}
+}
+
+func TestRunUninstallRemovesCodexEngramBlock(t *testing.T) {
+	home := t.TempDir()
+	stubInstallEnvironment(t, home)
+
+	configPath := filepath.Join(home, ".codex", "config.toml")
+	writeTestFile(t, configPath, "model = \"o3\"\n")
+
+	if _, err := RunInstall([]string{"--agent", "codex", "--component", "engram"}, system.DetectionResult{}); err != nil {
+		t.Fatalf("RunInstall() error = %v", err)
+	}
+
+	if _, err := RunUninstall([]string{"--agent", "codex", "--component", "engram"}, system.DetectionResult{}); err != nil {
+		t.Fatalf("RunUninstall() error = %v", err)
+	}
+
+	content, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatalf("ReadFile(config.toml) error = %v", err)
+	}
+	if string(content) != "model = \"o3\"\n" {
+		t.Fatalf("config.toml after uninstall = %q", string(content))
+	}
+
+	if _, err := os.Stat(filepath.Join(home, ".codex", "engram-instructions.md")); !os.IsNotExist(err) {
+		t.Fatalf("expected codex engram instructions to be removed, stat error = %v", err)
+	}
+}
+
+func TestRunUninstallDryRunLeavesFilesInPlace(t *testing.T) {
+	home := t.TempDir()
+	stubInstallEnvironment(t, home)
+
+	if _, err := RunInstall([]string{"--agent", "opencode", "--component", "permissions"}, system.DetectionResult{}); err != nil {
+		t.Fatalf("RunInstall() error = %v", err)
+	}
+
+	settings := filepath.Join(home, ".config", "opencode", "opencode.json")
+	before, err := os.ReadFile(settings)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+
+	result, err := RunUninstall([]string{"--agent", "opencode", "--component", "permissions", "--dry-run"}, system.DetectionResult{})
+	if err != nil {
+		t.Fatalf("RunUninstall() error = %v", err)
+	}
+	if !containsPath(result.Files, settings) {
+		t.Fatalf("dry-run files missing %q: %v", settings, result.Files)
+	}
+	if report := RenderUninstallReport(result); !strings.Contains(report, settings) {
+		t.Fatalf("dry-run report missing %q:\n%s", settings, report)
+	}
+
+	after, err := os.ReadFile(settings)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if string(before) != string(after) {
+		t.Fatalf("dry-run changed %q", settings)
+	}
+}
+
+func TestRunUninstallRejectsUnknownComponent(t *testing.T) {
+	home := t.TempDir()
+	stubInstallEnvironment(t, home)
+
+	if _, err := RunUninstall([]string{"--component", "nope"}, system.DetectionResult{}); err == nil {
+		t.Fatalf("RunUninstall() expected error for unknown component")
+	}
+}
@@ -6,34 +6,55 @@ import (
 
 	"github.com/gentleman-programming/gentle-ai/internal/catalog"
 	"github.com/gentleman-programming/gentle-ai/internal/model"
+	"github.com/gentleman-programming/gentle-ai/internal/preset"
+	"github.com/gentleman-programming/gentle-ai/internal/profile"
 	"github.com/gentleman-programming/gentle-ai/internal/system"
 )
 
 type InstallInput struct {
 	Selection model.Selection
 	DryRun    bool
+	Diff      bool
 }
 
 func NormalizeInstallFlags(flags InstallFlags, detection system.DetectionResult) (InstallInput, error) {
 	selection := model.Selection{}
 
+	if flags.Profile != "" {
+		loaded, err := profile.Load(flags.Profile)
+		if err != nil {
+			return InstallInput{}, err
+		}
+		flags = withProfile(flags, loaded)
+		selection.ModelAssignments = loaded.ModelAssignments
+	}
+
 	agents := defaultAgentsFromDetection(detection)
 	if len(flags.Agents) > 0 {
 		agents = asAgentIDs(flags.Agents)
 	}
 	selection.Agents = unique(agents)
 
-	persona, err := normalizePersona(flags.Persona)
+	presetID, err := normalizePreset(flags.Preset)
 	if err != nil {
 		return InstallInput{}, err
 	}
-	selection.Persona = persona
+	selection.Preset = presetID
+
+	// The preset supplies persona and SDD mode defaults; flags still win.
+	defaults := preset.Default().Resolve(presetID)
+	if flags.Persona == "" {
+		flags.Persona = string(defaults.Persona)
+	}
+	if flags.SDDMode == "" {
+		flags.SDDMode = string(defaults.SDDMode)
+	}
 
-	preset, err := normalizePreset(flags.Preset)
+	persona, err := normalizePersona(flags.Persona)
 	if err != nil {
 		return InstallInput{}, err
 	}
-	selection.Preset = preset
+	selection.Persona = persona
 
 	components, err := normalizeComponents(flags.Components, selection.Preset)
 	if err != nil {
@@ -53,7 +74,44 @@ func NormalizeInstallFlags(flags InstallFlags, detection system.DetectionResult)
 	}
 	selection.SDDMode = sddMode
 
-	return InstallInput{Selection: selection, DryRun: flags.DryRun}, nil
+	return InstallInput{Selection: selection, DryRun: flags.DryRun || flags.Diff, Diff: flags.Diff}, nil
+}
+
+// withProfile fills every selection flag that was not given on the command
+// line from the profile, so `--profile team.yaml --agent codex` still works.
+func withProfile(flags InstallFlags, loaded profile.Profile) InstallFlags {
+	if len(flags.Agents) == 0 {
+		flags.Agents = asStrings(loaded.Agents)
+	}
+	if len(flags.Components) == 0 {
+		flags.Components = asStrings(loaded.Components)
+	}
+	if len(flags.Skills) == 0 {
+		flags.Skills = asStrings(loaded.Skills)
+	}
+	if flags.Persona == "" {
+		flags.Persona = string(loaded.Persona)
+	}
+	if flags.Preset == "" {
+		flags.Preset = string(loaded.Preset)
+	}
+	if flags.SDDMode == "" {
+		flags.SDDMode = string(loaded.SDDMode)
+	}
+
+	return flags
+}
+
+func asStrings[T ~string](values []T) []string {
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		result = append(result, string(value))
+	}
+	return result
+}
+
+func joinPresetIDs(values []model.PresetID) string {
+	return strings.Join(asStrings(values), ", ")
 }
 
 func normalizePersona(value string) (model.PersonaID, error) {
@@ -74,12 +132,11 @@ func normalizePreset(value string) (model.PresetID, error) {
 		return model.PresetFullGentleman, nil
 	}
 
-	switch model.PresetID(value) {
-	case model.PresetFullGentleman, model.PresetEcosystemOnly, model.PresetMinimal, model.PresetCustom:
-		return model.PresetID(value), nil
-	default:
-		return "", fmt.Errorf("unsupported preset %q", value)
+	if _, ok := preset.Default().Get(model.PresetID(value)); !ok {
+		return "", fmt.Errorf("unsupported preset %q (available: %s)", value, joinPresetIDs(preset.Default().IDs()))
 	}
+
+	return model.PresetID(value), nil
 }
 
 func normalizeComponents(values []string, preset model.PresetID) ([]model.ComponentID, error) {
@@ -139,25 +196,8 @@ func normalizeSDDMode(value string) (model.SDDModeID, error) {
 	}
 }
 
-func componentsForPreset(preset model.PresetID) []model.ComponentID {
-	switch preset {
-	case model.PresetMinimal:
-		return []model.ComponentID{model.ComponentEngram}
-	case model.PresetEcosystemOnly:
-		return []model.ComponentID{model.ComponentEngram, model.ComponentSDD, model.ComponentSkills, model.ComponentContext7, model.ComponentGGA}
-	case model.PresetCustom:
-		return nil
-	default:
-		return []model.ComponentID{
-			model.ComponentEngram,
-			model.ComponentSDD,
-			model.ComponentSkills,
-			model.ComponentContext7,
-			model.ComponentPersona,
-			model.ComponentPermission,
-			model.ComponentGGA,
-		}
-	}
+func componentsForPreset(id model.PresetID) []model.ComponentID {
+	return preset.Default().Components(id)
 }
 
 func defaultAgentsFromDetection(detection system.DetectionResult) []model.AgentID {
@@ -12,6 +12,7 @@ import (
 	"github.com/gentleman-programming/gentle-ai/internal/agents/gemini"
 	"github.com/gentleman-programming/gentle-ai/internal/agents/opencode"
 	"github.com/gentleman-programming/gentle-ai/internal/agents/vscode"
+	"github.com/gentleman-programming/gentle-ai/internal/components/filemerge"
 )
 
 func claudeAdapter() agents.Adapter   { return claude.NewAdapter() }
@@ -409,6 +410,44 @@ func TestInjectCodexInjectsTOMLKeys(t *testing.T) {
 	}
 }
 
+func TestRemoveCodexDropsOnlyEngramInstructionKeys(t *testing.T) {
+	home := t.TempDir()
+
+	if _, err := Inject(home, codexAdapter()); err != nil {
+		t.Fatalf("Inject(codex) error = %v", err)
+	}
+
+	configPath := filepath.Join(home, ".codex", "config.toml")
+	content, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatalf("ReadFile(config.toml) error = %v", err)
+	}
+	userCompact := filepath.Join(home, "my-compact.md")
+	edited := filemerge.UpsertTopLevelTOMLString(string(content), "experimental_compact_prompt_file", userCompact)
+	if err := os.WriteFile(configPath, []byte(edited), 0o644); err != nil {
+		t.Fatalf("WriteFile(config.toml) error = %v", err)
+	}
+
+	if _, err := Remove(home, codexAdapter()); err != nil {
+		t.Fatalf("Remove(codex) error = %v", err)
+	}
+
+	content, err = os.ReadFile(configPath)
+	if err != nil {
+		t.Fatalf("ReadFile(config.toml) error = %v", err)
+	}
+	text := string(content)
+	if strings.Contains(text, "model_instructions_file") {
+		t.Fatalf("engram's model_instructions_file was kept; got:\n%s", text)
+	}
+	if !strings.Contains(text, userCompact) {
+		t.Fatalf("user's experimental_compact_prompt_file was removed; got:\n%s", text)
+	}
+	if strings.Contains(text, "[mcp_servers.engram]") {
+		t.Fatalf("engram MCP block was kept; got:\n%s", text)
+	}
+}
+
 // ─── Engram setup absolute path preservation tests ────────────────────────────
 
 // TestInjectClaudePreservesAbsoluteCommandFromEngramSetup verifies that when
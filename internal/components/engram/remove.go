@@ -0,0 +1,117 @@
+package engram
+
+import (
+	"github.com/gentleman-programming/gentle-ai/internal/agents"
+	"github.com/gentleman-programming/gentle-ai/internal/components/filemerge"
+	"github.com/gentleman-programming/gentle-ai/internal/model"
+)
+
+// Remove undoes Inject for a single agent: the engram MCP server entry is
+// dropped using the adapter's MCP strategy and the engram-protocol section is
+// stripped from the system prompt. The engram binary itself is left installed.
+func Remove(homeDir string, adapter agents.Adapter) (InjectionResult, error) {
+	if !adapter.SupportsMCP() {
+		return InjectionResult{}, nil
+	}
+
+	files := make([]string, 0, 4)
+	changed := false
+	track := func(path string, result filemerge.WriteResult) {
+		changed = changed || result.Changed
+		files = append(files, path)
+	}
+
+	switch adapter.MCPStrategy() {
+	case model.StrategySeparateMCPFiles:
+		mcpPath := adapter.MCPConfigPath(homeDir, "engram")
+		result, err := filemerge.RemoveFile(mcpPath)
+		if err != nil {
+			return InjectionResult{}, err
+		}
+		track(mcpPath, result)
+
+	case model.StrategyMergeIntoSettings:
+		settingsPath := adapter.SettingsPath(homeDir)
+		if settingsPath == "" {
+			break
+		}
+		overlay := defaultEngramOverlayJSON
+		if adapter.Agent() == model.AgentOpenCode {
+			overlay = openCodeEngramOverlayJSON
+		}
+		result, err := filemerge.RemoveJSONKeysFromFile(settingsPath, overlay)
+		if err != nil {
+			return InjectionResult{}, err
+		}
+		track(settingsPath, result)
+
+	case model.StrategyMCPConfigFile:
+		mcpPath := adapter.MCPConfigPath(homeDir, "engram")
+		if mcpPath == "" {
+			break
+		}
+		overlay := defaultEngramOverlayJSON
+		if adapter.Agent() == model.AgentVSCodeCopilot {
+			overlay = vsCodeEngramOverlayJSON
+		}
+		result, err := filemerge.RemoveJSONKeysFromFile(mcpPath, overlay)
+		if err != nil {
+			return InjectionResult{}, err
+		}
+		track(mcpPath, result)
+
+	case model.StrategyTOMLFile:
+		configPath := adapter.MCPConfigPath(homeDir, "engram")
+		if configPath == "" {
+			break
+		}
+
+		existing, err := readFileOrEmpty(configPath)
+		if err != nil {
+			return InjectionResult{}, err
+		}
+		if existing != "" {
+			// The instruction keys are only dropped while they still point at
+			// engram's files; a user who re-pointed them keeps their setting.
+			paths := CodexInstructionPaths(homeDir)
+			withoutMCP := filemerge.RemoveCodexEngramBlock(existing)
+			withoutInstr := filemerge.RemoveTopLevelTOMLString(withoutMCP, "model_instructions_file", paths[0])
+			withoutCompact := filemerge.RemoveTopLevelTOMLString(withoutInstr, "experimental_compact_prompt_file", paths[1])
+
+			result, err := filemerge.WriteOrRemove(configPath, withoutCompact)
+			if err != nil {
+				return InjectionResult{}, err
+			}
+			track(configPath, result)
+		}
+
+		for _, path := range CodexInstructionPaths(homeDir) {
+			result, err := filemerge.RemoveFile(path)
+			if err != nil {
+				return InjectionResult{}, err
+			}
+			track(path, result)
+		}
+	}
+
+	if adapter.SupportsSystemPrompt() {
+		promptPath := adapter.SystemPromptFile(homeDir)
+		result, err := filemerge.RemoveMarkdownSectionsFromFile(promptPath, "engram-protocol")
+		if err != nil {
+			return InjectionResult{}, err
+		}
+		track(promptPath, result)
+	}
+
+	return InjectionResult{Changed: changed, Files: files}, nil
+}
+
+// CodexInstructionPaths returns the instruction files Inject writes next to
+// ~/.codex/config.toml for agents using the TOML strategy.
+func CodexInstructionPaths(homeDir string) []string {
+	codexDir := homeDir + "/.codex"
+	return []string{
+		codexDir + "/engram-instructions.md",
+		codexDir + "/engram-compact-prompt.md",
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"reflect"
 )
 
 func MergeJSONObjects(baseJSON []byte, overlayJSON []byte) ([]byte, error) {
@@ -199,3 +200,57 @@ func mergeObjects(base map[string]any, overlay map[string]any) map[string]any {
 
 	return result
 }
+
+// RemoveJSONKeys is the inverse of MergeJSONObjects: every leaf key present in
+// overlayJSON is deleted from baseJSON, but only while its current value still
+// equals the overlay value. A key the user has edited since install is kept.
+// Nested objects that become empty after the removal are dropped as well, so a
+// merged-then-removed file ends up with the same keys it started with. An empty
+// object in the overlay removes the whole subtree under that key.
+func RemoveJSONKeys(baseJSON []byte, overlayJSON []byte) ([]byte, error) {
+	base, err := unmarshalJSONObject(baseJSON)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal base json: %w", err)
+	}
+
+	overlay, err := unmarshalJSONObject(overlayJSON)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal overlay json: %w", err)
+	}
+
+	removeObjectKeys(base, overlay)
+	encoded, err := json.MarshalIndent(base, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshal pruned json: %w", err)
+	}
+
+	return append(encoded, '\n'), nil
+}
+
+func removeObjectKeys(base map[string]any, overlay map[string]any) {
+	for key, overlayValue := range overlay {
+		baseValue, ok := base[key]
+		if !ok {
+			continue
+		}
+
+		baseMap, baseIsMap := baseValue.(map[string]any)
+		overlayMap, overlayIsMap := overlayValue.(map[string]any)
+		if baseIsMap && overlayIsMap && len(overlayMap) > 0 {
+			removeObjectKeys(baseMap, overlayMap)
+			if len(baseMap) == 0 {
+				delete(base, key)
+			}
+			continue
+		}
+
+		if overlayIsMap && len(overlayMap) == 0 {
+			delete(base, key)
+			continue
+		}
+
+		if reflect.DeepEqual(baseValue, overlayValue) {
+			delete(base, key)
+		}
+	}
+}
@@ -113,3 +113,108 @@ func TestMergeJSONObjectsMalformedBaseReturnsOverlayOnly(t *testing.T) {
 		})
 	}
 }
+
+func TestRemoveJSONKeysUndoesMerge(t *testing.T) {
+	base := []byte(`{"theme":"dark","mcpServers":{"mine":{"command":"x"}}}`)
+	overlay := []byte(`{"mcpServers":{"engram":{"command":"engram","args":["mcp"]}},"outputStyle":"Gentleman"}`)
+
+	merged, err := MergeJSONObjects(base, overlay)
+	if err != nil {
+		t.Fatalf("MergeJSONObjects() error = %v", err)
+	}
+
+	pruned, err := RemoveJSONKeys(merged, overlay)
+	if err != nil {
+		t.Fatalf("RemoveJSONKeys() error = %v", err)
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal(pruned, &got); err != nil {
+		t.Fatalf("Unmarshal pruned json error = %v", err)
+	}
+
+	if _, ok := got["outputStyle"]; ok {
+		t.Fatalf("outputStyle still present: %#v", got)
+	}
+	servers := got["mcpServers"].(map[string]any)
+	if _, ok := servers["engram"]; ok {
+		t.Fatalf("engram server still present: %#v", servers)
+	}
+	if _, ok := servers["mine"]; !ok {
+		t.Fatalf("user server was removed: %#v", servers)
+	}
+	if got["theme"] != "dark" {
+		t.Fatalf("theme = %v", got["theme"])
+	}
+}
+
+func TestRemoveJSONKeysDropsEmptiedObjects(t *testing.T) {
+	base := []byte(`{"mcp":{"context7":{"type":"remote"}},"keep":true}`)
+	overlay := []byte(`{"mcp":{"context7":{"type":"remote","url":"x"}}}`)
+
+	pruned, err := RemoveJSONKeys(base, overlay)
+	if err != nil {
+		t.Fatalf("RemoveJSONKeys() error = %v", err)
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal(pruned, &got); err != nil {
+		t.Fatalf("Unmarshal pruned json error = %v", err)
+	}
+
+	if _, ok := got["mcp"]; ok {
+		t.Fatalf("empty mcp object should be dropped: %#v", got)
+	}
+	if got["keep"] != true {
+		t.Fatalf("keep = %v", got["keep"])
+	}
+}
+
+func TestRemoveJSONKeysEmptyOverlayObjectRemovesSubtree(t *testing.T) {
+	base := []byte(`{"agent":{"gentleman":{"mode":"primary","model":"x"},"mine":{"mode":"all"}}}`)
+	overlay := []byte(`{"agent":{"gentleman":{}}}`)
+
+	pruned, err := RemoveJSONKeys(base, overlay)
+	if err != nil {
+		t.Fatalf("RemoveJSONKeys() error = %v", err)
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal(pruned, &got); err != nil {
+		t.Fatalf("Unmarshal pruned json error = %v", err)
+	}
+
+	agents := got["agent"].(map[string]any)
+	if _, ok := agents["gentleman"]; ok {
+		t.Fatalf("gentleman agent still present: %#v", agents)
+	}
+	if _, ok := agents["mine"]; !ok {
+		t.Fatalf("user agent was removed: %#v", agents)
+	}
+}
+
+func TestRemoveJSONKeysKeepsUserEditedValues(t *testing.T) {
+	base := []byte(`{"outputStyle":"Mine","permissions":{"defaultMode":"plan","deny":["Read(.env)"]}}`)
+	overlay := []byte(`{"outputStyle":"Gentleman","permissions":{"defaultMode":"plan","deny":["Read(.env)","Read(secrets/**)"]}}`)
+
+	pruned, err := RemoveJSONKeys(base, overlay)
+	if err != nil {
+		t.Fatalf("RemoveJSONKeys() error = %v", err)
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal(pruned, &got); err != nil {
+		t.Fatalf("Unmarshal pruned json error = %v", err)
+	}
+
+	if got["outputStyle"] != "Mine" {
+		t.Fatalf("user-edited outputStyle was removed: %#v", got)
+	}
+	permissions := got["permissions"].(map[string]any)
+	if _, ok := permissions["defaultMode"]; ok {
+		t.Fatalf("unchanged defaultMode still present: %#v", permissions)
+	}
+	if _, ok := permissions["deny"]; !ok {
+		t.Fatalf("user-edited deny list was removed: %#v", permissions)
+	}
+}
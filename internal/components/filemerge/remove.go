@@ -0,0 +1,89 @@
+package filemerge
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// RemoveFile deletes a file written by an injector. A missing file is not an
+// error; the result reports Changed only when something was actually removed.
+func RemoveFile(path string) (WriteResult, error) {
+	if err := os.Remove(path); err != nil {
+		if os.IsNotExist(err) {
+			return WriteResult{}, nil
+		}
+		return WriteResult{}, fmt.Errorf("remove %q: %w", path, err)
+	}
+
+	return WriteResult{Changed: true}, nil
+}
+
+// RemoveFileAndEmptyParent deletes path and then its parent directory when
+// that directory is left empty, e.g. a skill folder that only held SKILL.md.
+func RemoveFileAndEmptyParent(path string) (WriteResult, error) {
+	result, err := RemoveFile(path)
+	if err != nil {
+		return WriteResult{}, err
+	}
+
+	dir := filepath.Dir(path)
+	entries, err := os.ReadDir(dir)
+	if err == nil && len(entries) == 0 {
+		if err := os.Remove(dir); err != nil && !os.IsNotExist(err) {
+			return WriteResult{}, fmt.Errorf("remove empty directory %q: %w", dir, err)
+		}
+	}
+
+	return result, nil
+}
+
+// RemoveJSONKeysFromFile drops the keys of overlay from the JSON file at path.
+// Missing files are left alone so uninstall never creates empty configs.
+func RemoveJSONKeysFromFile(path string, overlay []byte) (WriteResult, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return WriteResult{}, nil
+		}
+		return WriteResult{}, fmt.Errorf("read json file %q: %w", path, err)
+	}
+
+	pruned, err := RemoveJSONKeys(content, overlay)
+	if err != nil {
+		return WriteResult{}, fmt.Errorf("remove keys from %q: %w", path, err)
+	}
+
+	return WriteFileAtomic(path, pruned, 0o644)
+}
+
+// RemoveMarkdownSectionsFromFile strips the given <!-- gentle-ai:ID --> sections
+// from a markdown file. When nothing but whitespace remains, the file is deleted.
+func RemoveMarkdownSectionsFromFile(path string, sectionIDs ...string) (WriteResult, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return WriteResult{}, nil
+		}
+		return WriteResult{}, fmt.Errorf("read file %q: %w", path, err)
+	}
+
+	updated := string(content)
+	for _, sectionID := range sectionIDs {
+		updated = InjectMarkdownSection(updated, sectionID, "")
+	}
+
+	return WriteOrRemove(path, updated)
+}
+
+// WriteOrRemove writes content to path, or deletes path when content is blank.
+// It is the write primitive for uninstall flows that strip managed content out
+// of a file that may have been created by the installer in the first place.
+func WriteOrRemove(path string, content string) (WriteResult, error) {
+	if strings.TrimSpace(content) == "" {
+		return RemoveFile(path)
+	}
+
+	return WriteFileAtomic(path, []byte(content), 0o644)
+}
@@ -0,0 +1,62 @@
+package filemerge
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRemoveMarkdownSectionsFromFileKeepsUserContent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "CLAUDE.md")
+	content := InjectMarkdownSection("# My rules\n", "persona", "be nice")
+	content = InjectMarkdownSection(content, "sdd-orchestrator", "delegate")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	result, err := RemoveMarkdownSectionsFromFile(path, "persona", "sdd-orchestrator")
+	if err != nil {
+		t.Fatalf("RemoveMarkdownSectionsFromFile() error = %v", err)
+	}
+	if !result.Changed {
+		t.Fatalf("expected Changed = true")
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if string(got) != "# My rules\n" {
+		t.Fatalf("content = %q", string(got))
+	}
+}
+
+func TestRemoveMarkdownSectionsFromFileDeletesManagedOnlyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "CLAUDE.md")
+	if err := os.WriteFile(path, []byte(InjectMarkdownSection("", "persona", "be nice")), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	if _, err := RemoveMarkdownSectionsFromFile(path, "persona"); err != nil {
+		t.Fatalf("RemoveMarkdownSectionsFromFile() error = %v", err)
+	}
+
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("expected %q to be removed, err = %v", path, err)
+	}
+}
+
+func TestRemoveJSONKeysFromFileIgnoresMissingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "settings.json")
+
+	result, err := RemoveJSONKeysFromFile(path, []byte(`{"theme":"x"}`))
+	if err != nil {
+		t.Fatalf("RemoveJSONKeysFromFile() error = %v", err)
+	}
+	if result.Changed {
+		t.Fatalf("expected Changed = false for missing file")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("missing file should not be created, err = %v", err)
+	}
+}
@@ -2,6 +2,7 @@ package filemerge
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 )
 
@@ -84,3 +85,79 @@ func UpsertTopLevelTOMLString(content, key, value string) string {
 
 	return strings.TrimSpace(strings.Join(out, "\n")) + "\n"
 }
+
+// RemoveCodexEngramBlock strips the [mcp_servers.engram] block written by
+// UpsertCodexEngramBlock. All other sections are preserved; content without
+// the block is returned with normalized trailing whitespace only.
+func RemoveCodexEngramBlock(content string) string {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	lines := strings.Split(content, "\n")
+
+	var kept []string
+	for i := 0; i < len(lines); {
+		if strings.TrimSpace(lines[i]) == "[mcp_servers.engram]" {
+			i++
+			for i < len(lines) {
+				next := strings.TrimSpace(lines[i])
+				if strings.HasPrefix(next, "[") && strings.HasSuffix(next, "]") {
+					break
+				}
+				i++
+			}
+			continue
+		}
+
+		kept = append(kept, lines[i])
+		i++
+	}
+
+	base := strings.TrimSpace(strings.Join(kept, "\n"))
+	if base == "" {
+		return ""
+	}
+
+	return base + "\n"
+}
+
+// RemoveTopLevelTOMLString removes every top-level `key = "value"` line
+// written by UpsertTopLevelTOMLString. Occurrences holding any other value and
+// keys inside [section] tables are left untouched.
+func RemoveTopLevelTOMLString(content, key, value string) string {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	lines := strings.Split(content, "\n")
+
+	var kept []string
+	inTable := false
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			inTable = true
+		}
+		if !inTable && topLevelTOMLStringIs(trimmed, key, value) {
+			continue
+		}
+		kept = append(kept, line)
+	}
+
+	base := strings.TrimSpace(strings.Join(kept, "\n"))
+	if base == "" {
+		return ""
+	}
+
+	return base + "\n"
+}
+
+// topLevelTOMLStringIs reports whether the trimmed line assigns the quoted
+// string value to key.
+func topLevelTOMLStringIs(trimmed, key, value string) bool {
+	rest, ok := strings.CutPrefix(trimmed, key)
+	if !ok {
+		return false
+	}
+	rest, ok = strings.CutPrefix(strings.TrimSpace(rest), "=")
+	if !ok {
+		return false
+	}
+	got, err := strconv.Unquote(strings.TrimSpace(rest))
+	return err == nil && got == value
+}
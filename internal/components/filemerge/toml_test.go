@@ -143,3 +143,51 @@ command = "engram"
 		t.Fatalf("UpsertTopLevelTOMLString is not idempotent:\nfirst:\n%s\nsecond:\n%s", first, second)
 	}
 }
+
+// ─── RemoveCodexEngramBlock / RemoveTopLevelTOMLString────────────────────────
+
+func TestRemoveCodexEngramBlock_RoundTrip(t *testing.T) {
+	input := "model = \"o3\"\n\n[other_section]\nkey = \"value\"\n"
+	upserted := UpsertCodexEngramBlock(input)
+
+	result := RemoveCodexEngramBlock(upserted)
+
+	if strings.Contains(result, "[mcp_servers.engram]") {
+		t.Fatalf("engram block still present; got:\n%s", result)
+	}
+	if !strings.Contains(result, "[other_section]") || !strings.Contains(result, `key = "value"`) {
+		t.Fatalf("unrelated section lost; got:\n%s", result)
+	}
+}
+
+func TestRemoveCodexEngramBlock_OnlyBlock(t *testing.T) {
+	if result := RemoveCodexEngramBlock(UpsertCodexEngramBlock("")); result != "" {
+		t.Fatalf("expected empty result, got:\n%s", result)
+	}
+}
+
+func TestRemoveTopLevelTOMLString_KeepsTableKeys(t *testing.T) {
+	input := "model_instructions_file = \"/x\"\nmodel = \"o3\"\n\n[profiles.work]\nmodel_instructions_file = \"/x\"\n"
+
+	result := RemoveTopLevelTOMLString(input, "model_instructions_file", "/x")
+
+	if strings.HasPrefix(result, "model_instructions_file") {
+		t.Fatalf("top-level key still present; got:\n%s", result)
+	}
+	if !strings.Contains(result, "[profiles.work]\nmodel_instructions_file = \"/x\"") {
+		t.Fatalf("table key was removed; got:\n%s", result)
+	}
+	if !strings.Contains(result, `model = "o3"`) {
+		t.Fatalf("unrelated key was removed; got:\n%s", result)
+	}
+}
+
+func TestRemoveTopLevelTOMLString_KeepsOtherValues(t *testing.T) {
+	input := "model_instructions_file = \"/mine.md\"\nmodel = \"o3\"\n"
+
+	result := RemoveTopLevelTOMLString(input, "model_instructions_file", "/x")
+
+	if result != input {
+		t.Fatalf("key with a different value was changed; got:\n%s", result)
+	}
+}
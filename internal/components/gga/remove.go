@@ -0,0 +1,30 @@
+package gga
+
+import (
+	"fmt"
+
+	"github.com/gentleman-programming/gentle-ai/internal/components/filemerge"
+)
+
+// Remove deletes the GGA global config and starter AGENTS.md template written
+// by Inject. The gga binary itself is left installed.
+func Remove(homeDir string) (InjectionResult, error) {
+	configPath := ConfigPath(homeDir)
+	configResult, err := filemerge.RemoveFile(configPath)
+	if err != nil {
+		return InjectionResult{}, fmt.Errorf("remove gga config: %w", err)
+	}
+
+	agentsPath := AgentsTemplatePath(homeDir)
+	agentsResult, err := filemerge.RemoveFileAndEmptyParent(agentsPath)
+	if err != nil {
+		return InjectionResult{}, fmt.Errorf("remove gga AGENTS.md template: %w", err)
+	}
+
+	return InjectionResult{
+		ConfigFile:    configPath,
+		AgentsFile:    agentsPath,
+		ConfigChanged: configResult.Changed,
+		AgentsChanged: agentsResult.Changed,
+	}, nil
+}
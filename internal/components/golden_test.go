@@ -1,6 +1,7 @@
 package components_test
 
 import (
+	"context"
 	"encoding/json"
 	"flag"
 	"os"
@@ -85,7 +86,7 @@ func TestGoldenConfigs(t *testing.T) {
 func TestGoldenSDD_Claude(t *testing.T) {
 	home := t.TempDir()
 
-	result, err := sdd.Inject(home, claudeAdapter(), "")
+	result, err := sdd.Inject(context.Background(), home, claudeAdapter(), "")
 	if err != nil {
 		t.Fatalf("sdd.Inject(claude) error = %v", err)
 	}
@@ -100,7 +101,7 @@ func TestGoldenSDD_Claude(t *testing.T) {
 func TestGoldenSDD_OpenCode(t *testing.T) {
 	home := t.TempDir()
 
-	result, err := sdd.Inject(home, opencodeAdapter(), "")
+	result, err := sdd.Inject(context.Background(), home, opencodeAdapter(), "")
 	if err != nil {
 		t.Fatalf("sdd.Inject(opencode) error = %v", err)
 	}
@@ -133,7 +134,7 @@ func TestGoldenSDD_OpenCode(t *testing.T) {
 func TestGoldenSDD_OpenCode_Multi(t *testing.T) {
 	home := t.TempDir()
 
-	result, err := sdd.Inject(home, opencodeAdapter(), "multi")
+	result, err := sdd.Inject(context.Background(), home, opencodeAdapter(), "multi")
 	if err != nil {
 		t.Fatalf("sdd.Inject(opencode, multi) error = %v", err)
 	}
@@ -160,7 +161,7 @@ func TestGoldenSDD_OpenCode_Multi(t *testing.T) {
 func TestGoldenSDD_Cursor(t *testing.T) {
 	home := t.TempDir()
 
-	result, err := sdd.Inject(home, cursorAdapter(), "")
+	result, err := sdd.Inject(context.Background(), home, cursorAdapter(), "")
 	if err != nil {
 		t.Fatalf("sdd.Inject(cursor) error = %v", err)
 	}
@@ -193,7 +194,7 @@ func TestGoldenSDD_Cursor(t *testing.T) {
 func TestGoldenSDD_Gemini(t *testing.T) {
 	home := t.TempDir()
 
-	result, err := sdd.Inject(home, geminiAdapter(), "")
+	result, err := sdd.Inject(context.Background(), home, geminiAdapter(), "")
 	if err != nil {
 		t.Fatalf("sdd.Inject(gemini) error = %v", err)
 	}
@@ -229,7 +230,7 @@ func TestGoldenSDD_VSCode(t *testing.T) {
 
 	adapter := vscodeAdapter()
 
-	result, err := sdd.Inject(home, adapter, "")
+	result, err := sdd.Inject(context.Background(), home, adapter, "")
 	if err != nil {
 		t.Fatalf("sdd.Inject(vscode) error = %v", err)
 	}
@@ -263,7 +264,7 @@ func TestGoldenSDD_VSCode(t *testing.T) {
 func TestGoldenSDD_Codex(t *testing.T) {
 	home := t.TempDir()
 
-	result, err := sdd.Inject(home, codexAdapter(), "")
+	result, err := sdd.Inject(context.Background(), home, codexAdapter(), "")
 	if err != nil {
 		t.Fatalf("sdd.Inject(codex) error = %v", err)
 	}
@@ -487,7 +488,7 @@ func TestGoldenCombined_Claude(t *testing.T) {
 	if _, err := persona.Inject(home, claudeAdapter(), model.PersonaGentleman); err != nil {
 		t.Fatalf("persona.Inject error = %v", err)
 	}
-	if _, err := sdd.Inject(home, claudeAdapter(), ""); err != nil {
+	if _, err := sdd.Inject(context.Background(), home, claudeAdapter(), ""); err != nil {
 		t.Fatalf("sdd.Inject error = %v", err)
 	}
 	if _, err := engram.Inject(home, claudeAdapter()); err != nil {
@@ -0,0 +1,56 @@
+package mcp
+
+import (
+	"github.com/gentleman-programming/gentle-ai/internal/agents"
+	"github.com/gentleman-programming/gentle-ai/internal/components/filemerge"
+	"github.com/gentleman-programming/gentle-ai/internal/model"
+)
+
+// Remove undoes Inject: the Context7 server file or merged server entry is
+// removed for the adapter's MCP strategy. Other servers are left untouched.
+func Remove(homeDir string, adapter agents.Adapter) (InjectionResult, error) {
+	if !adapter.SupportsMCP() {
+		return InjectionResult{}, nil
+	}
+
+	var (
+		path    string
+		overlay []byte
+	)
+
+	switch adapter.MCPStrategy() {
+	case model.StrategySeparateMCPFiles:
+		path = adapter.MCPConfigPath(homeDir, "context7")
+		result, err := filemerge.RemoveFile(path)
+		if err != nil {
+			return InjectionResult{}, err
+		}
+		return InjectionResult{Changed: result.Changed, Files: []string{path}}, nil
+	case model.StrategyMergeIntoSettings:
+		path = adapter.SettingsPath(homeDir)
+		overlay = DefaultContext7OverlayJSON()
+		if adapter.Agent() == model.AgentOpenCode {
+			overlay = OpenCodeContext7OverlayJSON()
+		}
+	case model.StrategyMCPConfigFile:
+		path = adapter.MCPConfigPath(homeDir, "context7")
+		overlay = DefaultContext7OverlayJSON()
+		if adapter.Agent() == model.AgentVSCodeCopilot {
+			overlay = VSCodeContext7OverlayJSON()
+		}
+	default:
+		// TOML agents never received a Context7 entry.
+		return InjectionResult{}, nil
+	}
+
+	if path == "" {
+		return InjectionResult{}, nil
+	}
+
+	result, err := filemerge.RemoveJSONKeysFromFile(path, overlay)
+	if err != nil {
+		return InjectionResult{}, err
+	}
+
+	return InjectionResult{Changed: result.Changed, Files: []string{path}}, nil
+}
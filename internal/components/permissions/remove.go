@@ -0,0 +1,26 @@
+package permissions
+
+import (
+	"github.com/gentleman-programming/gentle-ai/internal/agents"
+	"github.com/gentleman-programming/gentle-ai/internal/components/filemerge"
+)
+
+// Remove drops the permission keys Inject merged into the agent's settings file.
+func Remove(homeDir string, adapter agents.Adapter) (InjectionResult, error) {
+	settingsPath := adapter.SettingsPath(homeDir)
+	if settingsPath == "" {
+		return InjectionResult{}, nil
+	}
+
+	overlay := agentOverlay(adapter.Agent())
+	if overlay == nil {
+		return InjectionResult{}, nil
+	}
+
+	writeResult, err := filemerge.RemoveJSONKeysFromFile(settingsPath, overlay)
+	if err != nil {
+		return InjectionResult{}, err
+	}
+
+	return InjectionResult{Changed: writeResult.Changed, Files: []string{settingsPath}}, nil
+}
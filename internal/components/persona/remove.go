@@ -0,0 +1,113 @@
+package persona
+
+import (
+	"strings"
+
+	"github.com/gentleman-programming/gentle-ai/internal/agents"
+	"github.com/gentleman-programming/gentle-ai/internal/components/filemerge"
+	"github.com/gentleman-programming/gentle-ai/internal/model"
+)
+
+// openCodeGentlemanAgentOverlayJSON is the part of openCodeAgentOverlayJSON that
+// belongs to the persona alone. The "sdd-orchestrator" agent is shared with the
+// SDD component, which removes it on its own uninstall.
+var openCodeGentlemanAgentOverlayJSON = []byte("{\n  \"agent\": {\n    \"gentleman\": {}\n  }\n}\n")
+
+// Remove undoes Inject for any managed persona (gentleman or neutral).
+//
+// Marker-based prompts lose their persona section. Whole-file prompts are only
+// touched when they still start with the exact persona content we wrote, so a
+// custom persona written by the user is never deleted.
+func Remove(homeDir string, adapter agents.Adapter) (InjectionResult, error) {
+	if !adapter.SupportsSystemPrompt() {
+		return InjectionResult{}, nil
+	}
+
+	files := make([]string, 0, 3)
+	changed := false
+	track := func(path string, result filemerge.WriteResult) {
+		changed = changed || result.Changed
+		files = append(files, path)
+	}
+
+	promptPath := adapter.SystemPromptFile(homeDir)
+	switch adapter.SystemPromptStrategy() {
+	case model.StrategyMarkdownSections:
+		result, err := filemerge.RemoveMarkdownSectionsFromFile(promptPath, "persona")
+		if err != nil {
+			return InjectionResult{}, err
+		}
+		track(promptPath, result)
+
+	default:
+		existing, err := readFileOrEmpty(promptPath)
+		if err != nil {
+			return InjectionResult{}, err
+		}
+
+		remaining, ok := stripManagedPersona(adapter, existing)
+		if ok {
+			result, err := filemerge.WriteOrRemove(promptPath, remaining)
+			if err != nil {
+				return InjectionResult{}, err
+			}
+			track(promptPath, result)
+		}
+	}
+
+	if adapter.Agent() == model.AgentOpenCode {
+		if settingsPath := adapter.SettingsPath(homeDir); settingsPath != "" {
+			result, err := filemerge.RemoveJSONKeysFromFile(settingsPath, openCodeGentlemanAgentOverlayJSON)
+			if err != nil {
+				return InjectionResult{}, err
+			}
+			track(settingsPath, result)
+		}
+	}
+
+	if adapter.SupportsOutputStyles() {
+		if outputStyleDir := adapter.OutputStyleDir(homeDir); outputStyleDir != "" {
+			outputStylePath := outputStyleDir + "/gentleman.md"
+			result, err := filemerge.RemoveFile(outputStylePath)
+			if err != nil {
+				return InjectionResult{}, err
+			}
+			track(outputStylePath, result)
+		}
+
+		if settingsPath := adapter.SettingsPath(homeDir); settingsPath != "" {
+			result, err := filemerge.RemoveJSONKeysFromFile(settingsPath, outputStyleOverlayJSON)
+			if err != nil {
+				return InjectionResult{}, err
+			}
+			track(settingsPath, result)
+		}
+	}
+
+	return InjectionResult{Changed: changed, Files: files}, nil
+}
+
+// stripManagedPersona removes a managed persona from the start of a whole-file
+// system prompt. It reports false when the file does not begin with any persona
+// content we could have written, meaning the file belongs to the user.
+func stripManagedPersona(adapter agents.Adapter, existing string) (string, bool) {
+	instructions := adapter.SystemPromptStrategy() == model.StrategyInstructionsFile
+
+	for _, persona := range []model.PersonaID{model.PersonaGentleman, model.PersonaNeutral} {
+		content := personaContent(adapter.Agent(), persona)
+		if instructions {
+			content = wrapInstructionsFile(content)
+		}
+		if content == "" || !strings.HasPrefix(existing, content) {
+			continue
+		}
+
+		remaining := strings.TrimLeft(strings.TrimPrefix(existing, content), "\n")
+		if instructions && strings.TrimSpace(remaining) != "" {
+			remaining = wrapInstructionsFile(remaining)
+		}
+		return remaining, true
+	}
+
+	return existing, false
+}
@@ -0,0 +1,67 @@
+package persona
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gentleman-programming/gentle-ai/internal/model"
+)
+
+func TestRemoveClaudeGentlemanUndoesInject(t *testing.T) {
+	home := t.TempDir()
+
+	if _, err := Inject(home, claudeAdapter(), model.PersonaGentleman); err != nil {
+		t.Fatalf("Inject() error = %v", err)
+	}
+
+	result, err := Remove(home, claudeAdapter())
+	if err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if !result.Changed {
+		t.Fatalf("Remove() changed = false")
+	}
+
+	for _, path := range []string{
+		filepath.Join(home, ".claude", "CLAUDE.md"),
+		filepath.Join(home, ".claude", "output-styles", "gentleman.md"),
+	} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Fatalf("expected %q to be removed, stat error = %v", path, err)
+		}
+	}
+
+	settings, err := os.ReadFile(filepath.Join(home, ".claude", "settings.json"))
+	if err != nil {
+		t.Fatalf("ReadFile(settings.json) error = %v", err)
+	}
+	if strings.Contains(string(settings), "outputStyle") {
+		t.Fatalf("settings.json still has outputStyle: %s", settings)
+	}
+}
+
+func TestRemoveOpenCodeKeepsCustomPersona(t *testing.T) {
+	home := t.TempDir()
+	promptPath := filepath.Join(home, ".config", "opencode", "AGENTS.md")
+	if err := os.MkdirAll(filepath.Dir(promptPath), 0o755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	custom := "# My own persona\n"
+	if err := os.WriteFile(promptPath, []byte(custom), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	if _, err := Remove(home, opencodeAdapter()); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+
+	content, err := os.ReadFile(promptPath)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if string(content) != custom {
+		t.Fatalf("custom AGENTS.md was modified: %q", string(content))
+	}
+}
@@ -1,6 +1,7 @@
 package sdd
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/fs"
@@ -20,10 +21,26 @@ type InjectionResult struct {
 	Files   []string
 }
 
+// SharedFiles are the convention files every SDD skill references from
+// _shared/ in the agent's skills directory.
+var SharedFiles = []string{
+	"persistence-contract.md",
+	"engram-convention.md",
+	"openspec-convention.md",
+	"sdd-phase-common.md",
+}
+
+// Skills are the SDD phase skills written into the agent's skills directory,
+// one directory each.
+var Skills = []string{
+	"sdd-init", "sdd-explore", "sdd-propose", "sdd-spec",
+	"sdd-design", "sdd-tasks", "sdd-apply", "sdd-verify", "sdd-archive",
+}
+
 var (
 	npmLookPath = exec.LookPath
-	npmRun      = func(dir string, args ...string) ([]byte, error) {
-		cmd := exec.Command(args[0], args[1:]...)
+	npmRun      = func(ctx context.Context, dir string, args ...string) ([]byte, error) {
+		cmd := exec.CommandContext(ctx, args[0], args[1:]...)
 		cmd.Dir = dir
 		// CombinedOutput captures stdout+stderr so we can surface actionable
 		// error messages on failure. Do not set Stdout/Stderr separately.
@@ -41,7 +58,10 @@ func overlayAssetPath(sddMode model.SDDModeID) string {
 	return "opencode/sdd-overlay-single.json"
 }
 
-func Inject(homeDir string, adapter agents.Adapter, sddMode model.SDDModeID, modelAssignments ...map[string]model.ModelAssignment) (InjectionResult, error) {
+// Inject writes the SDD orchestrator, commands and skills for adapter. For
+// OpenCode it may also install the background-agents plugin dependency with
+// bun or npm; cancelling ctx stops that install.
+func Inject(ctx context.Context, homeDir string, adapter agents.Adapter, sddMode model.SDDModeID, modelAssignments ...map[string]model.ModelAssignment) (InjectionResult, error) {
 	if !adapter.SupportsSystemPrompt() {
 		return InjectionResult{}, nil
 	}
@@ -140,7 +160,7 @@ func Inject(homeDir string, adapter agents.Adapter, sddMode model.SDDModeID, mod
 			mergedSettingsBytes = agentResult.merged
 
 			// Install OpenCode plugins (all SDD modes).
-			pluginResult, err := installOpenCodePlugins(homeDir)
+			pluginResult, err := installOpenCodePlugins(ctx, homeDir)
 			if err != nil {
 				return InjectionResult{}, err
 			}
@@ -153,14 +173,7 @@ func Inject(homeDir string, adapter agents.Adapter, sddMode model.SDDModeID, mod
 	if adapter.SupportsSkills() {
 		skillDir := adapter.SkillsDir(homeDir)
 		if skillDir != "" {
-			sharedFiles := []string{
-				"persistence-contract.md",
-				"engram-convention.md",
-				"openspec-convention.md",
-				"sdd-phase-common.md",
-			}
-
-			for _, fileName := range sharedFiles {
+			for _, fileName := range SharedFiles {
 				assetPath := "skills/_shared/" + fileName
 				content, readErr := assets.Read(assetPath)
 				if readErr != nil {
@@ -180,12 +193,7 @@ func Inject(homeDir string, adapter agents.Adapter, sddMode model.SDDModeID, mod
 				files = append(files, path)
 			}
 
-			sddSkills := []string{
-				"sdd-init", "sdd-explore", "sdd-propose", "sdd-spec",
-				"sdd-design", "sdd-tasks", "sdd-apply", "sdd-verify", "sdd-archive",
-			}
-
-			for _, skill := range sddSkills {
+			for _, skill := range Skills {
 				assetPath := "skills/" + skill + "/SKILL.md"
 				content, readErr := assets.Read(assetPath)
 				if readErr != nil {
@@ -246,7 +254,7 @@ func Inject(homeDir string, adapter agents.Adapter, sddMode model.SDDModeID, mod
 // npm/bun dependency into ~/.config/opencode/. Returns an error with an
 // actionable message if the package manager is present but the install fails.
 // If no package manager is available, the install is skipped (soft failure).
-func installOpenCodePlugins(homeDir string) (InjectionResult, error) {
+func installOpenCodePlugins(ctx context.Context, homeDir string) (InjectionResult, error) {
 	opencodeDir := filepath.Join(homeDir, ".config", "opencode")
 	pluginsDir := filepath.Join(opencodeDir, "plugins")
 
@@ -262,24 +270,38 @@ func installOpenCodePlugins(homeDir string) (InjectionResult, error) {
 		return InjectionResult{}, fmt.Errorf("write plugin: %w", err)
 	}
 
-	files := []string{pluginPath}
-	changed := writeResult.Changed
+	if err := InstallOpenCodePluginDependency(ctx, homeDir); err != nil {
+		return InjectionResult{}, err
+	}
+
+	return InjectionResult{Changed: writeResult.Changed, Files: []string{pluginPath}}, nil
+}
+
+// InstallOpenCodePluginDependency installs the npm/bun dependency of the
+// background-agents plugin into ~/.config/opencode/ when it is missing.
+// Inject calls it too; calling it first keeps the network-bound install out
+// of the file writes that follow.
+func InstallOpenCodePluginDependency(ctx context.Context, homeDir string) error {
+	opencodeDir := filepath.Join(homeDir, ".config", "opencode")
 
 	// Install dependency — prefer bun (OpenCode uses it), fall back to npm.
 	// If neither is available, skip with a soft no-op (npm/bun not installed).
 	// If a package manager IS found and the install fails, surface the error.
-	depPkg := "unique-names-generator"
-	nmPath := filepath.Join(opencodeDir, "node_modules", depPkg)
+	depPkg := openCodePluginDependency
+	nmPath := OpenCodePluginDependencyPath(homeDir)
 
 	// Only run the install if the package is not already present.
 	pkgMissing := false
 	pkgMgrRan := false
 	if _, statErr := os.Stat(nmPath); os.IsNotExist(statErr) {
 		pkgMissing = true
+		if err := os.MkdirAll(opencodeDir, 0o755); err != nil {
+			return fmt.Errorf("create opencode dir: %w", err)
+		}
 		var installErr error
-		pkgMgrRan, installErr = runPkgInstall(opencodeDir, depPkg)
+		pkgMgrRan, installErr = runPkgInstall(ctx, opencodeDir, depPkg)
 		if installErr != nil {
-			return InjectionResult{}, installErr
+			return installErr
 		}
 	}
 
@@ -289,7 +311,7 @@ func installOpenCodePlugins(homeDir string) (InjectionResult, error) {
 		if _, statErr := os.Stat(nmPath); os.IsNotExist(statErr) {
 			// Package manager reported success but the package still isn't there.
 			// This is unusual (e.g. bun wrote to a different location). Surface it.
-			return InjectionResult{}, fmt.Errorf(
+			return fmt.Errorf(
 				"post-install check: %q was not found after install in %q — "+
 					"the background-agents plugin will fail to load.\n"+
 					"Fix: run `cd %s && bun add %s` (or npm install %s) manually",
@@ -298,17 +320,26 @@ func installOpenCodePlugins(homeDir string) (InjectionResult, error) {
 		}
 	}
 
-	return InjectionResult{Changed: changed, Files: files}, nil
+	return nil
+}
+
+// openCodePluginDependency is the npm package the background-agents plugin imports.
+const openCodePluginDependency = "unique-names-generator"
+
+// OpenCodePluginDependencyPath returns where the plugin's npm dependency is
+// installed. Inject only runs bun/npm when this directory is missing.
+func OpenCodePluginDependencyPath(homeDir string) string {
+	return filepath.Join(homeDir, ".config", "opencode", "node_modules", openCodePluginDependency)
 }
 
 // runPkgInstall installs a node package in the given directory using bun (if
 // available) or npm. Returns (true, nil) on success, (false, nil) if no
 // package manager is found (soft skip), or (true, error) with a descriptive,
 // actionable message if a package manager was found but the install failed.
-func runPkgInstall(dir, pkg string) (ran bool, err error) {
+func runPkgInstall(ctx context.Context, dir, pkg string) (ran bool, err error) {
 	// Prefer bun — OpenCode ships with bun.lock and recommends bun.
 	if bunPath, lookErr := npmLookPath("bun"); lookErr == nil {
-		out, runErr := npmRun(dir, bunPath, "add", pkg)
+		out, runErr := npmRun(ctx, dir, bunPath, "add", pkg)
 		if runErr != nil {
 			return true, fmt.Errorf(
 				"bun add %s failed in %s: %w\nOutput: %s\nFix: run `cd %s && bun add %s` manually",
@@ -320,7 +351,7 @@ func runPkgInstall(dir, pkg string) (ran bool, err error) {
 
 	// Fall back to npm.
 	if npmPath, lookErr := npmLookPath("npm"); lookErr == nil {
-		out, runErr := npmRun(dir, npmPath, "install", "--save", pkg)
+		out, runErr := npmRun(ctx, dir, npmPath, "install", "--save", pkg)
 		if runErr != nil {
 			return true, fmt.Errorf(
 				"npm install %s failed in %s: %w\nOutput: %s\nFix: run `cd %s && npm install %s` manually",
@@ -1,7 +1,9 @@
 package sdd
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -22,7 +24,7 @@ func opencodeAdapter() agents.Adapter { return opencode.NewAdapter() }
 func TestInjectClaudeWritesSectionMarkers(t *testing.T) {
 	home := t.TempDir()
 
-	result, err := Inject(home, claudeAdapter(), "")
+	result, err := Inject(context.Background(), home, claudeAdapter(), "")
 	if err != nil {
 		t.Fatalf("Inject() error = %v", err)
 	}
@@ -64,7 +66,7 @@ func TestInjectClaudePreservesExistingSections(t *testing.T) {
 		t.Fatalf("WriteFile() error = %v", err)
 	}
 
-	_, err := Inject(home, claudeAdapter(), "")
+	_, err := Inject(context.Background(), home, claudeAdapter(), "")
 	if err != nil {
 		t.Fatalf("Inject() error = %v", err)
 	}
@@ -86,7 +88,7 @@ func TestInjectClaudePreservesExistingSections(t *testing.T) {
 func TestInjectClaudeIsIdempotent(t *testing.T) {
 	home := t.TempDir()
 
-	first, err := Inject(home, claudeAdapter(), "")
+	first, err := Inject(context.Background(), home, claudeAdapter(), "")
 	if err != nil {
 		t.Fatalf("Inject() first error = %v", err)
 	}
@@ -94,7 +96,7 @@ func TestInjectClaudeIsIdempotent(t *testing.T) {
 		t.Fatalf("Inject() first changed = false")
 	}
 
-	second, err := Inject(home, claudeAdapter(), "")
+	second, err := Inject(context.Background(), home, claudeAdapter(), "")
 	if err != nil {
 		t.Fatalf("Inject() second error = %v", err)
 	}
@@ -106,7 +108,7 @@ func TestInjectClaudeIsIdempotent(t *testing.T) {
 func TestInjectOpenCodeWritesCommandFiles(t *testing.T) {
 	home := t.TempDir()
 
-	result, err := Inject(home, opencodeAdapter(), "")
+	result, err := Inject(context.Background(), home, opencodeAdapter(), "")
 	if err != nil {
 		t.Fatalf("Inject() error = %v", err)
 	}
@@ -162,7 +164,7 @@ func TestInjectOpenCodeWritesCommandFiles(t *testing.T) {
 func TestInjectOpenCodeIsIdempotent(t *testing.T) {
 	home := t.TempDir()
 
-	first, err := Inject(home, opencodeAdapter(), "")
+	first, err := Inject(context.Background(), home, opencodeAdapter(), "")
 	if err != nil {
 		t.Fatalf("Inject() first error = %v", err)
 	}
@@ -170,7 +172,7 @@ func TestInjectOpenCodeIsIdempotent(t *testing.T) {
 		t.Fatalf("Inject() first changed = false")
 	}
 
-	second, err := Inject(home, opencodeAdapter(), "")
+	second, err := Inject(context.Background(), home, opencodeAdapter(), "")
 	if err != nil {
 		t.Fatalf("Inject() second error = %v", err)
 	}
@@ -199,7 +201,7 @@ func TestInjectOpenCodeMigratesLegacyAgentsKey(t *testing.T) {
 		t.Fatalf("WriteFile(opencode.json) error = %v", err)
 	}
 
-	if _, err := Inject(home, opencodeAdapter(), ""); err != nil {
+	if _, err := Inject(context.Background(), home, opencodeAdapter(), ""); err != nil {
 		t.Fatalf("Inject() error = %v", err)
 	}
 
@@ -243,7 +245,7 @@ func TestInjectCursorWritesSDDOrchestratorAndSkills(t *testing.T) {
 		t.Fatalf("NewAdapter(cursor) error = %v", err)
 	}
 
-	result, injectErr := Inject(home, cursorAdapter, "")
+	result, injectErr := Inject(context.Background(), home, cursorAdapter, "")
 	if injectErr != nil {
 		t.Fatalf("Inject(cursor) error = %v", injectErr)
 	}
@@ -281,7 +283,7 @@ func TestInjectGeminiWritesSDDOrchestratorAndSkills(t *testing.T) {
 		t.Fatalf("NewAdapter(gemini-cli) error = %v", err)
 	}
 
-	result, injectErr := Inject(home, geminiAdapter, "")
+	result, injectErr := Inject(context.Background(), home, geminiAdapter, "")
 	if injectErr != nil {
 		t.Fatalf("Inject(gemini) error = %v", injectErr)
 	}
@@ -318,7 +320,7 @@ func TestInjectVSCodeWritesSDDOrchestratorAndSkills(t *testing.T) {
 		t.Fatalf("NewAdapter(vscode-copilot) error = %v", err)
 	}
 
-	result, injectErr := Inject(home, vscodeAdapter, "")
+	result, injectErr := Inject(context.Background(), home, vscodeAdapter, "")
 	if injectErr != nil {
 		t.Fatalf("Inject(vscode) error = %v", injectErr)
 	}
@@ -360,7 +362,7 @@ func TestInjectFileAppendSkipsIfAlreadyPresent(t *testing.T) {
 	}
 
 	// First injection.
-	first, firstErr := Inject(home, cursorAdapter, "")
+	first, firstErr := Inject(context.Background(), home, cursorAdapter, "")
 	if firstErr != nil {
 		t.Fatalf("Inject() first error = %v", firstErr)
 	}
@@ -369,7 +371,7 @@ func TestInjectFileAppendSkipsIfAlreadyPresent(t *testing.T) {
 	}
 
 	// Second injection — SDD content is already there, should not duplicate.
-	second, secondErr := Inject(home, cursorAdapter, "")
+	second, secondErr := Inject(context.Background(), home, cursorAdapter, "")
 	if secondErr != nil {
 		t.Fatalf("Inject() second error = %v", secondErr)
 	}
@@ -396,7 +398,7 @@ func TestInjectFileAppendSkipsLegacyHeading(t *testing.T) {
 		t.Fatalf("WriteFile() error = %v", err)
 	}
 
-	result, injectErr := Inject(home, cursorAdapter, "")
+	result, injectErr := Inject(context.Background(), home, cursorAdapter, "")
 	if injectErr != nil {
 		t.Fatalf("Inject() error = %v", injectErr)
 	}
@@ -418,7 +420,7 @@ func TestInjectFileAppendSkipsLegacyHeading(t *testing.T) {
 func TestInjectOpenCodeMultiMode(t *testing.T) {
 	home := t.TempDir()
 
-	result, err := Inject(home, opencodeAdapter(), "multi")
+	result, err := Inject(context.Background(), home, opencodeAdapter(), "multi")
 	if err != nil {
 		t.Fatalf("Inject(multi) error = %v", err)
 	}
@@ -512,7 +514,7 @@ func TestInjectOpenCodeMultiMode(t *testing.T) {
 func TestInjectOpenCodeMultiModeIdempotent(t *testing.T) {
 	home := t.TempDir()
 
-	first, err := Inject(home, opencodeAdapter(), "multi")
+	first, err := Inject(context.Background(), home, opencodeAdapter(), "multi")
 	if err != nil {
 		t.Fatalf("Inject(multi) first error = %v", err)
 	}
@@ -520,7 +522,7 @@ func TestInjectOpenCodeMultiModeIdempotent(t *testing.T) {
 		t.Fatal("Inject(multi) first changed = false")
 	}
 
-	second, err := Inject(home, opencodeAdapter(), "multi")
+	second, err := Inject(context.Background(), home, opencodeAdapter(), "multi")
 	if err != nil {
 		t.Fatalf("Inject(multi) second error = %v", err)
 	}
@@ -541,7 +543,7 @@ func TestInjectOpenCodeMultiModeIdempotent(t *testing.T) {
 func TestInjectOpenCodeEmptySDDModeDefaultsSingle(t *testing.T) {
 	home := t.TempDir()
 
-	result, err := Inject(home, opencodeAdapter(), "")
+	result, err := Inject(context.Background(), home, opencodeAdapter(), "")
 	if err != nil {
 		t.Fatalf("Inject(\"\") error = %v", err)
 	}
@@ -583,13 +585,13 @@ func TestInjectClaudeIgnoresSDDMode(t *testing.T) {
 	home := t.TempDir()
 
 	// Inject with multi mode for Claude — should be ignored.
-	resultMulti, err := Inject(home, claudeAdapter(), "multi")
+	resultMulti, err := Inject(context.Background(), home, claudeAdapter(), "multi")
 	if err != nil {
 		t.Fatalf("Inject(claude, multi) error = %v", err)
 	}
 
 	homeBaseline := t.TempDir()
-	resultSingle, err := Inject(homeBaseline, claudeAdapter(), "single")
+	resultSingle, err := Inject(context.Background(), homeBaseline, claudeAdapter(), "single")
 	if err != nil {
 		t.Fatalf("Inject(claude, single) error = %v", err)
 	}
@@ -618,7 +620,7 @@ func TestInjectOpenCodeSingleToMultiSwitch(t *testing.T) {
 	home := t.TempDir()
 
 	// First: inject single mode.
-	_, err := Inject(home, opencodeAdapter(), "single")
+	_, err := Inject(context.Background(), home, opencodeAdapter(), "single")
 	if err != nil {
 		t.Fatalf("Inject(single) error = %v", err)
 	}
@@ -632,7 +634,7 @@ func TestInjectOpenCodeSingleToMultiSwitch(t *testing.T) {
 	}
 
 	// Second: inject multi mode.
-	result, err := Inject(home, opencodeAdapter(), "multi")
+	result, err := Inject(context.Background(), home, opencodeAdapter(), "multi")
 	if err != nil {
 		t.Fatalf("Inject(multi) error = %v", err)
 	}
@@ -677,7 +679,7 @@ func TestInjectFileAppendSkipsAgentTeamsHeading(t *testing.T) {
 		t.Fatalf("WriteFile() error = %v", err)
 	}
 
-	result, injectErr := Inject(home, cursorAdapter, "")
+	result, injectErr := Inject(context.Background(), home, cursorAdapter, "")
 	if injectErr != nil {
 		t.Fatalf("Inject() error = %v", injectErr)
 	}
@@ -709,7 +711,7 @@ func TestInjectClaudeDeduplicatesBareOrchestratorSection(t *testing.T) {
 		t.Fatalf("WriteFile() error = %v", err)
 	}
 
-	result, err := Inject(home, claudeAdapter(), "")
+	result, err := Inject(context.Background(), home, claudeAdapter(), "")
 	if err != nil {
 		t.Fatalf("Inject() error = %v", err)
 	}
@@ -767,7 +769,7 @@ func TestInjectClaudeDeduplicatesBareOrchestratorAtEndOfFile(t *testing.T) {
 		t.Fatalf("WriteFile() error = %v", err)
 	}
 
-	_, err := Inject(home, claudeAdapter(), "")
+	_, err := Inject(context.Background(), home, claudeAdapter(), "")
 	if err != nil {
 		t.Fatalf("Inject() error = %v", err)
 	}
@@ -798,7 +800,7 @@ func TestInjectOpenCodeMultiModeWithModelAssignments(t *testing.T) {
 		"sdd-apply": {ProviderID: "openai", ModelID: "gpt-4o"},
 	}
 
-	result, err := Inject(home, opencodeAdapter(), "multi", assignments)
+	result, err := Inject(context.Background(), home, opencodeAdapter(), "multi", assignments)
 	if err != nil {
 		t.Fatalf("Inject(multi, assignments) error = %v", err)
 	}
@@ -854,7 +856,7 @@ func TestInjectOpenCodeMultiModeNoAssignmentsNoModel(t *testing.T) {
 	home := t.TempDir()
 
 	// Pass nil assignments — no model fields should be injected.
-	result, err := Inject(home, opencodeAdapter(), "multi")
+	result, err := Inject(context.Background(), home, opencodeAdapter(), "multi")
 	if err != nil {
 		t.Fatalf("Inject(multi) error = %v", err)
 	}
@@ -893,7 +895,7 @@ func TestInjectSingleModeIgnoresModelAssignments(t *testing.T) {
 		"sdd-init": {ProviderID: "anthropic", ModelID: "claude-sonnet-4-20250514"},
 	}
 
-	result, err := Inject(home, opencodeAdapter(), "single", assignments)
+	result, err := Inject(context.Background(), home, opencodeAdapter(), "single", assignments)
 	if err != nil {
 		t.Fatalf("Inject(single, assignments) error = %v", err)
 	}
@@ -924,7 +926,7 @@ func TestInjectSingleModeIgnoresModelAssignments(t *testing.T) {
 func TestInjectWritesAllFourSharedFilesToDisk(t *testing.T) {
 	home := t.TempDir()
 
-	result, err := Inject(home, opencodeAdapter(), "")
+	result, err := Inject(context.Background(), home, opencodeAdapter(), "")
 	if err != nil {
 		t.Fatalf("Inject() error = %v", err)
 	}
@@ -976,7 +978,7 @@ func TestInjectSharedDirCreatedWithAllFiles(t *testing.T) {
 		t.Fatal("precondition failed: _shared dir already exists")
 	}
 
-	if _, err := Inject(home, opencodeAdapter(), ""); err != nil {
+	if _, err := Inject(context.Background(), home, opencodeAdapter(), ""); err != nil {
 		t.Fatalf("Inject() error = %v", err)
 	}
 
@@ -1100,7 +1102,7 @@ func TestInjectClaudeDeduplicatesBareOrchestratorAtBeginning(t *testing.T) {
 		t.Fatalf("WriteFile() error = %v", err)
 	}
 
-	_, err := Inject(home, claudeAdapter(), "")
+	_, err := Inject(context.Background(), home, claudeAdapter(), "")
 	if err != nil {
 		t.Fatalf("Inject() error = %v", err)
 	}
@@ -1142,7 +1144,7 @@ func TestInjectClaudeDeduplicatesFileWithOnlyBareOrchestrator(t *testing.T) {
 		t.Fatalf("WriteFile() error = %v", err)
 	}
 
-	_, err := Inject(home, claudeAdapter(), "")
+	_, err := Inject(context.Background(), home, claudeAdapter(), "")
 	if err != nil {
 		t.Fatalf("Inject() error = %v", err)
 	}
@@ -1187,12 +1189,12 @@ func TestInjectClaudeDeduplicatesBareOrchestratorIsIdempotent(t *testing.T) {
 	}
 
 	// First inject — strips bare, inserts marked section.
-	if _, err := Inject(home, claudeAdapter(), ""); err != nil {
+	if _, err := Inject(context.Background(), home, claudeAdapter(), ""); err != nil {
 		t.Fatalf("Inject() first error = %v", err)
 	}
 
 	// Second inject — must be a no-op (already has markers).
-	second, err := Inject(home, claudeAdapter(), "")
+	second, err := Inject(context.Background(), home, claudeAdapter(), "")
 	if err != nil {
 		t.Fatalf("Inject() second error = %v", err)
 	}
@@ -1223,7 +1225,7 @@ func TestInjectClaudeDoesNotStripMarkedSection(t *testing.T) {
 	}
 
 	// Pre-inject once to produce the canonical marked state.
-	if _, err := Inject(home, claudeAdapter(), ""); err != nil {
+	if _, err := Inject(context.Background(), home, claudeAdapter(), ""); err != nil {
 		t.Fatalf("first Inject() error = %v", err)
 	}
 
@@ -1237,7 +1239,7 @@ func TestInjectClaudeDoesNotStripMarkedSection(t *testing.T) {
 	}
 
 	// Second inject — must not change the file.
-	second, err := Inject(home, claudeAdapter(), "")
+	second, err := Inject(context.Background(), home, claudeAdapter(), "")
 	if err != nil {
 		t.Fatalf("second Inject() error = %v", err)
 	}
@@ -1253,7 +1255,7 @@ func TestInjectClaudeDoesNotStripMarkedSection(t *testing.T) {
 func TestInjectOpenCodeMultiWritesPlugin(t *testing.T) {
 	home := t.TempDir()
 
-	result, err := Inject(home, opencodeAdapter(), "multi")
+	result, err := Inject(context.Background(), home, opencodeAdapter(), "multi")
 	if err != nil {
 		t.Fatalf("Inject(multi) error = %v", err)
 	}
@@ -1291,7 +1293,7 @@ func TestInjectOpenCodeMultiWritesPlugin(t *testing.T) {
 func TestInjectOpenCodeSingleWritesPlugin(t *testing.T) {
 	home := t.TempDir()
 
-	_, err := Inject(home, opencodeAdapter(), "single")
+	_, err := Inject(context.Background(), home, opencodeAdapter(), "single")
 	if err != nil {
 		t.Fatalf("Inject(single) error = %v", err)
 	}
@@ -1313,7 +1315,7 @@ func TestInjectOpenCodePluginNoPkgManagerAvailable(t *testing.T) {
 	home := t.TempDir()
 
 	// Assert: inject succeeds even when no package manager is available (soft skip).
-	result, err := Inject(home, opencodeAdapter(), "multi")
+	result, err := Inject(context.Background(), home, opencodeAdapter(), "multi")
 	if err != nil {
 		t.Fatalf("Inject(multi) with no package manager error = %v", err)
 	}
@@ -1340,7 +1342,7 @@ func TestInjectOpenCodePluginNpmFailureReturnsActionableError(t *testing.T) {
 		}
 		return "", fmt.Errorf("not found")
 	}
-	npmRun = func(dir string, args ...string) ([]byte, error) {
+	npmRun = func(_ context.Context, dir string, args ...string) ([]byte, error) {
 		return []byte("ERR! some npm error"), fmt.Errorf("exit status 1")
 	}
 	defer func() {
@@ -1350,7 +1352,7 @@ func TestInjectOpenCodePluginNpmFailureReturnsActionableError(t *testing.T) {
 
 	home := t.TempDir()
 
-	_, err := Inject(home, opencodeAdapter(), "multi")
+	_, err := Inject(context.Background(), home, opencodeAdapter(), "multi")
 	if err == nil {
 		t.Fatal("Inject(multi) should fail when npm install fails")
 	}
@@ -1378,7 +1380,7 @@ func TestInjectOpenCodePluginBunPreferredOverNpm(t *testing.T) {
 		}
 		return "", fmt.Errorf("not found")
 	}
-	npmRun = func(dir string, args ...string) ([]byte, error) {
+	npmRun = func(_ context.Context, dir string, args ...string) ([]byte, error) {
 		if len(args) > 0 {
 			calledWith = args[0]
 		}
@@ -1395,7 +1397,7 @@ func TestInjectOpenCodePluginBunPreferredOverNpm(t *testing.T) {
 	}()
 
 	home := t.TempDir()
-	_, err := Inject(home, opencodeAdapter(), "multi")
+	_, err := Inject(context.Background(), home, opencodeAdapter(), "multi")
 	if err != nil {
 		t.Fatalf("Inject(multi) error = %v", err)
 	}
@@ -1405,11 +1407,38 @@ func TestInjectOpenCodePluginBunPreferredOverNpm(t *testing.T) {
 	}
 }
 
+func TestInjectOpenCodePluginInstallStopsWhenContextIsCancelled(t *testing.T) {
+	orig := npmLookPath
+	origRun := npmRun
+	npmLookPath = func(bin string) (string, error) {
+		if bin == "npm" {
+			return "/usr/bin/npm", nil
+		}
+		return "", fmt.Errorf("not found")
+	}
+	npmRun = func(ctx context.Context, dir string, args ...string) ([]byte, error) {
+		// What exec.CommandContext does with a cancelled context.
+		return nil, ctx.Err()
+	}
+	defer func() {
+		npmLookPath = orig
+		npmRun = origRun
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := Inject(ctx, t.TempDir(), opencodeAdapter(), "multi")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Inject() error = %v, want the install cancelled", err)
+	}
+}
+
 func TestInjectOpenCodePluginIdempotent(t *testing.T) {
 	home := t.TempDir()
 
 	// First run
-	first, err := Inject(home, opencodeAdapter(), "multi")
+	first, err := Inject(context.Background(), home, opencodeAdapter(), "multi")
 	if err != nil {
 		t.Fatalf("Inject(multi) first error = %v", err)
 	}
@@ -1418,7 +1447,7 @@ func TestInjectOpenCodePluginIdempotent(t *testing.T) {
 	}
 
 	// Second run: Changed should be false (plugin unchanged)
-	second, err := Inject(home, opencodeAdapter(), "multi")
+	second, err := Inject(context.Background(), home, opencodeAdapter(), "multi")
 	if err != nil {
 		t.Fatalf("Inject(multi) second error = %v", err)
 	}
@@ -1503,7 +1532,7 @@ func TestInjectGeminiUsesAgentSpecificAsset(t *testing.T) {
 		t.Fatalf("NewAdapter(gemini-cli) error = %v", err)
 	}
 
-	result, injectErr := Inject(home, geminiAdapter, "")
+	result, injectErr := Inject(context.Background(), home, geminiAdapter, "")
 	if injectErr != nil {
 		t.Fatalf("Inject(gemini) error = %v", injectErr)
 	}
@@ -1540,7 +1569,7 @@ func TestInjectCodexWritesSDDOrchestratorAndSkills(t *testing.T) {
 		t.Fatalf("NewAdapter(codex) error = %v", err)
 	}
 
-	result, injectErr := Inject(home, codexAdapter, "")
+	result, injectErr := Inject(context.Background(), home, codexAdapter, "")
 	if injectErr != nil {
 		t.Fatalf("Inject(codex) error = %v", injectErr)
 	}
@@ -1593,7 +1622,7 @@ func TestInjectCodexIsIdempotent(t *testing.T) {
 		t.Fatalf("NewAdapter(codex) error = %v", err)
 	}
 
-	first, err := Inject(home, codexAdapter, "")
+	first, err := Inject(context.Background(), home, codexAdapter, "")
 	if err != nil {
 		t.Fatalf("Inject(codex) first error = %v", err)
 	}
@@ -1601,7 +1630,7 @@ func TestInjectCodexIsIdempotent(t *testing.T) {
 		t.Fatal("first Inject(codex) changed = false")
 	}
 
-	second, err := Inject(home, codexAdapter, "")
+	second, err := Inject(context.Background(), home, codexAdapter, "")
 	if err != nil {
 		t.Fatalf("Inject(codex) second error = %v", err)
 	}
@@ -1639,7 +1668,7 @@ func TestInjectOpenCodeMultiModeWithPreExistingMinimalConfig(t *testing.T) {
 	}
 
 	// This must NOT fail with "post-check: ... missing sdd-apply sub-agent".
-	result, err := Inject(home, opencodeAdapter(), "multi")
+	result, err := Inject(context.Background(), home, opencodeAdapter(), "multi")
 	if err != nil {
 		t.Fatalf("Inject(multi) with pre-existing minimal config error = %v", err)
 	}
@@ -1705,7 +1734,7 @@ func TestInjectOpenCodeMultiModeWithPreExistingFullConfig(t *testing.T) {
 		t.Fatalf("WriteFile(opencode.json) error = %v", err)
 	}
 
-	result, err := Inject(home, opencodeAdapter(), "multi")
+	result, err := Inject(context.Background(), home, opencodeAdapter(), "multi")
 	if err != nil {
 		t.Fatalf("Inject(multi) with full pre-existing config error = %v", err)
 	}
@@ -0,0 +1,175 @@
+package sdd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/gentleman-programming/gentle-ai/internal/agents"
+	"github.com/gentleman-programming/gentle-ai/internal/assets"
+	"github.com/gentleman-programming/gentle-ai/internal/components/filemerge"
+	"github.com/gentleman-programming/gentle-ai/internal/model"
+)
+
+// Remove undoes Inject for every SDD mode: the orchestrator prompt section,
+// slash commands, OpenCode agents and plugin, and the SDD skill files.
+//
+// The plugin's node_modules dependency is left in place; it is shared with
+// anything else the user installed into ~/.config/opencode.
+func Remove(homeDir string, adapter agents.Adapter) (InjectionResult, error) {
+	if !adapter.SupportsSystemPrompt() {
+		return InjectionResult{}, nil
+	}
+
+	files := make([]string, 0)
+	changed := false
+	track := func(path string, result filemerge.WriteResult) {
+		changed = changed || result.Changed
+		files = append(files, path)
+	}
+
+	// 1. Remove the SDD orchestrator from the system prompt.
+	promptPath := adapter.SystemPromptFile(homeDir)
+	switch adapter.SystemPromptStrategy() {
+	case model.StrategyMarkdownSections:
+		result, err := filemerge.RemoveMarkdownSectionsFromFile(promptPath, "sdd-orchestrator")
+		if err != nil {
+			return InjectionResult{}, err
+		}
+		track(promptPath, result)
+
+	case model.StrategyFileReplace, model.StrategyAppendToFile, model.StrategyInstructionsFile:
+		existing, err := readFileOrEmpty(promptPath)
+		if err != nil {
+			return InjectionResult{}, err
+		}
+
+		if remaining, ok := stripAppendedOrchestrator(adapter, existing); ok {
+			result, err := filemerge.WriteOrRemove(promptPath, remaining)
+			if err != nil {
+				return InjectionResult{}, err
+			}
+			track(promptPath, result)
+		}
+	}
+
+	// 2. Remove slash commands.
+	if adapter.SupportsSlashCommands() {
+		if commandsDir := adapter.CommandsDir(homeDir); commandsDir != "" {
+			commandEntries, err := fs.ReadDir(assets.FS, "opencode/commands")
+			if err != nil {
+				return InjectionResult{}, fmt.Errorf("read embedded opencode/commands: %w", err)
+			}
+
+			for _, entry := range commandEntries {
+				if entry.IsDir() {
+					continue
+				}
+				path := filepath.Join(commandsDir, entry.Name())
+				result, err := filemerge.RemoveFile(path)
+				if err != nil {
+					return InjectionResult{}, err
+				}
+				track(path, result)
+			}
+		}
+	}
+
+	// 3. Remove the OpenCode agents and plugin.
+	if adapter.Agent() == model.AgentOpenCode {
+		if settingsPath := adapter.SettingsPath(homeDir); settingsPath != "" {
+			overlay, err := agentRemovalOverlay()
+			if err != nil {
+				return InjectionResult{}, err
+			}
+			result, err := filemerge.RemoveJSONKeysFromFile(settingsPath, overlay)
+			if err != nil {
+				return InjectionResult{}, err
+			}
+			track(settingsPath, result)
+
+			pluginPath := filepath.Join(homeDir, ".config", "opencode", "plugins", "background-agents.ts")
+			pluginResult, err := filemerge.RemoveFileAndEmptyParent(pluginPath)
+			if err != nil {
+				return InjectionResult{}, err
+			}
+			track(pluginPath, pluginResult)
+		}
+	}
+
+	// 4. Remove SDD skill files.
+	if adapter.SupportsSkills() {
+		if skillDir := adapter.SkillsDir(homeDir); skillDir != "" {
+			paths := make([]string, 0, len(SharedFiles)+len(Skills))
+			for _, fileName := range SharedFiles {
+				paths = append(paths, filepath.Join(skillDir, "_shared", fileName))
+			}
+			for _, skill := range Skills {
+				paths = append(paths, filepath.Join(skillDir, skill, "SKILL.md"))
+			}
+
+			for _, path := range paths {
+				result, err := filemerge.RemoveFileAndEmptyParent(path)
+				if err != nil {
+					return InjectionResult{}, err
+				}
+				track(path, result)
+			}
+		}
+	}
+
+	return InjectionResult{Changed: changed, Files: files}, nil
+}
+
+// agentRemovalOverlay lists every agent key either SDD overlay defines, each
+// with an empty body so the whole definition (including injected model
+// assignments) is dropped.
+func agentRemovalOverlay() ([]byte, error) {
+	agentKeys := map[string]any{}
+	for _, mode := range []model.SDDModeID{model.SDDModeSingle, model.SDDModeMulti} {
+		content, err := assets.Read(overlayAssetPath(mode))
+		if err != nil {
+			return nil, fmt.Errorf("read SDD overlay asset: %w", err)
+		}
+
+		var overlay struct {
+			Agent map[string]json.RawMessage `json:"agent"`
+		}
+		if err := json.Unmarshal([]byte(content), &overlay); err != nil {
+			return nil, fmt.Errorf("parse SDD overlay asset: %w", err)
+		}
+		for key := range overlay.Agent {
+			agentKeys[key] = map[string]any{}
+		}
+	}
+
+	encoded, err := json.Marshal(map[string]any{"agent": agentKeys})
+	if err != nil {
+		return nil, fmt.Errorf("encode SDD removal overlay: %w", err)
+	}
+	return encoded, nil
+}
+
+// stripAppendedOrchestrator removes the orchestrator content injectFileAppend
+// wrote. It reports false when that exact content is not present, which is the
+// case when the orchestrator ships inside the persona file instead.
+func stripAppendedOrchestrator(adapter agents.Adapter, existing string) (string, bool) {
+	content := assets.MustRead(sddOrchestratorAsset(adapter.Agent()))
+	if content == "" || !strings.Contains(existing, content) {
+		return existing, false
+	}
+
+	remaining := strings.Replace(existing, content, "", 1)
+	remaining = strings.TrimRight(remaining, "\n")
+	if remaining != "" {
+		remaining += "\n"
+	}
+
+	if adapter.SystemPromptStrategy() == model.StrategyInstructionsFile && remaining == instructionsFrontmatter {
+		return "", true
+	}
+
+	return remaining, true
+}
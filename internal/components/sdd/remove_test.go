@@ -0,0 +1,78 @@
+package sdd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gentleman-programming/gentle-ai/internal/agents"
+	"github.com/gentleman-programming/gentle-ai/internal/model"
+)
+
+func TestRemoveClaudeKeepsUserSections(t *testing.T) {
+	home := t.TempDir()
+	promptPath := filepath.Join(home, ".claude", "CLAUDE.md")
+	if err := os.MkdirAll(filepath.Dir(promptPath), 0o755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	if err := os.WriteFile(promptPath, []byte("# My rules\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	if _, err := Inject(context.Background(), home, claudeAdapter(), ""); err != nil {
+		t.Fatalf("Inject() error = %v", err)
+	}
+
+	result, err := Remove(home, claudeAdapter())
+	if err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if !result.Changed {
+		t.Fatalf("Remove() changed = false")
+	}
+
+	content, err := os.ReadFile(promptPath)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if string(content) != "# My rules\n" {
+		t.Fatalf("CLAUDE.md after Remove() = %q", string(content))
+	}
+
+	if _, err := os.Stat(filepath.Join(home, ".claude", "skills", "sdd-init")); !os.IsNotExist(err) {
+		t.Fatalf("expected sdd-init skill dir to be removed, stat error = %v", err)
+	}
+}
+
+func TestRemoveGeminiDeletesAppendedOnlyPrompt(t *testing.T) {
+	home := t.TempDir()
+	adapter, err := agents.NewAdapter(model.AgentGeminiCLI)
+	if err != nil {
+		t.Fatalf("NewAdapter() error = %v", err)
+	}
+
+	if _, err := Inject(context.Background(), home, adapter, ""); err != nil {
+		t.Fatalf("Inject() error = %v", err)
+	}
+
+	if _, err := Remove(home, adapter); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+
+	if _, err := os.Stat(adapter.SystemPromptFile(home)); !os.IsNotExist(err) {
+		t.Fatalf("expected GEMINI.md to be removed, stat error = %v", err)
+	}
+}
+
+func TestRemoveIsNoOpWhenNothingInstalled(t *testing.T) {
+	home := t.TempDir()
+
+	result, err := Remove(home, claudeAdapter())
+	if err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if result.Changed {
+		t.Fatalf("Remove() changed = true on empty home")
+	}
+}
@@ -1,6 +1,9 @@
 package skills
 
-import "github.com/gentleman-programming/gentle-ai/internal/model"
+import (
+	"github.com/gentleman-programming/gentle-ai/internal/model"
+	"github.com/gentleman-programming/gentle-ai/internal/preset"
+)
 
 // sddSkills are the SDD orchestrator skills — always included.
 var sddSkills = []model.SkillID{
@@ -15,38 +18,18 @@ var sddSkills = []model.SkillID{
 	model.SkillSDDArchive,
 }
 
-// foundationSkills are baseline learning skills for the "recommended" tier.
+// foundationSkills are baseline learning skills that are not part of SDD.
 var foundationSkills = []model.SkillID{
 	model.SkillGoTesting,
 	model.SkillCreator,
 }
 
-// SkillsForPreset returns which skills should be installed for a given preset.
-//
-//   - "minimal" / PresetMinimal:       SDD skills only
-//   - "ecosystem-only" / PresetEcosystemOnly: SDD + common framework skills
-//   - "full-gentleman" / PresetFullGentleman: all available skills
-//   - "custom" / PresetCustom:         empty (caller should provide explicit list)
-func SkillsForPreset(preset model.PresetID) []model.SkillID {
-	switch preset {
-	case model.PresetMinimal:
-		return copySkills(sddSkills)
-	case model.PresetEcosystemOnly:
-		return copySkills(append(sddSkills, foundationSkills...))
-	case model.PresetFullGentleman:
-		all := make([]model.SkillID, 0, len(sddSkills)+len(foundationSkills))
-		all = append(all, sddSkills...)
-		all = append(all, foundationSkills...)
-		return all
-	case model.PresetCustom:
-		return nil
-	default:
-		// Unknown preset — default to full.
-		all := make([]model.SkillID, 0, len(sddSkills)+len(foundationSkills))
-		all = append(all, sddSkills...)
-		all = append(all, foundationSkills...)
-		return all
-	}
+// SkillsForPreset returns which skills should be installed for a given preset,
+// as defined in the shared preset registry. The custom preset returns nil
+// (the caller provides an explicit list) and unknown presets fall back to
+// full-gentleman.
+func SkillsForPreset(id model.PresetID) []model.SkillID {
+	return preset.Default().Skills(id)
 }
 
 // AllSkillIDs returns every known skill ID.
@@ -56,9 +39,3 @@ func AllSkillIDs() []model.SkillID {
 	all = append(all, foundationSkills...)
 	return all
 }
-
-func copySkills(src []model.SkillID) []model.SkillID {
-	dst := make([]model.SkillID, len(src))
-	copy(dst, src)
-	return dst
-}
@@ -0,0 +1,44 @@
+package skills
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/gentleman-programming/gentle-ai/internal/agents"
+	"github.com/gentleman-programming/gentle-ai/internal/components/filemerge"
+	"github.com/gentleman-programming/gentle-ai/internal/model"
+)
+
+// Remove deletes the SKILL.md files Inject wrote for the requested skills and
+// drops each skill directory once it is empty. SDD skills are skipped for the
+// same reason Inject skips them: the SDD component owns those files.
+func Remove(homeDir string, adapter agents.Adapter, skillIDs []model.SkillID) (InjectionResult, error) {
+	if !adapter.SupportsSkills() {
+		return InjectionResult{}, nil
+	}
+
+	skillDir := adapter.SkillsDir(homeDir)
+	if skillDir == "" {
+		return InjectionResult{}, nil
+	}
+
+	paths := make([]string, 0, len(skillIDs))
+	changed := false
+
+	for _, id := range skillIDs {
+		if isSDDSkill(id) {
+			continue
+		}
+
+		path := filepath.Join(skillDir, string(id), "SKILL.md")
+		result, err := filemerge.RemoveFileAndEmptyParent(path)
+		if err != nil {
+			return InjectionResult{}, fmt.Errorf("skill %q: remove failed: %w", id, err)
+		}
+
+		changed = changed || result.Changed
+		paths = append(paths, path)
+	}
+
+	return InjectionResult{Changed: changed, Files: paths}, nil
+}
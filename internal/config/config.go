@@ -0,0 +1,244 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gentleman-programming/gentle-ai/internal/backup"
+)
+
+// Filename is the user configuration file name inside ~/.gentle-ai.
+const Filename = "config.json"
+
+// Config holds user settings that apply to every gentle-ai run.
+type Config struct {
+	Backups BackupRetention `json:"backups"`
+	Hooks   Hooks           `json:"hooks"`
+}
+
+// HookPoint is where in the install pipeline a hook runs.
+type HookPoint string
+
+const (
+	// HookPrePrepare hooks run before the prepare stage: before dependency
+	// checks and before the backup snapshot is taken.
+	HookPrePrepare HookPoint = "pre-prepare"
+	// HookPostApply hooks run once every apply step has succeeded.
+	HookPostApply HookPoint = "post-apply"
+)
+
+// Hooks are user commands run around the install pipeline stages, in the
+// order they are listed.
+type Hooks struct {
+	PrePrepare []Hook `json:"pre_prepare,omitempty"`
+	PostApply  []Hook `json:"post_apply,omitempty"`
+}
+
+// Hook is one user command. Run and Rollback are shell command lines (sh -c,
+// or cmd /C on Windows). Rollback, when set, runs if the install is rolled
+// back after the hook succeeded. Timeout is a Go duration ("30s", "5m");
+// empty leaves the hook to the install's --step-timeout.
+type Hook struct {
+	ID       string `json:"id"`
+	Run      string `json:"run"`
+	Rollback string `json:"rollback,omitempty"`
+	Timeout  string `json:"timeout,omitempty"`
+}
+
+// At returns the hooks that run at point.
+func (h Hooks) At(point HookPoint) []Hook {
+	switch point {
+	case HookPrePrepare:
+		return h.PrePrepare
+	case HookPostApply:
+		return h.PostApply
+	default:
+		return nil
+	}
+}
+
+// StepID is the pipeline step ID of the hook at point, e.g.
+// "hook:pre-prepare:company-ca".
+func (h Hook) StepID(point HookPoint) string {
+	return "hook:" + string(point) + ":" + h.ID
+}
+
+// TimeoutDuration parses Timeout; zero means no limit of its own.
+func (h Hook) TimeoutDuration() (time.Duration, error) {
+	if strings.TrimSpace(h.Timeout) == "" {
+		return 0, nil
+	}
+	timeout, err := time.ParseDuration(strings.TrimSpace(h.Timeout))
+	if err != nil || timeout <= 0 {
+		return 0, fmt.Errorf("invalid timeout %q (use e.g. 30s or 5m)", h.Timeout)
+	}
+	return timeout, nil
+}
+
+// Validate checks that every hook has a unique ID made of letters, digits,
+// dots, dashes and underscores, a command and a valid timeout.
+func (h Hooks) Validate() error {
+	seen := map[string]bool{}
+	for _, point := range []HookPoint{HookPrePrepare, HookPostApply} {
+		field := "hooks." + strings.ReplaceAll(string(point), "-", "_")
+		for i, hook := range h.At(point) {
+			if !validHookID(hook.ID) {
+				return fmt.Errorf("%s[%d].id %q must be letters, digits, '.', '-' or '_'", field, i, hook.ID)
+			}
+			if seen[hook.ID] {
+				return fmt.Errorf("%s[%d].id %q is used by another hook", field, i, hook.ID)
+			}
+			seen[hook.ID] = true
+			if strings.TrimSpace(hook.Run) == "" {
+				return fmt.Errorf("%s[%d] (%s): run must not be empty", field, i, hook.ID)
+			}
+			if _, err := hook.TimeoutDuration(); err != nil {
+				return fmt.Errorf("%s[%d] (%s): %w", field, i, hook.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+func validHookID(id string) bool {
+	if id == "" {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// BackupRetention is the policy applied to ~/.gentle-ai/backups after every
+// successful install. Ages accept days ("30d"), weeks ("2w") or Go durations;
+// sizes accept B, KB, MB or GB (powers of 1024). Zero or empty disables a
+// limit.
+type BackupRetention struct {
+	KeepLast     int    `json:"keep_last"`
+	MaxAge       string `json:"max_age,omitempty"`
+	MaxTotalSize string `json:"max_total_size,omitempty"`
+}
+
+// Path returns the configuration file location for the given home directory.
+func Path(homeDir string) string {
+	return filepath.Join(homeDir, ".gentle-ai", Filename)
+}
+
+// Default returns the settings used when no configuration file exists.
+// Retention is opt-in: with no policy configured every backup is kept.
+func Default() Config {
+	return Config{}
+}
+
+// Load reads the configuration file. A missing file yields Default, and
+// fields left out of the file keep their default values.
+func Load(homeDir string) (Config, error) {
+	cfg := Default()
+
+	path := Path(homeDir)
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return Config{}, fmt.Errorf("read config %q: %w", path, err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&cfg); err != nil {
+		return Config{}, fmt.Errorf("parse config %q: %w", path, err)
+	}
+	if _, err := cfg.Backups.Policy(); err != nil {
+		return Config{}, fmt.Errorf("config %q: %w", path, err)
+	}
+	if err := cfg.Hooks.Validate(); err != nil {
+		return Config{}, fmt.Errorf("config %q: %w", path, err)
+	}
+
+	return cfg, nil
+}
+
+// Policy converts the retention settings into a prune policy.
+func (r BackupRetention) Policy() (backup.PrunePolicy, error) {
+	if r.KeepLast < 0 {
+		return backup.PrunePolicy{}, fmt.Errorf("backups.keep_last must not be negative, got %d", r.KeepLast)
+	}
+
+	policy := backup.PrunePolicy{Keep: r.KeepLast}
+	if r.MaxAge != "" {
+		age, err := ParseAge(r.MaxAge)
+		if err != nil {
+			return backup.PrunePolicy{}, fmt.Errorf("backups.max_age: %w", err)
+		}
+		policy.OlderThan = age
+	}
+	if r.MaxTotalSize != "" {
+		size, err := ParseSize(r.MaxTotalSize)
+		if err != nil {
+			return backup.PrunePolicy{}, fmt.Errorf("backups.max_total_size: %w", err)
+		}
+		policy.MaxTotalSize = size
+	}
+
+	return policy, nil
+}
+
+// ParseAge accepts Go durations plus whole days ("30d") and weeks ("2w").
+func ParseAge(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if number, ok := strings.CutSuffix(value, suffix); ok {
+			count, err := strconv.Atoi(number)
+			if err != nil || count <= 0 {
+				return 0, fmt.Errorf("invalid age %q (use e.g. 30d, 2w or 12h)", value)
+			}
+			return time.Duration(count) * unit, nil
+		}
+	}
+
+	age, err := time.ParseDuration(value)
+	if err != nil || age <= 0 {
+		return 0, fmt.Errorf("invalid age %q (use e.g. 30d, 2w or 12h)", value)
+	}
+	return age, nil
+}
+
+// ParseSize accepts a byte count with an optional B, KB, MB or GB suffix.
+func ParseSize(value string) (int64, error) {
+	normalized := strings.ToUpper(strings.TrimSpace(value))
+
+	multiplier := int64(1)
+	for _, unit := range []struct {
+		suffix string
+		bytes  int64
+	}{
+		{"GB", 1 << 30},
+		{"MB", 1 << 20},
+		{"KB", 1 << 10},
+		{"B", 1},
+	} {
+		if number, ok := strings.CutSuffix(normalized, unit.suffix); ok {
+			normalized = strings.TrimSpace(number)
+			multiplier = unit.bytes
+			break
+		}
+	}
+
+	count, err := strconv.ParseInt(normalized, 10, 64)
+	if err != nil || count <= 0 {
+		return 0, fmt.Errorf("invalid size %q (use e.g. 500MB or 2GB)", value)
+	}
+	return count * multiplier, nil
+}
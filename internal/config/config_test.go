@@ -0,0 +1,165 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadWithoutFileReturnsDefaults(t *testing.T) {
+	cfg, err := Load(t.TempDir())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	policy, err := cfg.Backups.Policy()
+	if err != nil {
+		t.Fatalf("Policy() error = %v", err)
+	}
+	if !policy.IsZero() {
+		t.Fatalf("default policy = %#v, want retention disabled", policy)
+	}
+}
+
+func TestLoadReadsRetention(t *testing.T) {
+	home := t.TempDir()
+	writeConfig(t, home, `{"backups": {"keep_last": 0, "max_age": "30d", "max_total_size": "1GB"}}`)
+
+	cfg, err := Load(home)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	policy, err := cfg.Backups.Policy()
+	if err != nil {
+		t.Fatalf("Policy() error = %v", err)
+	}
+	if policy.Keep != 0 || policy.OlderThan != 30*24*time.Hour || policy.MaxTotalSize != 1<<30 {
+		t.Fatalf("policy = %#v", policy)
+	}
+}
+
+func TestLoadKeepsDefaultsForMissingFields(t *testing.T) {
+	home := t.TempDir()
+	writeConfig(t, home, `{"backups": {"max_age": "2w"}}`)
+
+	cfg, err := Load(home)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Backups.KeepLast != 0 || cfg.Backups.MaxAge != "2w" {
+		t.Fatalf("backups = %#v", cfg.Backups)
+	}
+}
+
+func TestLoadRejectsInvalidConfig(t *testing.T) {
+	tests := map[string]struct {
+		content string
+		want    string
+	}{
+		"unknown field":       {`{"backups": {"keep": 3}}`, "unknown field \"keep\""},
+		"negative keep":       {`{"backups": {"keep_last": -1}}`, "keep_last must not be negative"},
+		"bad age":             {`{"backups": {"max_age": "soon"}}`, "backups.max_age: invalid age"},
+		"bad size":            {`{"backups": {"max_total_size": "lots"}}`, "backups.max_total_size: invalid size"},
+		"malformed json":      {`{"backups": `, "parse config"},
+		"hook without id":     {`{"hooks": {"pre_prepare": [{"run": "true"}]}}`, "hooks.pre_prepare[0].id"},
+		"hook id with spaces": {`{"hooks": {"post_apply": [{"id": "company ca", "run": "true"}]}}`, "hooks.post_apply[0].id"},
+		"duplicate hook id":   {`{"hooks": {"pre_prepare": [{"id": "ca", "run": "true"}], "post_apply": [{"id": "ca", "run": "true"}]}}`, "used by another hook"},
+		"hook without run":    {`{"hooks": {"post_apply": [{"id": "mcp"}]}}`, "run must not be empty"},
+		"bad hook timeout":    {`{"hooks": {"post_apply": [{"id": "mcp", "run": "true", "timeout": "soon"}]}}`, "invalid timeout"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			home := t.TempDir()
+			writeConfig(t, home, tc.content)
+
+			_, err := Load(home)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("Load() error = %v, want it to contain %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestLoadReadsHooks(t *testing.T) {
+	home := t.TempDir()
+	writeConfig(t, home, `{"hooks": {
+		"pre_prepare": [{"id": "company-ca", "run": "./write-ca.sh", "rollback": "./remove-ca.sh", "timeout": "30s"}],
+		"post_apply": [{"id": "internal-mcp", "run": "register-mcp"}]
+	}}`)
+
+	cfg, err := Load(home)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	pre := cfg.Hooks.At(HookPrePrepare)
+	if len(pre) != 1 || pre[0].Rollback != "./remove-ca.sh" || pre[0].StepID(HookPrePrepare) != "hook:pre-prepare:company-ca" {
+		t.Fatalf("pre_prepare = %#v", pre)
+	}
+	if timeout, err := pre[0].TimeoutDuration(); err != nil || timeout != 30*time.Second {
+		t.Fatalf("TimeoutDuration() = %v, %v; want 30s", timeout, err)
+	}
+	post := cfg.Hooks.At(HookPostApply)
+	if len(post) != 1 || post[0].StepID(HookPostApply) != "hook:post-apply:internal-mcp" {
+		t.Fatalf("post_apply = %#v", post)
+	}
+	if timeout, err := post[0].TimeoutDuration(); err != nil || timeout != 0 {
+		t.Fatalf("TimeoutDuration() = %v, %v; want no limit", timeout, err)
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	tests := map[string]time.Duration{
+		"30d": 30 * 24 * time.Hour,
+		"2w":  14 * 24 * time.Hour,
+		"12h": 12 * time.Hour,
+	}
+	for input, want := range tests {
+		got, err := ParseAge(input)
+		if err != nil || got != want {
+			t.Fatalf("ParseAge(%q) = %v, %v; want %v", input, got, err, want)
+		}
+	}
+
+	for _, input := range []string{"", "d", "-3d", "soon", "0h"} {
+		if _, err := ParseAge(input); err == nil {
+			t.Fatalf("ParseAge(%q) expected error", input)
+		}
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := map[string]int64{
+		"512":    512,
+		"100B":   100,
+		"4kb":    4 << 10,
+		"500 MB": 500 << 20,
+		"2GB":    2 << 30,
+	}
+	for input, want := range tests {
+		got, err := ParseSize(input)
+		if err != nil || got != want {
+			t.Fatalf("ParseSize(%q) = %d, %v; want %d", input, got, err, want)
+		}
+	}
+
+	for _, input := range []string{"", "MB", "-1GB", "1.5GB", "10TB"} {
+		if _, err := ParseSize(input); err == nil {
+			t.Fatalf("ParseSize(%q) expected error", input)
+		}
+	}
+}
+
+func writeConfig(t *testing.T, home, content string) {
+	t.Helper()
+
+	path := Path(home)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+}
@@ -0,0 +1,218 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultContext is the number of unchanged lines shown around each change,
+// matching `diff -u`.
+const DefaultContext = 3
+
+type opKind int
+
+const (
+	opEqual opKind = iota
+	opDelete
+	opInsert
+)
+
+type op struct {
+	kind opKind
+	line string
+}
+
+// Unified returns a unified diff between before and after. oldName and newName
+// label the `---` and `+++` headers; an empty before with oldName "/dev/null"
+// renders a file creation. It returns "" when the contents are identical.
+func Unified(oldName, newName, before, after string, context int) string {
+	if before == after {
+		return ""
+	}
+
+	ops := lineOps(splitLines(before), splitLines(after))
+
+	b := &strings.Builder{}
+	_, _ = fmt.Fprintf(b, "--- %s\n", oldName)
+	_, _ = fmt.Fprintf(b, "+++ %s\n", newName)
+	for _, h := range hunks(ops, context) {
+		writeHunk(b, ops, h)
+	}
+
+	return b.String()
+}
+
+// splitLines splits content into lines, keeping a trailing "\n" marker out of
+// the lines themselves. A final line without newline is kept as is.
+func splitLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+
+	lines := strings.Split(content, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// lineOps computes an edit script with a longest-common-subsequence table.
+// Common prefix and suffix are trimmed first, so the table only covers the
+// changed middle of the files.
+func lineOps(a, b []string) []op {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	ops := make([]op, 0, len(a)+len(b))
+	for _, line := range a[:prefix] {
+		ops = append(ops, op{kind: opEqual, line: line})
+	}
+
+	midA := a[prefix : len(a)-suffix]
+	midB := b[prefix : len(b)-suffix]
+	table := make([][]int, len(midA)+1)
+	for i := range table {
+		table[i] = make([]int, len(midB)+1)
+	}
+	for i := len(midA) - 1; i >= 0; i-- {
+		for j := len(midB) - 1; j >= 0; j-- {
+			if midA[i] == midB[j] {
+				table[i][j] = table[i+1][j+1] + 1
+			} else {
+				table[i][j] = max(table[i+1][j], table[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(midA) && j < len(midB) {
+		switch {
+		case midA[i] == midB[j]:
+			ops = append(ops, op{kind: opEqual, line: midA[i]})
+			i++
+			j++
+		case table[i+1][j] >= table[i][j+1]:
+			ops = append(ops, op{kind: opDelete, line: midA[i]})
+			i++
+		default:
+			ops = append(ops, op{kind: opInsert, line: midB[j]})
+			j++
+		}
+	}
+	for ; i < len(midA); i++ {
+		ops = append(ops, op{kind: opDelete, line: midA[i]})
+	}
+	for ; j < len(midB); j++ {
+		ops = append(ops, op{kind: opInsert, line: midB[j]})
+	}
+
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, op{kind: opEqual, line: line})
+	}
+
+	return ops
+}
+
+type hunk struct {
+	start, end int
+}
+
+// hunks groups changed ops with up to context equal lines around them,
+// merging groups whose context overlaps.
+func hunks(ops []op, context int) []hunk {
+	result := []hunk{}
+	for i, current := range ops {
+		if current.kind == opEqual {
+			continue
+		}
+
+		start := max(i-context, 0)
+		end := min(i+context+1, len(ops))
+		if len(result) > 0 && start <= result[len(result)-1].end {
+			result[len(result)-1].end = end
+			continue
+		}
+		result = append(result, hunk{start: start, end: end})
+	}
+
+	return result
+}
+
+func writeHunk(b *strings.Builder, ops []op, h hunk) {
+	oldLine, newLine := 1, 1
+	for _, current := range ops[:h.start] {
+		if current.kind != opInsert {
+			oldLine++
+		}
+		if current.kind != opDelete {
+			newLine++
+		}
+	}
+
+	oldCount, newCount := 0, 0
+	for _, current := range ops[h.start:h.end] {
+		if current.kind != opInsert {
+			oldCount++
+		}
+		if current.kind != opDelete {
+			newCount++
+		}
+	}
+
+	_, _ = fmt.Fprintf(b, "@@ -%s +%s @@\n", hunkRange(oldLine, oldCount), hunkRange(newLine, newCount))
+	for _, current := range ops[h.start:h.end] {
+		switch current.kind {
+		case opEqual:
+			_, _ = fmt.Fprintf(b, " %s\n", current.line)
+		case opDelete:
+			_, _ = fmt.Fprintf(b, "-%s\n", current.line)
+		case opInsert:
+			_, _ = fmt.Fprintf(b, "+%s\n", current.line)
+		}
+	}
+}
+
+// hunkRange formats a hunk side the way GNU diff does: an empty side starts
+// at the line before it.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start-1)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// FileChange is the planned new content of one file next to what is on disk.
+type FileChange struct {
+	Path   string
+	Exists bool
+	Before string
+	After  string
+}
+
+// Changed reports whether applying the change would alter the file.
+func (c FileChange) Changed() bool {
+	return !c.Exists || c.Before != c.After
+}
+
+// Unified renders the change as a unified diff, with a /dev/null header for
+// files that do not exist yet.
+func (c FileChange) Unified() string {
+	oldName := "a" + c.Path
+	if !c.Exists {
+		oldName = "/dev/null"
+	}
+	if !c.Exists && c.After == "" {
+		return fmt.Sprintf("--- /dev/null\n+++ b%s\n", c.Path)
+	}
+
+	return Unified(oldName, "b"+c.Path, c.Before, c.After, DefaultContext)
+}
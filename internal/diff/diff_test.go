@@ -0,0 +1,48 @@
+package diff
+
+import "testing"
+
+func TestUnifiedIdenticalIsEmpty(t *testing.T) {
+	if got := Unified("a", "b", "x\ny\n", "x\ny\n", DefaultContext); got != "" {
+		t.Fatalf("Unified() = %q, want empty", got)
+	}
+}
+
+func TestUnifiedSingleChange(t *testing.T) {
+	before := "one\ntwo\nthree\nfour\nfive\nsix\nseven\neight\n"
+	after := "one\ntwo\nthree\nfour\nFIVE\nsix\nseven\neight\n"
+
+	want := "--- a/f\n+++ b/f\n@@ -2,7 +2,7 @@\n two\n three\n four\n-five\n+FIVE\n six\n seven\n eight\n"
+	if got := Unified("a/f", "b/f", before, after, DefaultContext); got != want {
+		t.Fatalf("Unified() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestUnifiedSeparateHunks(t *testing.T) {
+	before := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n"
+	after := "one\n2\n3\n4\n5\n6\n7\n8\n9\nten\n"
+
+	want := "--- a\n+++ b\n@@ -1,2 +1,2 @@\n-1\n+one\n 2\n@@ -9,2 +9,2 @@\n 9\n-10\n+ten\n"
+	if got := Unified("a", "b", before, after, 1); got != want {
+		t.Fatalf("Unified() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestFileChangeCreation(t *testing.T) {
+	change := FileChange{Path: "/home/u/.claude/CLAUDE.md", After: "hello\nworld\n"}
+	if !change.Changed() {
+		t.Fatalf("Changed() = false for a new file")
+	}
+
+	want := "--- /dev/null\n+++ b/home/u/.claude/CLAUDE.md\n@@ -0,0 +1,2 @@\n+hello\n+world\n"
+	if got := change.Unified(); got != want {
+		t.Fatalf("Unified() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestFileChangeUnchanged(t *testing.T) {
+	change := FileChange{Path: "/x", Exists: true, Before: "same\n", After: "same\n"}
+	if change.Changed() {
+		t.Fatalf("Changed() = true for identical content")
+	}
+}
@@ -0,0 +1,225 @@
+// Package events writes pipeline runs as JSON lines: one object when the run
+// starts, one for every pipeline.ProgressEvent and one when the run ends.
+// `install --events=jsonl` streams them on stdout, and every run also keeps
+// them in a log file under ~/.gentle-ai/logs, so dashboards and CI wrappers
+// can follow an install without parsing the text report.
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gentleman-programming/gentle-ai/internal/pipeline"
+)
+
+// FormatJSONLines is the only --events format.
+const FormatJSONLines = "jsonl"
+
+// MaxLogs is how many run logs are kept; older ones are removed when a new
+// run starts.
+const MaxLogs = 20
+
+const (
+	// KindRunStarted opens a run.
+	KindRunStarted = "run-started"
+	// KindStep reports a step changing status.
+	KindStep = "step"
+	// KindRunFinished closes a run; Status is "succeeded" or "failed".
+	KindRunFinished = "run-finished"
+)
+
+// Record is one JSON line.
+type Record struct {
+	Time        time.Time `json:"time"`
+	Kind        string    `json:"kind"`
+	Command     string    `json:"command,omitempty"`
+	Stage       string    `json:"stage,omitempty"`
+	Step        string    `json:"step,omitempty"`
+	Status      string    `json:"status,omitempty"`
+	Attempt     int       `json:"attempt,omitempty"`
+	MaxAttempts int       `json:"max_attempts,omitempty"`
+	DurationMS  int64     `json:"duration_ms,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	Reason      string    `json:"reason,omitempty"`
+	Files       []string  `json:"files,omitempty"`
+}
+
+// StepRecord converts a progress event; files are the files the step changed.
+func StepRecord(event pipeline.ProgressEvent, files []string) Record {
+	record := Record{
+		Kind:        KindStep,
+		Stage:       string(event.Stage),
+		Step:        event.StepID,
+		Status:      string(event.Status),
+		Attempt:     event.Attempt,
+		MaxAttempts: event.MaxAttempts,
+		DurationMS:  event.Duration.Milliseconds(),
+		Reason:      event.Reason,
+		Files:       files,
+	}
+	if event.Err != nil {
+		record.Error = event.Err.Error()
+	}
+	return record
+}
+
+// Writer writes records to every output it was given. A failing output is
+// dropped after its first error, which Close reports, so a broken pipe on
+// stdout never stops the log file or the run.
+type Writer struct {
+	mu      sync.Mutex
+	command string
+	outputs []io.Writer
+	closers []io.Closer
+	errs    []error
+}
+
+// NewWriter returns a writer for a run of command.
+func NewWriter(command string, outputs ...io.Writer) *Writer {
+	return &Writer{command: command, outputs: outputs}
+}
+
+// LogDir is where run logs are kept for homeDir.
+func LogDir(homeDir string) string {
+	return filepath.Join(homeDir, ".gentle-ai", "logs")
+}
+
+// AddLog opens a new log file for this run under LogDir(homeDir), removing
+// the oldest logs beyond MaxLogs, and writes to it from now on. It returns
+// the path of the log.
+func (w *Writer) AddLog(homeDir string, startedAt time.Time) (string, error) {
+	dir := LogDir(homeDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("create log directory %q: %w", dir, err)
+	}
+	if err := pruneLogs(dir, MaxLogs-1); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("%s-%s.jsonl", startedAt.UTC().Format("20060102150405.000000000"), w.command))
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return "", fmt.Errorf("create log %q: %w", path, err)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.outputs = append(w.outputs, file)
+	w.closers = append(w.closers, file)
+	return path, nil
+}
+
+// Start writes the run-started record.
+func (w *Writer) Start() {
+	w.Write(Record{Kind: KindRunStarted})
+}
+
+// Progress returns a progress func that writes every event, with the files
+// the step changed as reported by files, before passing it on to next. Both
+// may be nil.
+func (w *Writer) Progress(files func(step string) []string, next pipeline.ProgressFunc) pipeline.ProgressFunc {
+	return func(event pipeline.ProgressEvent) {
+		var changed []string
+		if files != nil && event.Status == pipeline.StepStatusSucceeded {
+			changed = files(event.StepID)
+		}
+		w.Write(StepRecord(event, changed))
+		if next != nil {
+			next(event)
+		}
+	}
+}
+
+// Finish writes the run-finished record for a run that ended with err.
+func (w *Writer) Finish(err error) {
+	record := Record{Kind: KindRunFinished, Status: string(pipeline.StepStatusSucceeded)}
+	if err != nil {
+		record.Status = string(pipeline.StepStatusFailed)
+		record.Error = err.Error()
+	}
+	w.Write(record)
+}
+
+// Write stamps record with the time and command and writes it as one line.
+func (w *Writer) Write(record Record) {
+	if w == nil {
+		return
+	}
+	if record.Time.IsZero() {
+		record.Time = time.Now().UTC()
+	}
+	if record.Command == "" {
+		record.Command = w.command
+	}
+
+	line, err := json.Marshal(record)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err != nil {
+		w.errs = append(w.errs, fmt.Errorf("marshal event: %w", err))
+		return
+	}
+	line = append(line, '\n')
+
+	kept := w.outputs[:0]
+	for _, output := range w.outputs {
+		if _, err := output.Write(line); err != nil {
+			w.errs = append(w.errs, fmt.Errorf("write event: %w", err))
+			continue
+		}
+		kept = append(kept, output)
+	}
+	w.outputs = kept
+}
+
+// Close closes the log files and returns every error writing met.
+func (w *Writer) Close() error {
+	if w == nil {
+		return nil
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	errs := w.errs
+	for _, closer := range w.closers {
+		if err := closer.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	w.closers = nil
+	w.outputs = nil
+	return errors.Join(errs...)
+}
+
+// pruneLogs removes the oldest logs in dir until at most keep are left. Log
+// names start with the run's start time, so they sort oldest first.
+func pruneLogs(dir string, keep int) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("read log directory %q: %w", dir, err)
+	}
+
+	logs := []string{}
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".jsonl") {
+			logs = append(logs, entry.Name())
+		}
+	}
+	slices.Sort(logs)
+
+	for len(logs) > keep {
+		if err := os.Remove(filepath.Join(dir, logs[0])); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("remove old log %q: %w", logs[0], err)
+		}
+		logs = logs[1:]
+	}
+	return nil
+}
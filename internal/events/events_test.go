@@ -0,0 +1,129 @@
+package events
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gentleman-programming/gentle-ai/internal/pipeline"
+)
+
+func TestWriterStreamsRunAsJSONLinesToOutputsAndLog(t *testing.T) {
+	home := t.TempDir()
+	var out bytes.Buffer
+
+	w := NewWriter("install", &out)
+	path, err := w.AddLog(home, time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("AddLog() error = %v", err)
+	}
+
+	files := func(step string) []string { return []string{"/home/.claude/CLAUDE.md"} }
+	progress := w.Progress(files, nil)
+	w.Start()
+	progress(pipeline.ProgressEvent{Stage: pipeline.StageApply, StepID: "component:persona", Status: pipeline.StepStatusRunning})
+	progress(pipeline.ProgressEvent{Stage: pipeline.StageApply, StepID: "component:persona", Status: pipeline.StepStatusSucceeded, Duration: 1500 * time.Millisecond})
+	w.Finish(nil)
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	records := decode(t, out.Bytes())
+	if len(records) != 4 {
+		t.Fatalf("records = %#v, want 4", records)
+	}
+	if records[0].Kind != KindRunStarted || records[3].Kind != KindRunFinished || records[3].Status != "succeeded" {
+		t.Fatalf("records = %#v, want run-started first and run-finished succeeded last", records)
+	}
+	if len(records[1].Files) != 0 {
+		t.Fatalf("running record Files = %v, want none", records[1].Files)
+	}
+	step := records[2]
+	if step.Command != "install" || step.Stage != "apply" || step.Step != "component:persona" || step.DurationMS != 1500 {
+		t.Fatalf("step record = %#v", step)
+	}
+	if len(step.Files) != 1 || step.Files[0] != "/home/.claude/CLAUDE.md" {
+		t.Fatalf("step record Files = %v", step.Files)
+	}
+
+	logged, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile(log) error = %v", err)
+	}
+	if !bytes.Equal(logged, out.Bytes()) {
+		t.Fatalf("log = %q, want the same lines as the stream %q", logged, out.String())
+	}
+}
+
+func TestWriterRecordsFailedRunError(t *testing.T) {
+	var out bytes.Buffer
+	w := NewWriter("repair", &out)
+	w.Finish(errors.New("boom"))
+
+	records := decode(t, out.Bytes())
+	if len(records) != 1 || records[0].Status != "failed" || records[0].Error != "boom" {
+		t.Fatalf("records = %#v, want one failed run-finished", records)
+	}
+}
+
+func TestWriterDropsFailingOutputAndKeepsWriting(t *testing.T) {
+	var out bytes.Buffer
+	w := NewWriter("install", failingWriter{}, &out)
+	w.Start()
+	w.Finish(nil)
+
+	if records := decode(t, out.Bytes()); len(records) != 2 {
+		t.Fatalf("records = %#v, want both lines on the working output", records)
+	}
+	if err := w.Close(); err == nil || !strings.Contains(err.Error(), "write event") {
+		t.Fatalf("Close() error = %v, want the write error reported once", err)
+	}
+}
+
+func TestAddLogKeepsTheNewestLogs(t *testing.T) {
+	home := t.TempDir()
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < MaxLogs+3; i++ {
+		w := NewWriter("install")
+		if _, err := w.AddLog(home, start.Add(time.Duration(i)*time.Minute)); err != nil {
+			t.Fatalf("AddLog() error = %v", err)
+		}
+		_ = w.Close()
+	}
+
+	entries, err := os.ReadDir(LogDir(home))
+	if err != nil {
+		t.Fatalf("ReadDir() error = %v", err)
+	}
+	if len(entries) != MaxLogs {
+		t.Fatalf("logs = %d, want %d", len(entries), MaxLogs)
+	}
+	if !strings.HasPrefix(entries[0].Name(), "20260101120300") {
+		t.Fatalf("oldest log kept = %q, want the three oldest removed", entries[0].Name())
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("broken pipe")
+}
+
+func decode(t *testing.T, content []byte) []Record {
+	t.Helper()
+	records := []Record{}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("line %q is not JSON: %v", scanner.Text(), err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
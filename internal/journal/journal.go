@@ -0,0 +1,283 @@
+// Package journal is a write-ahead log of an install in progress. Every step
+// transition is appended to ~/.gentle-ai/journal.jsonl and synced to disk
+// before the run moves on, and the file is removed once the run ends. A
+// journal found at startup therefore belongs to a run that was killed: it
+// names the selection that run was installing, the backup it took and the
+// steps it finished, which is what resuming or rolling it back needs.
+package journal
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/gentleman-programming/gentle-ai/internal/model"
+)
+
+// Filename is the journal file under ~/.gentle-ai.
+const Filename = "journal.jsonl"
+
+// EntryType says what a journal line records.
+type EntryType string
+
+const (
+	// EntryStart opens the journal with the command and selection.
+	EntryStart EntryType = "start"
+	// EntryBackup names the backup snapshot the run took.
+	EntryBackup EntryType = "backup"
+	// EntryStep records a step changing status.
+	EntryStep EntryType = "step"
+	// EntryResume marks a later run picking the journal up.
+	EntryResume EntryType = "resume"
+)
+
+// Entry is one line of the journal.
+type Entry struct {
+	Time      time.Time        `json:"time"`
+	Type      EntryType        `json:"type"`
+	Command   string           `json:"command,omitempty"`
+	Selection *model.Selection `json:"selection,omitempty"`
+	BackupID  string           `json:"backup_id,omitempty"`
+	Stage     string           `json:"stage,omitempty"`
+	Step      string           `json:"step,omitempty"`
+	Status    string           `json:"status,omitempty"`
+	Error     string           `json:"error,omitempty"`
+}
+
+// Run is what a journal says about the run that wrote it.
+type Run struct {
+	Command   string
+	Selection model.Selection
+	StartedAt time.Time
+	UpdatedAt time.Time
+	// BackupID is empty when the run was killed before its backup finished.
+	BackupID string
+	// Steps maps each step ID to the last status recorded for it.
+	Steps   map[string]string
+	Resumes int
+}
+
+// Succeeded reports whether step finished successfully.
+func (r Run) Succeeded(step string) bool {
+	return r.Steps[step] == "succeeded"
+}
+
+// PendingError is returned when a journal left by an interrupted run is in
+// the way of starting a new one.
+type PendingError struct {
+	Path string
+	Run  Run
+}
+
+func (e *PendingError) Error() string {
+	return fmt.Sprintf("a gentle-ai %s started %s was interrupted (journal %s)",
+		e.Run.Command, e.Run.StartedAt.Local().Format("2006-01-02 15:04:05"), e.Path)
+}
+
+// Writer appends to the journal of the current run.
+type Writer struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+}
+
+// Path returns the journal file for homeDir.
+func Path(homeDir string) string {
+	return filepath.Join(homeDir, ".gentle-ai", Filename)
+}
+
+// Start creates the journal for a new run of command. It returns a
+// *PendingError when an earlier run left its journal behind.
+func Start(homeDir string, command string, selection model.Selection) (*Writer, error) {
+	path := Path(homeDir)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("create journal directory: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL|os.O_APPEND, 0o644)
+	if errors.Is(err, fs.ErrExist) {
+		run, loaded, loadErr := Load(homeDir)
+		if loadErr != nil {
+			return nil, loadErr
+		}
+		if loaded {
+			return nil, &PendingError{Path: path, Run: run}
+		}
+		// A journal without its start line never got past creating the
+		// file; nothing is pending.
+		if err := Discard(homeDir); err != nil {
+			return nil, err
+		}
+		file, err = os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL|os.O_APPEND, 0o644)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("create journal %q: %w", path, err)
+	}
+
+	w := &Writer{path: path, file: file}
+	if err := w.append(Entry{Type: EntryStart, Command: command, Selection: &selection}); err != nil {
+		_ = file.Close()
+		_ = os.Remove(path)
+		return nil, err
+	}
+	return w, nil
+}
+
+// Resume reopens the journal an interrupted run left behind so the run
+// continuing it keeps appending to the same file.
+func Resume(homeDir string) (*Writer, error) {
+	path := Path(homeDir)
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open journal %q: %w", path, err)
+	}
+
+	w := &Writer{path: path, file: file}
+	if err := w.append(Entry{Type: EntryResume}); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+// Backup records the backup snapshot the run took.
+func (w *Writer) Backup(id string) error {
+	return w.append(Entry{Type: EntryBackup, BackupID: id})
+}
+
+// Step records step in stage reaching status.
+func (w *Writer) Step(stage string, step string, status string, stepErr error) error {
+	entry := Entry{Type: EntryStep, Stage: stage, Step: step, Status: status}
+	if stepErr != nil {
+		entry.Error = stepErr.Error()
+	}
+	return w.append(entry)
+}
+
+// Close closes the journal and leaves it on disk, for a run that ended in a
+// state the next launch has to deal with.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	if err != nil {
+		return fmt.Errorf("close journal %q: %w", w.path, err)
+	}
+	return nil
+}
+
+// Finish closes the journal and removes it: the run is over and there is
+// nothing left to resume.
+func (w *Writer) Finish() error {
+	if err := w.Close(); err != nil {
+		return err
+	}
+	if err := os.Remove(w.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("remove journal %q: %w", w.path, err)
+	}
+	return nil
+}
+
+// append writes entry as one line and syncs it, so a crash right after a
+// step starts still finds the step in the journal.
+func (w *Writer) append(entry Entry) error {
+	entry.Time = time.Now().UTC()
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("marshal journal entry: %w", err)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return fmt.Errorf("journal %q is closed", w.path)
+	}
+	if _, err := w.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("write journal %q: %w", w.path, err)
+	}
+	if err := w.file.Sync(); err != nil {
+		return fmt.Errorf("sync journal %q: %w", w.path, err)
+	}
+	return nil
+}
+
+// Load reads the journal for homeDir. ok is false when there is none. A last
+// line cut short by the crash is ignored.
+func Load(homeDir string) (run Run, ok bool, err error) {
+	path := Path(homeDir)
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return Run{}, false, nil
+		}
+		return Run{}, false, fmt.Errorf("read journal %q: %w", path, err)
+	}
+
+	run.Steps = map[string]string{}
+	started := false
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			if !bytes.HasSuffix(content, []byte("\n")) && !scanner.Scan() {
+				break
+			}
+			return Run{}, false, fmt.Errorf("parse journal %q line %d: %w", path, line, err)
+		}
+
+		switch entry.Type {
+		case EntryStart:
+			started = true
+			run.Command = entry.Command
+			run.StartedAt = entry.Time
+			if entry.Selection != nil {
+				run.Selection = *entry.Selection
+			}
+		case EntryBackup:
+			run.BackupID = entry.BackupID
+		case EntryStep:
+			run.Steps[entry.Step] = entry.Status
+		case EntryResume:
+			run.Resumes++
+		}
+		run.UpdatedAt = entry.Time
+	}
+	if err := scanner.Err(); err != nil {
+		return Run{}, false, fmt.Errorf("read journal %q: %w", path, err)
+	}
+
+	// A crash between creating the file and writing the first line leaves
+	// nothing to resume.
+	if !started {
+		return Run{}, false, nil
+	}
+	return run, true, nil
+}
+
+// Discard removes the journal for homeDir, once the interrupted run has been
+// resumed or rolled back by other means.
+func Discard(homeDir string) error {
+	path := Path(homeDir)
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("remove journal %q: %w", path, err)
+	}
+	return nil
+}
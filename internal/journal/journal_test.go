@@ -0,0 +1,129 @@
+package journal
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/gentleman-programming/gentle-ai/internal/model"
+)
+
+func TestLoadWithoutJournalReportsNothingPending(t *testing.T) {
+	if _, ok, err := Load(t.TempDir()); err != nil || ok {
+		t.Fatalf("Load() ok = %v, err = %v; want no journal", ok, err)
+	}
+}
+
+func TestJournalRecordsSelectionBackupAndLastStepStatus(t *testing.T) {
+	home := t.TempDir()
+	selection := model.Selection{
+		Agents:     []model.AgentID{model.AgentClaudeCode},
+		Components: []model.ComponentID{model.ComponentEngram},
+		Persona:    model.PersonaGentleman,
+	}
+
+	w, err := Start(home, "install", selection)
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	mustWrite(t, w.Step("prepare", "prepare:backup-snapshot", "running", nil))
+	mustWrite(t, w.Backup("20260101120000.000000000"))
+	mustWrite(t, w.Step("prepare", "prepare:backup-snapshot", "succeeded", nil))
+	mustWrite(t, w.Step("apply", "component:engram", "running", nil))
+	mustWrite(t, w.Step("apply", "component:engram", "failed", errors.New("boom")))
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	run, ok, err := Load(home)
+	if err != nil || !ok {
+		t.Fatalf("Load() ok = %v, err = %v", ok, err)
+	}
+	if run.Command != "install" || run.BackupID != "20260101120000.000000000" {
+		t.Fatalf("Load() = %#v", run)
+	}
+	if !reflect.DeepEqual(run.Selection, selection) {
+		t.Fatalf("Selection = %#v, want %#v", run.Selection, selection)
+	}
+	if !run.Succeeded("prepare:backup-snapshot") || run.Succeeded("component:engram") {
+		t.Fatalf("Steps = %#v", run.Steps)
+	}
+}
+
+func TestStartRefusesWhileAnInterruptedRunIsPending(t *testing.T) {
+	home := t.TempDir()
+	w, err := Start(home, "install", model.Selection{})
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	_ = w.Close()
+
+	var pending *PendingError
+	if _, err := Start(home, "install", model.Selection{}); !errors.As(err, &pending) {
+		t.Fatalf("Start() error = %v, want *PendingError", err)
+	}
+
+	resumed, err := Resume(home)
+	if err != nil {
+		t.Fatalf("Resume() error = %v", err)
+	}
+	if err := resumed.Finish(); err != nil {
+		t.Fatalf("Finish() error = %v", err)
+	}
+	if _, err := os.Stat(Path(home)); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("Finish() left the journal behind: %v", err)
+	}
+	if _, err := Start(home, "install", model.Selection{}); err != nil {
+		t.Fatalf("Start() after Finish() error = %v", err)
+	}
+}
+
+func TestLoadIgnoresLineCutShortByACrash(t *testing.T) {
+	home := t.TempDir()
+	w, err := Start(home, "install", model.Selection{})
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	mustWrite(t, w.Step("apply", "agent:claude-code", "succeeded", nil))
+	_ = w.Close()
+
+	file, err := os.OpenFile(Path(home), os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatalf("OpenFile() error = %v", err)
+	}
+	_, _ = file.WriteString(`{"type":"step","step":"component:eng`)
+	_ = file.Close()
+
+	run, ok, err := Load(home)
+	if err != nil || !ok {
+		t.Fatalf("Load() ok = %v, err = %v", ok, err)
+	}
+	if !run.Succeeded("agent:claude-code") || len(run.Steps) != 1 {
+		t.Fatalf("Steps = %#v", run.Steps)
+	}
+}
+
+func TestStartReplacesJournalWithoutStartLine(t *testing.T) {
+	home := t.TempDir()
+	if err := os.MkdirAll(filepath.Dir(Path(home)), 0o755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	if err := os.WriteFile(Path(home), nil, 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	w, err := Start(home, "install", model.Selection{})
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	_ = w.Finish()
+}
+
+func mustWrite(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("journal write error = %v", err)
+	}
+}
@@ -0,0 +1,237 @@
+// Package lock keeps gentle-ai runs that change the user's configuration
+// from overlapping. The lock is an advisory file under ~/.gentle-ai naming
+// the process that holds it; a lock left behind by a process that is no
+// longer running on this host is taken over.
+package lock
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Filename is the lock file under ~/.gentle-ai.
+const Filename = "run.lock"
+
+// unreadableGrace is how long a lock file that cannot be parsed is assumed
+// to be still being written by its owner.
+const unreadableGrace = 5 * time.Second
+
+var (
+	hostname     = os.Hostname
+	processAlive = isProcessAlive
+	pollInterval = 500 * time.Millisecond
+)
+
+// Owner identifies the run holding the lock.
+type Owner struct {
+	PID       int       `json:"pid"`
+	Host      string    `json:"host"`
+	Command   string    `json:"command"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// HeldError is returned when another run holds the lock.
+type HeldError struct {
+	Path  string
+	Owner Owner
+}
+
+func (e *HeldError) Error() string {
+	if e.Owner.PID == 0 {
+		return fmt.Sprintf("another gentle-ai run holds the lock %s", e.Path)
+	}
+	return fmt.Sprintf("another gentle-ai run holds the lock %s: %s (pid %d on %s, started %s)",
+		e.Path, e.Owner.Command, e.Owner.PID, e.Owner.Host, e.Owner.StartedAt.Local().Format("2006-01-02 15:04:05"))
+}
+
+// Lock is a held run lock.
+type Lock struct {
+	path  string
+	owner Owner
+}
+
+// Path returns the lock file for homeDir.
+func Path(homeDir string) string {
+	return filepath.Join(homeDir, ".gentle-ai", Filename)
+}
+
+// Acquire takes the lock for command, or returns a *HeldError naming the
+// run that holds it.
+func Acquire(homeDir string, command string) (*Lock, error) {
+	path := Path(homeDir)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("create lock directory: %w", err)
+	}
+
+	host, err := hostname()
+	if err != nil {
+		return nil, fmt.Errorf("resolve hostname: %w", err)
+	}
+	owner := Owner{PID: os.Getpid(), Host: host, Command: command, StartedAt: time.Now().UTC()}
+	content, err := json.Marshal(owner)
+	if err != nil {
+		return nil, fmt.Errorf("marshal lock owner: %w", err)
+	}
+
+	// A stale lock is removed and creation retried once; losing that race
+	// to another process means it now holds the lock.
+	for attempt := 0; ; attempt++ {
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err == nil {
+			_, writeErr := file.Write(append(content, '\n'))
+			closeErr := file.Close()
+			if err := errors.Join(writeErr, closeErr); err != nil {
+				_ = os.Remove(path)
+				return nil, fmt.Errorf("write lock file %q: %w", path, err)
+			}
+			return &Lock{path: path, owner: owner}, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, fmt.Errorf("create lock file %q: %w", path, err)
+		}
+
+		current, held, err := readLock(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if attempt > 0 || !stale(current, host) {
+			return nil, &HeldError{Path: path, Owner: current.owner}
+		}
+		if err := removeIfUnchanged(path, held); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Wait takes the lock for command, polling until the run holding it
+// finishes or ctx is done. onWait, when set, is called once with the holder
+// before waiting starts.
+func Wait(ctx context.Context, homeDir string, command string, onWait func(Owner)) (*Lock, error) {
+	notified := false
+	for {
+		lock, err := Acquire(homeDir, command)
+		var held *HeldError
+		if !errors.As(err, &held) {
+			return lock, err
+		}
+		if !notified && onWait != nil {
+			onWait(held.Owner)
+			notified = true
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("wait for %s: %w", held.Path, ctx.Err())
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// Holder returns the run holding the lock for homeDir. ok is false when the
+// lock is free or was left behind by a run that is no longer running.
+func Holder(homeDir string) (owner Owner, ok bool, err error) {
+	current, _, err := readLock(Path(homeDir))
+	if errors.Is(err, fs.ErrNotExist) {
+		return Owner{}, false, nil
+	}
+	if err != nil {
+		return Owner{}, false, err
+	}
+
+	host, err := hostname()
+	if err != nil {
+		return Owner{}, false, fmt.Errorf("resolve hostname: %w", err)
+	}
+	if stale(current, host) {
+		return Owner{}, false, nil
+	}
+	return current.owner, true, nil
+}
+
+// Release removes the lock file if this run still holds it.
+func (l *Lock) Release() error {
+	if l == nil {
+		return nil
+	}
+
+	current, _, err := readLock(l.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if current.owner != l.owner {
+		return nil
+	}
+	if err := os.Remove(l.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("remove lock file %q: %w", l.path, err)
+	}
+	return nil
+}
+
+type lockFile struct {
+	owner    Owner
+	parsed   bool
+	modified time.Time
+}
+
+func readLock(path string) (lockFile, []byte, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return lockFile{}, nil, fmt.Errorf("read lock file %q: %w", path, err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return lockFile{}, nil, fmt.Errorf("stat lock file %q: %w", path, err)
+	}
+
+	current := lockFile{modified: info.ModTime()}
+	if err := json.Unmarshal(content, &current.owner); err == nil && current.owner.PID > 0 {
+		current.parsed = true
+	} else {
+		current.owner = Owner{}
+	}
+	return current, content, nil
+}
+
+// stale reports whether the lock was left behind: its owner ran on this
+// host and is gone, or the file never got an owner written to it. Locks
+// from other hosts sharing the home directory are never considered stale.
+func stale(current lockFile, host string) bool {
+	if !current.parsed {
+		return time.Since(current.modified) > unreadableGrace
+	}
+	if current.owner.Host != host {
+		return false
+	}
+	return !processAlive(current.owner.PID)
+}
+
+// removeIfUnchanged deletes a stale lock file unless another process has
+// replaced it since it was read.
+func removeIfUnchanged(path string, held []byte) error {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read lock file %q: %w", path, err)
+	}
+	if string(content) != string(held) {
+		return nil
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("remove stale lock file %q: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package lock
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAcquireRejectsSecondRunUntilReleased(t *testing.T) {
+	home := t.TempDir()
+
+	first, err := Acquire(home, "install")
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+
+	_, err = Acquire(home, "uninstall")
+	var held *HeldError
+	if !errors.As(err, &held) {
+		t.Fatalf("second Acquire() error = %v, want *HeldError", err)
+	}
+	if held.Owner.PID != os.Getpid() || held.Owner.Command != "install" || !strings.Contains(err.Error(), "install (pid") {
+		t.Fatalf("held = %#v, error = %v", held.Owner, err)
+	}
+
+	if err := first.Release(); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	second, err := Acquire(home, "uninstall")
+	if err != nil {
+		t.Fatalf("Acquire() after release error = %v", err)
+	}
+	_ = second.Release()
+}
+
+func TestAcquireTakesOverStaleLock(t *testing.T) {
+	home := t.TempDir()
+	host, _ := hostname()
+	writeOwner(t, home, Owner{PID: 4242, Host: host, Command: "install"})
+
+	restore := processAlive
+	processAlive = func(pid int) bool { return pid != 4242 }
+	t.Cleanup(func() { processAlive = restore })
+
+	lock, err := Acquire(home, "repair")
+	if err != nil {
+		t.Fatalf("Acquire() over a dead owner error = %v", err)
+	}
+	if lock.owner.PID != os.Getpid() {
+		t.Fatalf("owner = %#v", lock.owner)
+	}
+}
+
+func TestAcquireKeepsLockFromAnotherHost(t *testing.T) {
+	home := t.TempDir()
+	writeOwner(t, home, Owner{PID: 4242, Host: "other-machine", Command: "install"})
+
+	restore := processAlive
+	processAlive = func(int) bool { return false }
+	t.Cleanup(func() { processAlive = restore })
+
+	var held *HeldError
+	if _, err := Acquire(home, "install"); !errors.As(err, &held) || held.Owner.Host != "other-machine" {
+		t.Fatalf("Acquire() error = %v, want the other host's lock to be respected", err)
+	}
+}
+
+func TestAcquireTakesOverUnreadableLockAfterGrace(t *testing.T) {
+	home := t.TempDir()
+	path := Path(home)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	if err := os.WriteFile(path, nil, 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	if _, err := Acquire(home, "install"); err == nil {
+		t.Fatalf("Acquire() should respect a lock that is still being written")
+	}
+
+	old := time.Now().Add(-time.Minute)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatalf("Chtimes() error = %v", err)
+	}
+	if _, err := Acquire(home, "install"); err != nil {
+		t.Fatalf("Acquire() over an abandoned empty lock error = %v", err)
+	}
+}
+
+func TestWaitAcquiresOnceReleased(t *testing.T) {
+	home := t.TempDir()
+	restore := pollInterval
+	pollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { pollInterval = restore })
+
+	first, err := Acquire(home, "install")
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+
+	waited := make(chan Owner, 1)
+	go func() {
+		owner := <-waited
+		if owner.Command == "install" {
+			_ = first.Release()
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	second, err := Wait(ctx, home, "repair", func(owner Owner) { waited <- owner })
+	if err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	if second.owner.Command != "repair" {
+		t.Fatalf("owner = %#v", second.owner)
+	}
+}
+
+func TestReleaseLeavesAnotherOwnersLock(t *testing.T) {
+	home := t.TempDir()
+
+	lock, err := Acquire(home, "install")
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	writeOwner(t, home, Owner{PID: 1, Host: "other-machine", Command: "install"})
+
+	if err := lock.Release(); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	if _, err := os.Stat(Path(home)); err != nil {
+		t.Fatalf("Release() removed a lock it no longer held: %v", err)
+	}
+}
+
+func TestHolderIgnoresLockOfDeadProcess(t *testing.T) {
+	home := t.TempDir()
+	if _, ok, err := Holder(home); ok || err != nil {
+		t.Fatalf("Holder() without a lock ok = %v, err = %v", ok, err)
+	}
+
+	lock, err := Acquire(home, "install")
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	if owner, ok, err := Holder(home); !ok || err != nil || owner.Command != "install" {
+		t.Fatalf("Holder() = %#v, %v, %v; want the install holding it", owner, ok, err)
+	}
+	_ = lock.Release()
+
+	host, _ := os.Hostname()
+	writeOwner(t, home, Owner{PID: 4242, Host: host, Command: "install"})
+	restore := processAlive
+	processAlive = func(int) bool { return false }
+	t.Cleanup(func() { processAlive = restore })
+
+	if _, ok, err := Holder(home); ok || err != nil {
+		t.Fatalf("Holder() with a stale lock ok = %v, err = %v", ok, err)
+	}
+}
+
+func writeOwner(t *testing.T, home string, owner Owner) {
+	t.Helper()
+
+	content, err := json.Marshal(owner)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(Path(home)), 0o755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	if err := os.WriteFile(Path(home), content, 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+}
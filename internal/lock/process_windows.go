@@ -0,0 +1,16 @@
+//go:build windows
+
+package lock
+
+import "os"
+
+// isProcessAlive relies on FindProcess opening a handle to the process,
+// which fails on Windows when no such process exists.
+func isProcessAlive(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	_ = process.Release()
+	return true
+}
@@ -0,0 +1,32 @@
+package pipeline
+
+import "context"
+
+// CheckOutcome says what running a step would do.
+type CheckOutcome string
+
+const (
+	// CheckUpToDate means the step has nothing to do.
+	CheckUpToDate CheckOutcome = "up-to-date"
+	// CheckWillChange means the step would change something already there.
+	CheckWillChange CheckOutcome = "will-change"
+	// CheckWillCreate means the step would create something missing.
+	CheckWillCreate CheckOutcome = "will-create"
+)
+
+// CheckResult is what a CheckedStep found before running. Reason explains it
+// in a few words, e.g. "2 files already configured".
+type CheckResult struct {
+	Outcome CheckOutcome
+	Reason  string
+}
+
+// CheckedStep is a step that can tell, before it runs, whether it has
+// anything to do. The runner skips it when Check reports CheckUpToDate, and
+// the steps depending on it run as if it had succeeded. A check that fails
+// only means the step cannot tell, so it runs as if CheckWillChange had been
+// reported.
+type CheckedStep interface {
+	Step
+	Check(ctx context.Context) (CheckResult, error)
+}
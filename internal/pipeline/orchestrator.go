@@ -1,5 +1,11 @@
 package pipeline
 
+import (
+	"context"
+	"slices"
+	"time"
+)
+
 // OrchestratorOption configures the orchestrator.
 type OrchestratorOption func(*Orchestrator)
 
@@ -10,16 +16,40 @@ func WithFailurePolicy(policy FailurePolicy) OrchestratorOption {
 	}
 }
 
-// WithProgressFunc sets a callback that receives progress events during execution.
+// WithProgressFunc sets a callback that receives progress events during
+// execution, rollback included.
 func WithProgressFunc(fn ProgressFunc) OrchestratorOption {
 	return func(o *Orchestrator) {
 		o.runner.OnProgress = fn
 	}
 }
 
+// WithMaxParallel sets how many independent steps may run at once.
+func WithMaxParallel(n int) OrchestratorOption {
+	return func(o *Orchestrator) {
+		o.runner.MaxParallel = n
+	}
+}
+
+// WithStepTimeout bounds how long any single step may run.
+func WithStepTimeout(d time.Duration) OrchestratorOption {
+	return func(o *Orchestrator) {
+		o.runner.StepTimeout = d
+	}
+}
+
+// WithTimeout bounds how long the prepare and apply stages may take together.
+// Rollback is not limited.
+func WithTimeout(d time.Duration) OrchestratorOption {
+	return func(o *Orchestrator) {
+		o.timeout = d
+	}
+}
+
 type Orchestrator struct {
 	runner   Runner
 	policy   RollbackPolicy
+	timeout  time.Duration
 	stepByID map[string]Step
 }
 
@@ -37,16 +67,35 @@ func NewOrchestrator(policy RollbackPolicy, opts ...OrchestratorOption) *Orchest
 	return o
 }
 
-func (o *Orchestrator) Execute(plan StagePlan) ExecutionResult {
+// Execute runs the prepare stage, then the apply stage, and rolls back the
+// applied steps when the policy asks for it. Cancelling ctx, or running past
+// the WithTimeout limit, fails the stage that is running; apply steps that
+// never ran are reported as skipped and the ones that did are rolled back.
+func (o *Orchestrator) Execute(ctx context.Context, plan StagePlan) ExecutionResult {
 	o.indexSteps(plan.Prepare)
 	o.indexSteps(plan.Apply)
 
-	prepareResult := o.runner.Run(StagePrepare, plan.Prepare)
+	if o.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.timeout)
+		defer cancel()
+	}
+
+	prepareResult := o.runner.Run(ctx, StagePrepare, plan.Prepare)
 	if !prepareResult.Success {
-		return ExecutionResult{Prepare: prepareResult, Err: prepareResult.Err}
+		result := ExecutionResult{Prepare: prepareResult, Err: prepareResult.Err}
+		if ctx.Err() != nil {
+			// Nothing starts on a done context: this only reports the
+			// apply steps as skipped.
+			result.Apply = o.runner.Run(ctx, StageApply, plan.Apply)
+		}
+		if o.policy.ShouldRollback(StagePrepare, prepareResult.Err) {
+			o.rollback(&result, ExecuteRollback(prepareResult.Steps, o.stepByID, o.runner.OnProgress))
+		}
+		return result
 	}
 
-	applyResult := o.runner.Run(StageApply, plan.Apply)
+	applyResult := o.runner.Run(ctx, StageApply, plan.Apply)
 	result := ExecutionResult{Prepare: prepareResult, Apply: applyResult}
 	if applyResult.Success {
 		return result
@@ -54,15 +103,29 @@ func (o *Orchestrator) Execute(plan StagePlan) ExecutionResult {
 
 	result.Err = applyResult.Err
 	if o.policy.ShouldRollback(StageApply, applyResult.Err) {
-		result.Rollback = ExecuteRollback(applyResult.Steps, o.stepByID)
-		if !result.Rollback.Success {
-			result.Err = result.Rollback.Err
+		if o.policy.KeepSucceeded && ctx.Err() == nil {
+			o.rollback(&result, ExecutePartialRollback(applyResult.Steps, o.stepByID, o.runner.OnProgress))
+		} else {
+			steps := applyResult.Steps
+			if o.policy.RollbackPrepare {
+				steps = append(slices.Clone(prepareResult.Steps), steps...)
+			}
+			o.rollback(&result, ExecuteRollback(steps, o.stepByID, o.runner.OnProgress))
 		}
 	}
 
 	return result
 }
 
+// rollback records the rollback stage and reports a failed rollback as the
+// execution error.
+func (o *Orchestrator) rollback(result *ExecutionResult, rollback StageResult) {
+	result.Rollback = rollback
+	if !rollback.Success {
+		result.Err = rollback.Err
+	}
+}
+
 func (o *Orchestrator) indexSteps(steps []Step) {
 	for _, step := range steps {
 		o.stepByID[step.ID()] = step
@@ -1,16 +1,21 @@
 package pipeline
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestOrchestratorRunsPrepareThenApply(t *testing.T) {
 	order := []string{}
 	orchestrator := NewOrchestrator(DefaultRollbackPolicy())
 
-	result := orchestrator.Execute(StagePlan{
+	result := orchestrator.Execute(context.Background(), StagePlan{
 		Prepare: []Step{
 			newTestStep("prepare-1", &order),
 		},
@@ -36,7 +41,7 @@ func TestOrchestratorRollsBackApplyStepsOnFailure(t *testing.T) {
 	order := []string{}
 	orchestrator := NewOrchestrator(DefaultRollbackPolicy())
 
-	result := orchestrator.Execute(StagePlan{
+	result := orchestrator.Execute(context.Background(), StagePlan{
 		Apply: []Step{
 			newRollbackStep("apply-1", &order, nil),
 			newRollbackStep("apply-2", &order, errors.New("boom")),
@@ -65,7 +70,7 @@ func TestOrchestratorSkipsRollbackWhenPolicyDisabled(t *testing.T) {
 	order := []string{}
 	orchestrator := NewOrchestrator(RollbackPolicy{OnApplyFailure: false})
 
-	result := orchestrator.Execute(StagePlan{
+	result := orchestrator.Execute(context.Background(), StagePlan{
 		Apply: []Step{
 			newRollbackStep("apply-1", &order, errors.New("boom")),
 		},
@@ -94,7 +99,7 @@ func TestRunnerContinueOnErrorExecutesAllSteps(t *testing.T) {
 		newRollbackStep("step-3", &order, nil),
 	}
 
-	result := runner.Run(StageApply, steps)
+	result := runner.Run(context.Background(), StageApply, steps)
 
 	wantOrder := []string{"run:step-1", "run:step-2", "run:step-3"}
 	if !reflect.DeepEqual(order, wantOrder) {
@@ -134,7 +139,7 @@ func TestRunnerStopOnErrorHaltsExecution(t *testing.T) {
 		newRollbackStep("step-3", &order, nil),
 	}
 
-	result := runner.Run(StageApply, steps)
+	result := runner.Run(context.Background(), StageApply, steps)
 
 	wantOrder := []string{"run:step-1", "run:step-2"}
 	if !reflect.DeepEqual(order, wantOrder) {
@@ -166,7 +171,7 @@ func TestRunnerProgressCallbackEmitsEvents(t *testing.T) {
 		newTestStep("step-b", &order),
 	}
 
-	result := runner.Run(StageApply, steps)
+	result := runner.Run(context.Background(), StageApply, steps)
 
 	if result.Err != nil {
 		t.Fatalf("unexpected error: %v", result.Err)
@@ -207,7 +212,7 @@ func TestRunnerProgressCallbackEmitsFailedEvents(t *testing.T) {
 		newRollbackStep("bad-step", &order, errors.New("oops")),
 	}
 
-	result := runner.Run(StageApply, steps)
+	result := runner.Run(context.Background(), StageApply, steps)
 
 	if result.Success {
 		t.Fatalf("expected failure")
@@ -230,7 +235,7 @@ func TestOrchestratorContinueOnErrorWithRollback(t *testing.T) {
 		WithFailurePolicy(ContinueOnError),
 	)
 
-	result := orchestrator.Execute(StagePlan{
+	result := orchestrator.Execute(context.Background(), StagePlan{
 		Apply: []Step{
 			newRollbackStep("apply-1", &order, nil),
 			newRollbackStep("apply-2", &order, errors.New("boom")),
@@ -270,7 +275,7 @@ func TestOrchestratorWithProgressFunc(t *testing.T) {
 		}),
 	)
 
-	result := orchestrator.Execute(StagePlan{
+	result := orchestrator.Execute(context.Background(), StagePlan{
 		Prepare: []Step{newTestStep("prep", &order)},
 		Apply:   []Step{newTestStep("act", &order)},
 	})
@@ -292,6 +297,669 @@ func TestOrchestratorWithProgressFunc(t *testing.T) {
 	}
 }
 
+func TestOrchestratorReportsRollbackProgress(t *testing.T) {
+	order := []string{}
+	events := []ProgressEvent{}
+
+	orchestrator := NewOrchestrator(
+		DefaultRollbackPolicy(),
+		WithProgressFunc(func(e ProgressEvent) {
+			events = append(events, e)
+		}),
+	)
+
+	result := orchestrator.Execute(context.Background(), StagePlan{
+		Apply: []Step{
+			newRollbackStep("apply-1", &order, nil),
+			newRollbackStep("apply-2", &order, errors.New("boom")),
+		},
+	})
+	if result.Err == nil {
+		t.Fatalf("Execute() expected apply error")
+	}
+
+	rollback := []ProgressEvent{}
+	for _, event := range events {
+		if event.Stage == StageRollback {
+			rollback = append(rollback, event)
+		}
+	}
+	if len(rollback) != 2 {
+		t.Fatalf("rollback events = %+v, want running and rolled back", rollback)
+	}
+	if rollback[0].StepID != "apply-1" || rollback[0].Status != StepStatusRunning {
+		t.Fatalf("rollback event[0] = %+v", rollback[0])
+	}
+	if rollback[1].StepID != "apply-1" || rollback[1].Status != StepStatusRolledBack {
+		t.Fatalf("rollback event[1] = %+v", rollback[1])
+	}
+	if events[len(events)-1] != rollback[1] {
+		t.Fatalf("last event = %+v, want the rollback", events[len(events)-1])
+	}
+}
+
+func TestRunnerRunsIndependentStepsInParallel(t *testing.T) {
+	order := []string{}
+	events := map[string][]StepStatus{}
+
+	// Both install steps wait until the other has started, so they only
+	// succeed when they run at the same time.
+	var started sync.WaitGroup
+	started.Add(2)
+	overlap := func(context.Context) error {
+		started.Done()
+		done := make(chan struct{})
+		go func() {
+			started.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+			return nil
+		case <-time.After(5 * time.Second):
+			return errors.New("steps did not run in parallel")
+		}
+	}
+
+	runner := Runner{
+		MaxParallel: 2,
+		OnProgress: func(e ProgressEvent) {
+			events[e.StepID] = append(events[e.StepID], e.Status)
+		},
+	}
+
+	result := runner.Run(context.Background(), StageApply, []Step{
+		newTestStep("restore", &order),
+		newDependentStep("install-a", &order, overlap),
+		newDependentStep("install-b", &order, overlap),
+		newDependentStep("configure-a", &order, nil, "install-a"),
+	})
+
+	if result.Err != nil {
+		t.Fatalf("Run() error = %v", result.Err)
+	}
+	if order[0] != "run:restore" {
+		t.Fatalf("execution order = %v, want restore first", order)
+	}
+	if indexOf(order, "run:configure-a") < indexOf(order, "run:install-a") {
+		t.Fatalf("execution order = %v, want configure-a after install-a", order)
+	}
+
+	for _, id := range []string{"restore", "install-a", "install-b", "configure-a"} {
+		if want := []StepStatus{StepStatusRunning, StepStatusSucceeded}; !reflect.DeepEqual(events[id], want) {
+			t.Fatalf("events for %s = %v, want %v", id, events[id], want)
+		}
+	}
+
+	gotIDs := []string{}
+	for _, step := range result.Steps {
+		gotIDs = append(gotIDs, step.StepID)
+	}
+	if want := []string{"restore", "install-a", "install-b", "configure-a"}; !reflect.DeepEqual(gotIDs, want) {
+		t.Fatalf("result steps = %v, want plan order %v", gotIDs, want)
+	}
+}
+
+func TestRunnerSkipsStepsWhoseDependencyFailed(t *testing.T) {
+	order := []string{}
+	events := []ProgressEvent{}
+	runner := Runner{
+		FailurePolicy: ContinueOnError,
+		MaxParallel:   4,
+		OnProgress: func(e ProgressEvent) {
+			events = append(events, e)
+		},
+	}
+
+	result := runner.Run(context.Background(), StageApply, []Step{
+		newDependentStep("engram", &order, func(context.Context) error { return errors.New("boom") }),
+		newDependentStep("sdd", &order, nil, "engram"),
+		newDependentStep("skills", &order, nil, "sdd"),
+		newDependentStep("persona", &order, nil),
+	})
+
+	if result.Success {
+		t.Fatalf("expected failure")
+	}
+
+	statuses := map[string]StepStatus{}
+	for _, step := range result.Steps {
+		statuses[step.StepID] = step.Status
+	}
+	want := map[string]StepStatus{
+		"engram":  StepStatusFailed,
+		"sdd":     StepStatusSkipped,
+		"skills":  StepStatusSkipped,
+		"persona": StepStatusSucceeded,
+	}
+	if !reflect.DeepEqual(statuses, want) {
+		t.Fatalf("statuses = %v, want %v", statuses, want)
+	}
+	if indexOf(order, "run:sdd") >= 0 || indexOf(order, "run:skills") >= 0 {
+		t.Fatalf("execution order = %v, skipped steps ran", order)
+	}
+
+	for _, event := range events {
+		if event.StepID == "sdd" && (event.Status != StepStatusSkipped || event.Err == nil) {
+			t.Fatalf("sdd event = %+v, want skipped with reason", event)
+		}
+	}
+}
+
+func TestRunnerRejectsDependencyOnLaterStep(t *testing.T) {
+	order := []string{}
+	runner := Runner{MaxParallel: 2}
+
+	result := runner.Run(context.Background(), StageApply, []Step{
+		newDependentStep("sdd", &order, nil, "engram"),
+		newDependentStep("engram", &order, nil),
+	})
+
+	if result.Success || result.Err == nil || !strings.Contains(result.Err.Error(), `depends on "engram"`) {
+		t.Fatalf("Run() = %+v, want dependency error", result)
+	}
+	if len(order) != 0 {
+		t.Fatalf("execution order = %v, want no steps run", order)
+	}
+}
+
+func TestOrchestratorRollsBackParallelStepsInReversePlanOrder(t *testing.T) {
+	order := []string{}
+	orchestrator := NewOrchestrator(DefaultRollbackPolicy(), WithMaxParallel(4))
+
+	result := orchestrator.Execute(context.Background(), StagePlan{
+		Apply: []Step{
+			newTestStep("restore", &order),
+			newDependentStep("agent-a", &order, nil),
+			newDependentStep("agent-b", &order, nil),
+			newDependentStep("component", &order, func(context.Context) error { return errors.New("boom") }, "agent-a"),
+		},
+	})
+
+	if result.Err == nil {
+		t.Fatalf("Execute() expected apply error")
+	}
+
+	rollbacks := []string{}
+	for _, entry := range order {
+		if strings.HasPrefix(entry, "rollback:") {
+			rollbacks = append(rollbacks, entry)
+		}
+	}
+	want := []string{"rollback:agent-b", "rollback:agent-a", "rollback:restore"}
+	if !reflect.DeepEqual(rollbacks, want) {
+		t.Fatalf("rollback order = %v, want %v", rollbacks, want)
+	}
+}
+
+func TestOrchestratorCancellationSkipsUnstartedStepsAndRollsBack(t *testing.T) {
+	order := []string{}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The second step cancels the run, the way Ctrl-C would while it is busy,
+	// and fails because its context is done.
+	interrupt := func(ctx context.Context) error {
+		cancel()
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	events := []ProgressEvent{}
+	orchestrator := NewOrchestrator(
+		DefaultRollbackPolicy(),
+		WithFailurePolicy(ContinueOnError),
+		WithProgressFunc(func(e ProgressEvent) {
+			events = append(events, e)
+		}),
+	)
+
+	result := orchestrator.Execute(ctx, StagePlan{
+		Apply: []Step{
+			newTestStep("apply-1", &order),
+			newDependentStep("apply-2", &order, interrupt),
+			newTestStep("apply-3", &order),
+		},
+	})
+
+	if !errors.Is(result.Err, context.Canceled) {
+		t.Fatalf("Execute() error = %v, want context.Canceled", result.Err)
+	}
+
+	statuses := []StepStatus{}
+	for _, step := range result.Apply.Steps {
+		statuses = append(statuses, step.Status)
+	}
+	if want := []StepStatus{StepStatusSucceeded, StepStatusFailed, StepStatusSkipped}; !reflect.DeepEqual(statuses, want) {
+		t.Fatalf("apply statuses = %v, want %v", statuses, want)
+	}
+	if indexOf(order, "run:apply-3") >= 0 {
+		t.Fatalf("execution order = %v, apply-3 should never run", order)
+	}
+	if indexOf(order, "rollback:apply-1") < 0 {
+		t.Fatalf("execution order = %v, want apply-1 rolled back", order)
+	}
+
+	skipped := false
+	for _, event := range events {
+		if event.StepID == "apply-3" {
+			skipped = event.Status == StepStatusSkipped && errors.Is(event.Err, context.Canceled)
+		}
+	}
+	if !skipped {
+		t.Fatalf("events = %+v, want apply-3 reported skipped", events)
+	}
+}
+
+func TestOrchestratorCancelledBeforeApplyReportsEveryStepSkipped(t *testing.T) {
+	order := []string{}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	result := NewOrchestrator(DefaultRollbackPolicy()).Execute(ctx, StagePlan{
+		Prepare: []Step{newTestStep("prepare", &order)},
+		Apply:   []Step{newTestStep("apply-1", &order), newTestStep("apply-2", &order)},
+	})
+
+	if !errors.Is(result.Err, context.Canceled) {
+		t.Fatalf("Execute() error = %v, want context.Canceled", result.Err)
+	}
+	if len(order) != 0 {
+		t.Fatalf("execution order = %v, want nothing run", order)
+	}
+	if len(result.Apply.Steps) != 2 || result.Apply.Steps[0].Status != StepStatusSkipped || result.Apply.Steps[1].Status != StepStatusSkipped {
+		t.Fatalf("apply steps = %+v, want both skipped", result.Apply.Steps)
+	}
+}
+
+func TestRunnerStepTimeoutFailsSlowStep(t *testing.T) {
+	order := []string{}
+	runner := Runner{StepTimeout: 10 * time.Millisecond}
+
+	result := runner.Run(context.Background(), StageApply, []Step{
+		newDependentStep("hung", &order, func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}),
+	})
+
+	if result.Success || !errors.Is(result.Err, context.DeadlineExceeded) || !strings.Contains(result.Err.Error(), "timed out after 10ms") {
+		t.Fatalf("Run() error = %v, want step timeout", result.Err)
+	}
+}
+
+func TestOrchestratorTimeoutBoundsWholeExecution(t *testing.T) {
+	order := []string{}
+	orchestrator := NewOrchestrator(DefaultRollbackPolicy(), WithTimeout(10*time.Millisecond))
+
+	result := orchestrator.Execute(context.Background(), StagePlan{
+		Apply: []Step{
+			newDependentStep("hung", &order, func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			}),
+			newTestStep("after", &order),
+		},
+	})
+
+	if !errors.Is(result.Err, context.DeadlineExceeded) {
+		t.Fatalf("Execute() error = %v, want deadline exceeded", result.Err)
+	}
+	if got := result.Apply.Steps[1].Status; got != StepStatusSkipped {
+		t.Fatalf("after status = %q, want skipped", got)
+	}
+}
+
+func TestRunnerRetriesFailedAttempts(t *testing.T) {
+	order := []string{}
+	failures := 2
+	step := &retryStep{
+		dependentStep: newDependentStep("npm", &order, func(context.Context) error {
+			if failures > 0 {
+				failures--
+				return errors.New("ETIMEDOUT")
+			}
+			return nil
+		}),
+		policy: RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond},
+	}
+
+	events := []ProgressEvent{}
+	runner := Runner{OnProgress: func(e ProgressEvent) { events = append(events, e) }}
+	result := runner.Run(context.Background(), StageApply, []Step{step})
+
+	if result.Err != nil {
+		t.Fatalf("Run() error = %v", result.Err)
+	}
+	if got := len(result.Steps[0].Attempts); got != 3 {
+		t.Fatalf("attempts = %d, want 3", got)
+	}
+	if result.Steps[0].Attempts[0].Err == nil || result.Steps[0].Attempts[2].Err != nil {
+		t.Fatalf("attempts = %+v, want two failures then a success", result.Steps[0].Attempts)
+	}
+
+	got := []string{}
+	for _, event := range events {
+		got = append(got, fmt.Sprintf("%s %d/%d", event.Status, event.Attempt, event.MaxAttempts))
+	}
+	want := []string{"running 1/3", "retrying 2/3", "retrying 3/3", "succeeded 3/3"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("events = %v, want %v", got, want)
+	}
+}
+
+func TestRunnerDoesNotRetryErrorsThePolicyRejects(t *testing.T) {
+	order := []string{}
+	errConfig := errors.New("unsupported platform")
+	step := &retryStep{
+		dependentStep: newDependentStep("brew", &order, func(context.Context) error { return errConfig }),
+		policy: RetryPolicy{
+			MaxAttempts:    5,
+			InitialBackoff: time.Millisecond,
+			Retryable:      func(err error) bool { return !errors.Is(err, errConfig) },
+		},
+	}
+
+	result := Runner{}.Run(context.Background(), StageApply, []Step{step})
+
+	if !errors.Is(result.Err, errConfig) {
+		t.Fatalf("Run() error = %v, want %v", result.Err, errConfig)
+	}
+	if got := len(result.Steps[0].Attempts); got != 1 {
+		t.Fatalf("attempts = %d, want 1", got)
+	}
+}
+
+func TestRetryPolicyBackoffGrowsUpToMax(t *testing.T) {
+	policy := RetryPolicy{InitialBackoff: time.Second, MaxBackoff: 3 * time.Second}
+
+	got := []time.Duration{policy.Backoff(1), policy.Backoff(2), policy.Backoff(3)}
+	want := []time.Duration{time.Second, 2 * time.Second, 3 * time.Second}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("backoff = %v, want %v", got, want)
+	}
+}
+
+func TestRunnerSkipsUpToDateStepsAndRunsTheirDependents(t *testing.T) {
+	order := []string{}
+	events := []ProgressEvent{}
+	runner := Runner{OnProgress: func(e ProgressEvent) { events = append(events, e) }}
+
+	result := runner.Run(context.Background(), StageApply, []Step{
+		&checkedStep{dependentStep: newDependentStep("engram", &order, nil), check: CheckResult{Outcome: CheckUpToDate, Reason: "already configured"}},
+		&checkedStep{dependentStep: newDependentStep("sdd", &order, nil, "engram"), check: CheckResult{Outcome: CheckWillChange}},
+		&checkedStep{dependentStep: newDependentStep("skills", &order, nil, "sdd"), checkErr: errors.New("cannot tell")},
+	})
+
+	if !result.Success {
+		t.Fatalf("Run() error = %v", result.Err)
+	}
+	if !reflect.DeepEqual(order, []string{"run:sdd", "run:skills"}) {
+		t.Fatalf("execution order = %v, want the up-to-date step skipped and the others run", order)
+	}
+	if !result.Steps[0].UpToDate() || !result.Steps[0].Done() {
+		t.Fatalf("engram result = %+v, want skipped as up to date", result.Steps[0])
+	}
+	if result.Steps[1].Status != StepStatusSucceeded || result.Steps[1].Check.Outcome != CheckWillChange {
+		t.Fatalf("sdd result = %+v, want succeeded with its check recorded", result.Steps[1])
+	}
+
+	changed, upToDate := result.Changes()
+	if !reflect.DeepEqual(changed, []string{"sdd", "skills"}) || !reflect.DeepEqual(upToDate, []string{"engram"}) {
+		t.Fatalf("Changes() = %v, %v; want the step that could not be checked counted as changed", changed, upToDate)
+	}
+
+	skipped := events[1]
+	if skipped.StepID != "engram" || skipped.Status != StepStatusSkipped || skipped.Reason != "already configured" || skipped.Err != nil {
+		t.Fatalf("engram event = %+v, want skipped with the check's reason", skipped)
+	}
+}
+
+func indexOf(order []string, entry string) int {
+	for i, got := range order {
+		if got == entry {
+			return i
+		}
+	}
+	return -1
+}
+
+// orderMu guards the order slice when steps run in parallel.
+var orderMu sync.Mutex
+
+func TestOrchestratorRollsBackPrepareStepsAfterApplySteps(t *testing.T) {
+	order := []string{}
+	orchestrator := NewOrchestrator(RollbackPolicy{OnApplyFailure: true, RollbackPrepare: true})
+
+	result := orchestrator.Execute(context.Background(), StagePlan{
+		Prepare: []Step{newRollbackStep("prepare-1", &order, nil)},
+		Apply: []Step{
+			newRollbackStep("apply-1", &order, nil),
+			newRollbackStep("apply-2", &order, errors.New("boom")),
+		},
+	})
+
+	if result.Err == nil {
+		t.Fatalf("Execute() expected apply error")
+	}
+	wantOrder := []string{"run:prepare-1", "run:apply-1", "run:apply-2", "rollback:apply-1", "rollback:prepare-1"}
+	if !reflect.DeepEqual(order, wantOrder) {
+		t.Fatalf("execution order = %v, want %v", order, wantOrder)
+	}
+}
+
+func TestOrchestratorRollsBackPrepareStepsWhenPrepareFails(t *testing.T) {
+	order := []string{}
+	orchestrator := NewOrchestrator(RollbackPolicy{OnApplyFailure: true, RollbackPrepare: true})
+
+	result := orchestrator.Execute(context.Background(), StagePlan{
+		Prepare: []Step{
+			newRollbackStep("prepare-1", &order, nil),
+			newRollbackStep("prepare-2", &order, errors.New("boom")),
+		},
+		Apply: []Step{newRollbackStep("apply-1", &order, nil)},
+	})
+
+	if result.Err == nil {
+		t.Fatalf("Execute() expected prepare error")
+	}
+	wantOrder := []string{"run:prepare-1", "run:prepare-2", "rollback:prepare-1"}
+	if !reflect.DeepEqual(order, wantOrder) {
+		t.Fatalf("execution order = %v, want %v", order, wantOrder)
+	}
+}
+
+func TestOrchestratorDefaultPolicyKeepsPrepareSteps(t *testing.T) {
+	order := []string{}
+	orchestrator := NewOrchestrator(DefaultRollbackPolicy())
+
+	result := orchestrator.Execute(context.Background(), StagePlan{
+		Prepare: []Step{newRollbackStep("prepare-1", &order, nil)},
+		Apply:   []Step{newRollbackStep("apply-1", &order, errors.New("boom"))},
+	})
+
+	if result.Err == nil {
+		t.Fatalf("Execute() expected apply error")
+	}
+	if indexOf(order, "rollback:prepare-1") >= 0 {
+		t.Fatalf("execution order = %v, want the prepare step kept", order)
+	}
+}
+
+func TestRunnerTimedStepReplacesStepTimeout(t *testing.T) {
+	order := []string{}
+	runner := Runner{StepTimeout: time.Hour}
+
+	result := runner.Run(context.Background(), StageApply, []Step{
+		&timedStep{
+			dependentStep: newDependentStep("hook", &order, func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			}),
+			timeout: 10 * time.Millisecond,
+		},
+	})
+
+	if result.Success || !strings.Contains(result.Err.Error(), "timed out after 10ms") {
+		t.Fatalf("Run() error = %v, want the step's own timeout", result.Err)
+	}
+}
+
+func TestOrchestratorPartialRollbackKeepsSucceededSteps(t *testing.T) {
+	order := []string{}
+	restore := &partialRollbackStep{testStep: newTestStep("restore", &order)}
+	orchestrator := NewOrchestrator(PartialRollbackPolicy(), WithFailurePolicy(ContinueOnError))
+
+	result := orchestrator.Execute(context.Background(), StagePlan{
+		Prepare: []Step{newRollbackStep("hook", &order, nil)},
+		Apply: []Step{
+			restore,
+			newRollbackStep("claude", &order, nil),
+			newRollbackStep("gemini", &order, errors.New("boom")),
+		},
+	})
+
+	if result.Err == nil {
+		t.Fatalf("Execute() expected apply error")
+	}
+	wantOrder := []string{"run:hook", "run:restore", "run:claude", "run:gemini", "rollback-failed:restore"}
+	if !reflect.DeepEqual(order, wantOrder) {
+		t.Fatalf("execution order = %v, want %v", order, wantOrder)
+	}
+	if len(restore.results) != 3 || restore.results[2].StepID != "gemini" || restore.results[2].Status != StepStatusFailed {
+		t.Fatalf("RollbackFailed() results = %#v, want every apply result", restore.results)
+	}
+	if len(result.Rollback.Steps) != 1 || result.Rollback.Steps[0].Status != StepStatusRolledBack {
+		t.Fatalf("rollback steps = %#v", result.Rollback.Steps)
+	}
+}
+
+func TestOrchestratorPartialRollbackRollsBackEverythingWhenCancelled(t *testing.T) {
+	order := []string{}
+	ctx, cancel := context.WithCancel(context.Background())
+	orchestrator := NewOrchestrator(PartialRollbackPolicy())
+
+	orchestrator.Execute(ctx, StagePlan{
+		Apply: []Step{
+			&partialRollbackStep{testStep: newTestStep("restore", &order)},
+			newDependentStep("interrupted", &order, func(context.Context) error {
+				cancel()
+				return context.Canceled
+			}),
+		},
+	})
+
+	wantOrder := []string{"run:restore", "run:interrupted", "rollback:restore"}
+	if !reflect.DeepEqual(order, wantOrder) {
+		t.Fatalf("execution order = %v, want %v", order, wantOrder)
+	}
+}
+
+func TestOrchestratorPartialRollbackSkipsStepsOwningNothingFailed(t *testing.T) {
+	order := []string{}
+	events := []ProgressEvent{}
+	orchestrator := NewOrchestrator(
+		PartialRollbackPolicy(),
+		WithFailurePolicy(ContinueOnError),
+		WithProgressFunc(func(e ProgressEvent) { events = append(events, e) }),
+	)
+
+	result := orchestrator.Execute(context.Background(), StagePlan{
+		Apply: []Step{
+			&partialRollbackStep{testStep: newTestStep("restore", &order), ownsNothing: true},
+			newRollbackStep("gemini", &order, errors.New("boom")),
+		},
+	})
+
+	if result.Err == nil {
+		t.Fatalf("Execute() expected apply error")
+	}
+	if indexOf(order, "rollback-failed:restore") >= 0 {
+		t.Fatalf("execution order = %v, want no partial rollback", order)
+	}
+	if len(result.Rollback.Steps) != 0 {
+		t.Fatalf("rollback steps = %#v, want none", result.Rollback.Steps)
+	}
+	for _, event := range events {
+		if event.Stage == StageRollback {
+			t.Fatalf("rollback event %+v, want none", event)
+		}
+	}
+}
+
+type dependentStep struct {
+	*testStep
+	deps []string
+	run  func(context.Context) error
+}
+
+func newDependentStep(id string, order *[]string, run func(context.Context) error, deps ...string) *dependentStep {
+	return &dependentStep{testStep: newTestStep(id, order), deps: deps, run: run}
+}
+
+func (s *dependentStep) DependsOn() []string {
+	return s.deps
+}
+
+func (s *dependentStep) Run(ctx context.Context) error {
+	var err error
+	if s.run != nil {
+		err = s.run(ctx)
+	}
+
+	orderMu.Lock()
+	defer orderMu.Unlock()
+	if runErr := s.testStep.Run(ctx); runErr != nil {
+		return runErr
+	}
+	return err
+}
+
+type retryStep struct {
+	*dependentStep
+	policy RetryPolicy
+}
+
+func (s *retryStep) RetryPolicy() RetryPolicy {
+	return s.policy
+}
+
+type checkedStep struct {
+	*dependentStep
+	check    CheckResult
+	checkErr error
+}
+
+func (s *checkedStep) Check(context.Context) (CheckResult, error) {
+	return s.check, s.checkErr
+}
+
+type partialRollbackStep struct {
+	*testStep
+	results []StepResult
+	// ownsNothing makes OwnsFailed report no work to undo.
+	ownsNothing bool
+}
+
+func (s *partialRollbackStep) OwnsFailed([]StepResult) bool {
+	return !s.ownsNothing
+}
+
+func (s *partialRollbackStep) RollbackFailed(results []StepResult) error {
+	*s.order = append(*s.order, "rollback-failed:"+s.id)
+	s.results = results
+	return nil
+}
+
+type timedStep struct {
+	*dependentStep
+	timeout time.Duration
+}
+
+func (s *timedStep) Timeout() time.Duration {
+	return s.timeout
+}
+
 type testStep struct {
 	id      string
 	order   *[]string
@@ -311,7 +979,7 @@ func (s *testStep) ID() string {
 	return s.id
 }
 
-func (s *testStep) Run() error {
+func (s *testStep) Run(context.Context) error {
 	*s.order = append(*s.order, "run:"+s.id)
 	return s.runErr
 }
@@ -7,6 +7,7 @@ type StepStatus string
 const (
 	StepStatusPending    StepStatus = "pending"
 	StepStatusRunning    StepStatus = "running"
+	StepStatusRetrying   StepStatus = "retrying"
 	StepStatusSucceeded  StepStatus = "succeeded"
 	StepStatusFailed     StepStatus = "failed"
 	StepStatusRolledBack StepStatus = "rolled-back"
@@ -19,6 +20,31 @@ type StepResult struct {
 	StartedAt  time.Time
 	FinishedAt time.Time
 	Err        error
+	// Attempts lists every run of the step, oldest first. It holds more than
+	// one entry only for a step that was retried.
+	Attempts []Attempt
+	// Check is what a CheckedStep reported before running; it is zero for
+	// other steps.
+	Check CheckResult
+}
+
+// UpToDate reports whether the step was skipped because its check found
+// nothing to do.
+func (r StepResult) UpToDate() bool {
+	return r.Status == StepStatusSkipped && r.Check.Outcome == CheckUpToDate
+}
+
+// Done reports whether the step's work is in place: it succeeded, or there
+// was nothing for it to do.
+func (r StepResult) Done() bool {
+	return r.Status == StepStatusSucceeded || r.UpToDate()
+}
+
+// Attempt is one run of a step.
+type Attempt struct {
+	StartedAt  time.Time
+	FinishedAt time.Time
+	Err        error
 }
 
 type StageResult struct {
@@ -28,6 +54,20 @@ type StageResult struct {
 	Err     error
 }
 
+// Changes lists the checked steps of the stage that did work and those that
+// were already up to date. Steps without a check are in neither list.
+func (r StageResult) Changes() (changed []string, upToDate []string) {
+	for _, step := range r.Steps {
+		switch {
+		case step.UpToDate():
+			upToDate = append(upToDate, step.StepID)
+		case step.Status == StepStatusSucceeded && step.Check.Outcome != "":
+			changed = append(changed, step.StepID)
+		}
+	}
+	return changed, upToDate
+}
+
 type ExecutionResult struct {
 	Prepare  StageResult
 	Apply    StageResult
@@ -0,0 +1,78 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// RetryPolicy decides whether and when a failed step runs again.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of runs, the first included. Values
+	// below 2 disable retries.
+	MaxAttempts int
+	// InitialBackoff is the wait before the second attempt. Each further
+	// wait is Multiplier times the previous one, capped at MaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// Multiplier defaults to 2.
+	Multiplier float64
+	// Retryable reports whether an error is worth another attempt. With no
+	// Retryable every error is.
+	Retryable func(error) bool
+}
+
+// RetryableStep is a step the runner retries under its own policy.
+type RetryableStep interface {
+	Step
+	RetryPolicy() RetryPolicy
+}
+
+// Backoff returns the wait after the given failed attempt (1-based).
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier <= 0 {
+		multiplier = 2
+	}
+
+	wait := p.InitialBackoff
+	for i := 1; i < attempt; i++ {
+		wait = time.Duration(float64(wait) * multiplier)
+		if p.MaxBackoff > 0 && wait >= p.MaxBackoff {
+			return p.MaxBackoff
+		}
+	}
+	if p.MaxBackoff > 0 && wait > p.MaxBackoff {
+		return p.MaxBackoff
+	}
+	return wait
+}
+
+// ShouldRetry reports whether a step that failed its attempt-th run with err
+// should run again. Cancellation is never retried.
+func (p RetryPolicy) ShouldRetry(attempt int, err error) bool {
+	if err == nil || attempt >= p.MaxAttempts {
+		return false
+	}
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	return p.Retryable == nil || p.Retryable(err)
+}
+
+// wait sleeps for d or until ctx is done, and reports whether the full wait
+// elapsed.
+func wait(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -1,24 +1,64 @@
 package pipeline
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 type RollbackPolicy struct {
 	OnApplyFailure bool
+	// RollbackPrepare also rolls back the prepare steps that succeeded, such
+	// as user hooks: when a later prepare step fails, and after the apply
+	// steps when the apply stage is rolled back whole.
+	RollbackPrepare bool
+	// KeepSucceeded limits the rollback of a failed apply stage to the work
+	// of the steps that failed: PartialRollbackSteps undo only that, and
+	// every other step keeps its work. A cancelled run is still rolled back
+	// whole.
+	KeepSucceeded bool
+}
+
+// PartialRollbackStep is a RollbackStep that can also undo only the part of
+// the run that belongs to the steps that failed. results are every step
+// result of the apply stage. OwnsFailed reports whether there is any such
+// part; RollbackFailed is only called when there is.
+type PartialRollbackStep interface {
+	RollbackStep
+	OwnsFailed(results []StepResult) bool
+	RollbackFailed(results []StepResult) error
 }
 
 func DefaultRollbackPolicy() RollbackPolicy {
 	return RollbackPolicy{OnApplyFailure: true}
 }
 
+// PartialRollbackPolicy rolls back only what failed, for runs that continue
+// past a failed step and keep the rest.
+func PartialRollbackPolicy() RollbackPolicy {
+	policy := DefaultRollbackPolicy()
+	policy.KeepSucceeded = true
+	return policy
+}
+
 func (p RollbackPolicy) ShouldRollback(stage Stage, err error) bool {
 	if err == nil {
 		return false
 	}
 
-	return stage == StageApply && p.OnApplyFailure
+	switch stage {
+	case StageApply:
+		return p.OnApplyFailure
+	case StagePrepare:
+		return p.RollbackPrepare
+	default:
+		return false
+	}
 }
 
-func ExecuteRollback(steps []StepResult, stepIndex map[string]Step) StageResult {
+// ExecuteRollback calls Rollback on the succeeded RollbackSteps among steps,
+// last first, and stops at the first that fails. Each rollback is reported
+// to onProgress, which may be nil, as a rollback-stage event.
+func ExecuteRollback(steps []StepResult, stepIndex map[string]Step, onProgress ProgressFunc) StageResult {
 	result := StageResult{Stage: StageRollback, Success: true}
 
 	for i := len(steps) - 1; i >= 0; i-- {
@@ -37,19 +77,63 @@ func ExecuteRollback(steps []StepResult, stepIndex map[string]Step) StageResult
 			continue
 		}
 
-		err := rollbackStep.Rollback()
-		item := StepResult{StepID: rollbackStep.ID(), Status: StepStatusRolledBack}
-		if err != nil {
-			item.Status = StepStatusFailed
-			item.Err = err
-			result.Steps = append(result.Steps, item)
+		item := runRollback(rollbackStep.ID(), rollbackStep.Rollback, onProgress)
+		result.Steps = append(result.Steps, item)
+		if item.Err != nil {
 			result.Success = false
-			result.Err = fmt.Errorf("rollback step %q: %w", rollbackStep.ID(), err)
+			result.Err = fmt.Errorf("rollback step %q: %w", rollbackStep.ID(), item.Err)
 			return result
 		}
+	}
 
+	return result
+}
+
+// ExecutePartialRollback calls RollbackFailed on the succeeded
+// PartialRollbackSteps among steps that own work of the failed ones, last
+// first. Other steps keep their work and are left out of the result.
+// Progress is reported as by ExecuteRollback.
+func ExecutePartialRollback(steps []StepResult, stepIndex map[string]Step, onProgress ProgressFunc) StageResult {
+	result := StageResult{Stage: StageRollback, Success: true}
+
+	for i := len(steps) - 1; i >= 0; i-- {
+		if steps[i].Status != StepStatusSucceeded {
+			continue
+		}
+
+		partial, ok := stepIndex[steps[i].StepID].(PartialRollbackStep)
+		if !ok || !partial.OwnsFailed(steps) {
+			continue
+		}
+
+		item := runRollback(partial.ID(), func() error { return partial.RollbackFailed(steps) }, onProgress)
 		result.Steps = append(result.Steps, item)
+		if item.Err != nil {
+			result.Success = false
+			result.Err = fmt.Errorf("rollback step %q: %w", partial.ID(), item.Err)
+			return result
+		}
 	}
 
 	return result
 }
+
+// runRollback runs undo for the step with the given ID and reports it to
+// onProgress as running, then rolled back or failed.
+func runRollback(id string, undo func() error, onProgress ProgressFunc) StepResult {
+	emit := func(event ProgressEvent) {
+		if onProgress != nil {
+			onProgress(event)
+		}
+	}
+
+	item := StepResult{StepID: id, Status: StepStatusRolledBack, StartedAt: time.Now()}
+	emit(ProgressEvent{StepID: id, Stage: StageRollback, Status: StepStatusRunning})
+	item.Err = undo()
+	item.FinishedAt = time.Now()
+	if item.Err != nil {
+		item.Status = StepStatusFailed
+	}
+	emit(ProgressEvent{StepID: id, Stage: StageRollback, Status: item.Status, Err: item.Err, Duration: item.FinishedAt.Sub(item.StartedAt)})
+	return item
+}
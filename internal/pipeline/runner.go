@@ -1,63 +1,329 @@
 package pipeline
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"time"
 )
 
 // Runner executes a list of steps for a given stage.
+//
+// Steps that implement DependentStep run as soon as the steps they depend on
+// have succeeded, up to MaxParallel at a time. Every other step waits for all
+// steps listed before it, and every step listed after it waits for it, so a
+// plan of plain steps runs strictly in order.
+//
+// OnProgress is always called from the goroutine that called Run, one event
+// at a time: each step reports running before it reports its outcome, even
+// when other steps interleave. Results are listed in plan order whatever
+// order the steps finished in, so rolling them back in reverse undoes a step
+// before anything it depends on.
+//
+// Once ctx is done no further step starts: steps already running see the
+// cancellation through their own context, and every step that never started
+// is reported as skipped.
+//
+// A failed RetryableStep runs again under its RetryPolicy, after a
+// StepStatusRetrying event; every attempt is recorded in its result.
+//
+// A TimedStep's own timeout replaces StepTimeout for it.
+//
+// A CheckedStep is checked first and skipped, with the check's reason, when
+// it is already up to date.
 type Runner struct {
 	FailurePolicy FailurePolicy
 	OnProgress    ProgressFunc
+	// MaxParallel is the number of steps that may run at once. Values below
+	// 2 run one step at a time.
+	MaxParallel int
+	// StepTimeout bounds how long each attempt of a step may run. Zero
+	// means no limit.
+	StepTimeout time.Duration
 }
 
-func (r Runner) Run(stage Stage, steps []Step) StageResult {
+// stepMessage is sent by a running step: once before each retry, and once
+// with its final result.
+type stepMessage struct {
+	index  int
+	retry  *ProgressEvent
+	result StepResult
+}
+
+func (r Runner) Run(ctx context.Context, stage Stage, steps []Step) StageResult {
 	result := StageResult{Stage: stage, Success: true, Steps: make([]StepResult, 0, len(steps))}
+
+	after, requires, err := stepDependencies(stage, steps)
+	if err != nil {
+		result.Success = false
+		result.Err = err
+		return result
+	}
+
+	workers := max(r.MaxParallel, 1)
+	outcomes := make([]*StepResult, len(steps))
+	started := make([]bool, len(steps))
+	messages := make(chan stepMessage)
+	running := 0
+	stopped := false
+	cancelled := ctx.Done()
 	var errs []error
 
-	for _, step := range steps {
-		r.emitProgress(ProgressEvent{StepID: step.ID(), Stage: stage, Status: StepStatusRunning})
+	for {
+		if cancelled != nil && ctx.Err() != nil {
+			cancelled = nil
+			stopped = true
+			errs = append(errs, ctx.Err())
+			result.Success = false
+			r.skipUnstarted(stage, steps, started, outcomes, fmt.Errorf("not run: %w", ctx.Err()))
+		}
 
-		started := time.Now().UTC()
-		err := step.Run()
-		finished := time.Now().UTC()
+		for i := 0; i < len(steps) && !stopped && running < workers; i++ {
+			if started[i] || !allFinished(outcomes, after[i]) {
+				continue
+			}
+			started[i] = true
+
+			if failed := firstUndone(outcomes, requires[i]); failed >= 0 {
+				now := time.Now().UTC()
+				skipErr := fmt.Errorf("dependency %q did not succeed", steps[failed].ID())
+				outcomes[i] = &StepResult{StepID: steps[i].ID(), Status: StepStatusSkipped, StartedAt: now, FinishedAt: now, Err: skipErr}
+				r.emitProgress(ProgressEvent{StepID: steps[i].ID(), Stage: stage, Status: StepStatusSkipped, Err: skipErr})
+				continue
+			}
 
-		stepResult := StepResult{
-			StepID:     step.ID(),
-			StartedAt:  started,
-			FinishedAt: finished,
+			r.emitProgress(ProgressEvent{StepID: steps[i].ID(), Stage: stage, Status: StepStatusRunning, Attempt: 1, MaxAttempts: maxAttempts(steps[i])})
+			running++
+			go func(index int, step Step) {
+				notify := func(event ProgressEvent) {
+					messages <- stepMessage{index: index, retry: &event}
+				}
+				messages <- stepMessage{index: index, result: r.runStep(ctx, stage, step, notify)}
+			}(i, steps[i])
 		}
 
-		if err != nil {
-			stepResult.Status = StepStatusFailed
-			stepResult.Err = err
-			result.Steps = append(result.Steps, stepResult)
+		if running == 0 {
+			break
+		}
+
+		var message stepMessage
+		select {
+		case message = <-messages:
+		case <-cancelled:
+			// Report the steps that will never run now; the loop then keeps
+			// waiting for the running ones.
+			continue
+		}
+		if message.retry != nil {
+			r.emitProgress(*message.retry)
+			continue
+		}
+		running--
+		outcomes[message.index] = &message.result
 
-			r.emitProgress(ProgressEvent{StepID: step.ID(), Stage: stage, Status: StepStatusFailed, Err: err})
+		stepResult := message.result
+		took := stepResult.FinishedAt.Sub(stepResult.StartedAt)
+		if stepResult.UpToDate() {
+			r.emitProgress(ProgressEvent{StepID: stepResult.StepID, Stage: stage, Status: StepStatusSkipped, Reason: stepResult.Check.Reason, Duration: took})
+			continue
+		}
 
-			errs = append(errs, err)
+		attempts, limit := len(stepResult.Attempts), maxAttempts(steps[message.index])
+		if stepResult.Err != nil {
+			r.emitProgress(ProgressEvent{StepID: stepResult.StepID, Stage: stage, Status: StepStatusFailed, Err: stepResult.Err, Attempt: attempts, MaxAttempts: limit, Duration: took})
+			errs = append(errs, stepResult.Err)
 			result.Success = false
-
 			if r.FailurePolicy == StopOnError {
-				result.Err = err
-				return result
+				// Steps already running finish; nothing new starts.
+				stopped = true
 			}
-
 			continue
 		}
 
-		stepResult.Status = StepStatusSucceeded
-		result.Steps = append(result.Steps, stepResult)
-		r.emitProgress(ProgressEvent{StepID: step.ID(), Stage: stage, Status: StepStatusSucceeded})
+		r.emitProgress(ProgressEvent{StepID: stepResult.StepID, Stage: stage, Status: StepStatusSucceeded, Attempt: attempts, MaxAttempts: limit, Duration: took})
 	}
 
-	if len(errs) > 0 {
+	for _, outcome := range outcomes {
+		if outcome != nil {
+			result.Steps = append(result.Steps, *outcome)
+		}
+	}
+
+	switch {
+	case len(errs) == 0:
+	case r.FailurePolicy == StopOnError && len(errs) == 1:
+		result.Err = errs[0]
+	default:
 		result.Err = errors.Join(errs...)
 	}
 
 	return result
 }
 
+// skipUnstarted marks every step that has not started as skipped.
+func (r Runner) skipUnstarted(stage Stage, steps []Step, started []bool, outcomes []*StepResult, reason error) {
+	now := time.Now().UTC()
+	for i, step := range steps {
+		if started[i] {
+			continue
+		}
+		started[i] = true
+		outcomes[i] = &StepResult{StepID: step.ID(), Status: StepStatusSkipped, StartedAt: now, FinishedAt: now, Err: reason}
+		r.emitProgress(ProgressEvent{StepID: step.ID(), Stage: stage, Status: StepStatusSkipped, Err: reason})
+	}
+}
+
+// runStep runs step until an attempt succeeds or its retry policy gives up,
+// calling notify before each retry.
+func (r Runner) runStep(ctx context.Context, stage Stage, step Step, notify func(ProgressEvent)) StepResult {
+	var check CheckResult
+	if checked, ok := step.(CheckedStep); ok {
+		started := time.Now().UTC()
+		result, err := checked.Check(ctx)
+		if err != nil {
+			// A step that cannot tell runs as if it had work to do.
+			result = CheckResult{Outcome: CheckWillChange, Reason: fmt.Sprintf("check failed: %v", err)}
+		}
+		check = result
+		if check.Outcome == CheckUpToDate {
+			return StepResult{StepID: step.ID(), Status: StepStatusSkipped, StartedAt: started, FinishedAt: time.Now().UTC(), Check: check}
+		}
+	}
+
+	var policy RetryPolicy
+	if retryable, ok := step.(RetryableStep); ok {
+		policy = retryable.RetryPolicy()
+	}
+	limit := maxAttempts(step)
+
+	var attempts []Attempt
+	for attempt := 1; ; attempt++ {
+		current := r.runAttempt(ctx, step)
+		attempts = append(attempts, current)
+		if ctx.Err() != nil || !policy.ShouldRetry(attempt, current.Err) {
+			break
+		}
+
+		notify(ProgressEvent{StepID: step.ID(), Stage: stage, Status: StepStatusRetrying, Err: current.Err, Attempt: attempt + 1, MaxAttempts: limit})
+		if !wait(ctx, policy.Backoff(attempt)) {
+			break
+		}
+	}
+
+	last := attempts[len(attempts)-1]
+	stepResult := StepResult{
+		StepID:     step.ID(),
+		Status:     StepStatusSucceeded,
+		StartedAt:  attempts[0].StartedAt,
+		FinishedAt: last.FinishedAt,
+		Attempts:   attempts,
+		Check:      check,
+	}
+	if last.Err != nil {
+		stepResult.Status = StepStatusFailed
+		stepResult.Err = last.Err
+		if len(attempts) > 1 {
+			stepResult.Err = fmt.Errorf("failed after %d attempts: %w", len(attempts), last.Err)
+		}
+	}
+
+	return stepResult
+}
+
+func (r Runner) runAttempt(ctx context.Context, step Step) Attempt {
+	timeout := r.StepTimeout
+	if timed, ok := step.(TimedStep); ok && timed.Timeout() > 0 {
+		timeout = timed.Timeout()
+	}
+
+	stepCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		stepCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	started := time.Now().UTC()
+	err := step.Run(stepCtx)
+	finished := time.Now().UTC()
+
+	if err != nil && ctx.Err() == nil && errors.Is(stepCtx.Err(), context.DeadlineExceeded) {
+		err = fmt.Errorf("step %q timed out after %s: %w", step.ID(), timeout, err)
+	}
+
+	return Attempt{StartedAt: started, FinishedAt: finished, Err: err}
+}
+
+// maxAttempts returns how many times step may run.
+func maxAttempts(step Step) int {
+	if retryable, ok := step.(RetryableStep); ok {
+		return max(retryable.RetryPolicy().MaxAttempts, 1)
+	}
+	return 1
+}
+
+// stepDependencies returns, for each step, the indexes of the steps that must
+// finish before it starts and of those that must also have succeeded.
+// Dependencies must be listed earlier in the stage, which keeps plan order a
+// valid execution order.
+func stepDependencies(stage Stage, steps []Step) (after [][]int, requires [][]int, err error) {
+	index := make(map[string]int, len(steps))
+	after = make([][]int, len(steps))
+	requires = make([][]int, len(steps))
+	barrier := -1
+
+	for i, step := range steps {
+		dependent, ok := step.(DependentStep)
+		if !ok {
+			// A plain step waits for everything before it.
+			for j := barrier + 1; j < i; j++ {
+				after[i] = append(after[i], j)
+			}
+			if barrier >= 0 {
+				after[i] = append(after[i], barrier)
+			}
+			barrier = i
+			index[step.ID()] = i
+			continue
+		}
+
+		if barrier >= 0 {
+			after[i] = append(after[i], barrier)
+		}
+		for _, id := range dependent.DependsOn() {
+			j, ok := index[id]
+			if !ok {
+				return nil, nil, fmt.Errorf("%s step %q depends on %q, which is not listed before it", stage, step.ID(), id)
+			}
+			after[i] = append(after[i], j)
+			requires[i] = append(requires[i], j)
+		}
+		index[step.ID()] = i
+	}
+
+	return after, requires, nil
+}
+
+func allFinished(outcomes []*StepResult, indexes []int) bool {
+	for _, i := range indexes {
+		if outcomes[i] == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// firstUndone returns the first of indexes whose step is not done, or
+// -1.
+func firstUndone(outcomes []*StepResult, indexes []int) int {
+	for _, i := range indexes {
+		if !outcomes[i].Done() {
+			return i
+		}
+	}
+	return -1
+}
+
 func (r Runner) emitProgress(event ProgressEvent) {
 	if r.OnProgress != nil {
 		r.OnProgress(event)
@@ -1,5 +1,10 @@
 package pipeline
 
+import (
+	"context"
+	"time"
+)
+
 type Stage string
 
 const (
@@ -8,16 +13,36 @@ const (
 	StageRollback Stage = "rollback"
 )
 
+// Step is one unit of work in a stage. Run should return promptly once ctx
+// is done; the runner cancels ctx on interruption and when a step timeout
+// expires.
 type Step interface {
 	ID() string
-	Run() error
+	Run(ctx context.Context) error
+}
+
+// DependentStep is a step that only needs some earlier steps of its stage,
+// named by ID, to have succeeded before it runs. The runner may run it
+// alongside other steps; if a dependency fails it is skipped.
+type DependentStep interface {
+	Step
+	DependsOn() []string
 }
 
+// RollbackStep is a step that can undo its work. Rollback takes no context:
+// it runs after a cancelled stage too, and must be allowed to finish.
 type RollbackStep interface {
 	Step
 	Rollback() error
 }
 
+// TimedStep is a step with its own time limit for each attempt, which
+// replaces the runner's StepTimeout for it. Zero keeps the runner's limit.
+type TimedStep interface {
+	Step
+	Timeout() time.Duration
+}
+
 // FailurePolicy controls how the runner behaves when a step fails.
 type FailurePolicy int
 
@@ -28,12 +53,23 @@ const (
 	ContinueOnError
 )
 
-// ProgressEvent is emitted by the runner as each step starts and completes.
+// ProgressEvent is emitted by the runner as each step starts and completes,
+// and with StepStatusRetrying before each retry of a failed attempt.
 type ProgressEvent struct {
 	StepID string
 	Stage  Stage
 	Status StepStatus
 	Err    error
+	// Attempt is the attempt about to run (for retrying) or that finished,
+	// starting at 1. MaxAttempts is the step's limit, 1 without retries.
+	Attempt     int
+	MaxAttempts int
+	// Reason says why a step was skipped when it was not for an error, e.g.
+	// because its check found it already up to date.
+	Reason string
+	// Duration is how long the step took, on the event reporting its
+	// outcome.
+	Duration time.Duration
 }
 
 // ProgressFunc is a callback invoked for every step lifecycle event.
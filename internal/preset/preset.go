@@ -0,0 +1,303 @@
+package preset
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+
+	"github.com/gentleman-programming/gentle-ai/internal/assets"
+	"github.com/gentleman-programming/gentle-ai/internal/catalog"
+	"github.com/gentleman-programming/gentle-ai/internal/model"
+)
+
+// Preset is a named bundle of install choices. Built-in presets are embedded
+// from assets/presets; users can add their own as JSON files in
+// ~/.gentle-ai/presets.
+type Preset struct {
+	ID          model.PresetID      `json:"id"`
+	Order       int                 `json:"order,omitempty"`
+	Name        string              `json:"name"`
+	Description string              `json:"description"`
+	Components  []model.ComponentID `json:"components"`
+	Skills      []model.SkillID     `json:"skills"`
+	Persona     model.PersonaID     `json:"persona,omitempty"`
+	SDDMode     model.SDDModeID     `json:"sdd_mode,omitempty"`
+
+	// Source is the file the preset was read from.
+	Source string `json:"-"`
+	// Builtin is true for presets shipped with gentle-ai.
+	Builtin bool `json:"-"`
+}
+
+// Registry resolves preset IDs. The zero value is empty; use Builtin or Load.
+type Registry struct {
+	presets []Preset
+}
+
+var idPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+var (
+	builtinOnce     sync.Once
+	builtinRegistry Registry
+	builtinErr      error
+
+	defaultMu       sync.RWMutex
+	defaultRegistry *Registry
+)
+
+// UserDir returns the directory user presets are loaded from.
+func UserDir(homeDir string) string {
+	return filepath.Join(homeDir, ".gentle-ai", "presets")
+}
+
+// Builtin returns the presets embedded in the binary. It panics if the
+// embedded definitions are invalid, which the package tests rule out.
+func Builtin() Registry {
+	builtinOnce.Do(func() {
+		builtinRegistry, builtinErr = readBuiltin()
+	})
+	if builtinErr != nil {
+		panic("preset: " + builtinErr.Error())
+	}
+
+	return builtinRegistry
+}
+
+// Load returns the built-in presets plus every *.json preset in UserDir. A
+// missing directory is not an error; an invalid file or an ID that clashes
+// with another preset is.
+func Load(homeDir string) (Registry, error) {
+	registry := Registry{presets: append([]Preset{}, Builtin().presets...)}
+
+	dir := UserDir(homeDir)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return registry, nil
+		}
+		return Registry{}, fmt.Errorf("read preset directory %q: %w", dir, err)
+	}
+
+	user := []Preset{}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return Registry{}, fmt.Errorf("read preset %q: %w", path, err)
+		}
+
+		preset, err := parse(content, path)
+		if err != nil {
+			return Registry{}, err
+		}
+		user = append(user, preset)
+	}
+
+	sort.Slice(user, func(i, j int) bool { return user[i].ID < user[j].ID })
+	for _, preset := range user {
+		if err := registry.add(preset); err != nil {
+			return Registry{}, err
+		}
+	}
+
+	return registry, nil
+}
+
+// Default returns the registry every entry point resolves presets through.
+// It is the built-in set until SetDefault installs one with user presets.
+func Default() Registry {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+
+	if defaultRegistry == nil {
+		return Builtin()
+	}
+	return *defaultRegistry
+}
+
+// SetDefault replaces the shared registry and returns a function that
+// restores the previous one.
+func SetDefault(registry Registry) func() {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	previous := defaultRegistry
+	defaultRegistry = &registry
+	return func() {
+		defaultMu.Lock()
+		defer defaultMu.Unlock()
+		defaultRegistry = previous
+	}
+}
+
+// All returns the presets in display order: built-ins by their order field,
+// then user presets by ID.
+func (r Registry) All() []Preset {
+	presets := make([]Preset, len(r.presets))
+	copy(presets, r.presets)
+	return presets
+}
+
+// IDs returns the preset IDs in display order.
+func (r Registry) IDs() []model.PresetID {
+	ids := make([]model.PresetID, 0, len(r.presets))
+	for _, preset := range r.presets {
+		ids = append(ids, preset.ID)
+	}
+	return ids
+}
+
+// Get looks up a preset by ID.
+func (r Registry) Get(id model.PresetID) (Preset, bool) {
+	for _, preset := range r.presets {
+		if preset.ID == id {
+			return preset.clone(), true
+		}
+	}
+	return Preset{}, false
+}
+
+// Resolve returns the preset for id, falling back to full-gentleman for
+// unknown IDs so older selections keep installing what they used to.
+func (r Registry) Resolve(id model.PresetID) Preset {
+	if preset, ok := r.Get(id); ok {
+		return preset
+	}
+	preset, _ := r.Get(model.PresetFullGentleman)
+	return preset
+}
+
+// Components returns the components a preset installs. The custom preset
+// returns nil: the caller provides the list.
+func (r Registry) Components(id model.PresetID) []model.ComponentID {
+	components := r.Resolve(id).Components
+	if len(components) == 0 {
+		return nil
+	}
+	return components
+}
+
+// Skills returns the skills a preset installs, nil when it leaves the choice
+// to the caller.
+func (r Registry) Skills(id model.PresetID) []model.SkillID {
+	skills := r.Resolve(id).Skills
+	if len(skills) == 0 {
+		return nil
+	}
+	return skills
+}
+
+func (r *Registry) add(preset Preset) error {
+	for _, existing := range r.presets {
+		if existing.ID == preset.ID {
+			return fmt.Errorf("preset %q in %q clashes with the preset of the same id in %q", preset.ID, preset.Source, existing.Source)
+		}
+	}
+	r.presets = append(r.presets, preset)
+	return nil
+}
+
+func (p Preset) clone() Preset {
+	p.Components = append([]model.ComponentID(nil), p.Components...)
+	p.Skills = append([]model.SkillID(nil), p.Skills...)
+	return p
+}
+
+func readBuiltin() (Registry, error) {
+	paths, err := fs.Glob(assets.FS, "presets/*.json")
+	if err != nil {
+		return Registry{}, err
+	}
+
+	presets := []Preset{}
+	for _, path := range paths {
+		content, err := assets.FS.ReadFile(path)
+		if err != nil {
+			return Registry{}, fmt.Errorf("read embedded preset %q: %w", path, err)
+		}
+		preset, err := parse(content, path)
+		if err != nil {
+			return Registry{}, err
+		}
+		preset.Builtin = true
+		presets = append(presets, preset)
+	}
+
+	sort.SliceStable(presets, func(i, j int) bool { return presets[i].Order < presets[j].Order })
+
+	registry := Registry{}
+	for _, preset := range presets {
+		if err := registry.add(preset); err != nil {
+			return Registry{}, err
+		}
+	}
+	return registry, nil
+}
+
+func parse(content []byte, source string) (Preset, error) {
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.DisallowUnknownFields()
+
+	var preset Preset
+	if err := decoder.Decode(&preset); err != nil {
+		return Preset{}, fmt.Errorf("parse preset %q: %w", source, err)
+	}
+	preset.Source = source
+
+	if err := preset.Validate(); err != nil {
+		return Preset{}, fmt.Errorf("preset %q: %w", source, err)
+	}
+
+	return preset, nil
+}
+
+// Validate checks the preset against the component and skill catalogs.
+func (p Preset) Validate() error {
+	if !idPattern.MatchString(string(p.ID)) {
+		return fmt.Errorf("invalid id %q (use lowercase letters, digits and dashes)", p.ID)
+	}
+
+	components := map[model.ComponentID]bool{}
+	for _, component := range catalog.MVPComponents() {
+		components[component.ID] = true
+	}
+	for _, component := range p.Components {
+		if !components[component] {
+			return fmt.Errorf("unsupported component %q", component)
+		}
+	}
+
+	skills := map[model.SkillID]bool{}
+	for _, skill := range catalog.MVPSkills() {
+		skills[skill.ID] = true
+	}
+	for _, skill := range p.Skills {
+		if !skills[skill] {
+			return fmt.Errorf("unsupported skill %q", skill)
+		}
+	}
+
+	switch p.Persona {
+	case "", model.PersonaGentleman, model.PersonaNeutral, model.PersonaCustom:
+	default:
+		return fmt.Errorf("unsupported persona %q", p.Persona)
+	}
+
+	switch p.SDDMode {
+	case "", model.SDDModeSingle, model.SDDModeMulti:
+	default:
+		return fmt.Errorf("unsupported sdd_mode %q (valid: single, multi)", p.SDDMode)
+	}
+
+	return nil
+}
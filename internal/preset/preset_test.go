@@ -0,0 +1,154 @@
+package preset
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gentleman-programming/gentle-ai/internal/model"
+)
+
+func TestBuiltinPresetsAreOrdered(t *testing.T) {
+	want := []model.PresetID{
+		model.PresetFullGentleman,
+		model.PresetEcosystemOnly,
+		model.PresetMinimal,
+		model.PresetCustom,
+	}
+
+	if got := Builtin().IDs(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Builtin().IDs() = %v, want %v", got, want)
+	}
+
+	for _, preset := range Builtin().All() {
+		if !preset.Builtin {
+			t.Fatalf("preset %q not marked builtin", preset.ID)
+		}
+		if preset.Description == "" {
+			t.Fatalf("preset %q has no description", preset.ID)
+		}
+	}
+}
+
+func TestResolveFallsBackToFullGentleman(t *testing.T) {
+	registry := Builtin()
+
+	if got := registry.Resolve("nope").ID; got != model.PresetFullGentleman {
+		t.Fatalf("Resolve(unknown) = %q", got)
+	}
+	if got := registry.Components(model.PresetCustom); got != nil {
+		t.Fatalf("Components(custom) = %v, want nil", got)
+	}
+	if got := registry.Components(model.PresetMinimal); !reflect.DeepEqual(got, []model.ComponentID{model.ComponentEngram}) {
+		t.Fatalf("Components(minimal) = %v", got)
+	}
+}
+
+func TestLoadAddsUserPresets(t *testing.T) {
+	home := t.TempDir()
+	writePreset(t, home, "backend.json", `{
+  "id": "backend",
+  "name": "Backend",
+  "description": "Memory and SDD in multi mode",
+  "components": ["engram", "sdd"],
+  "skills": ["go-testing"],
+  "persona": "neutral",
+  "sdd_mode": "multi"
+}`)
+	writePreset(t, home, "notes.txt", "ignored")
+
+	registry, err := Load(home)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	ids := registry.IDs()
+	if ids[len(ids)-1] != "backend" {
+		t.Fatalf("IDs() = %v, want user preset last", ids)
+	}
+
+	backend, ok := registry.Get("backend")
+	if !ok {
+		t.Fatalf("Get(backend) not found")
+	}
+	if backend.Builtin || backend.Persona != model.PersonaNeutral || backend.SDDMode != model.SDDModeMulti {
+		t.Fatalf("backend preset = %#v", backend)
+	}
+	if !reflect.DeepEqual(registry.Components("backend"), []model.ComponentID{model.ComponentEngram, model.ComponentSDD}) {
+		t.Fatalf("Components(backend) = %v", registry.Components("backend"))
+	}
+}
+
+func TestLoadWithoutUserDirectoryReturnsBuiltins(t *testing.T) {
+	registry, err := Load(t.TempDir())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !reflect.DeepEqual(registry.IDs(), Builtin().IDs()) {
+		t.Fatalf("IDs() = %v", registry.IDs())
+	}
+}
+
+func TestLoadRejectsInvalidPresets(t *testing.T) {
+	tests := map[string]struct {
+		content string
+		want    string
+	}{
+		"clashing id":       {`{"id": "minimal", "name": "Mine"}`, "clashes with the preset of the same id"},
+		"unknown component": {`{"id": "x", "components": ["vim"]}`, "unsupported component \"vim\""},
+		"unknown skill":     {`{"id": "x", "skills": ["nope"]}`, "unsupported skill \"nope\""},
+		"unknown field":     {`{"id": "x", "colour": "blue"}`, "unknown field \"colour\""},
+		"bad id":            {`{"id": "Team Preset"}`, "invalid id"},
+		"bad sdd mode":      {`{"id": "x", "sdd_mode": "triple"}`, "unsupported sdd_mode"},
+		"broken json":       {`{"id": `, "parse preset"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			home := t.TempDir()
+			writePreset(t, home, "team.json", tc.content)
+
+			_, err := Load(home)
+			if err == nil {
+				t.Fatalf("Load() expected error containing %q", tc.want)
+			}
+			if !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("Load() error = %q, want it to contain %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestSetDefaultRestoresPreviousRegistry(t *testing.T) {
+	home := t.TempDir()
+	writePreset(t, home, "team.json", `{"id": "team", "components": ["engram"]}`)
+
+	registry, err := Load(home)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	restore := SetDefault(registry)
+	if _, ok := Default().Get("team"); !ok {
+		t.Fatalf("Default() missing user preset after SetDefault")
+	}
+
+	restore()
+	if _, ok := Default().Get("team"); ok {
+		t.Fatalf("Default() still has user preset after restore")
+	}
+}
+
+func writePreset(t *testing.T, home, name, content string) {
+	t.Helper()
+
+	dir := UserDir(home)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+}
@@ -0,0 +1,231 @@
+package profile
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/gentleman-programming/gentle-ai/internal/catalog"
+	"github.com/gentleman-programming/gentle-ai/internal/model"
+	"github.com/gentleman-programming/gentle-ai/internal/preset"
+	"gopkg.in/yaml.v3"
+)
+
+// CurrentVersion is the profile schema version written by this build. Files
+// with a newer version are rejected instead of being half understood.
+const CurrentVersion = 1
+
+// Profile is a shareable, declarative description of an install: the same
+// choices the TUI or the install flags produce, in a file a team can commit.
+type Profile struct {
+	Version          int
+	Name             string
+	Agents           []model.AgentID
+	Components       []model.ComponentID
+	Skills           []model.SkillID
+	Persona          model.PersonaID
+	Preset           model.PresetID
+	SDDMode          model.SDDModeID
+	ModelAssignments map[string]model.ModelAssignment
+}
+
+// FromSelection captures a selection as a profile.
+func FromSelection(name string, selection model.Selection) Profile {
+	profile := Profile{
+		Version:    CurrentVersion,
+		Name:       name,
+		Agents:     selection.Agents,
+		Components: selection.Components,
+		Skills:     selection.Skills,
+		Persona:    selection.Persona,
+		Preset:     selection.Preset,
+		SDDMode:    selection.SDDMode,
+	}
+	if len(selection.ModelAssignments) > 0 {
+		profile.ModelAssignments = selection.ModelAssignments
+	}
+
+	return profile
+}
+
+// Selection converts the profile back into an install selection.
+func (p Profile) Selection() model.Selection {
+	return model.Selection{
+		Agents:           p.Agents,
+		Components:       p.Components,
+		Skills:           p.Skills,
+		Persona:          p.Persona,
+		Preset:           p.Preset,
+		SDDMode:          p.SDDMode,
+		ModelAssignments: p.ModelAssignments,
+	}
+}
+
+// Load reads and validates the profile at path.
+func Load(path string) (Profile, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return Profile{}, fmt.Errorf("read profile %q: %w", path, err)
+	}
+
+	profile, err := Decode(content)
+	if err != nil {
+		return Profile{}, fmt.Errorf("profile %q: %w", path, err)
+	}
+
+	return profile, nil
+}
+
+// document is the on-disk shape of a profile.
+type document struct {
+	Version          int                           `yaml:"version"`
+	Name             string                        `yaml:"name,omitempty"`
+	Agents           []model.AgentID               `yaml:"agents,omitempty"`
+	Components       []model.ComponentID           `yaml:"components,omitempty"`
+	Skills           []model.SkillID               `yaml:"skills,omitempty"`
+	Persona          model.PersonaID               `yaml:"persona,omitempty"`
+	Preset           model.PresetID                `yaml:"preset,omitempty"`
+	SDDMode          model.SDDModeID               `yaml:"sdd_mode,omitempty"`
+	ModelAssignments map[string]assignmentDocument `yaml:"model_assignments,omitempty"`
+}
+
+type assignmentDocument struct {
+	ProviderID string `yaml:"provider_id"`
+	ModelID    string `yaml:"model_id"`
+}
+
+// Decode parses a profile document and validates it against the schema.
+// Unknown fields are rejected so a typo never silently falls back to a default.
+func Decode(content []byte) (Profile, error) {
+	doc := document{}
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&doc); err != nil && !errors.Is(err, io.EOF) {
+		return Profile{}, fmt.Errorf("parse profile: %w", err)
+	}
+
+	profile := Profile{
+		Version:    doc.Version,
+		Name:       doc.Name,
+		Agents:     doc.Agents,
+		Components: doc.Components,
+		Skills:     doc.Skills,
+		Persona:    doc.Persona,
+		Preset:     doc.Preset,
+		SDDMode:    doc.SDDMode,
+	}
+	if len(doc.ModelAssignments) > 0 {
+		profile.ModelAssignments = make(map[string]model.ModelAssignment, len(doc.ModelAssignments))
+		for name, assignment := range doc.ModelAssignments {
+			profile.ModelAssignments[name] = model.ModelAssignment{
+				ProviderID: assignment.ProviderID,
+				ModelID:    assignment.ModelID,
+			}
+		}
+	}
+
+	if err := profile.Validate(); err != nil {
+		return Profile{}, err
+	}
+
+	return profile, nil
+}
+
+// Validate checks every value against the catalog. Empty fields are allowed
+// and fall back to the installer defaults.
+func (p Profile) Validate() error {
+	if p.Version == 0 {
+		return fmt.Errorf("missing required field \"version\"")
+	}
+	if p.Version < 0 || p.Version > CurrentVersion {
+		return fmt.Errorf("unsupported profile version %d (this build reads version %d)", p.Version, CurrentVersion)
+	}
+
+	for _, agent := range p.Agents {
+		if !catalog.IsSupportedAgent(agent) {
+			return fmt.Errorf("unsupported agent %q", agent)
+		}
+	}
+
+	components := map[model.ComponentID]bool{}
+	for _, component := range catalog.MVPComponents() {
+		components[component.ID] = true
+	}
+	for _, component := range p.Components {
+		if !components[component] {
+			return fmt.Errorf("unsupported component %q", component)
+		}
+	}
+
+	skills := map[model.SkillID]bool{}
+	for _, skill := range catalog.MVPSkills() {
+		skills[skill.ID] = true
+	}
+	for _, skill := range p.Skills {
+		if !skills[skill] {
+			return fmt.Errorf("unsupported skill %q", skill)
+		}
+	}
+
+	switch p.Persona {
+	case "", model.PersonaGentleman, model.PersonaNeutral, model.PersonaCustom:
+	default:
+		return fmt.Errorf("unsupported persona %q", p.Persona)
+	}
+
+	if p.Preset != "" {
+		if _, ok := preset.Default().Get(p.Preset); !ok {
+			return fmt.Errorf("unsupported preset %q", p.Preset)
+		}
+	}
+
+	switch p.SDDMode {
+	case "", model.SDDModeSingle, model.SDDModeMulti:
+	default:
+		return fmt.Errorf("unsupported sdd_mode %q (valid: single, multi)", p.SDDMode)
+	}
+
+	for name, assignment := range p.ModelAssignments {
+		if assignment.ProviderID == "" || assignment.ModelID == "" {
+			return fmt.Errorf("model assignment %q needs both provider_id and model_id", name)
+		}
+	}
+
+	return nil
+}
+
+// Encode renders the profile as a commented YAML document.
+func Encode(p Profile) []byte {
+	doc := document{
+		Version:    p.Version,
+		Name:       p.Name,
+		Agents:     p.Agents,
+		Components: p.Components,
+		Skills:     p.Skills,
+		Persona:    p.Persona,
+		Preset:     p.Preset,
+		SDDMode:    p.SDDMode,
+	}
+	if len(p.ModelAssignments) > 0 {
+		doc.ModelAssignments = make(map[string]assignmentDocument, len(p.ModelAssignments))
+		for name, assignment := range p.ModelAssignments {
+			doc.ModelAssignments[name] = assignmentDocument{
+				ProviderID: assignment.ProviderID,
+				ModelID:    assignment.ModelID,
+			}
+		}
+	}
+
+	b := &bytes.Buffer{}
+	_, _ = fmt.Fprintln(b, "# gentle-ai install profile")
+	_, _ = fmt.Fprintln(b, "# Apply with: gentle-ai install --profile <file>")
+	encoder := yaml.NewEncoder(b)
+	encoder.SetIndent(2)
+	// A document made of strings, string slices and maps always encodes.
+	_ = encoder.Encode(doc)
+	_ = encoder.Close()
+
+	return b.Bytes()
+}
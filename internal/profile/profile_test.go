@@ -0,0 +1,114 @@
+package profile
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gentleman-programming/gentle-ai/internal/model"
+)
+
+func TestEncodeDecodeRoundTrip(t *testing.T) {
+	want := Profile{
+		Version:    CurrentVersion,
+		Name:       "platform team",
+		Agents:     []model.AgentID{model.AgentClaudeCode, model.AgentOpenCode},
+		Components: []model.ComponentID{model.ComponentEngram, model.ComponentSDD, model.ComponentSkills},
+		Skills:     []model.SkillID{"go-testing"},
+		Persona:    model.PersonaNeutral,
+		Preset:     model.PresetCustom,
+		SDDMode:    model.SDDModeMulti,
+		ModelAssignments: map[string]model.ModelAssignment{
+			"sdd-apply":  {ProviderID: "anthropic", ModelID: "claude-sonnet-4"},
+			"sdd-verify": {ProviderID: "openai", ModelID: "o3"},
+		},
+	}
+
+	got, err := Decode(Encode(want))
+	if err != nil {
+		t.Fatalf("Decode(Encode()) error = %v\n%s", err, Encode(want))
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("round trip = %#v\nwant %#v", got, want)
+	}
+}
+
+func TestDecodeAcceptsFlowListsAndComments(t *testing.T) {
+	content := `# team defaults
+version: 1
+agents: [claude-code, "opencode"]  # both editors
+components:
+- engram
+- sdd
+persona: gentleman
+`
+
+	got, err := Decode([]byte(content))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if !reflect.DeepEqual(got.Agents, []model.AgentID{model.AgentClaudeCode, model.AgentOpenCode}) {
+		t.Fatalf("agents = %v", got.Agents)
+	}
+	if !reflect.DeepEqual(got.Components, []model.ComponentID{model.ComponentEngram, model.ComponentSDD}) {
+		t.Fatalf("components = %v", got.Components)
+	}
+}
+
+func TestDecodeAcceptsQuotingAndAnchors(t *testing.T) {
+	content := `version: 1
+name: 'platform: team'
+agents: &editors
+  - claude-code
+  - opencode
+model_assignments:
+  sdd-apply: &sonnet {provider_id: "anthropic", model_id: claude-sonnet-4}
+  sdd-verify: *sonnet
+`
+
+	got, err := Decode([]byte(content))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if got.Name != "platform: team" {
+		t.Fatalf("name = %q", got.Name)
+	}
+	if !reflect.DeepEqual(got.Agents, []model.AgentID{model.AgentClaudeCode, model.AgentOpenCode}) {
+		t.Fatalf("agents = %v", got.Agents)
+	}
+	if got.ModelAssignments["sdd-verify"] != got.ModelAssignments["sdd-apply"] {
+		t.Fatalf("aliased assignment = %#v", got.ModelAssignments)
+	}
+}
+
+func TestDecodeRejectsInvalidProfiles(t *testing.T) {
+	tests := map[string]struct {
+		content string
+		want    string
+	}{
+		"missing version":     {"agents: [claude-code]\n", "missing required field \"version\""},
+		"newer version":       {"version: 9\n", "unsupported profile version 9"},
+		"unknown field":       {"version: 1\ncolour: blue\n", "line 2: field colour not found"},
+		"unknown agent":       {"version: 1\nagents: [vim]\n", "unsupported agent \"vim\""},
+		"unknown component":   {"version: 1\ncomponents:\n  - nope\n", "unsupported component \"nope\""},
+		"bad sdd mode":        {"version: 1\nsdd_mode: triple\n", "unsupported sdd_mode \"triple\""},
+		"scalar for list":     {"version: 1\nagents: claude-code\n", "line 2: cannot unmarshal"},
+		"incomplete model":    {"version: 1\nmodel_assignments:\n  sdd-apply:\n    provider_id: anthropic\n", "needs both provider_id and model_id"},
+		"bad indentation":     {"version: 1\n  agents: [claude-code]\n", "line 2:"},
+		"duplicate key":       {"version: 1\nversion: 1\n", "line 2: mapping key \"version\" already defined"},
+		"tab indentation":     {"version: 1\nagents:\n\t- claude-code\n", "line 3:"},
+		"non-numeric version": {"version: one\n", "line 1: cannot unmarshal"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Decode([]byte(tc.content))
+			if err == nil {
+				t.Fatalf("Decode() expected error containing %q", tc.want)
+			}
+			if !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("Decode() error = %q, want it to contain %q", err, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,296 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/gentleman-programming/gentle-ai/internal/model"
+)
+
+// Filename is the ledger file name inside ~/.gentle-ai.
+const Filename = "state.json"
+
+// CurrentVersion is the ledger schema version written by this build.
+const CurrentVersion = 1
+
+// MaxRecords bounds the ledger so it does not grow without limit; the oldest
+// records are dropped first.
+const MaxRecords = 50
+
+const (
+	CommandInstall   = "install"
+	CommandUninstall = "uninstall"
+	CommandRepair    = "repair"
+)
+
+// Ledger is the durable history of gentle-ai runs on this machine.
+type Ledger struct {
+	Version int      `json:"version"`
+	Records []Record `json:"records"`
+}
+
+// Record describes one run: what was selected, what was written, which backup
+// it produced and how each pipeline step ended.
+type Record struct {
+	ID               string                                `json:"id"`
+	Command          string                                `json:"command"`
+	Source           string                                `json:"source,omitempty"`
+	StartedAt        time.Time                             `json:"started_at"`
+	FinishedAt       time.Time                             `json:"finished_at"`
+	Agents           []model.AgentID                       `json:"agents,omitempty"`
+	Components       []model.ComponentID                   `json:"components,omitempty"`
+	Skills           []model.SkillID                       `json:"skills,omitempty"`
+	Persona          model.PersonaID                       `json:"persona,omitempty"`
+	Preset           model.PresetID                        `json:"preset,omitempty"`
+	SDDMode          model.SDDModeID                       `json:"sdd_mode,omitempty"`
+	ModelAssignments map[string]model.ModelAssignment      `json:"model_assignments,omitempty"`
+	Scope            map[model.AgentID][]model.ComponentID `json:"scope,omitempty"`
+	Files            []string                              `json:"files,omitempty"`
+	BackupID         string                                `json:"backup_id,omitempty"`
+	Steps            []StepRecord                          `json:"steps,omitempty"`
+	// AgentOutcomes says what an install left configured for each agent.
+	AgentOutcomes []AgentOutcome `json:"agent_outcomes,omitempty"`
+	Success       bool           `json:"success"`
+	Error         string         `json:"error,omitempty"`
+}
+
+// AgentStatus is how far a run got for one agent.
+type AgentStatus string
+
+const (
+	// AgentConfigured means every selected component is in place.
+	AgentConfigured AgentStatus = "configured"
+	// AgentPartial means some components are in place and others failed,
+	// were skipped or were rolled back.
+	AgentPartial AgentStatus = "partial"
+	// AgentRolledBack means nothing the run did for the agent was kept.
+	AgentRolledBack AgentStatus = "rolled-back"
+)
+
+// AgentOutcome is what a run left configured for one agent. Missing lists
+// the components that are not in place.
+type AgentOutcome struct {
+	Agent      model.AgentID       `json:"agent"`
+	Status     AgentStatus         `json:"status"`
+	Configured []model.ComponentID `json:"configured,omitempty"`
+	Missing    []model.ComponentID `json:"missing,omitempty"`
+}
+
+// StepRecord is the persisted form of a pipeline.StepResult.
+type StepRecord struct {
+	ID     string `json:"id"`
+	Stage  string `json:"stage"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	// Check is what the step's check reported before it ran, e.g.
+	// "up-to-date" for a step skipped because there was nothing to do.
+	Check  string `json:"check,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// Selection rebuilds the selection a record was produced from.
+func (r Record) Selection() model.Selection {
+	return model.Selection{
+		Agents:           r.Agents,
+		Components:       r.Components,
+		Skills:           r.Skills,
+		Persona:          r.Persona,
+		Preset:           r.Preset,
+		SDDMode:          r.SDDMode,
+		ModelAssignments: r.ModelAssignments,
+	}
+}
+
+// Path returns the ledger location for the given home directory.
+func Path(homeDir string) string {
+	return filepath.Join(homeDir, ".gentle-ai", Filename)
+}
+
+// Load reads the ledger. A missing file yields an empty ledger.
+func Load(homeDir string) (Ledger, error) {
+	path := Path(homeDir)
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Ledger{Version: CurrentVersion}, nil
+		}
+		return Ledger{}, fmt.Errorf("read state ledger %q: %w", path, err)
+	}
+
+	var ledger Ledger
+	if err := json.Unmarshal(content, &ledger); err != nil {
+		return Ledger{}, fmt.Errorf("unmarshal state ledger %q: %w", path, err)
+	}
+	if ledger.Version > CurrentVersion {
+		return Ledger{}, fmt.Errorf("state ledger %q has version %d, newer than supported version %d", path, ledger.Version, CurrentVersion)
+	}
+
+	return ledger, nil
+}
+
+// Save writes the ledger atomically so a crash never leaves a truncated file.
+func Save(homeDir string, ledger Ledger) error {
+	path := Path(homeDir)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create state directory for %q: %w", path, err)
+	}
+
+	ledger.Version = CurrentVersion
+	content, err := json.MarshalIndent(ledger, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal state ledger: %w", err)
+	}
+	content = append(content, '\n')
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".state-*.tmp")
+	if err != nil {
+		return fmt.Errorf("create temp file for %q: %w", path, err)
+	}
+	tmpPath := tmp.Name()
+	defer func() { _ = os.Remove(tmpPath) }()
+
+	if _, err := tmp.Write(content); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("write temp file for %q: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close temp file for %q: %w", path, err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("replace state ledger %q: %w", path, err)
+	}
+
+	return nil
+}
+
+// Append adds record to the ledger on disk, trimming it to MaxRecords.
+func Append(homeDir string, record Record) error {
+	ledger, err := Load(homeDir)
+	if err != nil {
+		return err
+	}
+
+	ledger.Records = append(ledger.Records, record)
+	if len(ledger.Records) > MaxRecords {
+		ledger.Records = ledger.Records[len(ledger.Records)-MaxRecords:]
+	}
+
+	return Save(homeDir, ledger)
+}
+
+// Latest returns the most recent record whose command is one of commands, or
+// the most recent record of any kind when no command is given.
+func (l Ledger) Latest(commands ...string) (Record, bool) {
+	for i := len(l.Records) - 1; i >= 0; i-- {
+		record := l.Records[i]
+		if len(commands) == 0 {
+			return record, true
+		}
+		for _, command := range commands {
+			if record.Command == command {
+				return record, true
+			}
+		}
+	}
+
+	return Record{}, false
+}
+
+// Installed reports whether component is currently installed for agent
+// according to the ledger: a successful install or repair that covered both
+// turns it on, and a later successful uninstall that covered both turns it off.
+// A failed install or repair turns it on when its agent outcomes list the
+// component as configured for agent.
+func (l Ledger) Installed(agent model.AgentID, component model.ComponentID) bool {
+	installed := false
+	for _, record := range l.Records {
+		if !record.Success {
+			if record.Command != CommandUninstall && record.configured(agent, component) {
+				installed = true
+			}
+			continue
+		}
+		if !record.covers(agent, component) {
+			continue
+		}
+		switch record.Command {
+		case CommandInstall, CommandRepair:
+			installed = true
+		case CommandUninstall:
+			installed = false
+		}
+	}
+
+	return installed
+}
+
+// LatestInstallFor returns the newest install record that included agent.
+// Its selection is what a re-run of the injectors should reproduce.
+func (l Ledger) LatestInstallFor(agent model.AgentID) (Record, bool) {
+	for i := len(l.Records) - 1; i >= 0; i-- {
+		record := l.Records[i]
+		if record.Command != CommandInstall {
+			continue
+		}
+		for _, current := range record.Agents {
+			if current == agent {
+				return record, true
+			}
+		}
+	}
+
+	return Record{}, false
+}
+
+// HasInstalls reports whether the ledger holds at least one install record.
+func (l Ledger) HasInstalls() bool {
+	_, ok := l.Latest(CommandInstall)
+	return ok
+}
+
+// configured reports whether the record's agent outcomes list component as
+// configured for agent.
+func (r Record) configured(agent model.AgentID, component model.ComponentID) bool {
+	for _, outcome := range r.AgentOutcomes {
+		if outcome.Agent == agent && slices.Contains(outcome.Configured, component) {
+			return true
+		}
+	}
+	return false
+}
+
+// covers reports whether the record touched component for agent. Records
+// with a Scope (repairs) only cover the pairs listed there; the others cover
+// every agent and component they name.
+func (r Record) covers(agent model.AgentID, component model.ComponentID) bool {
+	if r.Scope != nil {
+		for _, current := range r.Scope[agent] {
+			if current == component {
+				return true
+			}
+		}
+		return false
+	}
+
+	agentFound := false
+	for _, current := range r.Agents {
+		if current == agent {
+			agentFound = true
+			break
+		}
+	}
+	if !agentFound {
+		return false
+	}
+
+	for _, current := range r.Components {
+		if current == component {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,171 @@
+package state
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gentleman-programming/gentle-ai/internal/model"
+)
+
+func TestLoadMissingLedgerIsEmpty(t *testing.T) {
+	ledger, err := Load(t.TempDir())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(ledger.Records) != 0 {
+		t.Fatalf("Load() records = %d, want 0", len(ledger.Records))
+	}
+	if _, ok := ledger.Latest(); ok {
+		t.Fatalf("Latest() ok = true on empty ledger")
+	}
+}
+
+func TestAppendRoundTripsRecord(t *testing.T) {
+	home := t.TempDir()
+	record := Record{
+		ID:         "20260101120000.000000000",
+		Command:    CommandInstall,
+		StartedAt:  time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC),
+		FinishedAt: time.Date(2026, 1, 1, 12, 0, 5, 0, time.UTC),
+		Agents:     []model.AgentID{model.AgentClaudeCode},
+		Components: []model.ComponentID{model.ComponentEngram},
+		Persona:    model.PersonaGentleman,
+		SDDMode:    model.SDDModeMulti,
+		ModelAssignments: map[string]model.ModelAssignment{
+			"sdd-apply": {ProviderID: "anthropic", ModelID: "claude-sonnet"},
+		},
+		Files:    []string{"/home/u/.claude/CLAUDE.md"},
+		BackupID: "20260101120000.000000000",
+		Steps:    []StepRecord{{ID: "component:engram", Stage: "apply", Status: "succeeded"}},
+		Success:  true,
+	}
+
+	if err := Append(home, record); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+
+	ledger, err := Load(home)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	got, ok := ledger.Latest(CommandInstall)
+	if !ok {
+		t.Fatalf("Latest(install) ok = false")
+	}
+	if !reflect.DeepEqual(got, record) {
+		t.Fatalf("Latest() = %#v\nwant %#v", got, record)
+	}
+}
+
+func TestLatestFiltersByCommand(t *testing.T) {
+	ledger := Ledger{Records: []Record{
+		{ID: "1", Command: CommandInstall},
+		{ID: "2", Command: CommandUninstall},
+	}}
+
+	if got, _ := ledger.Latest(); got.ID != "2" {
+		t.Fatalf("Latest() = %q, want 2", got.ID)
+	}
+	if got, _ := ledger.Latest(CommandInstall); got.ID != "1" {
+		t.Fatalf("Latest(install) = %q, want 1", got.ID)
+	}
+}
+
+func TestAppendTrimsToMaxRecords(t *testing.T) {
+	home := t.TempDir()
+	for i := 0; i < MaxRecords+3; i++ {
+		if err := Append(home, Record{Command: CommandInstall}); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+
+	ledger, err := Load(home)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(ledger.Records) != MaxRecords {
+		t.Fatalf("records = %d, want %d", len(ledger.Records), MaxRecords)
+	}
+}
+
+func TestLoadRejectsNewerVersion(t *testing.T) {
+	home := t.TempDir()
+	if err := os.MkdirAll(home+"/.gentle-ai", 0o755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	if err := os.WriteFile(Path(home), []byte(`{"version": 99, "records": []}`), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	if _, err := Load(home); err == nil {
+		t.Fatalf("Load() expected error for newer ledger version")
+	}
+}
+
+func TestInstalledFollowsInstallAndUninstall(t *testing.T) {
+	ledger := Ledger{Records: []Record{
+		{Command: CommandInstall, Success: true, Agents: []model.AgentID{model.AgentClaudeCode}, Components: []model.ComponentID{model.ComponentEngram, model.ComponentSDD}},
+		{Command: CommandUninstall, Success: true, Agents: []model.AgentID{model.AgentClaudeCode}, Components: []model.ComponentID{model.ComponentSDD}},
+		{Command: CommandInstall, Success: false, Agents: []model.AgentID{model.AgentClaudeCode}, Components: []model.ComponentID{model.ComponentPersona}},
+	}}
+
+	if !ledger.Installed(model.AgentClaudeCode, model.ComponentEngram) {
+		t.Fatalf("engram should be installed")
+	}
+	if ledger.Installed(model.AgentClaudeCode, model.ComponentSDD) {
+		t.Fatalf("sdd should be uninstalled")
+	}
+	if ledger.Installed(model.AgentClaudeCode, model.ComponentPersona) {
+		t.Fatalf("failed install should not count")
+	}
+	if ledger.Installed(model.AgentOpenCode, model.ComponentEngram) {
+		t.Fatalf("engram was never installed for opencode")
+	}
+}
+
+func TestInstalledCountsConfiguredComponentsOfAFailedInstall(t *testing.T) {
+	ledger := Ledger{Records: []Record{{
+		Command:    CommandInstall,
+		Success:    false,
+		Agents:     []model.AgentID{model.AgentClaudeCode, model.AgentGeminiCLI},
+		Components: []model.ComponentID{model.ComponentPersona, model.ComponentSDD},
+		AgentOutcomes: []AgentOutcome{
+			{Agent: model.AgentClaudeCode, Status: AgentConfigured, Configured: []model.ComponentID{model.ComponentPersona, model.ComponentSDD}},
+			{Agent: model.AgentGeminiCLI, Status: AgentPartial, Configured: []model.ComponentID{model.ComponentPersona}, Missing: []model.ComponentID{model.ComponentSDD}},
+		},
+	}}}
+
+	if !ledger.Installed(model.AgentClaudeCode, model.ComponentSDD) {
+		t.Fatalf("sdd ended configured for claude-code")
+	}
+	if !ledger.Installed(model.AgentGeminiCLI, model.ComponentPersona) {
+		t.Fatalf("persona ended configured for gemini-cli")
+	}
+	if ledger.Installed(model.AgentGeminiCLI, model.ComponentSDD) {
+		t.Fatalf("sdd failed for gemini-cli")
+	}
+}
+
+func TestInstalledHonoursRepairScope(t *testing.T) {
+	ledger := Ledger{Records: []Record{
+		{
+			Command:    CommandRepair,
+			Success:    true,
+			Agents:     []model.AgentID{model.AgentClaudeCode, model.AgentOpenCode},
+			Components: []model.ComponentID{model.ComponentPersona, model.ComponentSDD},
+			Scope: map[model.AgentID][]model.ComponentID{
+				model.AgentClaudeCode: {model.ComponentPersona},
+				model.AgentOpenCode:   {model.ComponentSDD},
+			},
+		},
+	}}
+
+	if !ledger.Installed(model.AgentClaudeCode, model.ComponentPersona) {
+		t.Fatalf("repaired persona should be installed for claude-code")
+	}
+	if ledger.Installed(model.AgentClaudeCode, model.ComponentSDD) {
+		t.Fatalf("sdd was only repaired for opencode")
+	}
+}
@@ -9,10 +9,14 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/gentleman-programming/gentle-ai/internal/backup"
 	"github.com/gentleman-programming/gentle-ai/internal/catalog"
+	"github.com/gentleman-programming/gentle-ai/internal/config"
+	"github.com/gentleman-programming/gentle-ai/internal/journal"
 	"github.com/gentleman-programming/gentle-ai/internal/model"
 	"github.com/gentleman-programming/gentle-ai/internal/opencode"
 	"github.com/gentleman-programming/gentle-ai/internal/pipeline"
 	"github.com/gentleman-programming/gentle-ai/internal/planner"
+	"github.com/gentleman-programming/gentle-ai/internal/preset"
+	"github.com/gentleman-programming/gentle-ai/internal/state"
 	"github.com/gentleman-programming/gentle-ai/internal/system"
 	"github.com/gentleman-programming/gentle-ai/internal/tui/screens"
 	"github.com/gentleman-programming/gentle-ai/internal/update"
@@ -33,13 +37,21 @@ func tickCmd() tea.Cmd {
 // StepProgressMsg is sent from the pipeline goroutine when a step changes status.
 type StepProgressMsg struct {
 	StepID string
+	Stage  pipeline.Stage
 	Status pipeline.StepStatus
 	Err    error
+	// Attempt and MaxAttempts count the runs of a retried step.
+	Attempt     int
+	MaxAttempts int
+	// Reason says why a step was skipped when it was already up to date.
+	Reason string
 }
 
 // PipelineDoneMsg is sent when the pipeline finishes execution.
 type PipelineDoneMsg struct {
 	Result pipeline.ExecutionResult
+	// Agents says what the install left configured for each agent.
+	Agents []state.AgentOutcome
 }
 
 // BackupRestoreMsg is sent when a backup restore completes.
@@ -53,13 +65,16 @@ type UpdateCheckResultMsg struct {
 }
 
 // ExecuteFunc builds and runs the installation pipeline. It receives a ProgressFunc
-// callback to emit step-level progress events, and returns the ExecutionResult.
+// callback to emit step-level progress events, and returns the ExecutionResult
+// along with what it left configured for each agent.
+// ctx is cancelled when the user interrupts the install.
 type ExecuteFunc func(
+	ctx context.Context,
 	selection model.Selection,
 	resolved planner.ResolvedPlan,
 	detection system.DetectionResult,
 	onProgress pipeline.ProgressFunc,
-) pipeline.ExecutionResult
+) (pipeline.ExecutionResult, []state.AgentOutcome)
 
 // RestoreFunc restores a backup from a manifest.
 type RestoreFunc func(manifest backup.Manifest) error
@@ -80,6 +95,7 @@ const (
 	ScreenModelPicker
 	ScreenComplete
 	ScreenBackups
+	ScreenBackupScope
 )
 
 type Model struct {
@@ -97,7 +113,10 @@ type Model struct {
 	Review         planner.ReviewPayload
 	Progress       ProgressState
 	Execution      pipeline.ExecutionResult
+	AgentOutcomes  []state.AgentOutcome
 	Backups        []backup.Manifest
+	SelectedBackup backup.Manifest
+	LastRun        *state.Record
 	ModelPicker    screens.ModelPickerState
 	Err            error
 
@@ -108,6 +127,18 @@ type Model struct {
 	// RestoreFn is called to restore a backup. When nil, restore is a no-op.
 	RestoreFn RestoreFunc
 
+	// InterruptedRun is the journal of an install that was killed before it
+	// finished. The welcome screen tells the user how to resume or undo it.
+	InterruptedRun *journal.Run
+
+	// Hooks are the user hooks the install runs around its stages; they are
+	// listed on the installing screen with the other steps.
+	Hooks config.Hooks
+
+	// ConfigErr is why ~/.gentle-ai/config.json could not be loaded. The
+	// welcome screen shows it, and installing fails with it.
+	ConfigErr error
+
 	// UpdateResults holds the results of the background update check.
 	UpdateResults []update.UpdateResult
 
@@ -116,6 +147,15 @@ type Model struct {
 
 	// pipelineRunning tracks whether the pipeline goroutine is active.
 	pipelineRunning bool
+
+	// pipelineEvents carries the running pipeline's StepProgressMsgs, then
+	// its PipelineDoneMsg, to Update.
+	pipelineEvents chan tea.Msg
+
+	// cancelPipeline interrupts the running pipeline; cancelling is set once
+	// it has been called, so a second Ctrl-C quits immediately.
+	cancelPipeline context.CancelFunc
+	cancelling     bool
 }
 
 func NewModel(detection system.DetectionResult, version string) Model {
@@ -164,7 +204,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 	case StepProgressMsg:
-		return m.handleStepProgress(msg)
+		updated, _ := m.handleStepProgress(msg)
+		return updated, waitForPipeline(m.pipelineEvents)
 	case PipelineDoneMsg:
 		return m.handlePipelineDone(msg)
 	case BackupRestoreMsg:
@@ -185,6 +226,16 @@ func (m Model) handleStepProgress(msg StepProgressMsg) (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 
+	if msg.Stage == pipeline.StageRollback {
+		switch msg.Status {
+		case pipeline.StepStatusRolledBack:
+			m.Progress.AppendLog("rolled back: %s", msg.StepID)
+		case pipeline.StepStatusFailed:
+			m.Progress.AppendLog("rollback FAILED: %s — %v", msg.StepID, msg.Err)
+		}
+		return m, nil
+	}
+
 	idx := m.findProgressItem(msg.StepID)
 	if idx < 0 {
 		return m, nil
@@ -194,6 +245,13 @@ func (m Model) handleStepProgress(msg StepProgressMsg) (tea.Model, tea.Cmd) {
 	case pipeline.StepStatusRunning:
 		m.Progress.Start(idx)
 		m.Progress.AppendLog("running: %s", msg.StepID)
+	case pipeline.StepStatusRetrying:
+		m.Progress.Retry(idx, msg.Attempt, msg.MaxAttempts)
+		errMsg := "unknown error"
+		if msg.Err != nil {
+			errMsg = msg.Err.Error()
+		}
+		m.Progress.AppendLog("retrying: %s (attempt %d/%d) — %s", msg.StepID, msg.Attempt, msg.MaxAttempts, errMsg)
 	case pipeline.StepStatusSucceeded:
 		m.Progress.Mark(idx, string(pipeline.StepStatusSucceeded))
 		m.Progress.AppendLog("done: %s", msg.StepID)
@@ -204,6 +262,14 @@ func (m Model) handleStepProgress(msg StepProgressMsg) (tea.Model, tea.Cmd) {
 			errMsg = msg.Err.Error()
 		}
 		m.Progress.AppendLog("FAILED: %s — %s", msg.StepID, errMsg)
+	case pipeline.StepStatusSkipped:
+		m.Progress.Mark(idx, string(pipeline.StepStatusSkipped))
+		switch {
+		case msg.Reason != "":
+			m.Progress.AppendLog("up to date: %s — %s", msg.StepID, msg.Reason)
+		case msg.Err != nil:
+			m.Progress.AppendLog("skipped: %s — %s", msg.StepID, msg.Err.Error())
+		}
 	}
 
 	return m, nil
@@ -211,7 +277,10 @@ func (m Model) handleStepProgress(msg StepProgressMsg) (tea.Model, tea.Cmd) {
 
 func (m Model) handlePipelineDone(msg PipelineDoneMsg) (tea.Model, tea.Cmd) {
 	m.Execution = msg.Result
+	m.AgentOutcomes = msg.Agents
 	m.pipelineRunning = false
+	m.pipelineEvents = nil
+	m.cancelPipeline = nil
 
 	// Rebuild progress from real step results so failed steps show ✗ instead
 	// of being blindly marked as succeeded.
@@ -220,8 +289,13 @@ func (m Model) handlePipelineDone(msg PipelineDoneMsg) (tea.Model, tea.Cmd) {
 	// Surface individual error messages so the user knows WHAT failed.
 	appendStepErrors := func(steps []pipeline.StepResult) {
 		for _, step := range steps {
-			if step.Status == pipeline.StepStatusFailed && step.Err != nil {
+			switch {
+			case step.Status == pipeline.StepStatusFailed && step.Err != nil:
 				m.Progress.AppendLog("FAILED: %s — %s", step.StepID, step.Err.Error())
+			case step.UpToDate():
+				m.Progress.AppendLog("up to date: %s — %s", step.StepID, step.Check.Reason)
+			case step.Status == pipeline.StepStatusSkipped && step.Err != nil:
+				m.Progress.AppendLog("skipped: %s — %s", step.StepID, step.Err.Error())
 			}
 		}
 	}
@@ -263,7 +337,13 @@ func (m Model) View() string {
 		if m.UpdateCheckDone && update.HasUpdates(m.UpdateResults) {
 			banner = "Updates available: " + update.UpdateSummaryLine(m.UpdateResults)
 		}
-		return screens.RenderWelcome(m.Cursor, m.Version, banner)
+		if notice := interruptedRunNotice(m.InterruptedRun); notice != "" {
+			banner = strings.TrimPrefix(banner+"\n"+notice, "\n")
+		}
+		if m.ConfigErr != nil {
+			banner = strings.TrimPrefix(banner+"\nConfig error: "+m.ConfigErr.Error()+". Fix it before installing.", "\n")
+		}
+		return screens.RenderWelcome(m.Cursor, m.Version, banner, lastRunSummary(m.LastRun))
 	case ScreenDetection:
 		return screens.RenderDetection(m.Detection, m.Cursor)
 	case ScreenAgents:
@@ -283,17 +363,23 @@ func (m Model) View() string {
 	case ScreenInstalling:
 		return screens.RenderInstalling(m.Progress.ViewModel(), spinnerFrames[m.SpinnerFrame])
 	case ScreenComplete:
+		changed, upToDate := m.Execution.Apply.Changes()
 		return screens.RenderComplete(screens.CompletePayload{
+			ChangedSteps:        changed,
+			UpToDateSteps:       upToDate,
 			ConfiguredAgents:    len(m.Selection.Agents),
 			InstalledComponents: len(m.Selection.Components),
 			GGAInstalled:        hasSelectedComponent(m.Selection.Components, model.ComponentGGA),
 			FailedSteps:         extractFailedSteps(m.Execution),
 			RollbackPerformed:   len(m.Execution.Rollback.Steps) > 0,
+			Agents:              extractAgentOutcomes(m.AgentOutcomes),
 			MissingDeps:         extractMissingDeps(m.Detection),
 			AvailableUpdates:    extractAvailableUpdates(m.UpdateResults),
 		})
 	case ScreenBackups:
 		return screens.RenderBackups(m.Backups, m.Cursor)
+	case ScreenBackupScope:
+		return screens.RenderBackupScope(m.SelectedBackup, m.Cursor)
 	default:
 		return ""
 	}
@@ -313,6 +399,14 @@ func (m Model) handleKeyPress(key tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 	switch keyStr {
 	case "ctrl+c", "q":
+		// Quitting mid-install would leave files half written: cancel the
+		// pipeline instead and let it roll back before the user quits.
+		if m.Screen == ScreenInstalling && m.pipelineRunning && m.cancelPipeline != nil && !m.cancelling {
+			m.cancelling = true
+			m.cancelPipeline()
+			m.Progress.AppendLog("cancelling: waiting for running steps, then rolling back (press again to quit now)")
+			return m, nil
+		}
 		return m, tea.Quit
 	case "up", "k":
 		if m.Cursor > 0 {
@@ -387,6 +481,9 @@ func (m Model) confirmSelection() (tea.Model, tea.Cmd) {
 		if m.Cursor < len(options) {
 			m.Selection.Preset = options[m.Cursor]
 			m.Selection.Components = componentsForPreset(options[m.Cursor])
+			if mode := preset.Default().Resolve(options[m.Cursor]).SDDMode; mode != "" {
+				m.Selection.SDDMode = mode
+			}
 			if m.shouldShowSDDModeScreen() {
 				m.setScreen(ScreenSDDMode)
 				return m, nil
@@ -479,9 +576,17 @@ func (m Model) confirmSelection() (tea.Model, tea.Cmd) {
 		return m, tea.Quit
 	case ScreenBackups:
 		if m.Cursor < len(m.Backups) {
-			return m.restoreBackup(m.Backups[m.Cursor])
+			m.SelectedBackup = m.Backups[m.Cursor]
+			m.setScreen(ScreenBackupScope)
+			return m, nil
 		}
 		m.setScreen(ScreenWelcome)
+	case ScreenBackupScope:
+		scopes := screens.RestoreScopes(m.SelectedBackup)
+		if m.Cursor < len(scopes) {
+			return m.restoreBackup(m.SelectedBackup.Select(scopes[m.Cursor].Filter))
+		}
+		m.setScreen(ScreenBackups)
 	}
 
 	return m, nil
@@ -494,7 +599,7 @@ func (m Model) startInstalling() (tea.Model, tea.Cmd) {
 	m.SpinnerFrame = 0
 
 	// Build progress labels from the resolved plan.
-	labels := buildProgressLabels(m.DependencyPlan)
+	labels := buildProgressLabels(m.DependencyPlan, m.Hooks)
 	if len(labels) == 0 {
 		// Fallback labels when the plan is empty (dev/test).
 		labels = []string{
@@ -514,25 +619,51 @@ func (m Model) startInstalling() (tea.Model, tea.Cmd) {
 	}
 
 	m.pipelineRunning = true
+	m.cancelling = false
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancelPipeline = cancel
 
 	// Capture values for the goroutine closure.
 	executeFn := m.ExecuteFn
 	selection := m.Selection
 	resolved := m.DependencyPlan
 	detection := m.Detection
+	events := make(chan tea.Msg, 64)
+	m.pipelineEvents = events
 
-	return m, tea.Batch(tickCmd(), func() tea.Msg {
+	run := func() tea.Msg {
 		onProgress := func(event pipeline.ProgressEvent) {
-			// NOTE: ProgressFunc is called synchronously from the pipeline goroutine.
-			// We cannot use p.Send() here because we don't have a reference to the
-			// tea.Program. Instead, these events are collected in the ExecutionResult
-			// and the PipelineDoneMsg handles the final state. For real-time updates,
-			// we rely on the pipeline calling this synchronously from each step.
+			// Update drains the channel one message at a time; a full
+			// buffer holds the pipeline back until the screen catches up.
+			events <- StepProgressMsg{
+				StepID:      event.StepID,
+				Stage:       event.Stage,
+				Status:      event.Status,
+				Err:         event.Err,
+				Attempt:     event.Attempt,
+				MaxAttempts: event.MaxAttempts,
+				Reason:      event.Reason,
+			}
 		}
 
-		result := executeFn(selection, resolved, detection, onProgress)
-		return PipelineDoneMsg{Result: result}
-	})
+		defer cancel()
+		result, agents := executeFn(ctx, selection, resolved, detection, onProgress)
+		events <- PipelineDoneMsg{Result: result, Agents: agents}
+		return nil
+	}
+
+	return m, tea.Batch(tickCmd(), run, waitForPipeline(events))
+}
+
+// waitForPipeline returns a command that delivers the next message of the
+// running pipeline, or nil when no pipeline is running.
+func waitForPipeline(events chan tea.Msg) tea.Cmd {
+	if events == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		return <-events
+	}
 }
 
 // restoreBackup triggers a backup restore in a goroutine.
@@ -549,11 +680,14 @@ func (m Model) restoreBackup(manifest backup.Manifest) (tea.Model, tea.Cmd) {
 	}
 }
 
-// buildProgressLabels creates step labels from the resolved plan that match
-// the step IDs the pipeline will produce.
-func buildProgressLabels(resolved planner.ResolvedPlan) []string {
-	labels := make([]string, 0, 2+len(resolved.Agents)+len(resolved.OrderedComponents)+1)
+// buildProgressLabels creates step labels from the resolved plan and the
+// user hooks that match the step IDs the pipeline will produce.
+func buildProgressLabels(resolved planner.ResolvedPlan, hooks config.Hooks) []string {
+	labels := make([]string, 0, 2+len(resolved.Agents)+len(resolved.OrderedComponents)+1+len(hooks.PrePrepare)+len(hooks.PostApply))
 
+	for _, hook := range hooks.PrePrepare {
+		labels = append(labels, hook.StepID(config.HookPrePrepare))
+	}
 	labels = append(labels, "prepare:check-dependencies")
 	labels = append(labels, "prepare:backup-snapshot")
 	labels = append(labels, "apply:rollback-restore")
@@ -566,6 +700,10 @@ func buildProgressLabels(resolved planner.ResolvedPlan) []string {
 		labels = append(labels, "component:"+string(component))
 	}
 
+	for _, hook := range hooks.PostApply {
+		labels = append(labels, hook.StepID(config.HookPostApply))
+	}
+
 	return labels
 }
 
@@ -628,6 +766,8 @@ func (m Model) optionCount() int {
 		return 1
 	case ScreenBackups:
 		return len(m.Backups) + 1
+	case ScreenBackupScope:
+		return len(screens.RestoreScopes(m.SelectedBackup)) + 1
 	default:
 		return 0
 	}
@@ -712,6 +852,35 @@ func preselectedAgents(detection system.DetectionResult) []model.AgentID {
 	return selected
 }
 
+// lastRunSummary describes the newest ledger record for the welcome screen.
+func lastRunSummary(record *state.Record) string {
+	if record == nil {
+		return ""
+	}
+
+	outcome := "ok"
+	if !record.Success {
+		outcome = "failed"
+	}
+
+	return fmt.Sprintf("Last %s: %s — %d agent(s), %d component(s), %s",
+		record.Command,
+		record.FinishedAt.Local().Format("2006-01-02 15:04"),
+		len(record.Agents),
+		len(record.Components),
+		outcome,
+	)
+}
+
+func interruptedRunNotice(run *journal.Run) string {
+	if run == nil {
+		return ""
+	}
+
+	return fmt.Sprintf("The install started %s was interrupted. Run `gentle-ai install --resume` or `gentle-ai install --rollback` before installing again.",
+		run.StartedAt.Local().Format("2006-01-02 15:04"))
+}
+
 func extractMissingDeps(detection system.DetectionResult) []screens.MissingDep {
 	if detection.Dependencies.AllPresent {
 		return nil
@@ -744,6 +913,18 @@ func extractFailedSteps(result pipeline.ExecutionResult) []screens.FailedStep {
 	return failed
 }
 
+func extractAgentOutcomes(outcomes []state.AgentOutcome) []screens.AgentOutcome {
+	var agents []screens.AgentOutcome
+	for _, outcome := range outcomes {
+		missing := make([]string, 0, len(outcome.Missing))
+		for _, component := range outcome.Missing {
+			missing = append(missing, string(component))
+		}
+		agents = append(agents, screens.AgentOutcome{Agent: string(outcome.Agent), Status: string(outcome.Status), Missing: missing})
+	}
+	return agents
+}
+
 func extractAvailableUpdates(results []update.UpdateResult) []screens.UpdateInfo {
 	var updates []screens.UpdateInfo
 	for _, r := range results {
@@ -764,25 +945,8 @@ func (m Model) shouldShowSDDModeScreen() bool {
 		hasSelectedComponent(m.Selection.Components, model.ComponentSDD)
 }
 
-func componentsForPreset(preset model.PresetID) []model.ComponentID {
-	switch preset {
-	case model.PresetMinimal:
-		return []model.ComponentID{model.ComponentEngram}
-	case model.PresetEcosystemOnly:
-		return []model.ComponentID{model.ComponentEngram, model.ComponentSDD, model.ComponentSkills, model.ComponentContext7, model.ComponentGGA}
-	case model.PresetCustom:
-		return nil
-	default:
-		return []model.ComponentID{
-			model.ComponentEngram,
-			model.ComponentSDD,
-			model.ComponentSkills,
-			model.ComponentContext7,
-			model.ComponentPersona,
-			model.ComponentPermission,
-			model.ComponentGGA,
-		}
-	}
+func componentsForPreset(id model.PresetID) []model.ComponentID {
+	return preset.Default().Components(id)
 }
 
 func hasSelectedComponent(components []model.ComponentID, target model.ComponentID) bool {
@@ -1,14 +1,20 @@
 package tui
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/gentleman-programming/gentle-ai/internal/backup"
+	"github.com/gentleman-programming/gentle-ai/internal/config"
 	"github.com/gentleman-programming/gentle-ai/internal/model"
 	"github.com/gentleman-programming/gentle-ai/internal/pipeline"
 	"github.com/gentleman-programming/gentle-ai/internal/planner"
+	"github.com/gentleman-programming/gentle-ai/internal/state"
 	"github.com/gentleman-programming/gentle-ai/internal/system"
 	"github.com/gentleman-programming/gentle-ai/internal/tui/screens"
 )
@@ -24,6 +30,15 @@ func TestNavigationWelcomeToDetection(t *testing.T) {
 	}
 }
 
+func TestWelcomeShowsConfigError(t *testing.T) {
+	m := NewModel(system.DetectionResult{}, "dev")
+	m.ConfigErr = errors.New(`hooks.pre_prepare[0]: "run" is required`)
+
+	if view := m.View(); !strings.Contains(view, `Config error: hooks.pre_prepare[0]: "run" is required`) {
+		t.Fatalf("welcome view does not show the config error:\n%s", view)
+	}
+}
+
 func TestNavigationBackWithEscape(t *testing.T) {
 	m := NewModel(system.DetectionResult{}, "dev")
 	m.Screen = ScreenPersona
@@ -113,6 +128,56 @@ func TestStepProgressMsgUpdatesProgressState(t *testing.T) {
 	}
 }
 
+func TestStepProgressRetryShowsAttemptCounter(t *testing.T) {
+	m := NewModel(system.DetectionResult{}, "dev")
+	m.Screen = ScreenInstalling
+	m.Progress = NewProgressState([]string{"component:engram"})
+
+	updated, _ := m.Update(StepProgressMsg{StepID: "component:engram", Status: pipeline.StepStatusRunning, Attempt: 1, MaxAttempts: 3})
+	updated, _ = updated.(Model).Update(StepProgressMsg{
+		StepID:      "component:engram",
+		Status:      pipeline.StepStatusRetrying,
+		Err:         fmt.Errorf("brew: connection reset"),
+		Attempt:     2,
+		MaxAttempts: 3,
+	})
+	state := updated.(Model)
+
+	if got := state.Progress.Items[0].Status; got != ProgressStatusRunning {
+		t.Fatalf("status = %q, want running", got)
+	}
+	if view := state.View(); !strings.Contains(view, "component:engram (attempt 2/3)") {
+		t.Fatalf("view does not show the attempt counter:\n%s", view)
+	}
+}
+
+func TestInstallingForwardsLiveProgressFromTheExecutor(t *testing.T) {
+	m := NewModel(system.DetectionResult{}, "dev")
+	m.DependencyPlan = planner.ResolvedPlan{OrderedComponents: []model.ComponentID{model.ComponentEngram}}
+	m.ExecuteFn = func(_ context.Context, _ model.Selection, _ planner.ResolvedPlan, _ system.DetectionResult, onProgress pipeline.ProgressFunc) (pipeline.ExecutionResult, []state.AgentOutcome) {
+		onProgress(pipeline.ProgressEvent{StepID: "component:engram", Stage: pipeline.StageApply, Status: pipeline.StepStatusRunning, Attempt: 1, MaxAttempts: 3})
+		onProgress(pipeline.ProgressEvent{StepID: "component:engram", Stage: pipeline.StageApply, Status: pipeline.StepStatusRetrying, Err: fmt.Errorf("brew: connection reset"), Attempt: 2, MaxAttempts: 3})
+		return pipeline.ExecutionResult{}, nil
+	}
+
+	updated, cmd := m.startInstalling()
+	cmds := cmd().(tea.BatchMsg)
+	run, wait := cmds[1], cmds[2]
+	go run()
+
+	for range 2 {
+		updated, wait = updated.Update(wait())
+	}
+	if view := updated.View(); !strings.Contains(view, "component:engram (attempt 2/3)") {
+		t.Fatalf("view does not show the live attempt counter:\n%s", view)
+	}
+
+	updated, wait = updated.Update(wait())
+	if updated.(Model).pipelineRunning || wait != nil {
+		t.Fatalf("pipeline still running after its done message")
+	}
+}
+
 func TestPipelineDoneMsgMarksCompletion(t *testing.T) {
 	m := NewModel(system.DetectionResult{}, "dev")
 	m.Screen = ScreenInstalling
@@ -141,6 +206,28 @@ func TestPipelineDoneMsgMarksCompletion(t *testing.T) {
 	}
 }
 
+func TestCtrlCDuringInstallCancelsPipelineBeforeQuitting(t *testing.T) {
+	m := NewModel(system.DetectionResult{}, "dev")
+	m.Screen = ScreenInstalling
+	m.pipelineRunning = true
+	cancelled := false
+	m.cancelPipeline = func() { cancelled = true }
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlC})
+	state := updated.(Model)
+	if !cancelled || !state.cancelling {
+		t.Fatalf("first Ctrl-C should cancel the pipeline, cancelled = %v", cancelled)
+	}
+	if cmd != nil {
+		t.Fatalf("first Ctrl-C should not quit while the pipeline rolls back")
+	}
+
+	_, cmd = state.Update(tea.KeyMsg{Type: tea.KeyCtrlC})
+	if cmd == nil {
+		t.Fatalf("second Ctrl-C should quit")
+	}
+}
+
 func TestPipelineDoneMsgSurfacesFailedSteps(t *testing.T) {
 	m := NewModel(system.DetectionResult{}, "dev")
 	m.Screen = ScreenInstalling
@@ -241,7 +328,7 @@ func TestBuildProgressLabelsFromResolvedPlan(t *testing.T) {
 		OrderedComponents: []model.ComponentID{model.ComponentEngram, model.ComponentSDD},
 	}
 
-	labels := buildProgressLabels(resolved)
+	labels := buildProgressLabels(resolved, config.Hooks{})
 
 	want := []string{
 		"prepare:check-dependencies",
@@ -257,6 +344,28 @@ func TestBuildProgressLabelsFromResolvedPlan(t *testing.T) {
 	}
 }
 
+func TestBuildProgressLabelsListsHooksAroundTheStages(t *testing.T) {
+	resolved := planner.ResolvedPlan{Agents: []model.AgentID{model.AgentClaudeCode}}
+	hooks := config.Hooks{
+		PrePrepare: []config.Hook{{ID: "company-ca", Run: "./write-ca.sh"}},
+		PostApply:  []config.Hook{{ID: "internal-mcp", Run: "register-mcp"}},
+	}
+
+	labels := buildProgressLabels(resolved, hooks)
+
+	want := []string{
+		"hook:pre-prepare:company-ca",
+		"prepare:check-dependencies",
+		"prepare:backup-snapshot",
+		"apply:rollback-restore",
+		"agent:claude-code",
+		"hook:post-apply:internal-mcp",
+	}
+	if !reflect.DeepEqual(labels, want) {
+		t.Fatalf("labels = %v, want %v", labels, want)
+	}
+}
+
 func TestBackupRestoreMsgHandledGracefully(t *testing.T) {
 	m := NewModel(system.DetectionResult{}, "dev")
 	m.Progress = NewProgressState([]string{})
@@ -339,3 +448,62 @@ func TestShouldShowSDDModeScreen(t *testing.T) {
 func screensAgentOptions() []model.AgentID {
 	return screens.AgentOptions()
 }
+
+func TestBackupListShowsIDAndLabel(t *testing.T) {
+	view := screens.RenderBackups([]backup.Manifest{{
+		ID:     "20261016-120000",
+		Label:  backup.Label{Command: "install", Note: "before upgrade"},
+		Pinned: true,
+	}}, 0)
+
+	for _, want := range []string{"20261016-120000", "install", `"before upgrade"`, "[pinned]"} {
+		if !strings.Contains(view, want) {
+			t.Fatalf("backup row missing %q; got:\n%s", want, view)
+		}
+	}
+}
+
+func TestBackupRestoreScopeSelectsAgentEntries(t *testing.T) {
+	m := NewModel(system.DetectionResult{}, "dev")
+	m.Screen = ScreenBackups
+	m.Backups = []backup.Manifest{{
+		ID: "snap",
+		Entries: []backup.ManifestEntry{
+			{OriginalPath: "/home/u/.claude/settings.json", Agents: []model.AgentID{model.AgentClaudeCode}, Components: []model.ComponentID{model.ComponentPermission}},
+			{OriginalPath: "/home/u/.config/opencode/opencode.json", Agents: []model.AgentID{model.AgentOpenCode}, Components: []model.ComponentID{model.ComponentPermission}},
+		},
+	}}
+
+	var restored backup.Manifest
+	m.RestoreFn = func(manifest backup.Manifest) error {
+		restored = manifest
+		return nil
+	}
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	state := updated.(Model)
+	if state.Screen != ScreenBackupScope {
+		t.Fatalf("screen = %v, want %v", state.Screen, ScreenBackupScope)
+	}
+
+	// Options: All files, Agent: claude-code, Agent: opencode, Component: permissions, Back.
+	if got := state.optionCount(); got != 5 {
+		t.Fatalf("optionCount() = %d, want 5", got)
+	}
+
+	state.Cursor = 2
+	_, cmd := state.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if cmd == nil {
+		t.Fatalf("expected a restore command")
+	}
+	cmd()
+
+	if len(restored.Entries) != 1 || restored.Entries[0].OriginalPath != "/home/u/.config/opencode/opencode.json" {
+		t.Fatalf("restored entries = %#v", restored.Entries)
+	}
+
+	updated, _ = state.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if updated.(Model).Screen != ScreenBackups {
+		t.Fatalf("esc should return to the backup list")
+	}
+}
@@ -10,6 +10,10 @@ import (
 type ProgressItem struct {
 	Label  string
 	Status string
+	// Attempt is the current or last attempt of a retried step, and
+	// MaxAttempts its limit when known.
+	Attempt     int
+	MaxAttempts int
 }
 
 const (
@@ -55,6 +59,17 @@ func (p *ProgressState) Mark(index int, status string) {
 	p.Current = len(p.Items)
 }
 
+// Retry marks a step as running again for the given attempt.
+func (p *ProgressState) Retry(index int, attempt int, maxAttempts int) {
+	if index < 0 || index >= len(p.Items) {
+		return
+	}
+
+	p.Items[index].Status = ProgressStatusRunning
+	p.Items[index].Attempt = attempt
+	p.Items[index].MaxAttempts = maxAttempts
+}
+
 func (p *ProgressState) AppendLog(format string, args ...any) {
 	p.Logs = append(p.Logs, fmt.Sprintf(format, args...))
 }
@@ -70,7 +85,8 @@ func (p ProgressState) Percent() int {
 
 	completed := 0
 	for _, item := range p.Items {
-		if item.Status == string(pipeline.StepStatusSucceeded) || item.Status == string(pipeline.StepStatusFailed) {
+		switch pipeline.StepStatus(item.Status) {
+		case pipeline.StepStatusSucceeded, pipeline.StepStatusFailed, pipeline.StepStatusSkipped, pipeline.StepStatusRolledBack:
 			completed++
 		}
 	}
@@ -81,11 +97,13 @@ func (p ProgressState) Percent() int {
 func ProgressFromExecution(result pipeline.ExecutionResult) ProgressState {
 	labels := make([]string, 0, len(result.Prepare.Steps)+len(result.Apply.Steps)+len(result.Rollback.Steps))
 	statuses := make([]pipeline.StepStatus, 0, cap(labels))
+	attempts := make([]int, 0, cap(labels))
 
 	appendSteps := func(stage pipeline.StageResult) {
 		for _, step := range stage.Steps {
 			labels = append(labels, step.StepID)
 			statuses = append(statuses, step.Status)
+			attempts = append(attempts, len(step.Attempts))
 		}
 	}
 
@@ -96,6 +114,9 @@ func ProgressFromExecution(result pipeline.ExecutionResult) ProgressState {
 	progress := NewProgressState(labels)
 	for idx, status := range statuses {
 		progress.Mark(idx, string(status))
+		if attempts[idx] > 1 {
+			progress.Items[idx].Attempt = attempts[idx]
+		}
 	}
 
 	return progress
@@ -113,7 +134,7 @@ func (p ProgressState) HasFailures() bool {
 func (p ProgressState) ViewModel() screens.InstallProgress {
 	items := make([]screens.ProgressItem, 0, len(p.Items))
 	for _, item := range p.Items {
-		items = append(items, screens.ProgressItem{Label: item.Label, Status: item.Status})
+		items = append(items, screens.ProgressItem{Label: item.Label, Status: item.Status, Attempt: item.Attempt, MaxAttempts: item.MaxAttempts})
 	}
 
 	current := ""
@@ -18,6 +18,7 @@ var linearRoutes = map[Screen]Route{
 	ScreenInstalling:     {Forward: ScreenComplete, Backward: ScreenReview},
 	ScreenComplete:       {Backward: ScreenInstalling},
 	ScreenBackups:        {Backward: ScreenWelcome},
+	ScreenBackupScope:    {Backward: ScreenBackups},
 }
 
 func NextScreen(screen Screen) (Screen, bool) {
@@ -2,9 +2,12 @@ package screens
 
 import (
 	"fmt"
+	"maps"
+	"slices"
 	"strings"
 
 	"github.com/gentleman-programming/gentle-ai/internal/backup"
+	"github.com/gentleman-programming/gentle-ai/internal/model"
 	"github.com/gentleman-programming/gentle-ai/internal/tui/styles"
 )
 
@@ -22,7 +25,13 @@ func RenderBackups(backups []backup.Manifest, cursor int) string {
 	}
 
 	for idx, snapshot := range backups {
-		label := fmt.Sprintf("%s (%s)", snapshot.ID, snapshot.CreatedAt.Format("2006-01-02 15:04"))
+		label := fmt.Sprintf("%s (%s)", snapshot.ID, snapshot.CreatedAt.Local().Format("2006-01-02 15:04"))
+		if description := snapshot.Label.String(); description != "" {
+			label += "  " + description
+		}
+		if snapshot.Pinned {
+			label += "  [pinned]"
+		}
 		focused := idx == cursor
 		if focused {
 			b.WriteString(styles.SelectedStyle.Render(styles.Cursor + label))
@@ -39,3 +48,76 @@ func RenderBackups(backups []backup.Manifest, cursor int) string {
 
 	return b.String()
 }
+
+// RestoreScope is one choice on the restore scope screen.
+type RestoreScope struct {
+	Label  string
+	Filter backup.Filter
+}
+
+// RestoreScopes lists what can be restored from a backup: everything, then
+// each agent and component its entries are tagged with.
+func RestoreScopes(manifest backup.Manifest) []RestoreScope {
+	scopes := []RestoreScope{{Label: fmt.Sprintf("All files (%d)", len(manifest.Entries))}}
+
+	agents := map[model.AgentID]int{}
+	components := map[model.ComponentID]int{}
+	for _, entry := range manifest.Entries {
+		for _, agent := range entry.Agents {
+			agents[agent]++
+		}
+		for _, component := range entry.Components {
+			components[component]++
+		}
+	}
+
+	for _, agent := range slices.Sorted(maps.Keys(agents)) {
+		scopes = append(scopes, RestoreScope{
+			Label:  fmt.Sprintf("Agent: %s (%d)", agent, agents[agent]),
+			Filter: backup.Filter{Agents: []model.AgentID{agent}},
+		})
+	}
+	for _, component := range slices.Sorted(maps.Keys(components)) {
+		scopes = append(scopes, RestoreScope{
+			Label:  fmt.Sprintf("Component: %s (%d)", component, components[component]),
+			Filter: backup.Filter{Components: []model.ComponentID{component}},
+		})
+	}
+
+	return scopes
+}
+
+// RenderBackupScope shows the restore scopes for one backup and, for the
+// focused scope, the shared files it would restore whole.
+func RenderBackupScope(manifest backup.Manifest, cursor int) string {
+	var b strings.Builder
+
+	b.WriteString(styles.TitleStyle.Render("Restore " + manifest.ID))
+	b.WriteString("\n\n")
+	b.WriteString(styles.HelpStyle.Render("Choose what to restore:"))
+	b.WriteString("\n\n")
+
+	scopes := RestoreScopes(manifest)
+	labels := make([]string, 0, len(scopes)+1)
+	for _, scope := range scopes {
+		labels = append(labels, scope.Label)
+	}
+	labels = append(labels, "Back")
+
+	b.WriteString(renderOptions(labels, cursor))
+	b.WriteString("\n")
+	if cursor >= 0 && cursor < len(scopes) {
+		if shared := scopes[cursor].Filter.Shared(manifest.Entries); len(shared) > 0 {
+			b.WriteString(styles.WarningStyle.Render(fmt.Sprintf("%d shared file(s) are restored whole, reverting other agents' and components' changes too:", len(shared))))
+			b.WriteString("\n")
+			for _, entry := range shared {
+				b.WriteString(styles.WarningStyle.Render("  " + entry.Path))
+				b.WriteString("\n")
+			}
+			b.WriteString("\n")
+		}
+	}
+	b.WriteString(styles.HelpStyle.Render("j/k: navigate • enter: restore • esc: back"))
+
+	return b.String()
+}
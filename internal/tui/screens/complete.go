@@ -19,6 +19,14 @@ type MissingDep struct {
 	InstallHint string
 }
 
+// AgentOutcome is what an install left configured for one agent. Status is
+// "configured", "partial" or "rolled-back".
+type AgentOutcome struct {
+	Agent   string
+	Status  string
+	Missing []string
+}
+
 // UpdateInfo holds version update information for a single tool.
 type UpdateInfo struct {
 	Name             string
@@ -31,10 +39,15 @@ type CompletePayload struct {
 	ConfiguredAgents    int
 	InstalledComponents int
 	GGAInstalled        bool
-	FailedSteps         []FailedStep
-	RollbackPerformed   bool
-	MissingDeps         []MissingDep
-	AvailableUpdates    []UpdateInfo
+	// ChangedSteps did work; UpToDateSteps were skipped because everything
+	// they manage was already configured.
+	ChangedSteps      []string
+	UpToDateSteps     []string
+	FailedSteps       []FailedStep
+	RollbackPerformed bool
+	Agents            []AgentOutcome
+	MissingDeps       []MissingDep
+	AvailableUpdates  []UpdateInfo
 }
 
 func RenderComplete(data CompletePayload) string {
@@ -54,6 +67,8 @@ func renderCompleteSuccess(data CompletePayload) string {
 	b.WriteString("  " + styles.HeadingStyle.Render("Installed components") + "  " + styles.SuccessStyle.Render(fmt.Sprintf("%d", data.InstalledComponents)) + "\n")
 	b.WriteString("\n")
 
+	renderChanges(&b, data.ChangedSteps, data.UpToDateSteps)
+
 	renderMissingDeps(&b, data.MissingDeps)
 	renderAvailableUpdates(&b, data.AvailableUpdates)
 
@@ -82,6 +97,25 @@ func renderCompleteSuccess(data CompletePayload) string {
 	return b.String()
 }
 
+// renderChanges separates the steps that changed something from those that
+// were already configured, so a re-run that did nothing says so.
+func renderChanges(b *strings.Builder, changed, upToDate []string) {
+	if len(changed)+len(upToDate) == 0 {
+		return
+	}
+
+	b.WriteString("  " + styles.HeadingStyle.Render("Changed") + "  " + styles.SuccessStyle.Render(fmt.Sprintf("%d", len(changed))))
+	if len(changed) > 0 {
+		b.WriteString("  " + styles.SubtextStyle.Render(strings.Join(changed, ", ")))
+	}
+	b.WriteString("\n")
+	b.WriteString("  " + styles.HeadingStyle.Render("Already configured") + "  " + styles.SubtextStyle.Render(fmt.Sprintf("%d", len(upToDate))))
+	if len(upToDate) > 0 {
+		b.WriteString("  " + styles.SubtextStyle.Render(strings.Join(upToDate, ", ")))
+	}
+	b.WriteString("\n\n")
+}
+
 func renderMissingDeps(b *strings.Builder, deps []MissingDep) {
 	if len(deps) == 0 {
 		return
@@ -137,8 +171,14 @@ func renderCompleteFailed(data CompletePayload) string {
 	}
 	b.WriteString("\n")
 
+	renderAgentOutcomes(&b, data.Agents)
+
 	if data.RollbackPerformed {
-		b.WriteString(styles.WarningStyle.Render("Rollback was performed — previous configuration restored."))
+		message := "Rollback was performed — previous configuration restored."
+		if partialRollback(data.Agents) {
+			message = "Rollback was performed — only the failed agents' and components' files were restored."
+		}
+		b.WriteString(styles.WarningStyle.Render(message))
 		b.WriteString("\n\n")
 	}
 
@@ -158,3 +198,37 @@ func renderCompleteFailed(data CompletePayload) string {
 
 	return b.String()
 }
+
+// renderAgentOutcomes lists, after a failed install, which agents are still
+// fully configured, which are missing some components and which were rolled
+// back.
+func renderAgentOutcomes(b *strings.Builder, outcomes []AgentOutcome) {
+	if len(outcomes) == 0 {
+		return
+	}
+
+	b.WriteString(styles.HeadingStyle.Render("Agents"))
+	b.WriteString("\n")
+	for _, outcome := range outcomes {
+		switch outcome.Status {
+		case "configured":
+			b.WriteString("  " + styles.SuccessStyle.Render("✓ "+outcome.Agent+" — fully configured"))
+		case "partial":
+			b.WriteString("  " + styles.WarningStyle.Render("~ "+outcome.Agent+" — partially configured, missing "+strings.Join(outcome.Missing, ", ")))
+		default:
+			b.WriteString("  " + styles.ErrorStyle.Render("✗ "+outcome.Agent+" — rolled back"))
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+}
+
+// partialRollback reports whether a rollback left some agents configured.
+func partialRollback(outcomes []AgentOutcome) bool {
+	for _, outcome := range outcomes {
+		if outcome.Status != "rolled-back" {
+			return true
+		}
+	}
+	return false
+}
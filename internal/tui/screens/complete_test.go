@@ -31,3 +31,39 @@ func TestRenderCompleteSuccessHidesGGANotesWhenNotInstalled(t *testing.T) {
 		t.Fatalf("unexpected GGA section: %q", out)
 	}
 }
+
+func TestRenderCompleteSuccessSeparatesChangedFromAlreadyConfigured(t *testing.T) {
+	out := RenderComplete(CompletePayload{
+		ConfiguredAgents:    1,
+		InstalledComponents: 2,
+		ChangedSteps:        []string{"component:persona"},
+		UpToDateSteps:       []string{"component:engram"},
+	})
+
+	if !strings.Contains(out, "Changed") || !strings.Contains(out, "component:persona") {
+		t.Fatalf("missing changed steps: %q", out)
+	}
+	if !strings.Contains(out, "Already configured") || !strings.Contains(out, "component:engram") {
+		t.Fatalf("missing already configured steps: %q", out)
+	}
+}
+
+func TestRenderCompleteFailedListsAgentOutcomesAfterPartialRollback(t *testing.T) {
+	out := RenderComplete(CompletePayload{
+		FailedSteps:       []FailedStep{{ID: "component:persona", Error: "inject persona for \"opencode\": permission denied"}},
+		RollbackPerformed: true,
+		Agents: []AgentOutcome{
+			{Agent: "claude-code", Status: "configured"},
+			{Agent: "opencode", Status: "rolled-back", Missing: []string{"persona"}},
+		},
+	})
+
+	for _, want := range []string{"claude-code — fully configured", "opencode — rolled back", "only the failed agents' and components' files were restored"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("missing %q: %q", want, out)
+		}
+	}
+	if strings.Contains(out, "previous configuration restored") {
+		t.Fatalf("partial rollback reported as a full restore: %q", out)
+	}
+}
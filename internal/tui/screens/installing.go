@@ -8,8 +8,10 @@ import (
 )
 
 type ProgressItem struct {
-	Label  string
-	Status string
+	Label       string
+	Status      string
+	Attempt     int
+	MaxAttempts int
 }
 
 type InstallProgress struct {
@@ -46,10 +48,19 @@ func RenderInstalling(progress InstallProgress, spinner string) string {
 			icon = styles.ErrorStyle.Render("✗")
 		case "running":
 			icon = styles.WarningStyle.Render(spinner)
+		case "skipped":
+			icon = styles.SubtextStyle.Render("-")
 		default:
 			icon = styles.SubtextStyle.Render("·")
 		}
-		b.WriteString(fmt.Sprintf("  %s %s\n", icon, styles.UnselectedStyle.Render(item.Label)))
+		label := item.Label
+		switch {
+		case item.Attempt > 1 && item.MaxAttempts > 0:
+			label += fmt.Sprintf(" (attempt %d/%d)", item.Attempt, item.MaxAttempts)
+		case item.Attempt > 1:
+			label += fmt.Sprintf(" (%d attempts)", item.Attempt)
+		}
+		b.WriteString(fmt.Sprintf("  %s %s\n", icon, styles.UnselectedStyle.Render(label)))
 	}
 
 	if len(progress.Logs) > 0 {
@@ -4,23 +4,12 @@ import (
 	"strings"
 
 	"github.com/gentleman-programming/gentle-ai/internal/model"
+	"github.com/gentleman-programming/gentle-ai/internal/preset"
 	"github.com/gentleman-programming/gentle-ai/internal/tui/styles"
 )
 
 func PresetOptions() []model.PresetID {
-	return []model.PresetID{
-		model.PresetFullGentleman,
-		model.PresetEcosystemOnly,
-		model.PresetMinimal,
-		model.PresetCustom,
-	}
-}
-
-var presetDescriptions = map[model.PresetID]string{
-	model.PresetFullGentleman: "Everything: memory, SDD, skills, docs, persona & security",
-	model.PresetEcosystemOnly: "Core tools only: memory, SDD, skills & docs (no persona/security)",
-	model.PresetMinimal:       "Just Engram persistent memory",
-	model.PresetCustom:        "Pick individual components yourself",
+	return preset.Default().IDs()
 }
 
 func RenderPreset(selected model.PresetID, cursor int) string {
@@ -29,11 +18,11 @@ func RenderPreset(selected model.PresetID, cursor int) string {
 	b.WriteString(styles.TitleStyle.Render("Select Ecosystem Preset"))
 	b.WriteString("\n\n")
 
-	for idx, preset := range PresetOptions() {
-		isSelected := preset == selected
+	for idx, option := range preset.Default().All() {
+		isSelected := option.ID == selected
 		focused := idx == cursor
-		b.WriteString(renderRadio(string(preset), isSelected, focused))
-		b.WriteString(styles.SubtextStyle.Render("    "+presetDescriptions[preset]) + "\n")
+		b.WriteString(renderRadio(string(option.ID), isSelected, focused))
+		b.WriteString(styles.SubtextStyle.Render("    "+option.Description) + "\n")
 	}
 
 	b.WriteString("\n")
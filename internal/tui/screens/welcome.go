@@ -10,7 +10,7 @@ func WelcomeOptions() []string {
 	return []string{"Start installation", "Manage backups", "Quit"}
 }
 
-func RenderWelcome(cursor int, version string, updateBanner string) string {
+func RenderWelcome(cursor int, version string, updateBanner string, lastRun string) string {
 	var b strings.Builder
 
 	b.WriteString(styles.RenderLogo())
@@ -23,6 +23,11 @@ func RenderWelcome(cursor int, version string, updateBanner string) string {
 		b.WriteString("\n")
 	}
 
+	if lastRun != "" {
+		b.WriteString(styles.SubtextStyle.Render(lastRun))
+		b.WriteString("\n")
+	}
+
 	b.WriteString("\n")
 	b.WriteString(styles.HeadingStyle.Render("Menu"))
 	b.WriteString("\n\n")